@@ -7,6 +7,7 @@ package config
 import (
 	"context"
 	"fmt"
+	"net/netip"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -16,6 +17,8 @@ import (
 
 	"github.com/ccoveille/go-safecast"
 	"github.com/cloudzero/cloudzero-agent/app/domain/filter"
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
 	"github.com/cloudzero/cloudzero-agent/app/utils/scout"
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/pkg/errors"
@@ -33,8 +36,52 @@ const (
 	DefaultDatabaseCompressionLevel         = 8
 	DefaultDatabaseCostMaxInterval          = 10 * time.Minute
 	DefaultDatabaseObservabilityMaxInterval = 30 * time.Minute
+	DefaultCompressionMode                  = CompressionModeFixed
+	DefaultCompressionCodec                 = "brotli"
+	DefaultCompressionMinCPUHeadroom        = 0.25
+	DefaultCompressionHighDiskPercent       = 70.0
+	DefaultCompressionHighPendingBytes      = 256 << 20
 	DefaultServerPort                       = 8080
 	DefaultServerMode                       = "http"
+	DefaultServerTLSReloadInterval          = time.Minute
+	DefaultServerAuthCacheTTL               = time.Minute
+	DefaultShardingPath                     = "/collector"
+	DefaultShardingRefreshInterval          = 30 * time.Second
+	DefaultShardingVirtualNodes             = 128
+	DefaultShardingForwardTimeout           = 30 * time.Second
+	DefaultLeaderElectionLeaseName          = "cloudzero-shipper"
+	DefaultLeaderElectionLeaseDuration      = 15 * time.Second
+	DefaultLeaderElectionRenewDeadline      = 10 * time.Second
+	DefaultLeaderElectionRetryPeriod        = 2 * time.Second
+	DefaultTracingProtocol                  = TracingProtocolHTTP
+	DefaultTracingSampleRatio               = 1.0
+	DefaultIngestTimestampGuard             = TimestampGuardReject
+	DefaultIngestFutureTolerance            = 10 * time.Minute
+	DefaultIngestPastTolerance              = 24 * time.Hour
+	DefaultIngestSkewThreshold              = 2 * time.Minute
+
+	// Server modes
+	ServerModeHTTP  = "http"
+	ServerModeHTTPS = "https"
+
+	// Authentication modes of the remote_write endpoint
+	AuthModeNone        = "none"
+	AuthModeToken       = "token"
+	AuthModeTokenReview = "tokenreview"
+	AuthModeCertificate = "certificate"
+
+	// Compression modes of the metric files
+	CompressionModeFixed    = "fixed"
+	CompressionModeAdaptive = "adaptive"
+
+	// OTLP protocols of the trace exporter
+	TracingProtocolHTTP = "http/protobuf"
+	TracingProtocolGRPC = "grpc"
+
+	// Guards on the timestamps of the samples received
+	TimestampGuardOff    = "off"
+	TimestampGuardReject = "reject"
+	TimestampGuardClamp  = "clamp"
 
 	// Shutdown coordination
 	ShutdownMarkerFilename = "collector-shutdown-complete"
@@ -52,6 +99,12 @@ type Settings struct {
 	Database  Database  `yaml:"database"`
 	Cloudzero Cloudzero `yaml:"cloudzero"`
 	Metrics   Metrics   `yaml:"metrics"`
+	Ingest    Ingest    `yaml:"ingest"`
+	Sharding  Sharding  `yaml:"sharding"`
+
+	LeaderElection LeaderElection   `yaml:"leader_election"`
+	Tracing        Tracing          `yaml:"tracing"`
+	Egress         transport.Config `yaml:"egress"`
 
 	mu sync.Mutex
 }
@@ -63,6 +116,20 @@ type Metrics struct {
 	ObservabilityLabels []filter.FilterEntry `yaml:"observability_labels"`
 }
 
+// Ingest configures the guards on the timestamps of the samples received. A
+// source with a broken clock can send samples hours ahead or in 1970, which
+// land in the wrong cost windows. Samples more than FutureTolerance ahead of
+// the clock of the collector, or PastTolerance behind it, are dropped in
+// reject mode, and stamped with the time they were received in clamp mode.
+// Sources whose newest samples are typically more than SkewThreshold away
+// from the clock of the collector are reported as skewed.
+type Ingest struct {
+	TimestampGuard  string        `yaml:"timestamp_guard" default:"reject" env:"INGEST_TIMESTAMP_GUARD" env-description:"what to do with samples outside the tolerances: off, reject or clamp"`
+	FutureTolerance time.Duration `yaml:"future_tolerance" default:"10m" env:"INGEST_FUTURE_TOLERANCE" env-description:"how far ahead of the clock of the collector samples are accepted"`
+	PastTolerance   time.Duration `yaml:"past_tolerance" default:"24h" env:"INGEST_PAST_TOLERANCE" env-description:"how far behind the clock of the collector samples are accepted"`
+	SkewThreshold   time.Duration `yaml:"skew_threshold" default:"2m" env:"INGEST_SKEW_THRESHOLD" env-description:"offset of the newest samples of a source beyond which its clock is reported as skewed"`
+}
+
 type Logging struct {
 	Level   string `yaml:"level" default:"info" env:"LOG_LEVEL" env-description:"logging level such as debug, info, error"`
 	Capture bool   `yaml:"capture" default:"true" env:"LOG_CAPTURE" env-description:"whether to persist logs to disk or not"`
@@ -71,7 +138,8 @@ type Logging struct {
 type Database struct {
 	StoragePath              string        `yaml:"storage_path" default:"/cloudzero/data" env:"DATABASE_STORAGE_PATH" env-description:"location where to write database"`
 	MaxRecords               int           `yaml:"max_records" default:"1000000" env:"MAX_RECORDS_PER_FILE" env-description:"maximum records per file"`
-	CompressionLevel         int           `yaml:"compression_level" default:"8" env:"DATABASE_COMPRESS_LEVEL" env-description:"compression level for database files"`
+	CompressionLevel         int           `yaml:"compression_level" default:"8" env:"DATABASE_COMPRESS_LEVEL" env-description:"brotli compression level for database files"`
+	Compression              Compression   `yaml:"compression"`
 	CostMaxInterval          time.Duration `yaml:"cost_max_interval" default:"10m" env:"COST_MAX_INTERVAL" env-description:"maximum interval to wait before flushing cost metrics"`
 	ObservabilityMaxInterval time.Duration `yaml:"observability_max_interval" default:"10m" env:"OBSERVABILITY_MAX_INTERVAL" env-description:"maximum interval to wait before flushing observability metrics"`
 
@@ -79,6 +147,18 @@ type Database struct {
 	AvailableStorage string     `yaml:"available_storage" default:"" env:"DATABASE_AVAILABLE_STORAGE" env-description:"total size alloted to the gator to store metric files"`
 }
 
+// Compression selects the codec of each metric file. In fixed mode every file
+// uses Codec. In adaptive mode the codec is picked when a file is started:
+// snappy while the CPU is busy, brotli at CompressionLevel while the disk or
+// the backlog of files is filling up, and zstd otherwise.
+type Compression struct {
+	Mode             string  `yaml:"mode" default:"fixed" env:"DATABASE_COMPRESSION_MODE" env-description:"fixed to always use the codec, or adaptive to pick it per file"`
+	Codec            string  `yaml:"codec" default:"brotli" env:"DATABASE_COMPRESSION_CODEC" env-description:"codec of the fixed mode: brotli, zstd or snappy"`
+	MinCPUHeadroom   float64 `yaml:"min_cpu_headroom" default:"0.25" env:"DATABASE_COMPRESSION_MIN_CPU_HEADROOM" env-description:"share of the CPU left idle below which the cheapest codec is used"`
+	HighDiskPercent  float64 `yaml:"high_disk_percent" default:"70" env:"DATABASE_COMPRESSION_HIGH_DISK_PERCENT" env-description:"disk usage above which the densest codec is used"`
+	HighPendingBytes int64   `yaml:"high_pending_bytes" default:"268435456" env:"DATABASE_COMPRESSION_HIGH_PENDING_BYTES" env-description:"size of the files pending upload above which the densest codec is used"`
+}
+
 type PurgeRules struct {
 	MetricsOlderThan time.Duration `yaml:"metrics_older_than" env-default:"2160h" env:"PURGE_METRICS_OLDER_THAN" env-description:"The amount of time to keep metric information locally. Any file older than the duration specified here can be deleted to free up space on the disk"`
 	Lazy             bool          `yaml:"lazy" default:"true" env:"PURGE_LAZY" env-description:"Whether to purge the files in lazy mode. In this mode, if the metrics are older than 'metrics_older_than' but there is no detected disk pressure, the older 'stale' metrics will be retained"`
@@ -90,6 +170,106 @@ type Server struct {
 	Port               uint   `yaml:"port" default:"8080" env:"SERVER_PORT" env-description:"server port"`
 	Profiling          bool   `yaml:"profiling" default:"false" env:"SERVER_PROFILING" env-description:"enable profiling"`
 	ReconnectFrequency int    `yaml:"reconnect_frequency" default:"16" env:"SERVER_RECONNECT_FREQUENCY" env-description:"how frequently to close HTTP connections from clients, to distribute the load. 0=never, otherwise 1/N probability."`
+	// TrustedProxies lists the addresses and CIDR ranges of the proxies, such
+	// as the shard router, whose X-Forwarded-For header names the client of a
+	// request. The header of any other client is ignored, so it cannot pose
+	// as another Prometheus.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES" env-description:"addresses and CIDR ranges of the proxies whose X-Forwarded-For header is honoured"`
+
+	TLS  ServerTLS  `yaml:"tls"`
+	Auth ServerAuth `yaml:"auth"`
+}
+
+// ServerTLS configures the certificates served in https mode. The files are
+// reloaded periodically, so rotated certificates are picked up without a
+// restart. Setting a client CA requires clients to present a certificate
+// signed by it (mTLS).
+type ServerTLS struct {
+	CertFile       string        `yaml:"cert_file" env:"SERVER_TLS_CERT_FILE" env-description:"path to the server certificate"`
+	KeyFile        string        `yaml:"key_file" env:"SERVER_TLS_KEY_FILE" env-description:"path to the server certificate key"`
+	ClientCAFile   string        `yaml:"client_ca_file" env:"SERVER_TLS_CLIENT_CA_FILE" env-description:"path to the CA bundle client certificates must be signed by; enables mTLS"`
+	ReloadInterval time.Duration `yaml:"reload_interval" default:"1m" env:"SERVER_TLS_RELOAD_INTERVAL" env-description:"how often to reload the certificates from disk"`
+}
+
+// ServerAuth configures the authentication of remote_write requests.
+//
+// In token mode clients send a bearer token listed in TokensFile, in
+// tokenreview mode a Kubernetes ServiceAccount token validated with the
+// TokenReview API, and in certificate mode a client certificate. When
+// AllowedIdentities is set, only the identities listed may write: the name
+// of the token, the username of the ServiceAccount (for example
+// system:serviceaccount:monitoring:prometheus), or the common name of the
+// certificate.
+type ServerAuth struct {
+	Mode              string        `yaml:"mode" default:"none" env:"SERVER_AUTH_MODE" env-description:"authentication of remote_write requests such as none, token, tokenreview, certificate"`
+	TokensFile        string        `yaml:"tokens_file" env:"SERVER_AUTH_TOKENS_FILE" env-description:"path to a file of 'token,identity' lines accepted in token mode"`
+	Audiences         []string      `yaml:"audiences" env:"SERVER_AUTH_AUDIENCES" env-description:"audiences ServiceAccount tokens must be issued for in tokenreview mode"`
+	AllowedIdentities []string      `yaml:"allowed_identities" env:"SERVER_AUTH_ALLOWED_IDENTITIES" env-description:"identities allowed to write metrics; empty allows any authenticated identity"`
+	CacheTTL          time.Duration `yaml:"cache_ttl" default:"1m" env:"SERVER_AUTH_CACHE_TTL" env-description:"how long to cache TokenReview results"`
+}
+
+// Sharding configures the horizontal scaling of the aggregator. Each collector
+// replica owns a range of a consistent-hash ring of series, and stores and
+// ships them from its own storage directory. A router in front of the
+// replicas hashes each series by the values of Labels and forwards it to its
+// owner; the members of the ring are the ready endpoints of the headless
+// Service, so series are rebalanced when replicas are added or removed.
+//
+// The Helm chart deploys the router, the headless Service and the storage of
+// each replica with aggregator.sharding.enabled.
+//
+// The router forwards with the Scheme of the replicas. In https mode it
+// verifies their certificates with TLS.CAFile against TLS.ServerName, which
+// defaults to the DNS name of the headless Service, and presents the client
+// certificate of TLS.CertFile to replicas requiring mTLS. Bearer tokens are
+// passed on from the clients of the router.
+type Sharding struct {
+	Enabled         bool          `yaml:"enabled" default:"false" env:"SHARDING_ENABLED" env-description:"whether collector replicas each own a shard of the series"`
+	ShardID         string        `yaml:"shard_id" env:"POD_NAME" env-description:"identity of this collector replica, naming its storage directory"`
+	Labels          []string      `yaml:"labels" env:"SHARDING_LABELS" env-description:"labels whose values select the shard of a series; series with none of them are hashed by all their labels"`
+	Service         string        `yaml:"service" env:"SHARDING_SERVICE" env-description:"headless Service selecting the collector replicas"`
+	Namespace       string        `yaml:"namespace" env:"POD_NAMESPACE" env-description:"namespace of the headless Service"`
+	Scheme          string        `yaml:"scheme" default:"http" env:"SHARDING_SCHEME" env-description:"scheme of the collector replicas: http or https"`
+	Port            uint          `yaml:"port" env:"SHARDING_PORT" env-description:"port of the collector replicas; defaults to the server port"`
+	Path            string        `yaml:"path" default:"/collector" env:"SHARDING_PATH" env-description:"remote_write path of the collector replicas"`
+	RefreshInterval time.Duration `yaml:"refresh_interval" default:"30s" env:"SHARDING_REFRESH_INTERVAL" env-description:"how often the members of the ring are refreshed"`
+	VirtualNodes    int           `yaml:"virtual_nodes" default:"128" env:"SHARDING_VIRTUAL_NODES" env-description:"points of each replica on the hash ring"`
+	ForwardTimeout  time.Duration `yaml:"forward_timeout" default:"30s" env:"SHARDING_FORWARD_TIMEOUT" env-description:"timeout of requests forwarded to the replicas"`
+
+	TLS ShardingTLS `yaml:"tls"`
+}
+
+// ShardingTLS configures the connections of the router to collector replicas
+// served in https mode. The files are reloaded periodically, so rotated
+// certificates are picked up without a restart.
+type ShardingTLS struct {
+	CAFile         string        `yaml:"ca_file" env:"SHARDING_TLS_CA_FILE" env-description:"path to the CA bundle the certificates of the replicas are signed by; defaults to the system roots"`
+	CertFile       string        `yaml:"cert_file" env:"SHARDING_TLS_CERT_FILE" env-description:"path to the client certificate presented to the replicas"`
+	KeyFile        string        `yaml:"key_file" env:"SHARDING_TLS_KEY_FILE" env-description:"path to the client certificate key"`
+	ServerName     string        `yaml:"server_name" env:"SHARDING_TLS_SERVER_NAME" env-description:"name the certificates of the replicas are verified against; defaults to the DNS name of the headless Service"`
+	ReloadInterval time.Duration `yaml:"reload_interval" default:"1m" env:"SHARDING_TLS_RELOAD_INTERVAL" env-description:"how often to reload the certificates from disk"`
+}
+
+// LeaderElection configures the election of the one shipper uploading the
+// files of collector replicas which share a storage volume. The shippers
+// campaign for a Lease, and only its holder processes the files.
+type LeaderElection struct {
+	Enabled       bool          `yaml:"enabled" default:"false" env:"LEADER_ELECTION_ENABLED" env-description:"whether only the shipper holding the lease uploads files"`
+	LeaseName     string        `yaml:"lease_name" default:"cloudzero-shipper" env:"LEADER_ELECTION_LEASE_NAME" env-description:"name of the Lease the shippers campaign for"`
+	Namespace     string        `yaml:"namespace" env:"POD_NAMESPACE" env-description:"namespace of the Lease"`
+	Identity      string        `yaml:"identity" env:"POD_NAME" env-description:"identity of this replica as the holder of the Lease; defaults to the hostname"`
+	LeaseDuration time.Duration `yaml:"lease_duration" default:"15s" env:"LEADER_ELECTION_LEASE_DURATION" env-description:"how long the other replicas wait before taking over a Lease which is not renewed"`
+	RenewDeadline time.Duration `yaml:"renew_deadline" default:"10s" env:"LEADER_ELECTION_RENEW_DEADLINE" env-description:"how long the leader retries renewing the Lease before giving up"`
+	RetryPeriod   time.Duration `yaml:"retry_period" default:"2s" env:"LEADER_ELECTION_RETRY_PERIOD" env-description:"how often the replicas try to acquire or renew the Lease"`
+}
+
+// Tracing configures the export of the spans of the agent to an
+// OpenTelemetry collector, typically running on the node or as a sidecar.
+type Tracing struct {
+	Enabled     bool    `yaml:"enabled" default:"false" env:"TRACING_ENABLED" env-description:"whether spans are exported with OTLP"`
+	Protocol    string  `yaml:"protocol" default:"http/protobuf" env:"TRACING_PROTOCOL" env-description:"OTLP protocol of the exporter: http/protobuf or grpc"`
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" env-description:"URL of the OpenTelemetry collector; defaults to the OTEL_EXPORTER_OTLP_* environment variables, then to localhost"`
+	SampleRatio float64 `yaml:"sample_ratio" default:"1.0" env:"TRACING_SAMPLE_RATIO" env-description:"ratio of the traces started by the agent which are exported"`
 }
 
 type Cloudzero struct {
@@ -176,6 +356,174 @@ func (s *Settings) Validate() error {
 		return errors.Wrap(err, "cloudzero validation")
 	}
 
+	if err := s.Ingest.Validate(); err != nil {
+		return errors.Wrap(err, "ingest validation")
+	}
+
+	if err := s.Sharding.Validate(s.Server.Port); err != nil {
+		return errors.Wrap(err, "sharding validation")
+	}
+
+	if err := s.LeaderElection.Validate(s.Sharding.Enabled); err != nil {
+		return errors.Wrap(err, "leader election validation")
+	}
+
+	if err := s.Tracing.Validate(); err != nil {
+		return errors.Wrap(err, "tracing validation")
+	}
+
+	if err := s.Egress.Validate(); err != nil {
+		return errors.Wrap(err, "egress validation")
+	}
+
+	return nil
+}
+
+func (s *Sharding) Validate(serverPort uint) error {
+	if !s.Enabled {
+		return nil
+	}
+	if s.Port == 0 {
+		s.Port = serverPort
+	}
+	if s.Path == "" {
+		s.Path = DefaultShardingPath
+	}
+	if s.RefreshInterval <= 0 {
+		s.RefreshInterval = DefaultShardingRefreshInterval
+	}
+	if s.VirtualNodes <= 0 {
+		s.VirtualNodes = DefaultShardingVirtualNodes
+	}
+	if s.ForwardTimeout <= 0 {
+		s.ForwardTimeout = DefaultShardingForwardTimeout
+	}
+	if len(s.Labels) == 0 {
+		s.Labels = []string{"namespace", "pod", "node"}
+	}
+	if s.Service == "" || s.Namespace == "" {
+		return errors.New("sharding requires the service and namespace of the collector replicas")
+	}
+
+	switch s.Scheme {
+	case "":
+		s.Scheme = ServerModeHTTP
+	case ServerModeHTTP, ServerModeHTTPS:
+	default:
+		return fmt.Errorf("invalid sharding scheme %q, expected %s or %s", s.Scheme, ServerModeHTTP, ServerModeHTTPS)
+	}
+	if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+		return errors.New("the client certificate of the router requires both a certificate and a key")
+	}
+	if s.Scheme == ServerModeHTTP {
+		if s.TLS.CAFile != "" || s.TLS.CertFile != "" {
+			return errors.New("the sharding TLS settings require the https scheme")
+		}
+		return nil
+	}
+	if s.TLS.ServerName == "" {
+		s.TLS.ServerName = s.Service + "." + s.Namespace + ".svc"
+	}
+	if s.TLS.ReloadInterval <= 0 {
+		s.TLS.ReloadInterval = DefaultServerTLSReloadInterval
+	}
+	return nil
+}
+
+func (i *Ingest) Validate() error {
+	switch i.TimestampGuard {
+	case "":
+		i.TimestampGuard = DefaultIngestTimestampGuard
+	case TimestampGuardOff, TimestampGuardReject, TimestampGuardClamp:
+	default:
+		return fmt.Errorf("invalid timestamp guard %q, expected %s, %s or %s", i.TimestampGuard, TimestampGuardOff, TimestampGuardReject, TimestampGuardClamp)
+	}
+	if i.FutureTolerance <= 0 {
+		i.FutureTolerance = DefaultIngestFutureTolerance
+	}
+	if i.PastTolerance <= 0 {
+		i.PastTolerance = DefaultIngestPastTolerance
+	}
+	if i.SkewThreshold <= 0 {
+		i.SkewThreshold = DefaultIngestSkewThreshold
+	}
+	return nil
+}
+
+// Validate fills the defaults of an enabled leader election. It cannot be
+// combined with sharding, since every shard ships the files of its own
+// storage directory.
+func (l *LeaderElection) Validate(sharding bool) error {
+	if !l.Enabled {
+		return nil
+	}
+	if sharding {
+		return errors.New("leader election cannot be enabled with sharding, each shard ships its own files")
+	}
+	if l.LeaseName == "" {
+		l.LeaseName = DefaultLeaderElectionLeaseName
+	}
+	if l.LeaseDuration <= 0 {
+		l.LeaseDuration = DefaultLeaderElectionLeaseDuration
+	}
+	if l.RenewDeadline <= 0 {
+		l.RenewDeadline = DefaultLeaderElectionRenewDeadline
+	}
+	if l.RetryPeriod <= 0 {
+		l.RetryPeriod = DefaultLeaderElectionRetryPeriod
+	}
+	if l.Namespace == "" {
+		return errors.New("leader election requires the namespace of the lease")
+	}
+	return nil
+}
+
+func (t *Tracing) Validate() error {
+	if !t.Enabled {
+		return nil
+	}
+	switch t.Protocol {
+	case "":
+		t.Protocol = DefaultTracingProtocol
+	case TracingProtocolHTTP, TracingProtocolGRPC:
+	default:
+		return fmt.Errorf("invalid tracing protocol %q", t.Protocol)
+	}
+	if t.Endpoint != "" {
+		u, err := url.Parse(t.Endpoint)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid tracing endpoint %q, expected a URL such as http://localhost:4318", t.Endpoint)
+		}
+	}
+	if t.SampleRatio == 0 {
+		t.SampleRatio = DefaultTracingSampleRatio
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		return fmt.Errorf("invalid tracing sample ratio %v, expected a value between 0 and 1", t.SampleRatio)
+	}
+	return nil
+}
+
+// UseShardStorage points the storage path at the directory of this shard when
+// sharding is enabled, creating it if needed. The collector and the shipper of
+// a replica call it so they share the directory, while the replicas do not,
+// even when they mount the same volume.
+func (s *Settings) UseShardStorage() error {
+	if !s.Sharding.Enabled {
+		return nil
+	}
+	if s.Sharding.ShardID == "" {
+		return errors.New("sharding requires a shard ID")
+	}
+	if strings.ContainsAny(s.Sharding.ShardID, `/\`) || s.Sharding.ShardID == "." || s.Sharding.ShardID == ".." {
+		return fmt.Errorf("invalid shard ID %q", s.Sharding.ShardID)
+	}
+
+	path := filepath.Join(s.Database.StoragePath, "shards", s.Sharding.ShardID)
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return errors.Wrap(err, "failed to create the shard storage directory")
+	}
+	s.Database.StoragePath = path
 	return nil
 }
 
@@ -192,6 +540,9 @@ func (d *Database) Validate() error {
 	if _, err := os.Stat(d.StoragePath); os.IsNotExist(err) {
 		return errors.Wrap(err, "database storage path does not exist")
 	}
+	if err := d.Compression.Validate(); err != nil {
+		return errors.Wrap(err, "compression validation")
+	}
 
 	// validate the passed sizeLimit is valid if it is not empty
 	if d.AvailableStorage != "" {
@@ -203,6 +554,34 @@ func (d *Database) Validate() error {
 	return nil
 }
 
+func (c *Compression) Validate() error {
+	switch c.Mode {
+	case "":
+		c.Mode = DefaultCompressionMode
+	case CompressionModeFixed, CompressionModeAdaptive:
+	default:
+		return fmt.Errorf("unsupported compression mode %q, expected %s or %s", c.Mode, CompressionModeFixed, CompressionModeAdaptive)
+	}
+	if c.Codec == "" {
+		c.Codec = DefaultCompressionCodec
+	}
+	codec, err := compress.ParseCodec(c.Codec)
+	if err != nil {
+		return err
+	}
+	c.Codec = string(codec)
+	if c.MinCPUHeadroom <= 0 || c.MinCPUHeadroom >= 1 {
+		c.MinCPUHeadroom = DefaultCompressionMinCPUHeadroom
+	}
+	if c.HighDiskPercent <= 0 || c.HighDiskPercent > 100 {
+		c.HighDiskPercent = DefaultCompressionHighDiskPercent
+	}
+	if c.HighPendingBytes <= 0 {
+		c.HighPendingBytes = DefaultCompressionHighPendingBytes
+	}
+	return nil
+}
+
 func (s *Server) Validate() error {
 	if s.Mode == "" {
 		s.Mode = DefaultServerMode
@@ -210,6 +589,73 @@ func (s *Server) Validate() error {
 	if s.Port == 0 {
 		s.Port = DefaultServerPort
 	}
+
+	switch s.Mode {
+	case ServerModeHTTP:
+		if s.TLS.ClientCAFile != "" {
+			return errors.New("a client CA requires the https server mode")
+		}
+	case ServerModeHTTPS:
+		if s.TLS.CertFile == "" || s.TLS.KeyFile == "" {
+			return errors.New("the https server mode requires a certificate and a key")
+		}
+		if s.TLS.ReloadInterval <= 0 {
+			s.TLS.ReloadInterval = DefaultServerTLSReloadInterval
+		}
+	default:
+		return fmt.Errorf("invalid server mode %q", s.Mode)
+	}
+
+	if _, err := s.TrustedProxyPrefixes(); err != nil {
+		return err
+	}
+
+	return s.Auth.validate(s.TLS.ClientCAFile != "")
+}
+
+// TrustedProxyPrefixes parses TrustedProxies. An address is a prefix of a
+// single address.
+func (s *Server) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(s.TrustedProxies))
+	for _, proxy := range s.TrustedProxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q, expected an address or a CIDR range", proxy)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func (a *ServerAuth) validate(mtls bool) error {
+	if a.Mode == "" {
+		a.Mode = AuthModeNone
+	}
+	if a.CacheTTL <= 0 {
+		a.CacheTTL = DefaultServerAuthCacheTTL
+	}
+
+	switch a.Mode {
+	case AuthModeNone:
+		if len(a.AllowedIdentities) > 0 {
+			return errors.New("allowed identities require an authentication mode")
+		}
+	case AuthModeToken:
+		if a.TokensFile == "" {
+			return errors.New("the token authentication mode requires a tokens file")
+		}
+	case AuthModeTokenReview:
+	case AuthModeCertificate:
+		if !mtls {
+			return errors.New("the certificate authentication mode requires a client CA")
+		}
+	default:
+		return fmt.Errorf("invalid authentication mode %q", a.Mode)
+	}
 	return nil
 }
 
@@ -322,18 +768,24 @@ func (s *Settings) GetRemoteAPIBase() (*url.URL, error) {
 // to get the available size in bytes of the storage volume.
 // If the value fails to be parsed, it will return 0.
 func (s *Settings) GetAvailableSizeBytes() (uint64, error) {
-	if s.Database.AvailableStorage == "" {
-		return 0, nil
+	return s.Database.AvailableSizeBytes(), nil
+}
+
+// AvailableSizeBytes returns the size of the storage volume allotted to the
+// database, or 0 when all the available space may be used.
+func (d *Database) AvailableSizeBytes() uint64 {
+	if d.AvailableStorage == "" {
+		return 0
 	}
 
-	quantity, err := resource.ParseQuantity(s.Database.AvailableStorage)
+	quantity, err := resource.ParseQuantity(d.AvailableStorage)
 	if err != nil {
-		log.Ctx(context.Background()).Warn().Err(err).Str("sizeLimit", s.Database.AvailableStorage).Msg("failed to parse the size_limit, using 0 as the default value (all available space)")
-		return 0, nil
+		log.Ctx(context.Background()).Warn().Err(err).Str("sizeLimit", d.AvailableStorage).Msg("failed to parse the size_limit, using 0 as the default value (all available space)")
+		return 0
 	}
 
 	// value will give size in bytes
-	return safecast.MustConvert[uint64](quantity.Value()), nil
+	return safecast.MustConvert[uint64](quantity.Value())
 }
 
 func isValidURL(uri string) bool {
@@ -4,6 +4,7 @@
 package config_test
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -215,6 +216,93 @@ func TestServer_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalid mode",
+			server: config.Server{
+				Mode: "ftp",
+			},
+			wantErr: true,
+		},
+		{
+			name: "https",
+			server: config.Server{
+				Mode: "https",
+				TLS:  config.ServerTLS{CertFile: "tls.crt", KeyFile: "tls.key"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "https without a certificate",
+			server: config.Server{
+				Mode: "https",
+			},
+			wantErr: true,
+		},
+		{
+			name: "client CA without https",
+			server: config.Server{
+				Mode: "http",
+				TLS:  config.ServerTLS{ClientCAFile: "ca.crt"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "token auth",
+			server: config.Server{
+				Mode: "http",
+				Auth: config.ServerAuth{Mode: "token", TokensFile: "tokens", AllowedIdentities: []string{"prometheus"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "token auth without a tokens file",
+			server: config.Server{
+				Mode: "http",
+				Auth: config.ServerAuth{Mode: "token"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "certificate auth without a client CA",
+			server: config.Server{
+				Mode: "https",
+				TLS:  config.ServerTLS{CertFile: "tls.crt", KeyFile: "tls.key"},
+				Auth: config.ServerAuth{Mode: "certificate"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "allowed identities without auth",
+			server: config.Server{
+				Mode: "http",
+				Auth: config.ServerAuth{AllowedIdentities: []string{"prometheus"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid auth mode",
+			server: config.Server{
+				Mode: "http",
+				Auth: config.ServerAuth{Mode: "basic"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "trusted proxies",
+			server: config.Server{
+				Mode:           "http",
+				TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1", "fd00::/8"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid trusted proxy",
+			server: config.Server{
+				Mode:           "http",
+				TrustedProxies: []string{"router"},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -326,3 +414,141 @@ func TestUnit_Settings_RemoteAPIBase(t *testing.T) {
 	require.NoError(t, err, "failed to get the remote api base")
 	require.NotEmpty(t, u.String())
 }
+
+func TestSharding_Validate(t *testing.T) {
+	disabled := config.Sharding{}
+	assert.NoError(t, disabled.Validate(8080))
+	assert.Zero(t, disabled.Port, "defaults are only set when sharding is enabled")
+
+	s := config.Sharding{Enabled: true, Service: "collector", Namespace: "cloudzero"}
+	require.NoError(t, s.Validate(8080))
+	assert.Equal(t, uint(8080), s.Port)
+	assert.Equal(t, config.DefaultShardingPath, s.Path)
+	assert.Equal(t, config.DefaultShardingRefreshInterval, s.RefreshInterval)
+	assert.Equal(t, config.DefaultShardingVirtualNodes, s.VirtualNodes)
+	assert.Equal(t, config.DefaultShardingForwardTimeout, s.ForwardTimeout)
+	assert.Equal(t, []string{"namespace", "pod", "node"}, s.Labels)
+
+	assert.Equal(t, config.ServerModeHTTP, s.Scheme)
+
+	missing := config.Sharding{Enabled: true, Service: "collector"}
+	assert.Error(t, missing.Validate(8080))
+
+	https := config.Sharding{Enabled: true, Service: "collector", Namespace: "cloudzero", Scheme: config.ServerModeHTTPS}
+	require.NoError(t, https.Validate(8080))
+	assert.Equal(t, "collector.cloudzero.svc", https.TLS.ServerName, "the certificates of the replicas are verified against the headless Service")
+	assert.Equal(t, config.DefaultServerTLSReloadInterval, https.TLS.ReloadInterval)
+
+	invalid := []config.Sharding{
+		{Enabled: true, Service: "collector", Namespace: "cloudzero", Scheme: "grpc"},
+		{Enabled: true, Service: "collector", Namespace: "cloudzero", Scheme: config.ServerModeHTTPS, TLS: config.ShardingTLS{CertFile: "tls.crt"}},
+		{Enabled: true, Service: "collector", Namespace: "cloudzero", TLS: config.ShardingTLS{CAFile: "ca.crt"}},
+	}
+	for _, s := range invalid {
+		assert.Error(t, s.Validate(8080), "%+v", s)
+	}
+}
+
+func TestSettings_UseShardStorage(t *testing.T) {
+	dir := t.TempDir()
+
+	s := config.Settings{Database: config.Database{StoragePath: dir}}
+	require.NoError(t, s.UseShardStorage())
+	assert.Equal(t, dir, s.Database.StoragePath, "the storage path is unchanged without sharding")
+
+	s.Sharding = config.Sharding{Enabled: true, ShardID: "collector-1"}
+	require.NoError(t, s.UseShardStorage())
+	assert.Equal(t, filepath.Join(dir, "shards", "collector-1"), s.Database.StoragePath)
+	assert.DirExists(t, s.Database.StoragePath)
+
+	for _, id := range []string{"", ".", "..", "../collector-1", `a\b`} {
+		s := config.Settings{
+			Database: config.Database{StoragePath: dir},
+			Sharding: config.Sharding{Enabled: true, ShardID: id},
+		}
+		assert.Error(t, s.UseShardStorage(), id)
+	}
+}
+
+func TestLeaderElection_Validate(t *testing.T) {
+	disabled := config.LeaderElection{}
+	require.NoError(t, disabled.Validate(true))
+	assert.Empty(t, disabled.LeaseName, "defaults are only set when leader election is enabled")
+
+	l := config.LeaderElection{Enabled: true, Namespace: "cloudzero"}
+	require.NoError(t, l.Validate(false))
+	assert.Equal(t, config.DefaultLeaderElectionLeaseName, l.LeaseName)
+	assert.Equal(t, config.DefaultLeaderElectionLeaseDuration, l.LeaseDuration)
+	assert.Equal(t, config.DefaultLeaderElectionRenewDeadline, l.RenewDeadline)
+	assert.Equal(t, config.DefaultLeaderElectionRetryPeriod, l.RetryPeriod)
+
+	// Every shard ships its own files.
+	l = config.LeaderElection{Enabled: true, Namespace: "cloudzero"}
+	assert.Error(t, l.Validate(true))
+
+	l = config.LeaderElection{Enabled: true}
+	assert.Error(t, l.Validate(false))
+}
+
+func TestTracing_Validate(t *testing.T) {
+	disabled := config.Tracing{Protocol: "thrift"}
+	require.NoError(t, disabled.Validate())
+	assert.Zero(t, disabled.SampleRatio, "defaults are only set when tracing is enabled")
+
+	tr := config.Tracing{Enabled: true}
+	require.NoError(t, tr.Validate())
+	assert.Equal(t, config.DefaultTracingProtocol, tr.Protocol)
+	assert.Equal(t, config.DefaultTracingSampleRatio, tr.SampleRatio)
+
+	tr = config.Tracing{Enabled: true, Protocol: config.TracingProtocolGRPC, Endpoint: "https://otel-collector:4317", SampleRatio: 0.1}
+	require.NoError(t, tr.Validate())
+	assert.Equal(t, 0.1, tr.SampleRatio)
+
+	for _, tr := range []config.Tracing{
+		{Enabled: true, Protocol: "thrift"},
+		{Enabled: true, Endpoint: "otel-collector:4318"},
+		{Enabled: true, Endpoint: "ftp://otel-collector"},
+		{Enabled: true, SampleRatio: 1.5},
+		{Enabled: true, SampleRatio: -0.5},
+	} {
+		assert.Error(t, tr.Validate(), "%+v", tr)
+	}
+}
+
+func TestCompression_Validate(t *testing.T) {
+	c := config.Compression{}
+	require.NoError(t, c.Validate())
+	assert.Equal(t, config.CompressionModeFixed, c.Mode)
+	assert.Equal(t, "brotli", c.Codec)
+	assert.Equal(t, config.DefaultCompressionMinCPUHeadroom, c.MinCPUHeadroom)
+	assert.Equal(t, config.DefaultCompressionHighDiskPercent, c.HighDiskPercent)
+	assert.Equal(t, int64(config.DefaultCompressionHighPendingBytes), c.HighPendingBytes)
+
+	c = config.Compression{Mode: config.CompressionModeAdaptive, Codec: "ZSTD", MinCPUHeadroom: 0.5}
+	require.NoError(t, c.Validate())
+	assert.Equal(t, "zstd", c.Codec)
+	assert.Equal(t, 0.5, c.MinCPUHeadroom)
+
+	for _, c := range []config.Compression{
+		{Mode: "fastest"},
+		{Codec: "gzip"},
+	} {
+		assert.Error(t, c.Validate(), "%+v", c)
+	}
+}
+
+func TestIngest_Validate(t *testing.T) {
+	i := config.Ingest{}
+	require.NoError(t, i.Validate())
+	assert.Equal(t, config.TimestampGuardReject, i.TimestampGuard)
+	assert.Equal(t, config.DefaultIngestFutureTolerance, i.FutureTolerance)
+	assert.Equal(t, config.DefaultIngestPastTolerance, i.PastTolerance)
+	assert.Equal(t, config.DefaultIngestSkewThreshold, i.SkewThreshold)
+
+	i = config.Ingest{TimestampGuard: config.TimestampGuardClamp, FutureTolerance: time.Minute}
+	require.NoError(t, i.Validate())
+	assert.Equal(t, time.Minute, i.FutureTolerance)
+
+	i = config.Ingest{TimestampGuard: "drop"}
+	assert.Error(t, i.Validate())
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Collector configures how the diagnostics reach the collector: the data path
+// diagnostic sends its canary to the remote_write endpoint, and the scrape
+// coverage diagnostic reads the coverage endpoint. Both are authenticated like
+// remote_write requests.
+//
+// The default collector URLs use Scheme. The certificate of a collector
+// serving https is verified with TLS, and the bearer token read from TokenFile
+// is sent when the collector authenticates its clients: a token listed in its
+// tokens file, or the ServiceAccount token of the validator in tokenreview
+// mode. The identity of the validator must be one of the allowed identities
+// of the collector, when these are restricted.
+type Collector struct {
+	Scheme    string       `yaml:"scheme" default:"http" env:"COLLECTOR_SCHEME" env-description:"scheme of the default collector URLs such as http, https"`
+	TLS       CollectorTLS `yaml:"tls"`
+	TokenFile string       `yaml:"token_file" env:"COLLECTOR_TOKEN_FILE" env-description:"path to the bearer token sent to the collector"`
+}
+
+// CollectorTLS configures how the certificate of a collector serving https is
+// verified, and the client certificate presented to a collector requiring
+// mTLS.
+type CollectorTLS struct {
+	CAFile     string `yaml:"ca_file" env:"COLLECTOR_TLS_CA_FILE" env-description:"path to the CA bundle the collector certificate is verified with; empty uses the system roots"`
+	CertFile   string `yaml:"cert_file" env:"COLLECTOR_TLS_CERT_FILE" env-description:"path to the client certificate presented to the collector"`
+	KeyFile    string `yaml:"key_file" env:"COLLECTOR_TLS_KEY_FILE" env-description:"path to the client certificate key"`
+	ServerName string `yaml:"server_name" env:"COLLECTOR_TLS_SERVER_NAME" env-description:"name the collector certificate is verified against; defaults to the host of the collector URL"`
+}
+
+func (c *Collector) Validate() error {
+	if c.Scheme == "" {
+		c.Scheme = "http"
+	}
+	if c.Scheme != "http" && c.Scheme != "https" {
+		return fmt.Errorf("invalid collector scheme %s, must be http or https", c.Scheme)
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return errors.New("the collector TLS cert_file and key_file must be set together")
+	}
+	return nil
+}
+
+// collectorBaseURL returns the scheme and host of the collector service.
+func (s *Settings) collectorBaseURL() string {
+	scheme := s.Collector.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	return scheme + "://" + s.aggregatorHost()
+}
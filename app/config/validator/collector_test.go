@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+)
+
+func TestCollector_Scheme(t *testing.T) {
+	s := &config.Settings{
+		Services:  config.Services{Namespace: "cza", CollectorService: "cz-aggregator"},
+		Collector: config.Collector{Scheme: "https"},
+	}
+	require.NoError(t, s.Collector.Validate())
+	assert.Equal(t, "https://cz-aggregator.cza.svc.cluster.local/collector", s.CollectorURL())
+	assert.Equal(t, "https://cz-aggregator.cza.svc.cluster.local/coverage", s.CoverageURL())
+}
+
+func TestCollector_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		collector config.Collector
+		wantErr   bool
+	}{
+		{name: "defaults", collector: config.Collector{}},
+		{name: "https with CA", collector: config.Collector{Scheme: "https", TLS: config.CollectorTLS{CAFile: "/ca.crt"}}},
+		{name: "client certificate", collector: config.Collector{Scheme: "https", TLS: config.CollectorTLS{CertFile: "/tls.crt", KeyFile: "/tls.key"}}},
+		{name: "token", collector: config.Collector{TokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token"}},
+		{name: "unknown scheme", collector: config.Collector{Scheme: "ftp"}, wantErr: true},
+		{name: "cert without key", collector: config.Collector{Scheme: "https", TLS: config.CollectorTLS{CertFile: "/tls.crt"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.collector.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultRequiredNodeMetrics are the cost metrics which must be received for
+// every node of the cluster.
+var DefaultRequiredNodeMetrics = []string{
+	"container_cpu_usage_seconds_total",
+	"container_memory_working_set_bytes",
+	"kube_node_info",
+	"kube_node_status_capacity",
+}
+
+// ScrapeCoverage configures the scrape coverage diagnostic, which compares the
+// metrics received by the collector for each node with the nodes of the
+// cluster.
+//
+// The URL defaults to the coverage endpoint of the collector service; it only
+// needs to be set when the aggregator is reached some other way. Without it,
+// every ready replica behind the service is queried and their coverage is
+// merged, since each replica only knows the series sent to it; a configured
+// URL is queried alone.
+type ScrapeCoverage struct {
+	URL             string        `yaml:"url" env:"SCRAPE_COVERAGE_URL" env-description:"URL of the collector coverage endpoint"`
+	Window          time.Duration `yaml:"window" default:"10m" env:"SCRAPE_COVERAGE_WINDOW" env-description:"window in which every node must have reported the required metrics"`
+	RequiredMetrics []string      `yaml:"required_metrics" env:"SCRAPE_COVERAGE_REQUIRED_METRICS" env-description:"cost metrics which must be received for every node"`
+}
+
+func (c *ScrapeCoverage) Validate() error {
+	if c.URL != "" && !isValidURL(c.URL) {
+		return fmt.Errorf("invalid scrape coverage URL %s", c.URL)
+	}
+	if c.Window <= 0 {
+		c.Window = 10 * time.Minute
+	}
+	if c.Window > time.Hour {
+		return fmt.Errorf("scrape coverage window %s is longer than the 1h the collector keeps coverage for", c.Window)
+	}
+	if len(c.RequiredMetrics) == 0 {
+		c.RequiredMetrics = DefaultRequiredNodeMetrics
+	}
+	return nil
+}
+
+// CoverageURL returns the URL of the coverage endpoint of the collector.
+func (s *Settings) CoverageURL() string {
+	if s.ScrapeCoverage.URL != "" {
+		return s.ScrapeCoverage.URL
+	}
+	return s.collectorBaseURL() + "/coverage"
+}
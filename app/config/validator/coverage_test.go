@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+)
+
+func TestScrapeCoverage_Validate(t *testing.T) {
+	s := &config.Settings{
+		Services: config.Services{Namespace: "cza", CollectorService: "cz-aggregator"},
+	}
+	require.NoError(t, s.ScrapeCoverage.Validate())
+	assert.Equal(t, 10*time.Minute, s.ScrapeCoverage.Window)
+	assert.Equal(t, config.DefaultRequiredNodeMetrics, s.ScrapeCoverage.RequiredMetrics)
+	assert.Equal(t, "http://cz-aggregator.cza.svc.cluster.local/coverage", s.CoverageURL())
+
+	s.ScrapeCoverage.URL = "http://localhost:8080/coverage"
+	s.ScrapeCoverage.RequiredMetrics = []string{"kube_node_info"}
+	require.NoError(t, s.ScrapeCoverage.Validate())
+	assert.Equal(t, "http://localhost:8080/coverage", s.CoverageURL())
+	assert.Equal(t, []string{"kube_node_info"}, s.ScrapeCoverage.RequiredMetrics)
+
+	s.ScrapeCoverage.Window = 2 * time.Hour
+	assert.Error(t, s.ScrapeCoverage.Validate())
+}
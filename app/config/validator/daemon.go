@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// minDaemonInterval keeps a misconfigured schedule from hammering the
+// CloudZero API or the cluster.
+const minDaemonInterval = 10 * time.Second
+
+// Daemon configures the long-running diagnostics mode, which re-runs checks on
+// a schedule instead of once per lifecycle stage.
+type Daemon struct {
+	Address  string           `yaml:"address" default:":8081" env:"DAEMON_ADDRESS" env-description:"address on which the daemon serves /status and /metrics"`
+	Interval time.Duration    `yaml:"interval" default:"5m" env:"DAEMON_INTERVAL" env-description:"default interval between runs of a check"`
+	Checks   []ScheduledCheck `yaml:"checks"`
+}
+
+// ScheduledCheck is a check run by the daemon. A zero Interval uses the
+// daemon's default interval.
+type ScheduledCheck struct {
+	Name     string        `yaml:"name"`
+	Type     CheckType     `yaml:"type" default:"optional"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+func (d *Daemon) Validate() error {
+	if d.Interval == 0 {
+		d.Interval = 5 * time.Minute
+	}
+	if d.Interval < minDaemonInterval {
+		return fmt.Errorf("daemon interval must be at least %s", minDaemonInterval)
+	}
+
+	seen := make(map[string]bool, len(d.Checks))
+	for i := range d.Checks {
+		c := &d.Checks[i]
+		cc := CheckConfig{Name: c.Name, Type: c.Type}
+		if err := cc.Validate(); err != nil {
+			return err
+		}
+		c.Name, c.Type = cc.Name, cc.Type
+		if seen[c.Name] {
+			return fmt.Errorf("diagnostic check scheduled more than once: %s", c.Name)
+		}
+		seen[c.Name] = true
+
+		if c.Interval == 0 {
+			c.Interval = d.Interval
+		}
+		if c.Interval < minDaemonInterval {
+			return fmt.Errorf("interval of %s must be at least %s", c.Name, minDaemonInterval)
+		}
+	}
+	return nil
+}
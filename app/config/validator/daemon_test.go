@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+)
+
+func TestDaemon_Validate(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		d := config.Daemon{
+			Checks: []config.ScheduledCheck{
+				{Name: " API_KEY_VALID ", Type: "Required"},
+				{Name: config.DiagnosticKMS, Interval: time.Minute},
+			},
+		}
+		require.NoError(t, d.Validate())
+		assert.Equal(t, 5*time.Minute, d.Interval)
+		assert.Equal(t, config.ScheduledCheck{Name: config.DiagnosticAPIKey, Type: config.CheckTypeRequired, Interval: 5 * time.Minute}, d.Checks[0])
+		assert.Equal(t, config.ScheduledCheck{Name: config.DiagnosticKMS, Type: config.CheckTypeOptional, Interval: time.Minute}, d.Checks[1])
+	})
+
+	tcases := map[string]config.Daemon{
+		"unknown check":   {Checks: []config.ScheduledCheck{{Name: "bogus"}}},
+		"duplicate check": {Checks: []config.ScheduledCheck{{Name: config.DiagnosticKMS}, {Name: config.DiagnosticKMS}}},
+		"short interval":  {Interval: time.Second},
+		"short check interval": {
+			Checks: []config.ScheduledCheck{{Name: config.DiagnosticKMS, Interval: time.Second}},
+		},
+	}
+	for name, d := range tcases {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, d.Validate())
+		})
+	}
+}
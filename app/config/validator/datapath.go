@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DataPath configures the data path diagnostic, which sends a canary sample to
+// the collector and follows it until the shipper has uploaded it.
+//
+// The collector and shipper URLs default to the collector service; they only
+// need to be set when the aggregator is reached some other way. The canary is
+// sent with the settings of the Collector.
+//
+// With a sharded aggregator the canary is routed to the replica owning its
+// series, while the shipper polled may be another replica. The diagnostic
+// then fails although the data path works; point ShipperURL at the replica
+// owning the canary to trace it.
+type DataPath struct {
+	CollectorURL string        `yaml:"collector_url" env:"DATA_PATH_COLLECTOR_URL" env-description:"remote_write URL of the collector"`
+	ShipperURL   string        `yaml:"shipper_url" env:"DATA_PATH_SHIPPER_URL" env-description:"base URL of the shipper API"`
+	Timeout      time.Duration `yaml:"timeout" default:"20m" env:"DATA_PATH_TIMEOUT" env-description:"how long to wait for the canary to be uploaded"`
+	PollInterval time.Duration `yaml:"poll_interval" default:"15s" env:"DATA_PATH_POLL_INTERVAL" env-description:"interval between checks of the canary's progress"`
+}
+
+func (d *DataPath) Validate() error {
+	if d.CollectorURL != "" && !isValidURL(d.CollectorURL) {
+		return fmt.Errorf("invalid data path collector URL %s", d.CollectorURL)
+	}
+	if d.ShipperURL != "" && !isValidURL(d.ShipperURL) {
+		return fmt.Errorf("invalid data path shipper URL %s", d.ShipperURL)
+	}
+	if d.Timeout <= 0 {
+		d.Timeout = 20 * time.Minute
+	}
+	if d.PollInterval <= 0 {
+		d.PollInterval = 15 * time.Second
+	}
+	return nil
+}
+
+// CollectorURL returns the remote_write URL of the collector.
+func (s *Settings) CollectorURL() string {
+	if s.DataPath.CollectorURL != "" {
+		return s.DataPath.CollectorURL
+	}
+	return s.collectorBaseURL() + "/collector"
+}
+
+// ShipperURL returns the base URL of the shipper API.
+func (s *Settings) ShipperURL() string {
+	if s.DataPath.ShipperURL != "" {
+		return s.DataPath.ShipperURL
+	}
+	return "http://" + s.aggregatorHost() + ":8081"
+}
+
+func (s *Settings) aggregatorHost() string {
+	return s.Services.CollectorService + "." + s.Services.Namespace + ".svc.cluster.local"
+}
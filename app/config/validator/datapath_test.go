@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+)
+
+func TestDataPath_URLs(t *testing.T) {
+	s := &config.Settings{
+		Services: config.Services{Namespace: "cza", CollectorService: "cz-aggregator"},
+	}
+	require.NoError(t, s.DataPath.Validate())
+	assert.Equal(t, 20*time.Minute, s.DataPath.Timeout)
+	assert.Equal(t, 15*time.Second, s.DataPath.PollInterval)
+	assert.Equal(t, "http://cz-aggregator.cza.svc.cluster.local/collector", s.CollectorURL())
+	assert.Equal(t, "http://cz-aggregator.cza.svc.cluster.local:8081", s.ShipperURL())
+
+	s.DataPath.CollectorURL = "http://localhost:8080/collector"
+	s.DataPath.ShipperURL = "http://localhost:8081"
+	require.NoError(t, s.DataPath.Validate())
+	assert.Equal(t, "http://localhost:8080/collector", s.CollectorURL())
+	assert.Equal(t, "http://localhost:8081", s.ShipperURL())
+
+	s.DataPath.ShipperURL = "not a url"
+	assert.Error(t, s.DataPath.Validate())
+}
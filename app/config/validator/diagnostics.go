@@ -19,6 +19,10 @@ const (
 	DiagnosticInsightsIngress   string = "webhook_server_reachable"
 	DiagnosticAgentSettings     string = "agent_settings"
 	DiagnosticIstioXClusterLB   string = "istio_xcluster_lb"
+	DiagnosticDataPath          string = "data_path"
+	DiagnosticScrapeCoverage    string = "scrape_coverage"
+	DiagnosticClusterIdentity   string = "cluster_identity"
+	DiagnosticEgress            string = "egress"
 )
 
 const (
@@ -57,7 +61,9 @@ func IsValidDiagnostic(d string) bool {
 		DiagnosticK8sNamespace, DiagnosticK8sProvider,
 		DiagnosticKMS, DiagnosticScrapeConfig,
 		DiagnosticPrometheusVersion, DiagnosticInsightsIngress,
-		DiagnosticAgentSettings, DiagnosticIstioXClusterLB:
+		DiagnosticAgentSettings, DiagnosticIstioXClusterLB,
+		DiagnosticDataPath, DiagnosticScrapeCoverage,
+		DiagnosticClusterIdentity, DiagnosticEgress:
 		return true
 	}
 	return false
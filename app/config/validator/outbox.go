@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// outboxDirName is the name of the default outbox directory, created next to
+// the validator executable. The executable is copied to a volume shared by
+// the init containers and the lifecycle hooks of the agent, so a report
+// spooled by one of them is retried by the next.
+const outboxDirName = "outbox"
+
+// Outbox configures the telemetry outbox, where status reports which could not
+// be posted are kept and retried with exponential backoff.
+type Outbox struct {
+	Disabled       bool          `yaml:"disabled" default:"false" env:"OUTBOX_DISABLED" env-description:"post status reports once, without retries"`
+	Directory      string        `yaml:"directory" env:"OUTBOX_DIRECTORY" env-description:"directory failed status reports are kept in, next to the executable by default"`
+	MaxEntries     int           `yaml:"max_entries" default:"32" env:"OUTBOX_MAX_ENTRIES" env-description:"maximum number of status reports kept"`
+	MaxBytes       int64         `yaml:"max_bytes" default:"1048576" env:"OUTBOX_MAX_BYTES" env-description:"maximum total size of the status reports kept"`
+	MaxAge         time.Duration `yaml:"max_age" default:"24h" env:"OUTBOX_MAX_AGE" env-description:"age after which a status report is dropped"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" default:"5s" env:"OUTBOX_INITIAL_BACKOFF" env-description:"delay before the first retry"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" default:"5m" env:"OUTBOX_MAX_BACKOFF" env-description:"maximum delay between retries"`
+}
+
+func (o *Outbox) Validate() error {
+	if o.Disabled {
+		return nil
+	}
+	if o.Directory == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate the outbox directory: %w", err)
+		}
+		o.Directory = filepath.Join(filepath.Dir(exe), outboxDirName)
+	}
+	location, err := absFilePath(o.Directory)
+	if err != nil {
+		return err
+	}
+	o.Directory = location
+
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = 32
+	}
+	if o.MaxBytes <= 0 {
+		o.MaxBytes = 1 << 20
+	}
+	if o.MaxAge <= 0 {
+		o.MaxAge = 24 * time.Hour
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = 5 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Minute
+	}
+	if o.MaxBackoff < o.InitialBackoff {
+		return fmt.Errorf("outbox max backoff %s is shorter than the initial backoff %s", o.MaxBackoff, o.InitialBackoff)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+)
+
+func TestOutbox_Validate(t *testing.T) {
+	o := config.Outbox{}
+	require.NoError(t, o.Validate())
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(filepath.Dir(exe), "outbox"), o.Directory)
+	assert.Equal(t, 32, o.MaxEntries)
+	assert.Equal(t, int64(1<<20), o.MaxBytes)
+	assert.Equal(t, 24*time.Hour, o.MaxAge)
+	assert.Equal(t, 5*time.Second, o.InitialBackoff)
+	assert.Equal(t, 5*time.Minute, o.MaxBackoff)
+
+	o = config.Outbox{Directory: "/var/run/outbox", InitialBackoff: time.Minute, MaxBackoff: time.Second}
+	assert.Error(t, o.Validate())
+
+	o = config.Outbox{Disabled: true}
+	require.NoError(t, o.Validate())
+	assert.Empty(t, o.Directory)
+}
@@ -11,18 +11,27 @@ import (
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v3"
+
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
 )
 
 type Settings struct {
 	ExecutionContext Context
-	Logging          Logging      `yaml:"logging"`
-	Deployment       Deployment   `yaml:"deployment"`
-	Versions         Versions     `yaml:"versions"`
-	Cloudzero        Cloudzero    `yaml:"cloudzero"`
-	Prometheus       Prometheus   `yaml:"prometheus"`
-	Diagnostics      Diagnostics  `yaml:"diagnostics"`
-	Services         Services     `yaml:"services"`
-	Integrations     Integrations `yaml:"integrations"`
+	Logging          Logging        `yaml:"logging"`
+	Deployment       Deployment     `yaml:"deployment"`
+	Versions         Versions       `yaml:"versions"`
+	Cloudzero        Cloudzero      `yaml:"cloudzero"`
+	Prometheus       Prometheus     `yaml:"prometheus"`
+	Diagnostics      Diagnostics    `yaml:"diagnostics"`
+	Services         Services       `yaml:"services"`
+	Integrations     Integrations   `yaml:"integrations"`
+	Daemon           Daemon         `yaml:"daemon"`
+	Collector        Collector      `yaml:"collector"`
+	DataPath         DataPath       `yaml:"data_path"`
+	ScrapeCoverage   ScrapeCoverage `yaml:"scrape_coverage"`
+	Outbox           Outbox         `yaml:"outbox"`
+
+	Egress transport.Config `yaml:"egress"`
 }
 
 // Integrations contains configuration for third-party integrations
@@ -43,6 +52,16 @@ type Services struct {
 	CollectorService string `yaml:"collector_service" validate:"required"`
 }
 
+// MetricsURLs returns the URLs of the Prometheus metrics of the collector,
+// shipper and webhook server, keyed by component.
+func (s *Settings) MetricsURLs() map[string]string {
+	return map[string]string{
+		"collector": "http://" + s.aggregatorHost() + "/metrics",
+		"shipper":   s.ShipperURL() + "/metrics",
+		"webhook":   "https://" + s.Services.InsightsService + "." + s.Services.Namespace + ".svc.cluster.local/metrics",
+	}
+}
+
 func NewSettings(configFiles ...string) (*Settings, error) {
 	var cfg Settings
 
@@ -93,6 +112,30 @@ func (s *Settings) Validate() error {
 		return err
 	}
 
+	if err := s.Daemon.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Collector.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.DataPath.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.ScrapeCoverage.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Outbox.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.Egress.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
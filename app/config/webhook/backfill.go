@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+// ChangedSinceLastRun is the Backfill.ChangedSince value which selects the
+// resourceVersion recorded by the last completed backfill run.
+const ChangedSinceLastRun = "last"
+
+// Backfill configures the backfill job. List requests are rate limited on the
+// client side so a backfill of a large cluster does not starve other API
+// server tenants; the limit is lowered automatically while the API server
+// throttles requests. When a checkpoint location is set the job records its
+// progress per kind and namespace, and an interrupted run resumes where it
+// stopped instead of listing the whole cluster again.
+//
+// ChangedSince skips objects whose resourceVersion is not newer than the given
+// one. The API server treats resourceVersions as opaque, and neither list nor
+// watch can select the objects changed since one beyond the watch cache, so
+// the comparison is numeric and only best effort: it holds for etcd revisions,
+// but may skip changed objects on API servers with another storage. It is
+// therefore only honoured when ChangedSinceBestEffort acknowledges this.
+type Backfill struct {
+	Concurrency            int           `yaml:"concurrency" default:"0" env:"BACKFILL_CONCURRENCY" env-description:"maximum number of list operations run in parallel; 0 uses the number of CPUs, capped at 10"`
+	QPS                    float64       `yaml:"qps" default:"20" env:"BACKFILL_QPS" env-description:"maximum sustained rate of list requests per second; 0 disables the limit"`
+	Burst                  int           `yaml:"burst" default:"40" env:"BACKFILL_BURST" env-description:"maximum burst of list requests above the sustained rate"`
+	MaxRetries             int           `yaml:"max_retries" default:"5" env:"BACKFILL_MAX_RETRIES" env-description:"number of times a throttled (HTTP 429) list request is retried; client-go does not retry them itself"`
+	RetryBackoff           time.Duration `yaml:"retry_backoff" default:"1s" env:"BACKFILL_RETRY_BACKOFF" env-description:"initial delay before retrying a throttled list request without a Retry-After header; doubled on each attempt"`
+	CheckpointFile         string        `yaml:"checkpoint_file" env:"BACKFILL_CHECKPOINT_FILE" env-description:"path of a local file in which backfill progress is recorded"`
+	CheckpointConfigMap    string        `yaml:"checkpoint_configmap" env:"BACKFILL_CHECKPOINT_CONFIGMAP" env-description:"name of a ConfigMap in the server namespace in which backfill progress is recorded; takes precedence over checkpoint_file"`
+	CheckpointInterval     time.Duration `yaml:"checkpoint_interval" default:"5s" env:"BACKFILL_CHECKPOINT_INTERVAL" env-description:"minimum interval between checkpoint writes"`
+	ChangedSince           string        `yaml:"changed_since" env:"BACKFILL_CHANGED_SINCE" env-description:"only publish objects with a newer resourceVersion; 'last' uses the resourceVersion recorded by the last completed run"`
+	ChangedSinceBestEffort bool          `yaml:"changed_since_best_effort" default:"false" env:"BACKFILL_CHANGED_SINCE_BEST_EFFORT" env-description:"acknowledge that changed_since compares resourceVersions as numbers, which Kubernetes does not guarantee, and may skip changed objects; required with changed_since"`
+}
+
+func (b *Backfill) validate() error {
+	if b.ChangedSince != "" && !b.ChangedSinceBestEffort {
+		return errors.New("changed_since compares opaque resourceVersions and is only best effort, set changed_since_best_effort to use it")
+	}
+	return nil
+}
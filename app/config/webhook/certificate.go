@@ -3,7 +3,13 @@
 
 package config
 
+import "time"
+
 type Certificate struct {
 	Key  string `yaml:"key" env:"TLS_KEY" env-description:"path to the TLS key"`
 	Cert string `yaml:"cert" env:"TLS_CERT" env-description:"path to the TLS certificate"`
+	// ReloadInterval is how often the certificate files are read again, so a
+	// rotated certificate is served without a restart. SIGHUP also reloads
+	// them.
+	ReloadInterval time.Duration `yaml:"reload_interval" default:"5m" env:"TLS_RELOAD_INTERVAL" env-description:"how often to reload the TLS certificate and key"`
 }
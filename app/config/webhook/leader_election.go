@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DefaultLeaderElectionLeaseName     = "cloudzero-webhook-informer"
+	DefaultLeaderElectionLeaseDuration = 15 * time.Second
+	DefaultLeaderElectionRenewDeadline = 10 * time.Second
+	DefaultLeaderElectionRetryPeriod   = 2 * time.Second
+)
+
+// LeaderElection configures the election of the one replica running the
+// informers in informer mode. Every replica watching every resource would
+// send each change once per replica, so the replicas campaign for a Lease and
+// only its holder watches. Admission requests, the pusher and the
+// housekeeper are not elected: each replica stores and sends the changes it
+// received itself.
+type LeaderElection struct {
+	Enabled       bool          `yaml:"enabled" default:"false" env:"LEADER_ELECTION_ENABLED" env-description:"whether only the replica holding the lease runs the informers"`
+	LeaseName     string        `yaml:"lease_name" default:"cloudzero-webhook-informer" env:"LEADER_ELECTION_LEASE_NAME" env-description:"name of the Lease the replicas campaign for"`
+	Namespace     string        `yaml:"namespace" env:"POD_NAMESPACE" env-description:"namespace of the Lease"`
+	Identity      string        `yaml:"identity" env:"POD_NAME" env-description:"identity of this replica as the holder of the Lease; defaults to the hostname"`
+	LeaseDuration time.Duration `yaml:"lease_duration" default:"15s" env:"LEADER_ELECTION_LEASE_DURATION" env-description:"how long the other replicas wait before taking over a Lease which is not renewed"`
+	RenewDeadline time.Duration `yaml:"renew_deadline" default:"10s" env:"LEADER_ELECTION_RENEW_DEADLINE" env-description:"how long the leader retries renewing the Lease before giving up"`
+	RetryPeriod   time.Duration `yaml:"retry_period" default:"2s" env:"LEADER_ELECTION_RETRY_PERIOD" env-description:"how often the replicas try to acquire or renew the Lease"`
+}
+
+func (l *LeaderElection) validate() error {
+	if !l.Enabled {
+		return nil
+	}
+	if l.LeaseName == "" {
+		l.LeaseName = DefaultLeaderElectionLeaseName
+	}
+	if l.LeaseDuration <= 0 {
+		l.LeaseDuration = DefaultLeaderElectionLeaseDuration
+	}
+	if l.RenewDeadline <= 0 {
+		l.RenewDeadline = DefaultLeaderElectionRenewDeadline
+	}
+	if l.RetryPeriod <= 0 {
+		l.RetryPeriod = DefaultLeaderElectionRetryPeriod
+	}
+	if l.Namespace == "" {
+		return errors.New("leader election requires the namespace of the lease")
+	}
+	return nil
+}
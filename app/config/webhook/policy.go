@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PolicyMode selects how the webhook reacts to resources that are missing
+// required cost-allocation labels.
+type PolicyMode string
+
+const (
+	// PolicyModeDisabled turns policy enforcement off; every request is allowed
+	// without warnings. This is the default.
+	PolicyModeDisabled PolicyMode = "disabled"
+
+	// PolicyModeWarn allows the request but attaches an admission warning for
+	// each missing label.
+	PolicyModeWarn PolicyMode = "warn"
+
+	// PolicyModeDeny rejects creation of resources missing required labels in
+	// the enforced namespaces.
+	PolicyModeDeny PolicyMode = "deny"
+
+	// PolicyModeMutate patches missing labels with defaults derived from the
+	// labels of the resource's namespace. Labels which cannot be defaulted
+	// result in a warning.
+	//
+	// The API server only applies patches returned by a mutating webhook, so
+	// in this mode the policy is served on /mutate, which the chart registers
+	// as a MutatingWebhookConfiguration, instead of on /validate.
+	PolicyModeMutate PolicyMode = "mutate"
+)
+
+// Policy configures enforcement of required cost-allocation labels.
+type Policy struct {
+	Mode           PolicyMode        `yaml:"mode" default:"disabled" env:"POLICY_MODE" env-description:"policy enforcement mode: disabled, warn, deny or mutate"`
+	DryRun         bool              `yaml:"dry_run" default:"false" env:"POLICY_DRY_RUN" env-description:"evaluate the policy and report violations via metrics without affecting admission"`
+	RequiredLabels []string          `yaml:"required_labels" env:"POLICY_REQUIRED_LABELS" env-description:"labels every namespaced resource must carry"`
+	Namespaces     []string          `yaml:"namespaces" env:"POLICY_NAMESPACES" env-description:"namespaces in which deny mode rejects requests; empty means all namespaces"`
+	Defaults       map[string]string `yaml:"defaults" env-description:"map of required label to the namespace label its default is read from in mutate mode; unlisted labels use the namespace label of the same name"`
+	Exemptions     PolicyExemptions  `yaml:"exemptions"`
+}
+
+// PolicyExemptions lists requests which are never subject to the policy.
+type PolicyExemptions struct {
+	Namespaces      []string `yaml:"namespaces" env:"POLICY_EXEMPT_NAMESPACES" env-description:"namespaces exempt from the policy"`
+	ServiceAccounts []string `yaml:"service_accounts" env:"POLICY_EXEMPT_SERVICE_ACCOUNTS" env-description:"service accounts (namespace:name) exempt from the policy"`
+}
+
+// Enabled reports whether the policy should be evaluated at all.
+func (p *Policy) Enabled() bool {
+	return p.Mode != "" && p.Mode != PolicyModeDisabled && len(p.RequiredLabels) > 0
+}
+
+// Enforced reports whether deny mode applies to the given namespace.
+func (p *Policy) Enforced(namespace string) bool {
+	return len(p.Namespaces) == 0 || slices.Contains(p.Namespaces, namespace)
+}
+
+// Exempt reports whether a request in the given namespace, made by the given
+// user, is exempt from the policy. Service accounts may be listed either as
+// "namespace:name" or in their full "system:serviceaccount:namespace:name"
+// form.
+func (p *Policy) Exempt(namespace, username string) bool {
+	if slices.Contains(p.Exemptions.Namespaces, namespace) {
+		return true
+	}
+
+	sa, ok := strings.CutPrefix(username, "system:serviceaccount:")
+	if !ok {
+		return false
+	}
+	for _, exempt := range p.Exemptions.ServiceAccounts {
+		if exempt == sa || exempt == username {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) validate() error {
+	switch p.Mode {
+	case "":
+		p.Mode = PolicyModeDisabled
+	case PolicyModeDisabled, PolicyModeWarn, PolicyModeDeny, PolicyModeMutate:
+	default:
+		return fmt.Errorf("invalid policy mode %q", p.Mode)
+	}
+
+	for label := range p.Defaults {
+		if !slices.Contains(p.RequiredLabels, label) {
+			return fmt.Errorf("policy default for %q does not match a required label", label)
+		}
+	}
+	return nil
+}
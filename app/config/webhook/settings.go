@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
 	"github.com/cloudzero/cloudzero-agent/app/utils/scout"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/rs/zerolog/log"
@@ -35,6 +36,12 @@ type Settings struct {
 	Filters        Filters     `yaml:"filters"`
 	RemoteWrite    RemoteWrite `yaml:"remote_write"`
 	K8sClient      K8sClient   `yaml:"k8s_client"`
+	Policy         Policy      `yaml:"policy"`
+	Watch          Watch       `yaml:"watch"`
+	Backfill       Backfill    `yaml:"backfill"`
+
+	LeaderElection LeaderElection   `yaml:"leader_election"`
+	Egress         transport.Config `yaml:"egress"`
 
 	// Deprecated: removed in CP-28161 when the insights-controller stopped
 	// authenticating to the in-cluster aggregator. Kept as an ignored
@@ -95,6 +102,22 @@ func NewSettings(configFiles ...string) (*Settings, error) {
 
 	cfg.setCompiledFilters()
 
+	if err = cfg.Policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy configuration: %w", err)
+	}
+
+	if err = cfg.Backfill.validate(); err != nil {
+		return nil, fmt.Errorf("invalid backfill configuration: %w", err)
+	}
+
+	if err = cfg.LeaderElection.validate(); err != nil {
+		return nil, fmt.Errorf("invalid leader election configuration: %w", err)
+	}
+
+	if err = cfg.Egress.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid egress configuration: %w", err)
+	}
+
 	cfg.setRemoteWriteURL()
 	cfg.setPolicy()
 
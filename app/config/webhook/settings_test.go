@@ -179,3 +179,71 @@ func TestCleanString(t *testing.T) {
 		})
 	}
 }
+
+func TestPolicy(t *testing.T) {
+	t.Run("validate", func(t *testing.T) {
+		p := Policy{}
+		require.NoError(t, p.validate())
+		assert.Equal(t, PolicyModeDisabled, p.Mode)
+		assert.False(t, p.Enabled())
+
+		p = Policy{Mode: "enforce"}
+		assert.Error(t, p.validate())
+
+		p = Policy{Mode: PolicyModeWarn, RequiredLabels: []string{"team"}, Defaults: map[string]string{"cost-center": "cc"}}
+		assert.Error(t, p.validate())
+
+		p = Policy{Mode: PolicyModeWarn, RequiredLabels: []string{"team"}, Defaults: map[string]string{"team": "owner"}}
+		require.NoError(t, p.validate())
+		assert.True(t, p.Enabled())
+
+		p = Policy{Mode: PolicyModeMutate, RequiredLabels: []string{"team"}}
+		require.NoError(t, p.validate())
+		assert.True(t, p.Enabled())
+	})
+
+	t.Run("scope", func(t *testing.T) {
+		p := Policy{
+			Mode:           PolicyModeDeny,
+			RequiredLabels: []string{"team"},
+			Namespaces:     []string{"payments"},
+			Exemptions: PolicyExemptions{
+				Namespaces:      []string{"kube-system"},
+				ServiceAccounts: []string{"argocd:argocd-application-controller"},
+			},
+		}
+		assert.True(t, p.Enforced("payments"))
+		assert.False(t, p.Enforced("default"))
+		assert.True(t, p.Exempt("kube-system", "alice"))
+		assert.True(t, p.Exempt("payments", "system:serviceaccount:argocd:argocd-application-controller"))
+		assert.False(t, p.Exempt("payments", "system:serviceaccount:argocd:other"))
+		assert.False(t, p.Exempt("payments", "argocd:argocd-application-controller"))
+	})
+}
+
+func TestLeaderElection(t *testing.T) {
+	l := LeaderElection{}
+	require.NoError(t, l.validate())
+	assert.Empty(t, l.LeaseName, "defaults are only set when leader election is enabled")
+
+	l = LeaderElection{Enabled: true, Namespace: "cloudzero"}
+	require.NoError(t, l.validate())
+	assert.Equal(t, DefaultLeaderElectionLeaseName, l.LeaseName)
+	assert.Equal(t, DefaultLeaderElectionLeaseDuration, l.LeaseDuration)
+	assert.Equal(t, DefaultLeaderElectionRenewDeadline, l.RenewDeadline)
+	assert.Equal(t, DefaultLeaderElectionRetryPeriod, l.RetryPeriod)
+
+	l = LeaderElection{Enabled: true}
+	assert.Error(t, l.validate())
+}
+
+func TestBackfill(t *testing.T) {
+	b := Backfill{}
+	require.NoError(t, b.validate())
+
+	b = Backfill{ChangedSince: ChangedSinceLastRun}
+	assert.Error(t, b.validate(), "changed_since requires the best effort acknowledgement")
+
+	b = Backfill{ChangedSince: ChangedSinceLastRun, ChangedSinceBestEffort: true}
+	require.NoError(t, b.validate())
+}
@@ -0,0 +1,14 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import "time"
+
+// Watch configures the informer mode, in which resource changes are observed
+// with list+watch instead of admission webhooks. This is intended for clusters
+// which forbid webhook configurations, such as GKE Autopilot.
+type Watch struct {
+	Enabled      bool          `yaml:"enabled" default:"false" env:"WATCH_ENABLED" env-description:"observe resources with list+watch informers instead of admission webhooks"`
+	ResyncPeriod time.Duration `yaml:"resync_period" default:"0s" env:"WATCH_RESYNC_PERIOD" env-description:"interval at which informers replay every cached object, which is not reviewed again unless its labels or annotations changed; 0 disables resync"`
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package bundle collects the state of an agent installation into a single
+// tar.gz archive which can be attached to a support ticket.
+//
+// A bundle is made of sections, each collecting one kind of information such
+// as the configuration or the files on disk. A section which fails does not
+// fail the bundle: its error is recorded in the manifest, which is always the
+// last file of the archive, and the remaining sections are still collected.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/cloudzero/cloudzero-agent/app/build"
+)
+
+// ManifestName is the name of the manifest in the archive.
+const ManifestName = "manifest.json"
+
+// Manifest describes the content of a bundle.
+type Manifest struct {
+	// CreatedAt is when the bundle was created.
+	CreatedAt time.Time `json:"createdAt"`
+
+	// Since is the start of the window of time-based content, such as logs
+	// and file listings.
+	Since time.Time `json:"since"`
+
+	// Version is the version of the tool which created the bundle.
+	Version string `json:"version"`
+
+	// Files lists every file of the bundle, except the manifest.
+	Files []File `json:"files"`
+
+	// Errors lists the sections which could not be collected completely.
+	Errors []SectionError `json:"errors,omitempty"`
+}
+
+// File is a file of a bundle.
+type File struct {
+	Path    string `json:"path"`
+	Section string `json:"section"`
+	Size    int    `json:"size"`
+}
+
+// SectionError records why a section could not be collected.
+type SectionError struct {
+	Section string `json:"section"`
+	Error   string `json:"error"`
+}
+
+// Section collects one part of a bundle.
+type Section struct {
+	// Name identifies the section in the manifest.
+	Name string
+
+	// Collect adds the files of the section to the bundle.
+	Collect func(ctx context.Context, w *Writer) error
+}
+
+// Writer adds files to a bundle on behalf of a section.
+type Writer struct {
+	tw       *tar.Writer
+	manifest *Manifest
+	section  string
+}
+
+// Since returns the start of the window of time-based content.
+func (w *Writer) Since() time.Time {
+	return w.manifest.Since
+}
+
+// Add adds a file to the bundle.
+func (w *Writer) Add(name string, data []byte) error {
+	name = path.Clean(name)
+	if err := w.tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: w.manifest.CreatedAt,
+	}); err != nil {
+		return fmt.Errorf("failed to write the header of %s: %w", name, err)
+	}
+	if _, err := w.tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if w.section != "" {
+		w.manifest.Files = append(w.manifest.Files, File{Path: name, Section: w.section, Size: len(data)})
+	}
+	return nil
+}
+
+// AddJSON adds a file holding the indented JSON encoding of v to the bundle.
+func (w *Writer) AddJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return w.Add(name, append(data, '\n'))
+}
+
+// Write collects the sections into a tar.gz archive written to out, and
+// returns its manifest. Only errors writing the archive are returned; errors
+// collecting a section are recorded in the manifest.
+func Write(ctx context.Context, out io.Writer, since time.Time, sections ...Section) (*Manifest, error) {
+	gz := gzip.NewWriter(out)
+	w := &Writer{
+		tw: tar.NewWriter(gz),
+		manifest: &Manifest{
+			CreatedAt: time.Now().UTC(),
+			Since:     since.UTC(),
+			Version:   build.GetVersion(),
+			Files:     []File{},
+		},
+	}
+
+	for _, s := range sections {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		w.section = s.Name
+		if err := s.Collect(ctx, w); err != nil {
+			w.manifest.Errors = append(w.manifest.Errors, SectionError{Section: s.Name, Error: err.Error()})
+		}
+	}
+
+	w.section = ""
+	if err := w.AddJSON(ManifestName, w.manifest); err != nil {
+		return nil, err
+	}
+	if err := w.tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close the archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close the archive: %w", err)
+	}
+	return w.manifest, nil
+}
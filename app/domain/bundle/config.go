@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces secret values in a bundle.
+const Redacted = "REDACTED"
+
+// secretKey matches the configuration keys whose values are secrets. Keys
+// naming the path of a secret, such as api_key_path, are kept.
+var secretKey = regexp.MustCompile(`(?i)(password|passwd|secret|token|api_?key|credential|private_?key)`)
+
+// YAMLer is implemented by the settings of every component.
+type YAMLer interface {
+	ToYAML() ([]byte, error)
+}
+
+// ConfigSection adds the settings of a component, with secrets redacted, as
+// config/<name>.yaml.
+func ConfigSection(name string, settings YAMLer) Section {
+	return Section{
+		Name: "config/" + name,
+		Collect: func(_ context.Context, w *Writer) error {
+			raw, err := settings.ToYAML()
+			if err != nil {
+				return err
+			}
+			redacted, err := Redact(raw)
+			if err != nil {
+				return err
+			}
+			return w.Add("config/"+name+".yaml", redacted)
+		},
+	}
+}
+
+// Redact scrubs secrets from a YAML document: the values of keys which name a
+// secret, and the credentials of URLs.
+func Redact(doc []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(doc, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse the configuration: %w", err)
+	}
+	redactNode(&root, false)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return nil, fmt.Errorf("failed to encode the configuration: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode the configuration: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func redactNode(n *yaml.Node, secret bool) {
+	switch n.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, c := range n.Content {
+			redactNode(c, secret)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			redactNode(n.Content[i+1], secret || isSecretKey(n.Content[i].Value))
+		}
+	case yaml.ScalarNode:
+		switch {
+		case secret && n.Value != "":
+			n.Value = Redacted
+			n.Tag = "!!str"
+			n.Style = 0
+		case strings.Contains(n.Value, "@"):
+			n.Value = redactURL(n.Value)
+		}
+	}
+}
+
+func isSecretKey(key string) bool {
+	lower := strings.ToLower(key)
+	if strings.HasSuffix(lower, "_path") || strings.HasSuffix(lower, "_file") {
+		return false
+	}
+	return secretKey.MatchString(key)
+}
+
+// redactURL removes the password from a URL, leaving other values untouched.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil || u.Host == "" {
+		return value
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+	return u.String()
+}
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/shipper"
+	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
+)
+
+// FileInfo describes a file of the aggregator's store.
+type FileInfo struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// DiskSection adds the usage of the aggregator's storage volume, and listings
+// of the files pending upload, queued for replay and uploaded. Uploaded files
+// are only listed when modified within the window of the bundle. It only
+// works where the storage volume is mounted, i.e. in the aggregator pod.
+func DiskSection(settings *config.Settings) Section {
+	return Section{
+		Name: "disk",
+		Collect: func(_ context.Context, w *Writer) error {
+			root := settings.Database.StoragePath
+			if _, err := os.Stat(root); err != nil {
+				return fmt.Errorf("the storage path is not available, create the bundle in the aggregator pod: %w", err)
+			}
+
+			limit, err := settings.GetAvailableSizeBytes()
+			if err != nil {
+				return err
+			}
+			usage, err := disk.Usage(root, limit)
+			if err != nil {
+				return err
+			}
+			if err := w.AddJSON("disk/usage.json", usage); err != nil {
+				return err
+			}
+
+			var errs []error
+			for _, listing := range []struct{ name, dir string }{
+				{"pending", ""},
+				{"replay", shipper.ReplaySubDirectory},
+				{"uploaded", shipper.UploadedSubDirectory},
+			} {
+				name, dir := listing.name, listing.dir
+				since := time.Time{}
+				if dir == shipper.UploadedSubDirectory {
+					since = w.Since()
+				}
+				files, err := listFiles(filepath.Join(root, dir), since)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				if err := w.AddJSON("disk/"+name+".json", files); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+// listFiles lists the flushed files of a directory modified at or after
+// since, oldest first.
+func listFiles(dir string, since time.Time) ([]FileInfo, error) {
+	paths, err := disk.FlushedFiles(dir, "")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []FileInfo{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().Before(since) {
+			continue
+		}
+		files = append(files, FileInfo{Name: info.Name(), Size: info.Size(), ModTime: info.ModTime().UTC()})
+	}
+	slices.SortFunc(files, func(a, b FileInfo) int { return a.ModTime.Compare(b.ModTime) })
+	return files, nil
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/bundle"
+	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+func TestDiskSection(t *testing.T) {
+	ctx := context.Background()
+	settings := &config.Settings{Database: config.Database{StoragePath: t.TempDir()}}
+
+	store, err := disk.NewDiskStore(settings.Database, disk.WithContentIdentifier(disk.CostContentIdentifier))
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, store.Put(ctx,
+		types.Metric{ID: uuid.New(), MetricName: "cpu", TimeStamp: now, CreatedAt: now, Value: "1"},
+	))
+	require.NoError(t, store.Flush())
+
+	var buf bytes.Buffer
+	manifest, err := bundle.Write(ctx, &buf, now.Add(-time.Hour), bundle.DiskSection(settings))
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Errors)
+
+	files := readBundle(t, buf.Bytes())
+	assert.Contains(t, files, "disk/usage.json")
+
+	var pending []bundle.FileInfo
+	require.NoError(t, json.Unmarshal(files["disk/pending.json"], &pending))
+	require.Len(t, pending, 1)
+	assert.True(t, strings.HasPrefix(pending[0].Name, disk.CostContentIdentifier+"_"))
+	assert.JSONEq(t, "[]", string(files["disk/uploaded.json"]))
+}
+
+func TestDiskSection_NotInAggregator(t *testing.T) {
+	settings := &config.Settings{Database: config.Database{StoragePath: filepath.Join(t.TempDir(), "missing")}}
+
+	var buf bytes.Buffer
+	manifest, err := bundle.Write(context.Background(), &buf, time.Now(), bundle.DiskSection(settings))
+	require.NoError(t, err)
+	require.Len(t, manifest.Errors, 1)
+	assert.Contains(t, manifest.Errors[0].Error, "aggregator pod")
+}
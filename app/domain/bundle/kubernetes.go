@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// NodeSummary summarises a node of the cluster.
+type NodeSummary struct {
+	Name           string            `json:"name"`
+	ProviderID     string            `json:"providerID,omitempty"`
+	KubeletVersion string            `json:"kubeletVersion"`
+	Ready          bool              `json:"ready"`
+	Allocatable    map[string]string `json:"allocatable,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	CreatedAt      time.Time         `json:"createdAt"`
+}
+
+// PodSummary summarises a pod of the agent's namespace.
+type PodSummary struct {
+	Name     string   `json:"name"`
+	Node     string   `json:"node,omitempty"`
+	Phase    string   `json:"phase"`
+	Ready    string   `json:"ready"`
+	Restarts int32    `json:"restarts"`
+	Images   []string `json:"images"`
+
+	// LastTermination is the reason the containers last terminated, such as
+	// OOMKilled.
+	LastTermination []string  `json:"lastTermination,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// WorkloadSummary summarises a deployment, daemon set or stateful set of the
+// agent's namespace.
+type WorkloadSummary struct {
+	Kind    string   `json:"kind"`
+	Name    string   `json:"name"`
+	Desired int32    `json:"desired"`
+	Ready   int32    `json:"ready"`
+	Updated int32    `json:"updated"`
+	Images  []string `json:"images"`
+}
+
+// EventSummary summarises an event of the agent's namespace.
+type EventSummary struct {
+	Type     string    `json:"type"`
+	Reason   string    `json:"reason"`
+	Object   string    `json:"object"`
+	Message  string    `json:"message"`
+	Count    int32     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// summarisedNodeLabels are the node labels kept in node summaries.
+var summarisedNodeLabels = []string{
+	corev1.LabelInstanceTypeStable,
+	corev1.LabelTopologyRegion,
+	corev1.LabelTopologyZone,
+	corev1.LabelOSStable,
+	corev1.LabelArchStable,
+}
+
+// KubernetesSection adds summaries of the cluster's version and nodes, and of
+// the pods, workloads and recent events of the agent's namespace.
+func KubernetesSection(clientset kubernetes.Interface, namespace string) Section {
+	return Section{
+		Name: "kubernetes",
+		Collect: func(ctx context.Context, w *Writer) error {
+			var errs []error
+			collect := func(name string, fn func() (any, error)) {
+				v, err := fn()
+				if err == nil {
+					err = w.AddJSON("kubernetes/"+name+".json", v)
+				}
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				}
+			}
+
+			collect("version", func() (any, error) {
+				return clientset.Discovery().ServerVersion()
+			})
+			collect("nodes", func() (any, error) {
+				return summariseNodes(ctx, clientset)
+			})
+			collect("pods", func() (any, error) {
+				return summarisePods(ctx, clientset, namespace)
+			})
+			collect("workloads", func() (any, error) {
+				return summariseWorkloads(ctx, clientset, namespace)
+			})
+			collect("events", func() (any, error) {
+				return summariseEvents(ctx, clientset, namespace, w.Since())
+			})
+			return errors.Join(errs...)
+		},
+	}
+}
+
+func summariseNodes(ctx context.Context, clientset kubernetes.Interface) ([]NodeSummary, error) {
+	list, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]NodeSummary, 0, len(list.Items))
+	for _, n := range list.Items {
+		summary := NodeSummary{
+			Name:           n.Name,
+			ProviderID:     n.Spec.ProviderID,
+			KubeletVersion: n.Status.NodeInfo.KubeletVersion,
+			Allocatable:    map[string]string{},
+			Labels:         map[string]string{},
+			CreatedAt:      n.CreationTimestamp.UTC(),
+		}
+		for _, c := range n.Status.Conditions {
+			if c.Type == corev1.NodeReady {
+				summary.Ready = c.Status == corev1.ConditionTrue
+			}
+		}
+		for _, r := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourcePods} {
+			if q, ok := n.Status.Allocatable[r]; ok {
+				summary.Allocatable[string(r)] = q.String()
+			}
+		}
+		for _, l := range summarisedNodeLabels {
+			if v, ok := n.Labels[l]; ok {
+				summary.Labels[l] = v
+			}
+		}
+		nodes = append(nodes, summary)
+	}
+	return nodes, nil
+}
+
+func summarisePods(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]PodSummary, error) {
+	list, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]PodSummary, 0, len(list.Items))
+	for _, p := range list.Items {
+		summary := PodSummary{
+			Name:      p.Name,
+			Node:      p.Spec.NodeName,
+			Phase:     string(p.Status.Phase),
+			Images:    containerImages(p.Spec.Containers),
+			CreatedAt: p.CreationTimestamp.UTC(),
+		}
+		ready := 0
+		for _, cs := range p.Status.ContainerStatuses {
+			if cs.Ready {
+				ready++
+			}
+			summary.Restarts += cs.RestartCount
+			if t := cs.LastTerminationState.Terminated; t != nil {
+				summary.LastTermination = append(summary.LastTermination, cs.Name+": "+t.Reason)
+			}
+		}
+		summary.Ready = fmt.Sprintf("%d/%d", ready, len(p.Spec.Containers))
+		pods = append(pods, summary)
+	}
+	return pods, nil
+}
+
+func summariseWorkloads(ctx context.Context, clientset kubernetes.Interface, namespace string) ([]WorkloadSummary, error) {
+	var workloads []WorkloadSummary
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range deployments.Items {
+		desired := int32(1)
+		if d.Spec.Replicas != nil {
+			desired = *d.Spec.Replicas
+		}
+		workloads = append(workloads, WorkloadSummary{
+			Kind: "Deployment", Name: d.Name, Desired: desired,
+			Ready: d.Status.ReadyReplicas, Updated: d.Status.UpdatedReplicas,
+			Images: containerImages(d.Spec.Template.Spec.Containers),
+		})
+	}
+
+	daemonSets, err := clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range daemonSets.Items {
+		workloads = append(workloads, WorkloadSummary{
+			Kind: "DaemonSet", Name: d.Name, Desired: d.Status.DesiredNumberScheduled,
+			Ready: d.Status.NumberReady, Updated: d.Status.UpdatedNumberScheduled,
+			Images: containerImages(d.Spec.Template.Spec.Containers),
+		})
+	}
+
+	statefulSets, err := clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range statefulSets.Items {
+		desired := int32(1)
+		if s.Spec.Replicas != nil {
+			desired = *s.Spec.Replicas
+		}
+		workloads = append(workloads, WorkloadSummary{
+			Kind: "StatefulSet", Name: s.Name, Desired: desired,
+			Ready: s.Status.ReadyReplicas, Updated: s.Status.UpdatedReplicas,
+			Images: containerImages(s.Spec.Template.Spec.Containers),
+		})
+	}
+	return workloads, nil
+}
+
+func summariseEvents(ctx context.Context, clientset kubernetes.Interface, namespace string, since time.Time) ([]EventSummary, error) {
+	list, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	events := []EventSummary{}
+	for _, e := range list.Items {
+		lastSeen := e.LastTimestamp.Time
+		if lastSeen.IsZero() {
+			lastSeen = e.EventTime.Time
+		}
+		if lastSeen.Before(since) {
+			continue
+		}
+		events = append(events, EventSummary{
+			Type:     e.Type,
+			Reason:   e.Reason,
+			Object:   strings.ToLower(e.InvolvedObject.Kind) + "/" + e.InvolvedObject.Name,
+			Message:  e.Message,
+			Count:    e.Count,
+			LastSeen: lastSeen.UTC(),
+		})
+	}
+	slices.SortFunc(events, func(a, b EventSummary) int { return a.LastSeen.Compare(b.LastSeen) })
+	return events, nil
+}
+
+func containerImages(containers []corev1.Container) []string {
+	images := make([]string, 0, len(containers))
+	for _, c := range containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// logLimitBytes caps the logs kept of each container, so that a noisy
+// container cannot make the bundle too large to share.
+const logLimitBytes = 10 << 20
+
+// LogsSection adds the logs of the containers of the pods of the agent's
+// namespace within the window of the bundle, as logs/<pod>/<container>.log.
+// The logs of the previous instance of restarted containers are added as
+// <container>.previous.log, since they usually explain the restart. The logs
+// are read with the Kubernetes API, so the section works from any pod whose
+// service account may get pods/log.
+func LogsSection(clientset kubernetes.Interface, namespace string) Section {
+	return Section{
+		Name: "logs",
+		Collect: func(ctx context.Context, w *Writer) error {
+			pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+
+			var errs []error
+			for _, p := range pods.Items {
+				statuses := slices.Concat(p.Status.InitContainerStatuses, p.Status.ContainerStatuses)
+				for _, cs := range statuses {
+					if cs.State.Waiting != nil && cs.RestartCount == 0 {
+						// the container never started, so it has no logs
+						continue
+					}
+					if err := addContainerLogs(ctx, w, clientset, p, cs.Name, false); err != nil {
+						errs = append(errs, err)
+					}
+					if cs.RestartCount > 0 {
+						if err := addContainerLogs(ctx, w, clientset, p, cs.Name, true); err != nil {
+							errs = append(errs, err)
+						}
+					}
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+func addContainerLogs(ctx context.Context, w *Writer, clientset kubernetes.Interface, p corev1.Pod, container string, previous bool) error {
+	name := "logs/" + p.Name + "/" + container
+	if previous {
+		name += ".previous"
+	}
+	name += ".log"
+
+	stream, err := clientset.CoreV1().Pods(p.Namespace).GetLogs(p.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		SinceTime:  &metav1.Time{Time: w.Since()},
+		Timestamps: true,
+		LimitBytes: ptr.To[int64](logLimitBytes),
+	}).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer stream.Close()
+
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return w.Add(name, data)
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package bundle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+// maxMetricsSize caps the size of a metrics snapshot.
+const maxMetricsSize = 16 * 1024 * 1024
+
+// StatusSection adds the cluster status returned by fetch as status.json.
+func StatusSection(fetch func(ctx context.Context) (*status.ClusterStatus, error)) Section {
+	return Section{
+		Name: "status",
+		Collect: func(ctx context.Context, w *Writer) error {
+			cs, err := fetch(ctx)
+			if err != nil {
+				return err
+			}
+			data, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(cs)
+			if err != nil {
+				return fmt.Errorf("failed to encode the status: %w", err)
+			}
+			return w.Add("status.json", append(data, '\n'))
+		},
+	}
+}
+
+// MetricsSection adds a snapshot of the Prometheus metrics of each component,
+// keyed by component name, as metrics/<component>.prom.
+func MetricsSection(client *http.Client, urls map[string]string) Section {
+	return Section{
+		Name: "metrics",
+		Collect: func(ctx context.Context, w *Writer) error {
+			var errs []error
+			for _, name := range slices.Sorted(maps.Keys(urls)) {
+				data, err := get(ctx, client, urls[name])
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
+					continue
+				}
+				if err := w.Add("metrics/"+name+".prom", data); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		},
+	}
+}
+
+func get(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received %d from %s", resp.StatusCode, url)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxMetricsSize))
+}
@@ -255,7 +255,10 @@ func (s *CertificateService) UpdateResources(ctx context.Context, namespace, sec
 	return nil
 }
 
-// ValidateExistingCertificate checks if the existing certificate is valid
+// ValidateExistingCertificate checks if the existing certificate is valid. The
+// secret must hold ca.crt, tls.crt and tls.key, and the certificate must be
+// within its validity period; an expired certificate is reported as invalid.
+// InspectCertificate returns the expiry date itself.
 func (s *CertificateService) ValidateExistingCertificate(ctx context.Context, namespace, secretName string) (bool, error) {
 	secret, err := s.k8sClient.GetTLSSecret(ctx, namespace, secretName)
 	if err != nil {
@@ -275,5 +278,16 @@ func (s *CertificateService) ValidateExistingCertificate(ctx context.Context, na
 		}
 	}
 
+	// A certificate which cannot be parsed, has expired or is not yet valid is
+	// as unusable as a missing one
+	crtPEM, err := secretField(data, "tls.crt")
+	if err != nil {
+		return false, nil
+	}
+	chain, err := parseCertificates(crtPEM)
+	if err != nil || !newStatus(chain[0]).ValidAt(time.Now()) {
+		return false, nil
+	}
+
 	return true, nil
 }
@@ -427,7 +427,23 @@ func TestCertificateService_ValidateExistingCertificate(t *testing.T) {
 		errorContains string
 	}{
 		{
-			name:       "valid certificate",
+			name:        "valid certificate",
+			namespace:   "test-namespace",
+			secretName:  "test-secret",
+			mockSecret:  newTestPKI(t, time.Now().Add(-time.Hour), time.Now().Add(time.Hour)).secret(nil),
+			expectValid: true,
+			expectError: false,
+		},
+		{
+			name:        "expired certificate",
+			namespace:   "test-namespace",
+			secretName:  "test-secret",
+			mockSecret:  newTestPKI(t, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour)).secret(nil),
+			expectValid: false,
+			expectError: false,
+		},
+		{
+			name:       "unparseable certificate",
 			namespace:  "test-namespace",
 			secretName: "test-secret",
 			mockSecret: map[string]interface{}{
@@ -437,7 +453,7 @@ func TestCertificateService_ValidateExistingCertificate(t *testing.T) {
 					"tls.key": "dGxzLWtleQ==",
 				},
 			},
-			expectValid: true,
+			expectValid: false,
 			expectError: false,
 		},
 		{
@@ -0,0 +1,374 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// ModeSelfSigned generates a self-signed CA and serving certificate, and
+	// rotates them before they expire.
+	ModeSelfSigned = "self-signed"
+	// ModeExternal uses a serving certificate issued outside of certifik8s,
+	// for example by a cert-manager Issuer or a corporate CA.
+	ModeExternal = "external"
+
+	// InjectCAFromAnnotation is the cert-manager cainjector annotation. When it
+	// is set on the webhook configuration the caBundle is owned by cainjector,
+	// so certifik8s only verifies it.
+	InjectCAFromAnnotation = "cert-manager.io/inject-ca-from"
+	// CertManagerCertificateAnnotation is set by cert-manager on the Secrets
+	// it manages.
+	CertManagerCertificateAnnotation = "cert-manager.io/certificate-name"
+
+	// DefaultCAConfigMapKey is the ConfigMap key holding the CA bundle.
+	DefaultCAConfigMapKey = "ca.crt"
+)
+
+// Sources of the CA bundle used to verify an external certificate.
+const (
+	CASourceSecret      = "secret"
+	CASourceConfigMap   = "configmap"
+	CASourceCAInjector  = "cainjector"
+	CASourceSelfSigned  = "self-signed"
+	caBundleWebhookPath = "/webhooks/0/clientConfig/caBundle"
+)
+
+// CertificateStatus describes the serving certificate stored in a TLS Secret.
+type CertificateStatus struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	DNSNames  []string  `json:"dnsNames,omitempty"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	// ManagedBy is "cert-manager" when the Secret is managed by cert-manager.
+	ManagedBy string `json:"managedBy,omitempty"`
+	// CASource is where the CA bundle used to verify the certificate came from.
+	CASource string `json:"caSource,omitempty"`
+	// CAInjected reports whether the webhook caBundle was patched.
+	CAInjected bool `json:"caInjected,omitempty"`
+}
+
+// ExpiresWithin reports whether the certificate expires within d of now.
+func (c *CertificateStatus) ExpiresWithin(now time.Time, d time.Duration) bool {
+	return !now.Add(d).Before(c.NotAfter)
+}
+
+// ValidAt reports whether now lies within the certificate validity period.
+func (c *CertificateStatus) ValidAt(now time.Time) bool {
+	return !now.Before(c.NotBefore) && now.Before(c.NotAfter)
+}
+
+// ExternalOptions configures ReconcileExternal.
+type ExternalOptions struct {
+	ServiceName string
+	Namespace   string
+	SecretName  string
+	WebhookName string
+	// CAConfigMap names a ConfigMap in Namespace holding the CA bundle. When
+	// empty, the bundle is taken from the cainjector annotation on the webhook
+	// configuration, or from the Secret's ca.crt.
+	CAConfigMap    string
+	CAConfigMapKey string
+}
+
+// RotationOptions configures EnsureCertificate.
+type RotationOptions struct {
+	ServiceName string
+	Namespace   string
+	SecretName  string
+	WebhookName string
+	KeySize     int
+	Validity    time.Duration
+	Algorithm   string
+	// RenewBefore is how long before expiry the certificate is replaced.
+	RenewBefore time.Duration
+}
+
+// servingCertificate is the parsed content of a TLS Secret.
+type servingCertificate struct {
+	chain  []*x509.Certificate
+	caPEM  []byte
+	status *CertificateStatus
+}
+
+// InspectCertificate parses the serving certificate stored in the Secret and
+// reports its validity period, without verifying it.
+func (s *CertificateService) InspectCertificate(ctx context.Context, namespace, secretName string) (*CertificateStatus, error) {
+	cert, err := s.loadServingCertificate(ctx, namespace, secretName)
+	if err != nil {
+		return nil, err
+	}
+	return cert.status, nil
+}
+
+// ReconcileExternal validates a serving certificate issued outside of
+// certifik8s and makes sure the webhook trusts its CA.
+//
+// The certificate and key must form a pair, be currently valid and cover the
+// webhook Service DNS name, and the certificate must chain to the CA bundle.
+// The bundle is read from the CA ConfigMap when one is configured. Otherwise,
+// when the webhook configuration carries the cert-manager inject-ca-from
+// annotation, the bundle injected by cainjector is verified but never patched;
+// if cainjector has not injected it yet an error is returned so the caller can
+// retry. Without either, the Secret's ca.crt is used. In the ConfigMap and
+// Secret cases the webhook caBundle is patched when it differs.
+func (s *CertificateService) ReconcileExternal(ctx context.Context, opts ExternalOptions) (*CertificateStatus, error) {
+	if opts.ServiceName == "" || opts.Namespace == "" || opts.SecretName == "" || opts.WebhookName == "" {
+		return nil, errors.New("service name, namespace, secret name and webhook name are required")
+	}
+
+	cert, err := s.loadServingCertificate(ctx, opts.Namespace, opts.SecretName)
+	if err != nil {
+		return nil, err
+	}
+	status := cert.status
+	if now := time.Now(); !status.ValidAt(now) {
+		return status, fmt.Errorf("certificate is not valid at %s (valid from %s to %s)", now.UTC().Format(time.RFC3339), status.NotBefore.UTC().Format(time.RFC3339), status.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	annotations, err := s.k8sClient.GetWebhookAnnotations(ctx, opts.WebhookName)
+	if err != nil {
+		return status, fmt.Errorf("failed to get webhook annotations: %w", err)
+	}
+	currentBundle, err := s.k8sClient.GetWebhookCABundle(ctx, opts.WebhookName)
+	if err != nil {
+		return status, fmt.Errorf("failed to get webhook CA bundle: %w", err)
+	}
+
+	var caPEM []byte
+	switch {
+	case opts.CAConfigMap != "":
+		key := opts.CAConfigMapKey
+		if key == "" {
+			key = DefaultCAConfigMapKey
+		}
+		data, err := s.k8sClient.GetConfigMap(ctx, opts.Namespace, opts.CAConfigMap)
+		if err != nil {
+			return status, fmt.Errorf("failed to get CA configmap: %w", err)
+		}
+		if data[key] == "" {
+			return status, fmt.Errorf("CA configmap %s has no %q key", opts.CAConfigMap, key)
+		}
+		caPEM = []byte(data[key])
+		status.CASource = CASourceConfigMap
+	case annotations[InjectCAFromAnnotation] != "":
+		if currentBundle == "" {
+			return status, fmt.Errorf("CA bundle has not been injected from %s yet", annotations[InjectCAFromAnnotation])
+		}
+		if caPEM, err = base64.StdEncoding.DecodeString(currentBundle); err != nil {
+			return status, fmt.Errorf("failed to decode webhook CA bundle: %w", err)
+		}
+		status.CASource = CASourceCAInjector
+	default:
+		if len(cert.caPEM) == 0 {
+			return status, errors.New("secret has no ca.crt; configure a CA configmap or the cert-manager inject-ca-from annotation")
+		}
+		caPEM = cert.caPEM
+		status.CASource = CASourceSecret
+	}
+
+	if err = verifyChain(cert.chain, caPEM, opts.ServiceName+"."+opts.Namespace+".svc"); err != nil {
+		return status, err
+	}
+
+	if status.CASource == CASourceCAInjector {
+		return status, nil
+	}
+	bundle := base64.StdEncoding.EncodeToString(caPEM)
+	if bundle == currentBundle {
+		return status, nil
+	}
+	err = s.k8sClient.PatchWebhookConfiguration(ctx, opts.WebhookName, []WebhookPatch{
+		{Op: "replace", Path: caBundleWebhookPath, Value: bundle},
+	})
+	if err != nil {
+		return status, fmt.Errorf("failed to patch webhook configuration: %w", err)
+	}
+	status.CAInjected = true
+	return status, nil
+}
+
+// EnsureCertificate keeps a self-signed certificate current. A new certificate
+// is generated when the Secret holds none, when it cannot be parsed, when it
+// expires within RenewBefore, or when it is not valid for the webhook Service
+// DNS name under the Secret's CA, for example after the Service was renamed.
+// A certificate which is still good is kept, and only the webhook caBundle is
+// restored if it no longer matches. It reports whether a new certificate was
+// generated.
+func (s *CertificateService) EnsureCertificate(ctx context.Context, opts RotationOptions) (bool, *CertificateStatus, error) {
+	if opts.RenewBefore < 0 || (opts.Validity > 0 && opts.RenewBefore >= opts.Validity) {
+		return false, nil, errors.New("renew before must be non-negative and shorter than the validity duration")
+	}
+
+	cert, err := s.loadServingCertificate(ctx, opts.Namespace, opts.SecretName)
+	if err == nil && len(cert.caPEM) > 0 && !cert.status.ExpiresWithin(time.Now(), opts.RenewBefore) &&
+		verifyChain(cert.chain, cert.caPEM, opts.ServiceName+"."+opts.Namespace+".svc") == nil {
+		cert.status.CASource = CASourceSelfSigned
+		bundle := base64.StdEncoding.EncodeToString(cert.caPEM)
+		current, err := s.k8sClient.GetWebhookCABundle(ctx, opts.WebhookName)
+		if err != nil {
+			return false, cert.status, fmt.Errorf("failed to get webhook CA bundle: %w", err)
+		}
+		if current != bundle {
+			err = s.k8sClient.PatchWebhookConfiguration(ctx, opts.WebhookName, []WebhookPatch{
+				{Op: "replace", Path: caBundleWebhookPath, Value: bundle},
+			})
+			if err != nil {
+				return false, cert.status, fmt.Errorf("failed to patch webhook configuration: %w", err)
+			}
+			cert.status.CAInjected = true
+		}
+		return false, cert.status, nil
+	}
+
+	certData, err := s.GenerateCertificate(ctx, opts.ServiceName, opts.Namespace, opts.KeySize, opts.Validity, opts.Algorithm)
+	if err != nil {
+		return false, nil, err
+	}
+	if err = s.UpdateResources(ctx, opts.Namespace, opts.SecretName, opts.WebhookName, certData); err != nil {
+		return false, nil, err
+	}
+
+	status, err := statusFromData(certData)
+	if err != nil {
+		return true, nil, err
+	}
+	status.CASource = CASourceSelfSigned
+	status.CAInjected = true
+	return true, status, nil
+}
+
+// loadServingCertificate reads and parses the TLS Secret. tls.crt and tls.key
+// must be present and form a key pair; ca.crt is optional.
+func (s *CertificateService) loadServingCertificate(ctx context.Context, namespace, secretName string) (*servingCertificate, error) {
+	secret, err := s.k8sClient.GetTLSSecret(ctx, namespace, secretName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TLS secret: %w", err)
+	}
+	data, ok := secret["data"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("secret data is not a map")
+	}
+
+	crtPEM, err := secretField(data, "tls.crt")
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := secretField(data, "tls.key")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tls.X509KeyPair(crtPEM, keyPEM); err != nil {
+		return nil, fmt.Errorf("tls.crt and tls.key do not form a valid key pair: %w", err)
+	}
+	chain, err := parseCertificates(crtPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tls.crt: %w", err)
+	}
+
+	cert := &servingCertificate{chain: chain, status: newStatus(chain[0])}
+	if _, ok := data["ca.crt"]; ok {
+		if cert.caPEM, err = secretField(data, "ca.crt"); err != nil {
+			return nil, err
+		}
+	}
+	if metadata, ok := secret["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok && annotations[CertManagerCertificateAnnotation] != nil {
+			cert.status.ManagedBy = "cert-manager"
+		}
+	}
+	return cert, nil
+}
+
+// secretField returns a decoded value of a Secret's data.
+func secretField(data map[string]interface{}, field string) ([]byte, error) {
+	encoded, ok := data[field].(string)
+	if !ok || encoded == "" {
+		return nil, fmt.Errorf("secret has no %s", field)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", field, err)
+	}
+	return decoded, nil
+}
+
+// parseCertificates parses every certificate in a PEM bundle, leaf first.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return certs, nil
+}
+
+// verifyChain verifies that the leaf of the chain is trusted by the CA bundle
+// and valid for the DNS name.
+func verifyChain(chain []*x509.Certificate, caPEM []byte, dnsName string) error {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(bytes.TrimSpace(caPEM)) {
+		return errors.New("CA bundle contains no PEM certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       dnsName,
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return fmt.Errorf("certificate is not trusted by the CA bundle for %s: %w", dnsName, err)
+	}
+	return nil
+}
+
+func newStatus(leaf *x509.Certificate) *CertificateStatus {
+	return &CertificateStatus{
+		Subject:   leaf.Subject.String(),
+		Issuer:    leaf.Issuer.String(),
+		DNSNames:  leaf.DNSNames,
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+	}
+}
+
+func statusFromData(certData *CertificateData) (*CertificateStatus, error) {
+	crtPEM, err := base64.StdEncoding.DecodeString(certData.TLSCrt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode generated certificate: %w", err)
+	}
+	chain, err := parseCertificates(crtPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse generated certificate: %w", err)
+	}
+	return newStatus(chain[0]), nil
+}
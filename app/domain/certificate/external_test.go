@@ -0,0 +1,313 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package certificate_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/certificate"
+	"github.com/cloudzero/cloudzero-agent/app/domain/certificate/mocks"
+)
+
+const (
+	testService = "webhook"
+	testNS      = "cza"
+	testSecret  = "webhook-tls"
+	testWebhook = "webhook-config"
+)
+
+// testPKI is a CA and a serving certificate for testService issued by it.
+type testPKI struct {
+	caPEM  []byte
+	crtPEM []byte
+	keyPEM []byte
+}
+
+func newTestPKI(t *testing.T, notBefore, notAfter time.Time) *testPKI {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "corporate-ca"},
+		NotBefore:             notBefore.Add(-time.Hour),
+		NotAfter:              notAfter.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: testService},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{testService + "." + testNS + ".svc"},
+	}
+	crtDER, err := x509.CreateCertificate(rand.Reader, template, caTemplate, key.Public(), caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testPKI{
+		caPEM:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		crtPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: crtDER}),
+		keyPEM: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// secret returns the PKI as returned by KubernetesClient.GetTLSSecret.
+func (p *testPKI) secret(annotations map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+		"data": map[string]interface{}{
+			"ca.crt":  base64.StdEncoding.EncodeToString(p.caPEM),
+			"tls.crt": base64.StdEncoding.EncodeToString(p.crtPEM),
+			"tls.key": base64.StdEncoding.EncodeToString(p.keyPEM),
+		},
+	}
+}
+
+func (p *testPKI) bundle() string {
+	return base64.StdEncoding.EncodeToString(p.caPEM)
+}
+
+func TestCertificateService_ReconcileExternal(t *testing.T) {
+	now := time.Now()
+	pki := newTestPKI(t, now.Add(-time.Hour), now.Add(24*time.Hour))
+	other := newTestPKI(t, now.Add(-time.Hour), now.Add(24*time.Hour))
+	opts := certificate.ExternalOptions{ServiceName: testService, Namespace: testNS, SecretName: testSecret, WebhookName: testWebhook}
+
+	tests := []struct {
+		name          string
+		opts          func(o certificate.ExternalOptions) certificate.ExternalOptions
+		secret        map[string]interface{}
+		annotations   map[string]string
+		currentBundle string
+		configMap     map[string]string
+		expectPatch   bool
+		expectSource  string
+		errorContains string
+	}{
+		{
+			name:         "ca from secret is injected",
+			secret:       pki.secret(map[string]interface{}{certificate.CertManagerCertificateAnnotation: "webhook"}),
+			expectPatch:  true,
+			expectSource: certificate.CASourceSecret,
+		},
+		{
+			name:          "ca from secret already injected",
+			secret:        pki.secret(nil),
+			currentBundle: pki.bundle(),
+			expectSource:  certificate.CASourceSecret,
+		},
+		{
+			name: "configmap ca does not trust the certificate",
+			opts: func(o certificate.ExternalOptions) certificate.ExternalOptions {
+				o.CAConfigMap = "corporate-ca"
+				return o
+			},
+			secret:        other.secret(nil),
+			configMap:     map[string]string{certificate.DefaultCAConfigMapKey: string(pki.caPEM)},
+			errorContains: "not trusted",
+		},
+		{
+			name: "ca from configmap verified and injected",
+			opts: func(o certificate.ExternalOptions) certificate.ExternalOptions {
+				o.CAConfigMap = "corporate-ca"
+				o.CAConfigMapKey = "bundle.pem"
+				return o
+			},
+			secret:       pki.secret(nil),
+			configMap:    map[string]string{"bundle.pem": string(pki.caPEM)},
+			expectPatch:  true,
+			expectSource: certificate.CASourceConfigMap,
+		},
+		{
+			name:          "cainjector bundle is verified but not patched",
+			secret:        pki.secret(nil),
+			annotations:   map[string]string{certificate.InjectCAFromAnnotation: testNS + "/webhook"},
+			currentBundle: pki.bundle(),
+			expectSource:  certificate.CASourceCAInjector,
+		},
+		{
+			name:          "cainjector has not injected yet",
+			secret:        pki.secret(nil),
+			annotations:   map[string]string{certificate.InjectCAFromAnnotation: testNS + "/webhook"},
+			errorContains: "has not been injected",
+		},
+		{
+			name:          "expired certificate",
+			secret:        newTestPKI(t, now.Add(-2*time.Hour), now.Add(-time.Hour)).secret(nil),
+			errorContains: "not valid at",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockClient := mocks.NewMockKubernetesClient(ctrl)
+			service := certificate.NewCertificateService(mockClient)
+
+			o := opts
+			if tt.opts != nil {
+				o = tt.opts(o)
+			}
+
+			mockClient.EXPECT().GetTLSSecret(gomock.Any(), testNS, testSecret).Return(tt.secret, nil)
+			mockClient.EXPECT().GetWebhookAnnotations(gomock.Any(), testWebhook).Return(tt.annotations, nil).AnyTimes()
+			mockClient.EXPECT().GetWebhookCABundle(gomock.Any(), testWebhook).Return(tt.currentBundle, nil).AnyTimes()
+			if tt.configMap != nil {
+				mockClient.EXPECT().GetConfigMap(gomock.Any(), testNS, o.CAConfigMap).Return(tt.configMap, nil)
+			}
+			if tt.expectPatch {
+				mockClient.EXPECT().PatchWebhookConfiguration(gomock.Any(), testWebhook, gomock.Len(1)).Return(nil)
+			}
+
+			status, err := service.ReconcileExternal(context.Background(), o)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectSource, status.CASource)
+			assert.Equal(t, tt.expectPatch, status.CAInjected)
+			assert.WithinDuration(t, now.Add(24*time.Hour), status.NotAfter, time.Second)
+		})
+	}
+
+	t.Run("cert-manager secret is reported", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockClient := mocks.NewMockKubernetesClient(ctrl)
+		mockClient.EXPECT().GetTLSSecret(gomock.Any(), testNS, testSecret).
+			Return(pki.secret(map[string]interface{}{certificate.CertManagerCertificateAnnotation: "webhook"}), nil)
+
+		status, err := certificate.NewCertificateService(mockClient).InspectCertificate(context.Background(), testNS, testSecret)
+		require.NoError(t, err)
+		assert.Equal(t, "cert-manager", status.ManagedBy)
+		assert.Contains(t, status.Issuer, "corporate-ca")
+	})
+}
+
+func TestCertificateService_EnsureCertificate(t *testing.T) {
+	now := time.Now()
+	opts := certificate.RotationOptions{
+		ServiceName: testService,
+		Namespace:   testNS,
+		SecretName:  testSecret,
+		WebhookName: testWebhook,
+		KeySize:     2048,
+		Validity:    90 * 24 * time.Hour,
+		Algorithm:   "RSA",
+		RenewBefore: 30 * 24 * time.Hour,
+	}
+
+	t.Run("valid certificate is kept", func(t *testing.T) {
+		pki := newTestPKI(t, now.Add(-time.Hour), now.Add(60*24*time.Hour))
+		ctrl := gomock.NewController(t)
+		mockClient := mocks.NewMockKubernetesClient(ctrl)
+		mockClient.EXPECT().GetTLSSecret(gomock.Any(), testNS, testSecret).Return(pki.secret(nil), nil)
+		mockClient.EXPECT().GetWebhookCABundle(gomock.Any(), testWebhook).Return(pki.bundle(), nil)
+
+		rotated, status, err := certificate.NewCertificateService(mockClient).EnsureCertificate(context.Background(), opts)
+		require.NoError(t, err)
+		assert.False(t, rotated)
+		assert.False(t, status.CAInjected)
+	})
+
+	t.Run("missing webhook bundle is restored without rotating", func(t *testing.T) {
+		pki := newTestPKI(t, now.Add(-time.Hour), now.Add(60*24*time.Hour))
+		ctrl := gomock.NewController(t)
+		mockClient := mocks.NewMockKubernetesClient(ctrl)
+		mockClient.EXPECT().GetTLSSecret(gomock.Any(), testNS, testSecret).Return(pki.secret(nil), nil)
+		mockClient.EXPECT().GetWebhookCABundle(gomock.Any(), testWebhook).Return("", nil)
+		mockClient.EXPECT().PatchWebhookConfiguration(gomock.Any(), testWebhook, []certificate.WebhookPatch{
+			{Op: "replace", Path: "/webhooks/0/clientConfig/caBundle", Value: pki.bundle()},
+		}).Return(nil)
+
+		rotated, status, err := certificate.NewCertificateService(mockClient).EnsureCertificate(context.Background(), opts)
+		require.NoError(t, err)
+		assert.False(t, rotated)
+		assert.True(t, status.CAInjected)
+	})
+
+	for name, secret := range map[string]map[string]interface{}{
+		"expiring certificate is rotated": newTestPKI(t, now.Add(-time.Hour), now.Add(7*24*time.Hour)).secret(nil),
+		"empty secret is generated":       {"data": map[string]interface{}{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockClient := mocks.NewMockKubernetesClient(ctrl)
+			mockClient.EXPECT().GetTLSSecret(gomock.Any(), testNS, testSecret).Return(secret, nil)
+			mockClient.EXPECT().PatchSecret(gomock.Any(), testNS, testSecret, gomock.Any()).Return(nil)
+			mockClient.EXPECT().PatchWebhookConfiguration(gomock.Any(), testWebhook, gomock.Len(1)).Return(nil)
+
+			rotated, status, err := certificate.NewCertificateService(mockClient).EnsureCertificate(context.Background(), opts)
+			require.NoError(t, err)
+			assert.True(t, rotated)
+			assert.WithinDuration(t, now.Add(opts.Validity), status.NotAfter, time.Minute)
+		})
+	}
+
+	t.Run("certificate for another service is rotated", func(t *testing.T) {
+		pki := newTestPKI(t, now.Add(-time.Hour), now.Add(60*24*time.Hour))
+		ctrl := gomock.NewController(t)
+		mockClient := mocks.NewMockKubernetesClient(ctrl)
+		mockClient.EXPECT().GetTLSSecret(gomock.Any(), testNS, testSecret).Return(pki.secret(nil), nil)
+		mockClient.EXPECT().PatchSecret(gomock.Any(), testNS, testSecret, gomock.Any()).Return(nil)
+		mockClient.EXPECT().PatchWebhookConfiguration(gomock.Any(), testWebhook, gomock.Len(1)).Return(nil)
+
+		renamed := opts
+		renamed.ServiceName = "renamed-webhook"
+		rotated, status, err := certificate.NewCertificateService(mockClient).EnsureCertificate(context.Background(), renamed)
+		require.NoError(t, err)
+		assert.True(t, rotated)
+		assert.Contains(t, status.DNSNames, "renamed-webhook."+testNS+".svc")
+	})
+
+	t.Run("certificate not issued by the secret's CA is rotated", func(t *testing.T) {
+		pki := newTestPKI(t, now.Add(-time.Hour), now.Add(60*24*time.Hour))
+		other := newTestPKI(t, now.Add(-time.Hour), now.Add(60*24*time.Hour))
+		secret := pki.secret(nil)
+		secret["data"].(map[string]interface{})["ca.crt"] = other.bundle()
+		ctrl := gomock.NewController(t)
+		mockClient := mocks.NewMockKubernetesClient(ctrl)
+		mockClient.EXPECT().GetTLSSecret(gomock.Any(), testNS, testSecret).Return(secret, nil)
+		mockClient.EXPECT().PatchSecret(gomock.Any(), testNS, testSecret, gomock.Any()).Return(nil)
+		mockClient.EXPECT().PatchWebhookConfiguration(gomock.Any(), testWebhook, gomock.Len(1)).Return(nil)
+
+		rotated, _, err := certificate.NewCertificateService(mockClient).EnsureCertificate(context.Background(), opts)
+		require.NoError(t, err)
+		assert.True(t, rotated)
+	})
+
+	t.Run("renew before longer than validity", func(t *testing.T) {
+		invalid := opts
+		invalid.RenewBefore = invalid.Validity
+		_, _, err := certificate.NewCertificateService(nil).EnsureCertificate(context.Background(), invalid)
+		assert.Error(t, err)
+	})
+}
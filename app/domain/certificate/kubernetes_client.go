@@ -18,4 +18,8 @@ type KubernetesClient interface {
 	PatchSecret(ctx context.Context, namespace, secretName string, patchData map[string]interface{}) error
 	// PatchWebhookConfiguration applies patches to a webhook configuration
 	PatchWebhookConfiguration(ctx context.Context, webhookName string, patches []WebhookPatch) error
+	// GetWebhookAnnotations retrieves the annotations of a webhook configuration
+	GetWebhookAnnotations(ctx context.Context, webhookName string) (map[string]string, error)
+	// GetConfigMap retrieves the data of a ConfigMap in the specified namespace
+	GetConfigMap(ctx context.Context, namespace, name string) (map[string]string, error)
 }
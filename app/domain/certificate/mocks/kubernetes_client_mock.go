@@ -41,6 +41,21 @@ func (m *MockKubernetesClient) EXPECT() *MockKubernetesClientMockRecorder {
 	return m.recorder
 }
 
+// GetConfigMap mocks base method.
+func (m *MockKubernetesClient) GetConfigMap(ctx context.Context, namespace, name string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigMap", ctx, namespace, name)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigMap indicates an expected call of GetConfigMap.
+func (mr *MockKubernetesClientMockRecorder) GetConfigMap(ctx, namespace, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMap", reflect.TypeOf((*MockKubernetesClient)(nil).GetConfigMap), ctx, namespace, name)
+}
+
 // GetTLSSecret mocks base method.
 func (m *MockKubernetesClient) GetTLSSecret(ctx context.Context, namespace, secretName string) (map[string]any, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +71,21 @@ func (mr *MockKubernetesClientMockRecorder) GetTLSSecret(ctx, namespace, secretN
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTLSSecret", reflect.TypeOf((*MockKubernetesClient)(nil).GetTLSSecret), ctx, namespace, secretName)
 }
 
+// GetWebhookAnnotations mocks base method.
+func (m *MockKubernetesClient) GetWebhookAnnotations(ctx context.Context, webhookName string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookAnnotations", ctx, webhookName)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookAnnotations indicates an expected call of GetWebhookAnnotations.
+func (mr *MockKubernetesClientMockRecorder) GetWebhookAnnotations(ctx, webhookName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookAnnotations", reflect.TypeOf((*MockKubernetesClient)(nil).GetWebhookAnnotations), ctx, webhookName)
+}
+
 // GetWebhookCABundle mocks base method.
 func (m *MockKubernetesClient) GetWebhookCABundle(ctx context.Context, webhookName string) (string, error) {
 	m.ctrl.T.Helper()
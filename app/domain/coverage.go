@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"cmp"
+	"hash/maphash"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+// CoverageRetention is how long the CoverageTracker remembers a series after
+// it was last received. Reports cannot cover a longer window.
+const CoverageRetention = time.Hour
+
+// coverageKey identifies a series in the coverage tracker.
+type coverageKey struct {
+	node, job, metric string
+}
+
+// coverageEntry is what the coverage tracker knows about a series.
+type coverageEntry struct {
+	cost     bool
+	lastSeen time.Time
+}
+
+// coverageShards is the number of independently locked shards of the series,
+// so concurrent remote_write requests rarely wait for each other.
+const coverageShards = 16
+
+// coverageShard holds the series whose key hashes to it.
+type coverageShard struct {
+	mu     sync.Mutex
+	series map[coverageKey]coverageEntry
+}
+
+// CoverageTracker records which metrics the collector received for each node
+// and scrape job, so that gaps in the scrape coverage can be found without
+// querying Prometheus or Alloy.
+type CoverageTracker struct {
+	seed   maphash.Seed
+	shards [coverageShards]coverageShard
+
+	pruneMu   sync.Mutex
+	lastPrune time.Time
+}
+
+// NewCoverageTracker creates an empty CoverageTracker.
+func NewCoverageTracker() *CoverageTracker {
+	c := &CoverageTracker{seed: maphash.MakeSeed()}
+	for i := range c.shards {
+		c.shards[i].series = map[coverageKey]coverageEntry{}
+	}
+	return c
+}
+
+// Record marks the node, job and metric of every metric as seen at now.
+// isCost reports whether a metric name matches the cost metric filters.
+func (c *CoverageTracker) Record(metrics []types.Metric, isCost func(name string) bool, now time.Time) {
+	if len(metrics) == 0 {
+		return
+	}
+
+	// Group the metrics by shard, so each shard is locked once. The cost
+	// filters are applied here, once per metric name of the request, rather
+	// than while holding the lock of a shard.
+	keys := make([]coverageKey, len(metrics))
+	shardOf := make([]uint8, len(metrics))
+	cost := make(map[string]bool)
+	var counts [coverageShards]int
+	var h maphash.Hash
+	h.SetSeed(c.seed)
+	for i, metric := range metrics {
+		if _, ok := cost[metric.MetricName]; !ok {
+			cost[metric.MetricName] = isCost(metric.MetricName)
+		}
+		keys[i] = coverageKey{node: metric.NodeName, job: metric.Labels["job"], metric: metric.MetricName}
+		h.Reset()
+		h.WriteString(keys[i].node)
+		h.WriteByte(0)
+		h.WriteString(keys[i].job)
+		h.WriteByte(0)
+		h.WriteString(keys[i].metric)
+		shardOf[i] = uint8(h.Sum64() % coverageShards)
+		counts[shardOf[i]]++
+	}
+
+	for s := range c.shards {
+		if counts[s] == 0 {
+			continue
+		}
+		shard := &c.shards[s]
+		shard.mu.Lock()
+		for i, key := range keys {
+			if int(shardOf[i]) != s {
+				continue
+			}
+			shard.series[key] = coverageEntry{cost: cost[key.metric], lastSeen: now}
+		}
+		shard.mu.Unlock()
+	}
+
+	// Forget series which stopped being sent, such as those of deleted nodes.
+	c.pruneMu.Lock()
+	prune := now.Sub(c.lastPrune) >= CoverageRetention/4
+	if prune {
+		c.lastPrune = now
+	}
+	c.pruneMu.Unlock()
+	if !prune {
+		return
+	}
+	for s := range c.shards {
+		shard := &c.shards[s]
+		shard.mu.Lock()
+		for key, entry := range shard.series {
+			if now.Sub(entry.lastSeen) > CoverageRetention {
+				delete(shard.series, key)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// Since returns the series received at or after since, sorted by node, job and
+// metric.
+func (c *CoverageTracker) Since(since time.Time) []types.SeriesCoverage {
+	result := []types.SeriesCoverage{}
+	for s := range c.shards {
+		shard := &c.shards[s]
+		shard.mu.Lock()
+		for key, entry := range shard.series {
+			if entry.lastSeen.Before(since) {
+				continue
+			}
+			result = append(result, types.SeriesCoverage{
+				Node:     key.node,
+				Job:      key.job,
+				Metric:   key.metric,
+				Cost:     entry.cost,
+				LastSeen: entry.lastSeen,
+			})
+		}
+		shard.mu.Unlock()
+	}
+	slices.SortFunc(result, func(a, b types.SeriesCoverage) int {
+		return cmp.Or(cmp.Compare(a.Node, b.Node), cmp.Compare(a.Job, b.Job), cmp.Compare(a.Metric, b.Metric))
+	})
+	return result
+}
@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+func TestCoverageTracker(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	isCost := func(name string) bool { return name == "container_cpu_usage_seconds_total" }
+	metric := func(node, job, name string) types.Metric {
+		return types.Metric{NodeName: node, MetricName: name, Labels: map[string]string{"job": job}}
+	}
+
+	tracker := domain.NewCoverageTracker()
+	tracker.Record([]types.Metric{
+		metric("node-b", "cloudzero-nodes-cadvisor", "container_cpu_usage_seconds_total"),
+		metric("node-a", "cloudzero-nodes-cadvisor", "container_cpu_usage_seconds_total"),
+		metric("node-a", "cloudzero-nodes-cadvisor", "container_cpu_usage_seconds_total"),
+		metric("node-a", "static-kube-state-metrics", "kube_node_info"),
+	}, isCost, now)
+	tracker.Record([]types.Metric{
+		metric("node-a", "static-kube-state-metrics", "kube_node_info"),
+	}, isCost, now.Add(10*time.Minute))
+
+	assert.Equal(t, []types.SeriesCoverage{
+		{Node: "node-a", Job: "cloudzero-nodes-cadvisor", Metric: "container_cpu_usage_seconds_total", Cost: true, LastSeen: now},
+		{Node: "node-a", Job: "static-kube-state-metrics", Metric: "kube_node_info", LastSeen: now.Add(10 * time.Minute)},
+		{Node: "node-b", Job: "cloudzero-nodes-cadvisor", Metric: "container_cpu_usage_seconds_total", Cost: true, LastSeen: now},
+	}, tracker.Since(now))
+
+	assert.Equal(t, []types.SeriesCoverage{
+		{Node: "node-a", Job: "static-kube-state-metrics", Metric: "kube_node_info", LastSeen: now.Add(10 * time.Minute)},
+	}, tracker.Since(now.Add(time.Minute)))
+
+	// series not received for longer than the retention are forgotten
+	tracker.Record([]types.Metric{
+		metric("node-c", "cloudzero-nodes-cadvisor", "container_cpu_usage_seconds_total"),
+	}, isCost, now.Add(domain.CoverageRetention+5*time.Minute))
+	series := tracker.Since(time.Time{})
+	assert.Len(t, series, 2)
+	assert.Equal(t, "node-a", series[0].Node)
+	assert.Equal(t, "node-c", series[1].Node)
+}
+
+func TestCoverageTracker_Concurrent(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	isCost := func(name string) bool { return name == "container_cpu_usage_seconds_total" }
+
+	tracker := domain.NewCoverageTracker()
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metrics := make([]types.Metric, 0, 100)
+			for i := 0; i < 100; i++ {
+				metrics = append(metrics, types.Metric{
+					NodeName:   fmt.Sprintf("node-%d", n),
+					MetricName: fmt.Sprintf("metric_%d", i),
+					Labels:     map[string]string{"job": "cloudzero-nodes-cadvisor"},
+				})
+			}
+			tracker.Record(metrics, isCost, now)
+			_ = tracker.Since(now)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, tracker.Since(now), 800)
+}
+
+func TestCoverageTracker_IsCostOncePerName(t *testing.T) {
+	calls := map[string]int{}
+	isCost := func(name string) bool {
+		calls[name]++
+		return true
+	}
+
+	metrics := make([]types.Metric, 0, 20)
+	for i := 0; i < 10; i++ {
+		for _, name := range []string{"container_cpu_usage_seconds_total", "kube_node_info"} {
+			metrics = append(metrics, types.Metric{NodeName: fmt.Sprintf("node-%d", i), MetricName: name})
+		}
+	}
+	domain.NewCoverageTracker().Record(metrics, isCost, time.Now())
+
+	assert.Equal(t, map[string]int{"container_cpu_usage_seconds_total": 1, "kube_node_info": 1}, calls)
+}
@@ -11,12 +11,16 @@ import (
 	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/cz"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/datapath"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/egress"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/istio"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/k8s/identity"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/k8s/namespace"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/k8s/provider"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/k8s/version"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/kms"
 	promcfg "github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/prom/config"
+	promcov "github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/prom/coverage"
 	promver "github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/prom/version"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/stage"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/webhook"
@@ -57,6 +61,10 @@ func createRegistry(ctx context.Context, c *config.Settings) *registry {
 	r.add(config.DiagnosticPrometheusVersion, false, promver.NewProvider(ctx, c))
 	r.add(config.DiagnosticInsightsIngress, false, webhook.NewProvider(ctx, c))
 	r.add(config.DiagnosticIstioXClusterLB, false, istio.NewProvider(ctx, c))
+	r.add(config.DiagnosticDataPath, false, datapath.NewProvider(ctx, c))
+	r.add(config.DiagnosticScrapeCoverage, false, promcov.NewProvider(ctx, c))
+	r.add(config.DiagnosticClusterIdentity, false, identity.NewProvider(ctx, c))
+	r.add(config.DiagnosticEgress, false, egress.NewProvider(ctx, c))
 
 	// Internal diagnostics emitted based on stage
 	r.add(config.DiagnosticInternalInitStart, true, stage.NewProvider(ctx, c, status.StatusType_STATUS_TYPE_INIT_STARTED))
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package collector contains the client the diagnostics reach the collector
+// with.
+package collector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+)
+
+// NewClient returns client configured to reach the collector: it verifies the
+// certificate of a collector serving https and presents the client
+// certificate with the TLS settings, and authenticates with the bearer token
+// of the token file. client is returned as is when nothing is configured.
+func NewClient(cfg config.Collector, client *http.Client) (*http.Client, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if cfg.TLS == (config.CollectorTLS{}) && cfg.TokenFile == "" {
+		return client, nil
+	}
+
+	rt := client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if cfg.TLS != (config.CollectorTLS{}) {
+		tlsConfig, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport, ok := rt.(*http.Transport)
+		if !ok {
+			transport = http.DefaultTransport.(*http.Transport)
+		}
+		transport = transport.Clone()
+		transport.TLSClientConfig = tlsConfig
+		rt = transport
+	}
+	if cfg.TokenFile != "" {
+		rt = &tokenTransport{file: cfg.TokenFile, next: rt}
+	}
+	return &http.Client{Transport: rt, Timeout: client.Timeout}, nil
+}
+
+func newTLSConfig(cfg config.CollectorTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the collector CA bundle: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in the collector CA bundle %s", cfg.CAFile)
+		}
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the collector client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// tokenTransport sets the bearer token read from a file on every request. The
+// file is read for every request, so a rotated token is used.
+type tokenTransport struct {
+	file string
+	next http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := os.ReadFile(t.file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the collector token: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	return t.next.RoundTrip(req)
+}
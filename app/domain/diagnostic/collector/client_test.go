@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package collector_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/collector"
+)
+
+func TestNewClient(t *testing.T) {
+	t.Run("unconfigured", func(t *testing.T) {
+		client, err := collector.NewClient(config.Collector{}, http.DefaultClient)
+		require.NoError(t, err)
+		assert.Same(t, http.DefaultClient, client)
+	})
+
+	t.Run("token", func(t *testing.T) {
+		var authorization string
+		server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+		}))
+		defer server.Close()
+
+		tokenFile := filepath.Join(t.TempDir(), "token")
+		require.NoError(t, os.WriteFile(tokenFile, []byte("first\n"), 0o600))
+		client, err := collector.NewClient(config.Collector{TokenFile: tokenFile}, server.Client())
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, "Bearer first", authorization)
+
+		require.NoError(t, os.WriteFile(tokenFile, []byte("rotated"), 0o600))
+		resp, err = client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, "Bearer rotated", authorization, "a rotated token is used")
+	})
+
+	t.Run("invalid CA bundle", func(t *testing.T) {
+		caFile := filepath.Join(t.TempDir(), "ca.crt")
+		require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0o600))
+		_, err := collector.NewClient(config.Collector{TLS: config.CollectorTLS{CAFile: caFile}}, nil)
+		assert.ErrorContains(t, err, "no certificates found")
+	})
+}
@@ -0,0 +1,245 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package datapath contains code for checking that metrics flow from the
+// collector through to an upload.
+package datapath
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/collector"
+	logging "github.com/cloudzero/cloudzero-agent/app/logging/validator"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+const DiagnosticDataPath = config.DiagnosticDataPath
+
+// Hops of the data path, in order.
+const (
+	HopCollector = "collector"
+	HopDisk      = "disk"
+	HopUpload    = "upload"
+)
+
+var (
+	hopLatency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: types.ObservabilityMetric("validator_data_path_hop_seconds"),
+			Help: "Latency of each hop of the last canary sent through the data path",
+		},
+		[]string{"hop"},
+	)
+
+	registerMetricsOnce sync.Once
+)
+
+// Hop is the latency of one hop of the data path.
+type Hop struct {
+	Name    string
+	Latency time.Duration
+}
+
+type checker struct {
+	cfg    *config.Settings
+	logger *logrus.Entry
+}
+
+func NewProvider(ctx context.Context, cfg *config.Settings) diagnostic.Provider {
+	registerMetricsOnce.Do(func() {
+		prometheus.MustRegister(hopLatency)
+	})
+
+	return &checker{
+		cfg: cfg,
+		logger: logging.NewLogger().
+			WithContext(ctx).WithField(logging.OpField, "datapath"),
+	}
+}
+
+// Check sends a canary sample to the collector and waits until the file it is
+// flushed to has been uploaded by the shipper, reporting the latency of every
+// hop on the way.
+//
+// With a sharded aggregator the shipper polled may not be the replica the
+// canary was routed to, in which case the canary is reported as never
+// flushed.
+func (c *checker) Check(ctx context.Context, client *http.Client, accessor status.Accessor) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	collectorClient, err := collector.NewClient(c.cfg.Collector, client)
+	if err != nil {
+		c.logger.WithError(err).Error("failed to configure the collector client")
+		accessor.AddCheck(&status.StatusCheck{Name: DiagnosticDataPath, Passing: false, Error: err.Error()})
+		return nil
+	}
+
+	hops, err := c.trace(ctx, collectorClient, client)
+
+	fields := logrus.Fields{}
+	for _, hop := range hops {
+		hopLatency.WithLabelValues(hop.Name).Set(hop.Latency.Seconds())
+		fields[hop.Name] = hop.Latency.String()
+	}
+	if err != nil {
+		c.logger.WithFields(fields).WithError(err).Error("canary did not make it through the data path")
+		accessor.AddCheck(&status.StatusCheck{Name: DiagnosticDataPath, Passing: false, Error: err.Error()})
+		return nil
+	}
+
+	c.logger.WithFields(fields).Info("canary made it through the data path")
+	accessor.AddCheck(&status.StatusCheck{Name: DiagnosticDataPath, Passing: true})
+	return nil
+}
+
+// trace sends the canary with the collector client and polls the shipper with
+// client until the canary was uploaded, the timeout expired or the canary was
+// found to be mangled. The hops completed so far are always returned.
+func (c *checker) trace(ctx context.Context, collectorClient, client *http.Client) ([]Hop, error) {
+	id := uuid.NewString()
+	sentAt := time.Now().Truncate(time.Millisecond)
+
+	if err := c.send(ctx, collectorClient, id, sentAt); err != nil {
+		return nil, fmt.Errorf("the collector rejected the canary: %w", err)
+	}
+	hops := []Hop{{Name: HopCollector, Latency: time.Since(sentAt)}}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.DataPath.Timeout)
+	defer cancel()
+	ticker := time.NewTicker(c.cfg.DataPath.PollInterval)
+	defer ticker.Stop()
+
+	var (
+		trace   *types.CanaryTrace
+		lastErr error
+	)
+	for {
+		current, err := c.poll(ctx, client, id, sentAt)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// the poll was cut short by the timeout
+		case err != nil:
+			lastErr = err
+			c.logger.WithError(err).Warn("failed to query the canary from the shipper")
+		case current.LabelDropped:
+			return hops, fmt.Errorf("the canary reached %s without its %s label: the cost label filters (metrics.cost_labels) do not keep labels with the \"_\" prefix",
+				current.File, types.CanaryIDLabel)
+		case current.Flushed():
+			lastErr = nil
+			if trace == nil {
+				hops = append(hops, Hop{Name: HopDisk, Latency: current.FlushedAt.Sub(current.ReceivedAt)})
+			}
+			trace = current
+			if trace.Uploaded {
+				// The upload time is only observed when polling, so this hop
+				// is accurate to the poll interval.
+				return append(hops, Hop{Name: HopUpload, Latency: max(time.Since(trace.FlushedAt), 0)}), nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return hops, c.timeoutError(trace, lastErr)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *checker) timeoutError(trace *types.CanaryTrace, lastErr error) error {
+	timeout := c.cfg.DataPath.Timeout
+	if trace != nil {
+		return fmt.Errorf("the canary was flushed to %s but not uploaded within %s: check the shipper logs for upload errors", trace.File, timeout)
+	}
+	if lastErr != nil {
+		return fmt.Errorf("the canary was not found within %s, the shipper could not be queried: %w", timeout, lastErr)
+	}
+	return fmt.Errorf("the canary was not found in any flushed file within %s: the cost metric filters (metrics.cost) drop %s, the cost flush interval (database.cost_max_interval) is longer than the timeout, or the canary was routed to another replica of a sharded aggregator",
+		timeout, types.CanaryMetricName)
+}
+
+// send writes the canary sample to the collector using remote_write v1.
+func (c *checker) send(ctx context.Context, client *http.Client, id string, sentAt time.Time) error {
+	data, err := proto.Marshal(protoadapt.MessageV2Of(&prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{{
+			Labels: []prompb.Label{
+				{Name: "__name__", Value: types.CanaryMetricName},
+				{Name: types.CanaryIDLabel, Value: id},
+			},
+			Samples: []prompb.Sample{{Value: 1, Timestamp: sentAt.UnixMilli()}},
+		}},
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to marshal the write request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.CollectorURL(), bytes.NewReader(snappy.Encode(nil, data)))
+	if err != nil {
+		return fmt.Errorf("failed to create the request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("received %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// poll asks the shipper how far the canary has travelled.
+func (c *checker) poll(ctx context.Context, client *http.Client, id string, sentAt time.Time) (*types.CanaryTrace, error) {
+	endpoint := c.cfg.ShipperURL() + "/canary/" + url.PathEscape(id) + "?sent=" + strconv.FormatInt(sentAt.UnixMilli(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("received %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var trace types.CanaryTrace
+	if err := json.NewDecoder(resp.Body).Decode(&trace); err != nil {
+		return nil, fmt.Errorf("failed to decode the canary trace: %w", err)
+	}
+	if trace.ID != id {
+		return nil, errors.New("the shipper returned the trace of another canary")
+	}
+	return &trace, nil
+}
@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package datapath_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/datapath"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+// aggregator fakes the collector and shipper APIs. Every poll of the shipper
+// advances the canary by one step of the script.
+type aggregator struct {
+	t      *testing.T
+	script []func(trace *types.CanaryTrace)
+
+	mu    sync.Mutex
+	id    string
+	polls int
+}
+
+func (a *aggregator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/collector":
+		assert.Equal(a.t, "snappy", r.Header.Get("Content-Encoding"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(a.t, err)
+		data, err := snappy.Decode(nil, body)
+		require.NoError(a.t, err)
+		var req prompb.WriteRequest
+		require.NoError(a.t, proto.Unmarshal(data, protoadapt.MessageV2Of(&req)))
+		require.Len(a.t, req.Timeseries, 1)
+		for _, l := range req.Timeseries[0].Labels {
+			if l.Name == types.CanaryIDLabel {
+				a.id = l.Value
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case strings.HasPrefix(r.URL.Path, "/canary/"):
+		assert.Equal(a.t, a.id, strings.TrimPrefix(r.URL.Path, "/canary/"))
+		assert.NotEmpty(a.t, r.URL.Query().Get("sent"))
+		trace := &types.CanaryTrace{ID: a.id}
+		for i := 0; i < a.polls && i < len(a.script); i++ {
+			a.script[i](trace)
+		}
+		a.polls++
+		_ = json.NewEncoder(w).Encode(trace)
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func flushed(trace *types.CanaryTrace) {
+	trace.File = "metrics_1_2.json.br"
+	trace.ReceivedAt = time.Now().Add(-2 * time.Second)
+	trace.FlushedAt = time.Now().Add(-time.Second)
+}
+
+func uploaded(trace *types.CanaryTrace) {
+	trace.Uploaded = true
+}
+
+func runCheck(t *testing.T, agg http.Handler) *status.StatusCheck {
+	t.Helper()
+	server := httptest.NewServer(agg)
+	defer server.Close()
+
+	cfg := &config.Settings{
+		DataPath: config.DataPath{
+			CollectorURL: server.URL + "/collector",
+			ShipperURL:   server.URL,
+			Timeout:      500 * time.Millisecond,
+			PollInterval: 10 * time.Millisecond,
+		},
+	}
+	accessor := status.NewAccessor(&status.ClusterStatus{})
+	require.NoError(t, datapath.NewProvider(context.Background(), cfg).Check(context.Background(), server.Client(), accessor))
+
+	var check *status.StatusCheck
+	accessor.ReadFromReport(func(cs *status.ClusterStatus) {
+		require.Len(t, cs.Checks, 1)
+		check = cs.Checks[0]
+	})
+	assert.Equal(t, config.DiagnosticDataPath, check.Name)
+	return check
+}
+
+func TestCheck_CanaryUploaded(t *testing.T) {
+	check := runCheck(t, &aggregator{t: t, script: []func(*types.CanaryTrace){flushed, uploaded}})
+	assert.True(t, check.Passing)
+	assert.Empty(t, check.Error)
+}
+
+func TestCheck_CanaryNeverFlushed(t *testing.T) {
+	check := runCheck(t, &aggregator{t: t})
+	assert.False(t, check.Passing)
+	assert.Contains(t, check.Error, "metrics.cost")
+}
+
+func TestCheck_CanaryNeverUploaded(t *testing.T) {
+	check := runCheck(t, &aggregator{t: t, script: []func(*types.CanaryTrace){flushed}})
+	assert.False(t, check.Passing)
+	assert.Contains(t, check.Error, "not uploaded")
+}
+
+func TestCheck_CanaryLabelDropped(t *testing.T) {
+	check := runCheck(t, &aggregator{t: t, script: []func(*types.CanaryTrace){
+		func(trace *types.CanaryTrace) {
+			flushed(trace)
+			trace.LabelDropped = true
+		},
+	}})
+	assert.False(t, check.Passing)
+	assert.Contains(t, check.Error, "metrics.cost_labels")
+}
+
+func TestCheck_CollectorRejects(t *testing.T) {
+	check := runCheck(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	assert.False(t, check.Passing)
+	assert.Contains(t, check.Error, "collector rejected")
+	assert.Contains(t, check.Error, "401")
+}
+
+func TestCheck_CollectorTLSAndToken(t *testing.T) {
+	agg := &aggregator{t: t, script: []func(*types.CanaryTrace){flushed, uploaded}}
+	collector := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		agg.ServeHTTP(w, r)
+	}))
+	defer collector.Close()
+	shipper := httptest.NewServer(agg)
+	defer shipper.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: collector.Certificate().Raw}), 0o600))
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600))
+
+	cfg := &config.Settings{
+		Collector: config.Collector{
+			TLS:       config.CollectorTLS{CAFile: caFile},
+			TokenFile: tokenFile,
+		},
+		DataPath: config.DataPath{
+			CollectorURL: collector.URL + "/collector",
+			ShipperURL:   shipper.URL,
+			Timeout:      500 * time.Millisecond,
+			PollInterval: 10 * time.Millisecond,
+		},
+	}
+	accessor := status.NewAccessor(&status.ClusterStatus{})
+	require.NoError(t, datapath.NewProvider(context.Background(), cfg).Check(context.Background(), http.DefaultClient, accessor))
+
+	accessor.ReadFromReport(func(cs *status.ClusterStatus) {
+		require.Len(t, cs.Checks, 1)
+		assert.True(t, cs.Checks[0].Passing, cs.Checks[0].Error)
+	})
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package egress contains code for checking that the CloudZero API is
+// reachable through the egress proxy with a trusted certificate chain.
+package egress
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
+	logging "github.com/cloudzero/cloudzero-agent/app/logging/validator"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+const DiagnosticEgress = config.DiagnosticEgress
+
+// checkTimeout bounds the request sent to the CloudZero API.
+const checkTimeout = 15 * time.Second
+
+type checker struct {
+	cfg    *config.Settings
+	logger *logrus.Entry
+}
+
+func NewProvider(ctx context.Context, cfg *config.Settings) diagnostic.Provider {
+	return &checker{
+		cfg: cfg,
+		logger: logging.NewLogger().
+			WithContext(ctx).WithField(logging.OpField, "egress"),
+	}
+}
+
+// Check sends a request to the CloudZero API with the egress configuration,
+// logging the proxy it goes through and the certificate chain of the API. The
+// client is not used: the transport is built from the configuration, so a
+// broken configuration is reported rather than bypassed.
+func (c *checker) Check(ctx context.Context, _ *http.Client, accessor status.Accessor) error {
+	err := c.check(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("the CloudZero API is not reachable with the egress configuration")
+		accessor.AddCheck(&status.StatusCheck{Name: DiagnosticEgress, Passing: false, Error: err.Error()})
+		return nil
+	}
+
+	accessor.AddCheck(&status.StatusCheck{Name: DiagnosticEgress, Passing: true})
+	return nil
+}
+
+func (c *checker) check(ctx context.Context) error {
+	t, err := transport.New(c.cfg.Egress)
+	if err != nil {
+		return err
+	}
+	defer t.CloseIdleConnections()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	probe, err := t.Check(ctx, c.cfg.Cloudzero.Host)
+	if err != nil {
+		return err
+	}
+
+	fields := logrus.Fields{"host": c.cfg.Cloudzero.Host, "proxy": "none"}
+	if probe.Proxy != nil {
+		fields["proxy"] = probe.Proxy.String()
+	}
+	if probe.TLSVersion != 0 {
+		fields["tls_version"] = tls.VersionName(probe.TLSVersion)
+	}
+	for i, cert := range probe.Chain {
+		c.logger.WithFields(logrus.Fields{
+			"depth":     i,
+			"subject":   cert.Subject.String(),
+			"issuer":    cert.Issuer.String(),
+			"not_after": cert.NotAfter.Format(time.RFC3339),
+		}).Info("certificate chain of the CloudZero API")
+	}
+	c.logger.WithFields(fields).Info("the CloudZero API is reachable with the egress configuration")
+	return nil
+}
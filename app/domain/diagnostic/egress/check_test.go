@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package egress_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/egress"
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+func TestChecker_Check(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "")
+	t.Setenv("https_proxy", "")
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	empty := filepath.Join(dir, "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name    string
+		egress  transport.Config
+		passing bool
+		errText string
+	}{
+		{
+			name:    "trusted through the CA bundle",
+			egress:  transport.Config{CABundlePath: bundle},
+			passing: true,
+		},
+		{
+			name:    "untrusted certificate",
+			errText: "the TLS handshake with " + server.Listener.Addr().String() + " failed",
+		},
+		{
+			name:    "invalid CA bundle",
+			egress:  transport.Config{CABundlePath: empty},
+			errText: "no PEM certificate found in the CA bundle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Settings{
+				Cloudzero: config.Cloudzero{Host: server.URL},
+				Egress:    tt.egress,
+			}
+			provider := egress.NewProvider(context.Background(), cfg)
+
+			accessor := status.NewAccessor(&status.ClusterStatus{})
+			require.NoError(t, provider.Check(context.Background(), nil, accessor))
+
+			accessor.ReadFromReport(func(cs *status.ClusterStatus) {
+				require.Len(t, cs.Checks, 1)
+				assert.Equal(t, config.DiagnosticEgress, cs.Checks[0].Name)
+				assert.Equal(t, tt.passing, cs.Checks[0].Passing)
+				assert.Contains(t, cs.Checks[0].Error, tt.errText)
+			})
+		})
+	}
+}
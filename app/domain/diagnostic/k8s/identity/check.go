@@ -0,0 +1,162 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package identity contains code for checking that no other cluster uses the
+// same account and cluster name as this one.
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
+	logging "github.com/cloudzero/cloudzero-agent/app/logging/validator"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+const DiagnosticClusterIdentity = config.DiagnosticClusterIdentity
+
+type checker struct {
+	cfg       *config.Settings
+	logger    *logrus.Entry
+	clientset kubernetes.Interface
+}
+
+// NewProvider creates the cluster identity diagnostic. The clientset is only
+// passed by tests; otherwise one is created for the cluster when checking.
+func NewProvider(ctx context.Context, cfg *config.Settings, clientset ...kubernetes.Interface) diagnostic.Provider {
+	c := &checker{
+		cfg: cfg,
+		logger: logging.NewLogger().
+			WithContext(ctx).WithField(logging.OpField, "cluster_identity"),
+	}
+	if len(clientset) > 0 {
+		c.clientset = clientset[0]
+	}
+	return c
+}
+
+// notVerifiableError is returned when the shipper cannot tell whether another
+// cluster uses the same account and cluster name.
+type notVerifiableError struct {
+	error
+}
+
+// Check computes the fingerprint of the cluster, adds it to the report, and
+// fails when the shipper has recently seen another fingerprint for the same
+// account and cluster name.
+//
+// Other clusters are only seen through the fingerprint the CloudZero API
+// returns on upload allocations, so until the shipper has recently received
+// one the check is inconclusive rather than passing.
+func (c *checker) Check(ctx context.Context, client *http.Client, accessor status.Accessor) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	err := c.check(ctx, client, accessor)
+	var notVerifiable *notVerifiableError
+	if errors.As(err, &notVerifiable) {
+		c.logger.WithError(err).Warn("cluster identity is not verifiable")
+		accessor.AddCheck(diagnostic.Inconclusive(DiagnosticClusterIdentity, err.Error()))
+		return nil
+	}
+	if err != nil {
+		c.logger.WithError(err).Error("cluster identity check failed")
+		accessor.AddCheck(&status.StatusCheck{Name: DiagnosticClusterIdentity, Passing: false, Error: err.Error()})
+		return nil
+	}
+
+	c.logger.Info("no other cluster uses this account and cluster name")
+	accessor.AddCheck(&status.StatusCheck{Name: DiagnosticClusterIdentity, Passing: true})
+	return nil
+}
+
+func (c *checker) check(ctx context.Context, client *http.Client, accessor status.Accessor) error {
+	if c.clientset == nil {
+		clientset, err := k8s.GetClient()
+		if err != nil {
+			return fmt.Errorf("failed to create the kubernetes client: %w", err)
+		}
+		c.clientset = clientset
+	}
+
+	fingerprint, err := k8s.ClusterFingerprint(ctx, c.clientset)
+	if err != nil {
+		return err
+	}
+	accessor.WriteToReport(func(cs *status.ClusterStatus) {
+		cs.ClusterFingerprint = fingerprint
+	})
+
+	identity, err := c.identity(ctx, client)
+	if err != nil {
+		return fmt.Errorf("failed to get the fingerprints seen by the shipper: %w", err)
+	}
+
+	// The shipper may not have computed the fingerprint itself, e.g. when it
+	// may not read the kube-system namespace.
+	identity.Fingerprint = fingerprint
+	now := time.Now()
+	conflicts := identity.Conflicts(now)
+	if len(conflicts) == 0 {
+		if !seenFromAPI(identity, now) {
+			return &notVerifiableError{errors.New("not verifiable: the CloudZero API has not returned the fingerprint it has on record for this account and cluster name, so other clusters using them cannot be seen")}
+		}
+		return nil
+	}
+
+	others := make([]string, 0, len(conflicts))
+	for _, r := range conflicts {
+		others = append(others, fmt.Sprintf("%s (source %s, last seen %s)", r.Fingerprint, r.Source, r.LastSeen.UTC().Format(time.RFC3339)))
+	}
+	return fmt.Errorf("cluster name %q in account %q is also used by another cluster with fingerprint %s; this cluster has fingerprint %s: give each cluster a unique clusterName",
+		c.cfg.Deployment.ClusterName, c.cfg.Deployment.AccountID, strings.Join(others, ", "), fingerprint)
+}
+
+// seenFromAPI reports whether the CloudZero API returned a fingerprint within
+// the conflict window, i.e. whether the conflicts of identity are known.
+func seenFromAPI(identity *types.ClusterIdentity, now time.Time) bool {
+	for _, r := range identity.Seen {
+		if r.Source == types.FingerprintSourceAPI && !r.LastSeen.Before(now.Add(-types.FingerprintConflictWindow)) {
+			return true
+		}
+	}
+	return false
+}
+
+// identity asks the shipper which fingerprints it has seen.
+func (c *checker) identity(ctx context.Context, client *http.Client) (*types.ClusterIdentity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.ShipperURL()+"/identity", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("received %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var identity types.ClusterIdentity
+	if err := json.NewDecoder(resp.Body).Decode(&identity); err != nil {
+		return nil, fmt.Errorf("failed to decode the cluster identity: %w", err)
+	}
+	return &identity, nil
+}
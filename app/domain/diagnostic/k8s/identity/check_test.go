@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package identity_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/k8s/identity"
+	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+func TestChecker_Check(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: "8d7d1d3c-5f1e-4c4e-9a3e-2f5d6b7a8c9d"},
+	})
+	fingerprint, err := k8s.ClusterFingerprint(context.Background(), clientset)
+	require.NoError(t, err)
+
+	now := time.Now()
+	tests := []struct {
+		name         string
+		seen         []types.FingerprintRecord
+		code         int
+		passing      bool
+		inconclusive bool
+		errText      string
+	}{
+		{
+			name: "only this cluster",
+			seen: []types.FingerprintRecord{
+				{Fingerprint: fingerprint, Source: types.FingerprintSourceLocal, LastSeen: now},
+				{Fingerprint: fingerprint, Source: types.FingerprintSourceAPI, LastSeen: now},
+			},
+			code:    http.StatusOK,
+			passing: true,
+		},
+		{
+			name: "another cluster replaced long ago",
+			seen: []types.FingerprintRecord{
+				{Fingerprint: fingerprint, Source: types.FingerprintSourceLocal, LastSeen: now},
+				{Fingerprint: fingerprint, Source: types.FingerprintSourceAPI, LastSeen: now},
+				{Fingerprint: "old", Source: types.FingerprintSourceLocal, LastSeen: now.Add(-7 * 24 * time.Hour)},
+			},
+			code:    http.StatusOK,
+			passing: true,
+		},
+		{
+			name:         "no fingerprint from the API",
+			seen:         []types.FingerprintRecord{{Fingerprint: fingerprint, Source: types.FingerprintSourceLocal, LastSeen: now}},
+			code:         http.StatusOK,
+			inconclusive: true,
+			errText:      "not verifiable",
+		},
+		{
+			name: "another cluster with the same name",
+			seen: []types.FingerprintRecord{
+				{Fingerprint: fingerprint, Source: types.FingerprintSourceLocal, LastSeen: now},
+				{Fingerprint: "other", Source: types.FingerprintSourceAPI, LastSeen: now},
+			},
+			code:    http.StatusOK,
+			errText: `cluster name "prod" in account "123456789012" is also used by another cluster with fingerprint other (source api`,
+		},
+		{
+			name:    "shipper unavailable",
+			code:    http.StatusInternalServerError,
+			errText: "failed to get the fingerprints seen by the shipper: received 500",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/identity", r.URL.Path)
+				w.WriteHeader(tt.code)
+				// The shipper did not compute the fingerprint itself.
+				_ = json.NewEncoder(w).Encode(types.ClusterIdentity{Seen: tt.seen})
+			}))
+			defer server.Close()
+
+			cfg := &config.Settings{
+				Deployment: config.Deployment{AccountID: "123456789012", ClusterName: "prod"},
+				DataPath:   config.DataPath{ShipperURL: server.URL},
+			}
+			provider := identity.NewProvider(context.Background(), cfg, clientset)
+
+			accessor := status.NewAccessor(&status.ClusterStatus{})
+			require.NoError(t, provider.Check(context.Background(), server.Client(), accessor))
+
+			accessor.ReadFromReport(func(cs *status.ClusterStatus) {
+				assert.Equal(t, fingerprint, cs.ClusterFingerprint)
+				require.Len(t, cs.Checks, 1)
+				assert.Equal(t, config.DiagnosticClusterIdentity, cs.Checks[0].Name)
+				assert.Equal(t, tt.passing, cs.Checks[0].Passing)
+				assert.Equal(t, tt.inconclusive, !cs.Checks[0].Passing && !diagnostic.Failed(cs.Checks[0]))
+				assert.Contains(t, cs.Checks[0].Error, tt.errText)
+			})
+		})
+	}
+}
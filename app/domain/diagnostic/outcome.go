@@ -0,0 +1,23 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package diagnostic
+
+import (
+	"github.com/cloudzero/cloudzero-agent/app/inspector"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+// Inconclusive returns the check of a diagnostic which could not tell whether
+// the cluster passes it, for example because what it verifies cannot be
+// observed. The check does not pass and carries the reason, but its info
+// severity keeps it from counting as a failure.
+func Inconclusive(name, reason string) *status.StatusCheck {
+	return &status.StatusCheck{Name: name, Passing: false, Error: reason, Severity: string(inspector.SeverityInfo)}
+}
+
+// Failed reports whether a check failed. Inconclusive checks do not pass, but
+// did not fail either.
+func Failed(c *status.StatusCheck) bool {
+	return !c.Passing && c.Severity != string(inspector.SeverityInfo)
+}
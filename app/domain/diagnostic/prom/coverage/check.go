@@ -0,0 +1,354 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package coverage contains code for checking that the required cost metrics
+// are scraped for every node of the cluster.
+package coverage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/collector"
+	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
+	logging "github.com/cloudzero/cloudzero-agent/app/logging/validator"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+const DiagnosticScrapeCoverage = config.DiagnosticScrapeCoverage
+
+// maxListedNodes is the number of missing nodes named in a finding.
+const maxListedNodes = 5
+
+// collectorPortName is the name of the port of the collector service the
+// collector listens on.
+const collectorPortName = "metrics"
+
+// Jobs of the scrape configuration installed by the chart.
+const (
+	jobCadvisor         = "cloudzero-nodes-cadvisor"
+	jobKubeStateMetrics = "static-kube-state-metrics"
+)
+
+type checker struct {
+	cfg       *config.Settings
+	logger    *logrus.Entry
+	clientset kubernetes.Interface
+}
+
+// NewProvider creates the scrape coverage diagnostic. The clientset is only
+// passed by tests; otherwise one is created for the cluster when checking.
+func NewProvider(ctx context.Context, cfg *config.Settings, clientset ...kubernetes.Interface) diagnostic.Provider {
+	c := &checker{
+		cfg: cfg,
+		logger: logging.NewLogger().
+			WithContext(ctx).WithField(logging.OpField, "scrape_coverage"),
+	}
+	if len(clientset) > 0 {
+		c.clientset = clientset[0]
+	}
+	return c
+}
+
+// inconclusiveError is returned when the coverage of the cluster cannot be
+// known, such as when a replica of the collector cannot be queried.
+type inconclusiveError struct {
+	error
+}
+
+// Check compares the metrics the collector received for each node with the
+// nodes of the cluster, and reports every required cost metric which is
+// missing for some nodes along with the scrape job expected to provide it.
+//
+// Each replica of the collector only knows the series sent to it, so the
+// coverage of every ready replica is merged. The check is inconclusive when
+// the replicas cannot be listed or one of them cannot be queried.
+func (c *checker) Check(ctx context.Context, client *http.Client, accessor status.Accessor) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	findings, err := c.findings(ctx, client)
+	if err == nil && len(findings) > 0 {
+		err = errors.New(strings.Join(findings, "; "))
+	}
+	var inconclusive *inconclusiveError
+	if errors.As(err, &inconclusive) {
+		c.logger.WithError(err).Warn("scrape coverage is inconclusive")
+		accessor.AddCheck(diagnostic.Inconclusive(DiagnosticScrapeCoverage, err.Error()))
+		return nil
+	}
+	if err != nil {
+		c.logger.WithError(err).Error("scrape coverage is incomplete")
+		accessor.AddCheck(&status.StatusCheck{Name: DiagnosticScrapeCoverage, Passing: false, Error: err.Error()})
+		return nil
+	}
+
+	c.logger.Info("every node reports the required cost metrics")
+	accessor.AddCheck(&status.StatusCheck{Name: DiagnosticScrapeCoverage, Passing: true})
+	return nil
+}
+
+// findings returns one human readable finding per required metric which is
+// not received for every node.
+func (c *checker) findings(ctx context.Context, client *http.Client) ([]string, error) {
+	window := c.cfg.ScrapeCoverage.Window
+
+	nodes, err := c.nodes(ctx, window)
+	if err != nil {
+		return nil, err
+	}
+	report, err := c.coverage(ctx, client, window)
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Series) == 0 {
+		return nil, fmt.Errorf("the collector has not received any metrics in the last %s: check the remote_write configuration of the agent", window)
+	}
+
+	// metric -> node -> seen, and metric -> jobs providing it
+	seen := map[string]map[string]bool{}
+	jobs := map[string][]string{}
+	cost := map[string]bool{}
+	for _, series := range report.Series {
+		if seen[series.Metric] == nil {
+			seen[series.Metric] = map[string]bool{}
+		}
+		seen[series.Metric][series.Node] = true
+		if series.Job != "" && !slices.Contains(jobs[series.Metric], series.Job) {
+			jobs[series.Metric] = append(jobs[series.Metric], series.Job)
+		}
+		cost[series.Metric] = series.Cost
+	}
+
+	var findings []string
+	for _, metric := range c.cfg.ScrapeCoverage.RequiredMetrics {
+		if seen[metric] == nil {
+			job, hint := remediation(metric)
+			findings = append(findings, fmt.Sprintf("%s was not received for any node: the %s job is not scraped, %s", metric, job, hint))
+			continue
+		}
+		if !cost[metric] {
+			findings = append(findings, fmt.Sprintf("%s is received but not a cost metric: add it to the cost metric filters (metrics.cost)", metric))
+			continue
+		}
+
+		var missing []string
+		for _, node := range nodes {
+			if !seen[metric][node] {
+				missing = append(missing, node)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		job, hint := remediation(metric)
+		if len(jobs[metric]) > 0 {
+			job = strings.Join(jobs[metric], ", ")
+		}
+		findings = append(findings, fmt.Sprintf("%s is missing for %d of %d nodes (%s) from job %s: %s",
+			metric, len(missing), len(nodes), listNodes(missing), job, hint))
+	}
+	return findings, nil
+}
+
+// kubernetes returns the clientset of the cluster, creating it on first use.
+func (c *checker) kubernetes() (kubernetes.Interface, error) {
+	if c.clientset == nil {
+		clientset, err := k8s.GetClient()
+		if err != nil {
+			return nil, err
+		}
+		c.clientset = clientset
+	}
+	return c.clientset, nil
+}
+
+// nodes returns the names of the nodes of the cluster which are older than the
+// window, so were expected to be scraped within it.
+func (c *checker) nodes(ctx context.Context, window time.Duration) ([]string, error) {
+	clientset, err := c.kubernetes()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the nodes, check the validator is allowed to list nodes: %w", err)
+	}
+
+	cutoff := time.Now().Add(-window)
+	nodes := []string{}
+	for _, node := range list.Items {
+		if node.CreationTimestamp.After(cutoff) {
+			continue
+		}
+		nodes = append(nodes, node.Name)
+	}
+	slices.Sort(nodes)
+	return nodes, nil
+}
+
+// coverage returns the series received by the collector within the window,
+// merged across its replicas.
+func (c *checker) coverage(ctx context.Context, client *http.Client, window time.Duration) (*types.CoverageReport, error) {
+	endpoints, err := c.replicas(ctx)
+	if err != nil {
+		return nil, &inconclusiveError{fmt.Errorf("failed to list the collector replicas, whose coverage must be merged: %w", err)}
+	}
+
+	cfg := c.cfg.Collector
+	if len(endpoints) > 1 && cfg.TLS.ServerName == "" {
+		// The replicas are queried by address, but serve the certificate of
+		// the collector service.
+		if u, err := url.Parse(c.cfg.CoverageURL()); err == nil {
+			cfg.TLS.ServerName = u.Hostname()
+		}
+	}
+	client, err = collector.NewClient(cfg, client)
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoints) <= 1 {
+		return c.query(ctx, client, c.cfg.CoverageURL(), window)
+	}
+
+	merged := &types.CoverageReport{Series: []types.SeriesCoverage{}}
+	index := map[types.SeriesCoverage]int{}
+	for _, endpoint := range endpoints {
+		report, err := c.query(ctx, client, endpoint, window)
+		if err != nil {
+			return nil, &inconclusiveError{fmt.Errorf("the coverage of %d collector replicas cannot be merged: %w", len(endpoints), err)}
+		}
+		if merged.Since.IsZero() || report.Since.Before(merged.Since) {
+			merged.Since = report.Since
+		}
+		for _, series := range report.Series {
+			key := types.SeriesCoverage{Node: series.Node, Job: series.Job, Metric: series.Metric}
+			i, ok := index[key]
+			if !ok {
+				index[key] = len(merged.Series)
+				merged.Series = append(merged.Series, series)
+				continue
+			}
+			merged.Series[i].Cost = merged.Series[i].Cost || series.Cost
+			if series.LastSeen.After(merged.Series[i].LastSeen) {
+				merged.Series[i].LastSeen = series.LastSeen
+			}
+		}
+	}
+	return merged, nil
+}
+
+// replicas returns the coverage URLs of the ready replicas behind the
+// collector service. A configured coverage URL is the only one queried.
+func (c *checker) replicas(ctx context.Context) ([]string, error) {
+	if c.cfg.ScrapeCoverage.URL != "" {
+		return []string{c.cfg.ScrapeCoverage.URL}, nil
+	}
+
+	clientset, err := c.kubernetes()
+	if err != nil {
+		return nil, err
+	}
+	list, err := clientset.DiscoveryV1().EndpointSlices(c.cfg.Services.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + c.cfg.Services.CollectorService,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "http"
+	if u, err := url.Parse(c.cfg.CoverageURL()); err == nil {
+		scheme = u.Scheme
+	}
+	var endpoints []string
+	for _, slice := range list.Items {
+		var port int32
+		for _, p := range slice.Ports {
+			if p.Name != nil && *p.Name == collectorPortName && p.Port != nil {
+				port = *p.Port
+			}
+		}
+		if port == 0 {
+			continue
+		}
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			for _, address := range endpoint.Addresses {
+				endpoint := scheme + "://" + net.JoinHostPort(address, strconv.Itoa(int(port))) + "/coverage"
+				if !slices.Contains(endpoints, endpoint) {
+					endpoints = append(endpoints, endpoint)
+				}
+			}
+		}
+	}
+	return endpoints, nil
+}
+
+// query queries the series received by a collector within the window.
+func (c *checker) query(ctx context.Context, client *http.Client, coverageURL string, window time.Duration) (*types.CoverageReport, error) {
+	endpoint := coverageURL + "?window=" + url.QueryEscape(window.String())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the collector coverage: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("failed to query the collector coverage: received %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var report types.CoverageReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, fmt.Errorf("failed to decode the collector coverage: %w", err)
+	}
+	return &report, nil
+}
+
+// remediation returns the scrape job expected to provide a metric, and a hint
+// on what usually prevents it from doing so.
+func remediation(metric string) (job, hint string) {
+	switch {
+	case strings.HasPrefix(metric, "container_"):
+		return jobCadvisor, "check that the agent may proxy to the kubelet (nodes/proxy RBAC), or that the kubelet port of the nodes is reachable when scraping nodes directly"
+	case strings.HasPrefix(metric, "kube_"):
+		return jobKubeStateMetrics, "check that kube-state-metrics is running and that the job's metric_relabel_configs keep the metric"
+	default:
+		return "expected", "check the scrape configuration for the job providing the metric"
+	}
+}
+
+// listNodes names the first few nodes of a list.
+func listNodes(nodes []string) string {
+	if len(nodes) <= maxListedNodes {
+		return strings.Join(nodes, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(nodes[:maxListedNodes], ", "), len(nodes)-maxListedNodes)
+}
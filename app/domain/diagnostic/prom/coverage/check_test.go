@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package coverage_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/utils/ptr"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/prom/coverage"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+func node(name string, age time.Duration) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:              name,
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+	}}
+}
+
+// fullCoverage returns the series of every required metric for the nodes.
+func fullCoverage(nodes ...string) []types.SeriesCoverage {
+	var series []types.SeriesCoverage
+	for _, n := range nodes {
+		series = append(series,
+			types.SeriesCoverage{Node: n, Job: "cloudzero-nodes-cadvisor", Metric: "container_cpu_usage_seconds_total", Cost: true},
+			types.SeriesCoverage{Node: n, Job: "static-kube-state-metrics", Metric: "kube_node_info", Cost: true},
+		)
+	}
+	return series
+}
+
+func runCheck(t *testing.T, series []types.SeriesCoverage, nodes ...*corev1.Node) *status.StatusCheck {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/coverage", r.URL.Path)
+		assert.Equal(t, "10m0s", r.URL.Query().Get("window"))
+		_ = json.NewEncoder(w).Encode(types.CoverageReport{Series: series})
+	}))
+	defer server.Close()
+
+	cfg := &config.Settings{
+		ScrapeCoverage: config.ScrapeCoverage{
+			URL:             server.URL + "/coverage",
+			RequiredMetrics: []string{"container_cpu_usage_seconds_total", "kube_node_info"},
+		},
+	}
+	require.NoError(t, cfg.ScrapeCoverage.Validate())
+
+	objects := make([]runtime.Object, 0, len(nodes))
+	for _, n := range nodes {
+		objects = append(objects, n)
+	}
+	provider := coverage.NewProvider(context.Background(), cfg, fake.NewSimpleClientset(objects...))
+
+	accessor := status.NewAccessor(&status.ClusterStatus{})
+	require.NoError(t, provider.Check(context.Background(), server.Client(), accessor))
+
+	var check *status.StatusCheck
+	accessor.ReadFromReport(func(cs *status.ClusterStatus) {
+		require.Len(t, cs.Checks, 1)
+		check = cs.Checks[0]
+	})
+	assert.Equal(t, config.DiagnosticScrapeCoverage, check.Name)
+	return check
+}
+
+func TestCheck_FullCoverage(t *testing.T) {
+	check := runCheck(t, fullCoverage("node-a", "node-b"),
+		node("node-a", time.Hour), node("node-b", time.Hour),
+		// too young to have been scraped yet
+		node("node-c", time.Minute),
+	)
+	assert.True(t, check.Passing)
+	assert.Empty(t, check.Error)
+}
+
+func TestCheck_MissingNode(t *testing.T) {
+	series := append(fullCoverage("node-a"),
+		types.SeriesCoverage{Node: "node-b", Job: "static-kube-state-metrics", Metric: "kube_node_info", Cost: true})
+	check := runCheck(t, series, node("node-a", time.Hour), node("node-b", time.Hour))
+	assert.False(t, check.Passing)
+	assert.Contains(t, check.Error, "container_cpu_usage_seconds_total is missing for 1 of 2 nodes (node-b) from job cloudzero-nodes-cadvisor")
+	assert.Contains(t, check.Error, "nodes/proxy")
+	assert.NotContains(t, check.Error, "kube_node_info")
+}
+
+func TestCheck_MissingJob(t *testing.T) {
+	series := []types.SeriesCoverage{
+		{Node: "node-a", Job: "cloudzero-nodes-cadvisor", Metric: "container_cpu_usage_seconds_total", Cost: true},
+	}
+	check := runCheck(t, series, node("node-a", time.Hour))
+	assert.False(t, check.Passing)
+	assert.Contains(t, check.Error, "kube_node_info was not received for any node: the static-kube-state-metrics job is not scraped")
+}
+
+func TestCheck_NotCostMetric(t *testing.T) {
+	series := fullCoverage("node-a")
+	series[1].Cost = false
+	check := runCheck(t, series, node("node-a", time.Hour))
+	assert.False(t, check.Passing)
+	assert.Contains(t, check.Error, "kube_node_info is received but not a cost metric")
+	assert.Contains(t, check.Error, "metrics.cost")
+}
+
+func TestCheck_NothingReceived(t *testing.T) {
+	check := runCheck(t, nil, node("node-a", time.Hour))
+	assert.False(t, check.Passing)
+	assert.Contains(t, check.Error, "remote_write")
+}
+
+// replica serves the coverage of one collector replica and returns the
+// EndpointSlice it is listed in.
+func replica(t *testing.T, name string, handler http.HandlerFunc) *discoveryv1.EndpointSlice {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.ParseInt(port, 10, 32)
+	require.NoError(t, err)
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "cza",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "cz-aggregator"},
+		},
+		Ports:     []discoveryv1.EndpointPort{{Name: ptr.To("metrics"), Port: ptr.To(int32(portNum))}},
+		Endpoints: []discoveryv1.Endpoint{{Addresses: []string{host}, Conditions: discoveryv1.EndpointConditions{Ready: ptr.To(true)}}},
+	}
+}
+
+func serveCoverage(series []types.SeriesCoverage) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(types.CoverageReport{Series: series})
+	}
+}
+
+func runReplicasCheck(t *testing.T, objects ...runtime.Object) *status.StatusCheck {
+	t.Helper()
+	cfg := &config.Settings{
+		Services:       config.Services{Namespace: "cza", CollectorService: "cz-aggregator"},
+		ScrapeCoverage: config.ScrapeCoverage{RequiredMetrics: []string{"container_cpu_usage_seconds_total", "kube_node_info"}},
+	}
+	require.NoError(t, cfg.ScrapeCoverage.Validate())
+
+	objects = append(objects, node("node-a", time.Hour), node("node-b", time.Hour))
+	provider := coverage.NewProvider(context.Background(), cfg, fake.NewSimpleClientset(objects...))
+	accessor := status.NewAccessor(&status.ClusterStatus{})
+	require.NoError(t, provider.Check(context.Background(), http.DefaultClient, accessor))
+
+	var check *status.StatusCheck
+	accessor.ReadFromReport(func(cs *status.ClusterStatus) {
+		require.Len(t, cs.Checks, 1)
+		check = cs.Checks[0]
+	})
+	return check
+}
+
+func TestCheck_MergesReplicas(t *testing.T) {
+	check := runReplicasCheck(t,
+		replica(t, "cz-aggregator-a", serveCoverage(fullCoverage("node-a"))),
+		replica(t, "cz-aggregator-b", serveCoverage(fullCoverage("node-b"))),
+	)
+	assert.True(t, check.Passing, check.Error)
+}
+
+func TestCheck_ReplicaUnavailable(t *testing.T) {
+	check := runReplicasCheck(t,
+		replica(t, "cz-aggregator-a", serveCoverage(fullCoverage("node-a", "node-b"))),
+		replica(t, "cz-aggregator-b", func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		}),
+	)
+	assert.False(t, check.Passing)
+	assert.False(t, diagnostic.Failed(check), "the check is inconclusive")
+	assert.Contains(t, check.Error, "2 collector replicas")
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package diagnostic
+
+import (
+	"github.com/cloudzero/cloudzero-agent/app/inspector"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+// Remediation returns what to do when the named diagnostic fails, whatever the
+// error, or an empty string when there is no advice for it.
+func Remediation(name string) string {
+	if r := inspector.DefaultCatalog().MatchCheck(name, ""); r != nil {
+		return r.Explanation
+	}
+	return ""
+}
+
+// Remediate attaches the remediation of the catalog matching a failing check.
+// Advice the check set itself is kept.
+func Remediate(c *status.StatusCheck) {
+	if !Failed(c) {
+		return
+	}
+	r := inspector.DefaultCatalog().MatchCheck(c.Name, c.Error)
+	if r == nil {
+		return
+	}
+	if c.Remediation == "" {
+		c.Remediation = r.Explanation
+	}
+	c.RemediationId = r.ID
+	c.Severity = string(r.Severity)
+	c.DocUrl = r.DocURL
+}
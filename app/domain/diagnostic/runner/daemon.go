@@ -0,0 +1,308 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cloudzero/cloudzero-agent/app/build"
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/catalog"
+	logging "github.com/cloudzero/cloudzero-agent/app/logging/validator"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+	"github.com/cloudzero/cloudzero-agent/app/utils/telemetry"
+)
+
+var (
+	checkPassing = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: types.ObservabilityMetric("validator_check_passing"),
+			Help: "Whether the last run of a diagnostic check passed (1) or failed (0)",
+		},
+		[]string{"check"},
+	)
+	checkDuration = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: types.ObservabilityMetric("validator_check_duration_seconds"),
+			Help: "Duration of the last run of a diagnostic check",
+		},
+		[]string{"check"},
+	)
+	checkLastRun = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: types.ObservabilityMetric("validator_check_last_run_timestamp_seconds"),
+			Help: "Unix time of the last run of a diagnostic check",
+		},
+		[]string{"check"},
+	)
+
+	registerDaemonMetricsOnce sync.Once
+)
+
+// poster sends a status report to CloudZero.
+type poster func(ctx context.Context, client *http.Client, cfg *config.Settings, accessor status.Accessor) error
+
+// schedule is a check run periodically by the daemon.
+type schedule struct {
+	name      string
+	interval  time.Duration
+	providers []diagnostic.Provider
+}
+
+// Daemon is the long-running mode of the diagnostics Engine. Each configured
+// check is re-run on its own interval, and the latest result of every check
+// is kept in a single ClusterStatus.
+//
+// The result of each check is exported as Prometheus gauges. Telemetry is only
+// posted when a check changes between passing and failing, and then only
+// contains the checks which changed. Changes which cannot be posted are kept
+// in the telemetry outbox, and retried while the daemon runs.
+type Daemon struct {
+	cfg    *config.Settings
+	logger *logrus.Entry
+	client *http.Client
+	outbox *telemetry.Outbox
+	post   poster
+
+	schedules []schedule
+
+	mu         sync.RWMutex
+	latest     *status.ClusterStatus
+	results    map[string]*status.StatusCheck
+	checkTypes map[string]config.CheckType
+}
+
+var _ Engine = (*Daemon)(nil)
+
+// NewDaemon creates a daemon running the checks of cfg.Daemon.Checks.
+func NewDaemon(c *config.Settings, reg catalog.Registry) *Daemon {
+	registerDaemonMetricsOnce.Do(func() {
+		prometheus.MustRegister(checkPassing, checkDuration, checkLastRun)
+	})
+
+	client := NewClient(c)
+	outbox := telemetry.NewOutbox(context.Background(), c, client)
+	d := &Daemon{
+		cfg:        c,
+		logger:     logging.NewLogger().WithField(logging.OpField, "daemon"),
+		client:     client,
+		outbox:     outbox,
+		post:       outbox.Post,
+		results:    make(map[string]*status.StatusCheck),
+		checkTypes: make(map[string]config.CheckType),
+		latest: &status.ClusterStatus{
+			Account:          c.Deployment.AccountID,
+			Region:           c.Deployment.Region,
+			Name:             c.Deployment.ClusterName,
+			ValidatorVersion: build.GetVersion(),
+			ChartVersion:     c.Versions.ChartVersion,
+		},
+	}
+
+	for _, check := range c.Daemon.Checks {
+		interval := check.Interval
+		if interval <= 0 {
+			interval = c.Daemon.Interval
+		}
+		if interval <= 0 {
+			interval = 5 * time.Minute
+		}
+		d.checkTypes[check.Name] = check.Type
+		d.schedules = append(d.schedules, schedule{
+			name:      check.Name,
+			interval:  interval,
+			providers: reg.Get(check.Name),
+		})
+	}
+	return d
+}
+
+// Run runs every check immediately and then on its interval until the context
+// is cancelled. The latest status is returned once all schedules stopped.
+func (d *Daemon) Run(ctx context.Context) (status.Accessor, error) {
+	if len(d.schedules) == 0 {
+		return status.NewAccessor(d.Status()), errors.New("no diagnostic checks scheduled")
+	}
+
+	if err := d.outbox.Run(); err != nil {
+		d.logger.WithError(err).Warn("failed to start the telemetry outbox")
+	}
+	defer func() { _ = d.outbox.Shutdown() }()
+
+	var wg sync.WaitGroup
+	for i := range d.schedules {
+		wg.Add(1)
+		go func(s *schedule) {
+			defer wg.Done()
+			d.loop(ctx, s)
+		}(&d.schedules[i])
+	}
+	wg.Wait()
+
+	return status.NewAccessor(d.Status()), nil
+}
+
+// ShouldFail returns true if the latest run of any required check failed.
+func (d *Daemon) ShouldFail() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for name, result := range d.results {
+		if diagnostic.Failed(result) && d.checkTypes[name] == config.CheckTypeRequired {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns a copy of the latest status.
+func (d *Daemon) Status() *status.ClusterStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return proto.Clone(d.latest).(*status.ClusterStatus)
+}
+
+// ServeHTTP serves the latest status as JSON.
+func (d *Daemon) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	b, err := protojson.Marshal(d.Status())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func (d *Daemon) loop(ctx context.Context, s *schedule) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		d.runCheck(ctx, s)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runCheck runs the providers of a check against an empty report and merges
+// the result into the latest status.
+func (d *Daemon) runCheck(ctx context.Context, s *schedule) {
+	recorder := status.NewAccessor(&status.ClusterStatus{})
+
+	start := time.Now()
+	var errs []error
+	for _, p := range s.providers {
+		if err := p.Check(ctx, d.client, recorder); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	elapsed := time.Since(start)
+	if ctx.Err() != nil {
+		// a check interrupted by shutdown says nothing about the cluster
+		return
+	}
+
+	var result *status.ClusterStatus
+	recorder.ReadFromReport(func(cs *status.ClusterStatus) {
+		result = proto.Clone(cs).(*status.ClusterStatus)
+	})
+	checks := result.Checks
+	result.Checks = nil
+	if len(checks) == 0 {
+		check := &status.StatusCheck{Name: s.name, Passing: true}
+		if err := errors.Join(errs...); err != nil {
+			check.Passing = false
+			check.Error = err.Error()
+		} else if len(s.providers) == 0 {
+			check.Passing = false
+			check.Error = "no provider registered for check"
+		}
+		checks = []*status.StatusCheck{check}
+	}
+
+	checkDuration.WithLabelValues(s.name).Set(elapsed.Seconds())
+	checkLastRun.WithLabelValues(s.name).Set(float64(time.Now().Unix()))
+	for _, c := range checks {
+		annotate(c, d.checkTypes[c.Name], elapsed)
+		checkPassing.WithLabelValues(c.Name).Set(boolToFloat(c.Passing))
+	}
+
+	if changed := d.record(result, checks); len(changed) > 0 {
+		d.postDelta(ctx, changed)
+	}
+}
+
+// record merges the result of a check into the latest status and returns the
+// checks whose passing state changed, including checks seen for the first
+// time.
+func (d *Daemon) record(result *status.ClusterStatus, checks []*status.StatusCheck) []*status.StatusCheck {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// fields set by the check, such as the Kubernetes version
+	proto.Merge(d.latest, result)
+
+	var changed []*status.StatusCheck
+	for _, c := range checks {
+		previous, seen := d.results[c.Name]
+		if !seen || previous.Passing != c.Passing {
+			changed = append(changed, proto.Clone(c).(*status.StatusCheck))
+		}
+		d.results[c.Name] = c
+
+		replaced := false
+		for i, existing := range d.latest.Checks {
+			if existing.Name == c.Name {
+				d.latest.Checks[i] = c
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			d.latest.Checks = append(d.latest.Checks, c)
+		}
+	}
+	return changed
+}
+
+// postDelta posts a report containing only the checks which changed.
+func (d *Daemon) postDelta(ctx context.Context, changed []*status.StatusCheck) {
+	for _, c := range changed {
+		d.logger.WithFields(logrus.Fields{
+			"check":   c.Name,
+			"passing": c.Passing,
+			"error":   c.Error,
+		}).Info("diagnostic check changed state")
+	}
+
+	if d.cfg.Cloudzero.DisableTelemetry {
+		return
+	}
+
+	delta := d.Status()
+	delta.Checks = changed
+	if err := d.post(ctx, d.client, d.cfg, status.NewAccessor(delta)); err != nil {
+		d.logger.WithError(err).Warn("failed to post status change")
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,227 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+// staticRegistry is a catalog.Registry backed by a map.
+type staticRegistry map[string]diagnostic.Provider
+
+func (r staticRegistry) Has(id string) bool { _, ok := r[id]; return ok }
+
+func (r staticRegistry) Get(ids ...string) []diagnostic.Provider {
+	var providers []diagnostic.Provider
+	for _, id := range ids {
+		if p, ok := r[id]; ok {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+func (r staticRegistry) List() []string {
+	ids := make([]string, 0, len(r))
+	for id := range r {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// recordingPoster records the reports posted by the daemon.
+type recordingPoster struct {
+	mu      sync.Mutex
+	reports []*status.ClusterStatus
+}
+
+func (p *recordingPoster) post(_ context.Context, _ *http.Client, _ *config.Settings, accessor status.Accessor) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	accessor.ReadFromReport(func(cs *status.ClusterStatus) {
+		p.reports = append(p.reports, cs)
+	})
+	return nil
+}
+
+func (p *recordingPoster) posted() []*status.ClusterStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*status.ClusterStatus(nil), p.reports...)
+}
+
+func daemonSettings(checks ...config.ScheduledCheck) *config.Settings {
+	return &config.Settings{
+		Deployment: config.Deployment{
+			AccountID:   "test-account",
+			Region:      "test-region",
+			ClusterName: "test-cluster",
+		},
+		Daemon: config.Daemon{Interval: time.Minute, Checks: checks},
+	}
+}
+
+// toggleProvider reports a check whose result is controlled by the test.
+func toggleProvider(name string, passing *atomic.Bool) diagnostic.Provider {
+	return &mockProvider{
+		Test: func(_ context.Context, _ *http.Client, recorder status.Accessor) error {
+			check := &status.StatusCheck{Name: name, Passing: passing.Load()}
+			if !check.Passing {
+				check.Error = "unreachable"
+			}
+			recorder.AddCheck(check)
+			return nil
+		},
+	}
+}
+
+func TestDaemon_PostsOnlyTransitions(t *testing.T) {
+	var apiKey, kms atomic.Bool
+	apiKey.Store(true)
+	kms.Store(true)
+
+	cfg := daemonSettings(
+		config.ScheduledCheck{Name: config.DiagnosticAPIKey, Type: config.CheckTypeRequired},
+		config.ScheduledCheck{Name: config.DiagnosticKMS, Type: config.CheckTypeOptional},
+	)
+	d := NewDaemon(cfg, staticRegistry{
+		config.DiagnosticAPIKey: toggleProvider(config.DiagnosticAPIKey, &apiKey),
+		config.DiagnosticKMS:    toggleProvider(config.DiagnosticKMS, &kms),
+	})
+	poster := &recordingPoster{}
+	d.post = poster.post
+
+	ctx := context.Background()
+	apiKeySchedule, kmsSchedule := &d.schedules[0], &d.schedules[1]
+
+	// the first result of every check is posted
+	d.runCheck(ctx, apiKeySchedule)
+	d.runCheck(ctx, kmsSchedule)
+	require.Len(t, poster.posted(), 2)
+
+	// unchanged results are not
+	d.runCheck(ctx, apiKeySchedule)
+	d.runCheck(ctx, kmsSchedule)
+	require.Len(t, poster.posted(), 2)
+	assert.False(t, d.ShouldFail())
+
+	kms.Store(false)
+	d.runCheck(ctx, apiKeySchedule)
+	d.runCheck(ctx, kmsSchedule)
+	reports := poster.posted()
+	require.Len(t, reports, 3)
+	delta := reports[2]
+	assert.Equal(t, "test-cluster", delta.Name)
+	require.Len(t, delta.Checks, 1)
+	assert.Equal(t, config.DiagnosticKMS, delta.Checks[0].Name)
+	assert.False(t, delta.Checks[0].Passing)
+	assert.False(t, d.ShouldFail(), "optional checks do not fail the daemon")
+
+	apiKey.Store(false)
+	d.runCheck(ctx, apiKeySchedule)
+	require.Len(t, poster.posted(), 4)
+	assert.True(t, d.ShouldFail())
+
+	// the latest status holds one entry per check
+	latest := d.Status()
+	require.Len(t, latest.Checks, 2)
+	for _, c := range latest.Checks {
+		assert.False(t, c.Passing, c.Name)
+	}
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(checkPassing.WithLabelValues(config.DiagnosticKMS)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(checkPassing.WithLabelValues(config.DiagnosticAPIKey)))
+	assert.NotZero(t, testutil.ToFloat64(checkLastRun.WithLabelValues(config.DiagnosticAPIKey)))
+}
+
+func TestDaemon_ProviderError(t *testing.T) {
+	cfg := daemonSettings(config.ScheduledCheck{Name: config.DiagnosticK8sVersion})
+	d := NewDaemon(cfg, staticRegistry{
+		config.DiagnosticK8sVersion: &mockProvider{
+			Test: func(context.Context, *http.Client, status.Accessor) error {
+				return errors.New("connection refused")
+			},
+		},
+	})
+	cfg.Cloudzero.DisableTelemetry = true
+
+	d.runCheck(context.Background(), &d.schedules[0])
+
+	latest := d.Status()
+	require.Len(t, latest.Checks, 1)
+	assert.Equal(t, config.DiagnosticK8sVersion, latest.Checks[0].Name)
+	assert.False(t, latest.Checks[0].Passing)
+	assert.Contains(t, latest.Checks[0].Error, "connection refused")
+}
+
+func TestDaemon_Run(t *testing.T) {
+	var runs atomic.Int32
+	cfg := daemonSettings(config.ScheduledCheck{
+		Name:     config.DiagnosticK8sVersion,
+		Interval: 10 * time.Millisecond,
+	})
+	d := NewDaemon(cfg, staticRegistry{
+		config.DiagnosticK8sVersion: &mockProvider{
+			Test: func(_ context.Context, _ *http.Client, recorder status.Accessor) error {
+				runs.Add(1)
+				recorder.WriteToReport(func(cs *status.ClusterStatus) {
+					cs.K8SVersion = "1.31"
+				})
+				recorder.AddCheck(&status.StatusCheck{Name: config.DiagnosticK8sVersion, Passing: true})
+				return nil
+			},
+		},
+	})
+	poster := &recordingPoster{}
+	d.post = poster.post
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		report, err := d.Run(ctx)
+		assert.NoError(t, err)
+		report.ReadFromReport(func(cs *status.ClusterStatus) {
+			assert.Equal(t, "1.31", cs.K8SVersion)
+		})
+	}()
+
+	require.Eventually(t, func() bool { return runs.Load() >= 3 }, 5*time.Second, 5*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var served status.ClusterStatus
+	require.NoError(t, protojson.Unmarshal(rec.Body.Bytes(), &served))
+	assert.Equal(t, "test-cluster", served.Name)
+	assert.Equal(t, "1.31", served.K8SVersion)
+	require.Len(t, served.Checks, 1)
+	assert.True(t, served.Checks[0].Passing)
+
+	cancel()
+	<-done
+	assert.Len(t, poster.posted(), 1, "a check which keeps passing is posted once")
+}
+
+func TestDaemon_NoChecks(t *testing.T) {
+	_, err := NewDaemon(daemonSettings(), staticRegistry{}).Run(context.Background())
+	assert.Error(t, err)
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -16,6 +17,7 @@ import (
 	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/catalog"
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
 	logging "github.com/cloudzero/cloudzero-agent/app/logging/validator"
 	"github.com/cloudzero/cloudzero-agent/app/types/status"
 )
@@ -45,13 +47,27 @@ type runner struct {
 	requiredFailures bool
 }
 
+// NewClient returns the client of the checks and of the telemetry, going
+// through the egress proxy of the configuration. When the egress files cannot
+// be loaded, the default client is returned instead, so the checks still run
+// and the egress check reports the error.
+func NewClient(c *config.Settings) *http.Client {
+	client, err := transport.NewClient(c.Egress, 0)
+	if err != nil {
+		logging.NewLogger().WithField(logging.OpField, "runner").WithError(err).
+			Error("failed to configure the egress transport, using the default transport")
+		return http.DefaultClient
+	}
+	return client
+}
+
 func NewRunner(c *config.Settings, reg catalog.Registry, stage string) Engine {
 	r := &runner{
 		cfg:        c,
 		stage:      stage,
 		reg:        reg,
 		logger:     logging.NewLogger().WithField(logging.OpField, "runner"),
-		client:     http.DefaultClient,
+		client:     NewClient(c),
 		checkTypes: make(map[string]config.CheckType),
 	}
 
@@ -118,7 +134,7 @@ func (r *runner) Run(ctx context.Context) (status.Accessor, error) {
 
 	// Pre steps sequentially
 	for _, pv := range r.pre {
-		if err := pv.Check(ctx, r.client, recorder); err != nil {
+		if err := r.check(ctx, pv, recorder); err != nil {
 			return recorder, err
 		}
 	}
@@ -132,7 +148,7 @@ func (r *runner) Run(ctx context.Context) (status.Accessor, error) {
 		wg.Add(1)
 		go func(wgi *sync.WaitGroup, p diagnostic.Provider, i int) {
 			defer wgi.Done()
-			if err := p.Check(ctx, r.client, recorder); err != nil {
+			if err := r.check(ctx, p, recorder); err != nil {
 				errHistory[i] = err
 			}
 		}(&wg, p, i)
@@ -146,7 +162,7 @@ func (r *runner) Run(ctx context.Context) (status.Accessor, error) {
 
 	// Post steps sequentially
 	for _, ps := range r.post {
-		if err := ps.Check(ctx, r.client, recorder); err != nil {
+		if err := r.check(ctx, ps, recorder); err != nil {
 			return recorder, err
 		}
 	}
@@ -157,7 +173,7 @@ func (r *runner) Run(ctx context.Context) (status.Accessor, error) {
 	// Track if any required checks failed for exit code determination
 	recorder.ReadFromReport(func(cs *status.ClusterStatus) {
 		for _, c := range cs.Checks {
-			if !c.Passing && r.checkTypes[c.Name] == config.CheckTypeRequired {
+			if diagnostic.Failed(c) && r.checkTypes[c.Name] == config.CheckTypeRequired {
 				r.requiredFailures = true
 			}
 		}
@@ -166,6 +182,62 @@ func (r *runner) Run(ctx context.Context) (status.Accessor, error) {
 	return recorder, nil
 }
 
+// check runs a provider and annotates the checks it added with their type,
+// how long the provider took and, for failing checks, a remediation.
+func (r *runner) check(ctx context.Context, p diagnostic.Provider, recorder status.Accessor) error {
+	added := &addedChecks{Accessor: recorder}
+	start := time.Now()
+	err := p.Check(ctx, r.client, added)
+	elapsed := time.Since(start)
+
+	recorder.WriteToReport(func(*status.ClusterStatus) {
+		for _, c := range added.checks {
+			annotate(c, r.checkTypes[c.Name], elapsed)
+		}
+	})
+	return err
+}
+
+// addedChecks remembers the checks a provider adds to the report, whether
+// with AddCheck or by appending them in WriteToReport.
+type addedChecks struct {
+	status.Accessor
+
+	mu     sync.Mutex
+	checks []*status.StatusCheck
+}
+
+func (a *addedChecks) AddCheck(checks ...*status.StatusCheck) {
+	a.remember(checks)
+	a.Accessor.AddCheck(checks...)
+}
+
+func (a *addedChecks) WriteToReport(fn func(*status.ClusterStatus)) {
+	a.Accessor.WriteToReport(func(cs *status.ClusterStatus) {
+		n := len(cs.Checks)
+		fn(cs)
+		if len(cs.Checks) > n {
+			a.remember(cs.Checks[n:])
+		}
+	})
+}
+
+func (a *addedChecks) remember(checks []*status.StatusCheck) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checks = append(a.checks, checks...)
+}
+
+// annotate fills in the fields of a check which are known to the runner rather
+// than to the provider which ran it.
+func annotate(c *status.StatusCheck, checkType config.CheckType, elapsed time.Duration) {
+	if c.Type == "" {
+		c.Type = string(checkType)
+	}
+	c.DurationMs = elapsed.Milliseconds()
+	diagnostic.Remediate(c)
+}
+
 // this function returns a function which will set an error code if necessary
 func processFailures(ctx context.Context, recorder status.Accessor, r *runner) func() {
 	handleFailure := func() {}
@@ -174,7 +246,7 @@ func processFailures(ctx context.Context, recorder status.Accessor, r *runner) f
 			return
 		}
 		for _, c := range cs.Checks {
-			if !c.Passing {
+			if diagnostic.Failed(c) {
 				if chkr := r.reg.Get(config.DiagnosticInternalInitFailed); len(chkr) > 0 {
 					// set to read handler since we already hold the lock
 					handleFailure = func() {
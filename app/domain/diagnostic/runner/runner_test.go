@@ -8,6 +8,7 @@ import (
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
@@ -15,6 +16,7 @@ import (
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/kms"
 	"github.com/cloudzero/cloudzero-agent/app/types/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -436,3 +438,55 @@ func TestRunner_ShouldFailIntegration(t *testing.T) {
 		})
 	}
 }
+
+func TestRunner_AnnotatesChecks(t *testing.T) {
+	originalNewProvider := kms.NewProvider
+	kms.NewProvider = NewMockKMSProvider
+	defer func() { kms.NewProvider = originalNewProvider }()
+
+	cfg := &config.Settings{}
+	r := NewRunner(cfg, catalog.NewCatalog(context.Background(), cfg), config.ContextStageStart)
+	engine := r.(*runner)
+	engine.pre = nil
+	engine.plan = nil
+	engine.post = nil
+	engine.checkTypes[config.DiagnosticKMS] = config.CheckTypeRequired
+	engine.checkTypes[config.DiagnosticScrapeConfig] = config.CheckTypeOptional
+
+	engine.AddStep(
+		&mockProvider{Test: func(_ context.Context, _ *http.Client, recorder status.Accessor) error {
+			time.Sleep(5 * time.Millisecond)
+			recorder.AddCheck(&status.StatusCheck{Name: config.DiagnosticKMS, Error: "unreachable"})
+			return nil
+		}},
+		&mockProvider{Test: func(_ context.Context, _ *http.Client, recorder status.Accessor) error {
+			recorder.WriteToReport(func(cs *status.ClusterStatus) {
+				cs.Checks = append(cs.Checks, &status.StatusCheck{Name: config.DiagnosticScrapeConfig, Passing: true})
+			})
+			return nil
+		}},
+	)
+
+	report, err := r.Run(context.Background())
+	require.NoError(t, err)
+
+	checks := map[string]*status.StatusCheck{}
+	report.ReadFromReport(func(cs *status.ClusterStatus) {
+		for _, c := range cs.Checks {
+			checks[c.Name] = c
+		}
+	})
+	require.Len(t, checks, 2)
+
+	kmsCheck := checks[config.DiagnosticKMS]
+	assert.Equal(t, string(config.CheckTypeRequired), kmsCheck.Type)
+	assert.GreaterOrEqual(t, kmsCheck.DurationMs, int64(5))
+	assert.Equal(t, diagnostic.Remediation(config.DiagnosticKMS), kmsCheck.Remediation)
+	assert.NotEmpty(t, kmsCheck.Remediation)
+	assert.Equal(t, "kms-check-failed", kmsCheck.RemediationId)
+	assert.Equal(t, "error", kmsCheck.Severity)
+
+	scrapeCheck := checks[config.DiagnosticScrapeConfig]
+	assert.Equal(t, string(config.CheckTypeOptional), scrapeCheck.Type)
+	assert.Empty(t, scrapeCheck.Remediation, "passing checks need no remediation")
+}
@@ -6,11 +6,13 @@ package k8s
 //go:generate mockgen -destination=mocks/certificate_client_mock.go -package=mocks . CertificateClient
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -29,6 +31,10 @@ type CertificateClient interface {
 	PatchSecret(ctx context.Context, namespace, secretName string, patchData map[string]interface{}) error
 	// PatchWebhookConfiguration applies patches to a webhook configuration
 	PatchWebhookConfiguration(ctx context.Context, webhookName string, patches []certificate.WebhookPatch) error
+	// GetWebhookAnnotations retrieves the annotations of a webhook configuration
+	GetWebhookAnnotations(ctx context.Context, webhookName string) (map[string]string, error)
+	// GetConfigMap retrieves the data of a ConfigMap in the specified namespace
+	GetConfigMap(ctx context.Context, namespace, name string) (map[string]string, error)
 }
 
 // certificateClient implements CertificateClient using the existing k8s client
@@ -77,7 +83,10 @@ func (c *certificateClient) GetTLSSecret(ctx context.Context, namespace, secretN
 	return secretMap, nil
 }
 
-// GetWebhookCABundle retrieves the CA bundle from a webhook configuration
+// GetWebhookCABundle retrieves the CA bundle from a webhook configuration. When
+// a MutatingWebhookConfiguration of the same name exists (the policy mutate
+// mode) and its CA bundle differs, an empty bundle is returned so that callers
+// patch both configurations.
 func (c *certificateClient) GetWebhookCABundle(ctx context.Context, webhookName string) (string, error) {
 	webhook, err := c.clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, webhookName, metav1.GetOptions{})
 	if err != nil {
@@ -93,6 +102,15 @@ func (c *certificateClient) GetWebhookCABundle(ctx context.Context, webhookName
 		return "", nil
 	}
 
+	mutating, err := c.clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, webhookName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return "", fmt.Errorf("failed to get mutating webhook configuration %s: %w", webhookName, err)
+	case len(mutating.Webhooks) == 0 || !bytes.Equal(mutating.Webhooks[0].ClientConfig.CABundle, caBundle):
+		return "", nil
+	}
+
 	return base64.StdEncoding.EncodeToString(caBundle), nil
 }
 
@@ -117,7 +135,9 @@ func (c *certificateClient) PatchSecret(ctx context.Context, namespace, secretNa
 	return nil
 }
 
-// PatchWebhookConfiguration patches a webhook configuration with the provided patches
+// PatchWebhookConfiguration patches a webhook configuration with the provided
+// patches. The same patches are applied to a MutatingWebhookConfiguration of
+// the same name if one exists.
 func (c *certificateClient) PatchWebhookConfiguration(ctx context.Context, webhookName string, patches []certificate.WebhookPatch) error {
 	patchBytes, err := json.Marshal(patches)
 	if err != nil {
@@ -135,5 +155,36 @@ func (c *certificateClient) PatchWebhookConfiguration(ctx context.Context, webho
 		return fmt.Errorf("failed to patch webhook configuration: %w", err)
 	}
 
+	_, err = c.clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Patch(
+		ctx,
+		webhookName,
+		types.JSONPatchType,
+		patchBytes,
+		metav1.PatchOptions{},
+	)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to patch mutating webhook configuration: %w", err)
+	}
+
 	return nil
 }
+
+// GetWebhookAnnotations retrieves the annotations of a webhook configuration
+func (c *certificateClient) GetWebhookAnnotations(ctx context.Context, webhookName string) (map[string]string, error) {
+	webhook, err := c.clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, webhookName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook configuration %s: %w", webhookName, err)
+	}
+
+	return webhook.Annotations, nil
+}
+
+// GetConfigMap retrieves the data of a ConfigMap
+func (c *certificateClient) GetConfigMap(ctx context.Context, namespace, name string) (map[string]string, error) {
+	configMap, err := c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get configmap %s in namespace %s: %w", name, namespace, err)
+	}
+
+	return configMap.Data, nil
+}
@@ -479,6 +479,58 @@ func TestCertificateClient_PatchWebhookConfiguration(t *testing.T) {
 	}
 }
 
+func TestCertificateClient_MutatingWebhookConfiguration(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewClientset(
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-webhook"},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				{Name: "test-webhook", ClientConfig: admissionregistrationv1.WebhookClientConfig{CABundle: []byte("old-ca-bundle")}},
+			},
+		},
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-webhook"},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{
+				{Name: "test-webhook", ClientConfig: admissionregistrationv1.WebhookClientConfig{}},
+			},
+		},
+	)
+	client := k8s.NewCertificateClientWithConfig(&rest.Config{}, clientset)
+
+	// The mutating configuration has no CA bundle yet, so both need patching.
+	bundle, err := client.GetWebhookCABundle(ctx, "test-webhook")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bundle != "" {
+		t.Errorf("expected an empty CA bundle while the configurations differ, got %q", bundle)
+	}
+
+	newBundle := base64.StdEncoding.EncodeToString([]byte("new-ca-bundle"))
+	err = client.PatchWebhookConfiguration(ctx, "test-webhook", []certificate.WebhookPatch{
+		{Op: "add", Path: "/webhooks/0/clientConfig/caBundle", Value: newBundle},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mutating, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, "test-webhook", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get mutating webhook: %v", err)
+	}
+	if string(mutating.Webhooks[0].ClientConfig.CABundle) != "new-ca-bundle" {
+		t.Errorf("expected the mutating webhook CA bundle to be patched, got %q", mutating.Webhooks[0].ClientConfig.CABundle)
+	}
+
+	bundle, err = client.GetWebhookCABundle(ctx, "test-webhook")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bundle != newBundle {
+		t.Errorf("expected CA bundle %q, got %q", newBundle, bundle)
+	}
+}
+
 func TestCertificateClient_PatchDataMarshalError(t *testing.T) {
 	clientset := fake.NewClientset()
 	client := k8s.NewCertificateClientWithConfig(&rest.Config{}, clientset)
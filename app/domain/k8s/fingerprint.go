@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// fingerprintNamespace is the namespace whose UID identifies a cluster. It
+// exists in every cluster, and is created with it.
+const fingerprintNamespace = "kube-system"
+
+// ClusterFingerprint returns a stable fingerprint of the cluster, derived from
+// the UID of the kube-system namespace. It does not change for the life of
+// the cluster, and differs between clusters even when they share a name.
+//
+// The providerID of the nodes is deliberately not part of the fingerprint:
+// it identifies a node, and nodes come and go.
+func ClusterFingerprint(ctx context.Context, client kubernetes.Interface) (string, error) {
+	ns, err := client.CoreV1().Namespaces().Get(ctx, fingerprintNamespace, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get the %s namespace: %w", fingerprintNamespace, err)
+	}
+	if ns.UID == "" {
+		return "", fmt.Errorf("the %s namespace has no UID", fingerprintNamespace)
+	}
+
+	sum := sha256.Sum256([]byte(ns.UID))
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// GetClusterFingerprint returns the fingerprint of the cluster, using a client
+// created from the environment.
+func GetClusterFingerprint(ctx context.Context) (string, error) {
+	client, err := GetClient()
+	if err != nil {
+		return "", err
+	}
+	return ClusterFingerprint(ctx, client)
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
+)
+
+func kubeSystem(uid string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", UID: types.UID(uid)}}
+}
+
+func TestClusterFingerprint(t *testing.T) {
+	ctx := context.Background()
+
+	first, err := k8s.ClusterFingerprint(ctx, fake.NewSimpleClientset(kubeSystem("5f1b2c3d-0000-4000-8000-000000000001")))
+	require.NoError(t, err)
+	assert.Len(t, first, 32)
+
+	again, err := k8s.ClusterFingerprint(ctx, fake.NewSimpleClientset(kubeSystem("5f1b2c3d-0000-4000-8000-000000000001")))
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+
+	other, err := k8s.ClusterFingerprint(ctx, fake.NewSimpleClientset(kubeSystem("5f1b2c3d-0000-4000-8000-000000000002")))
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+
+	_, err = k8s.ClusterFingerprint(ctx, fake.NewSimpleClientset())
+	assert.ErrorContains(t, err, "kube-system")
+}
@@ -41,6 +41,21 @@ func (m *MockCertificateClient) EXPECT() *MockCertificateClientMockRecorder {
 	return m.recorder
 }
 
+// GetConfigMap mocks base method.
+func (m *MockCertificateClient) GetConfigMap(ctx context.Context, namespace, name string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigMap", ctx, namespace, name)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigMap indicates an expected call of GetConfigMap.
+func (mr *MockCertificateClientMockRecorder) GetConfigMap(ctx, namespace, name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMap", reflect.TypeOf((*MockCertificateClient)(nil).GetConfigMap), ctx, namespace, name)
+}
+
 // GetTLSSecret mocks base method.
 func (m *MockCertificateClient) GetTLSSecret(ctx context.Context, namespace, secretName string) (map[string]any, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +71,21 @@ func (mr *MockCertificateClientMockRecorder) GetTLSSecret(ctx, namespace, secret
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTLSSecret", reflect.TypeOf((*MockCertificateClient)(nil).GetTLSSecret), ctx, namespace, secretName)
 }
 
+// GetWebhookAnnotations mocks base method.
+func (m *MockCertificateClient) GetWebhookAnnotations(ctx context.Context, webhookName string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookAnnotations", ctx, webhookName)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookAnnotations indicates an expected call of GetWebhookAnnotations.
+func (mr *MockCertificateClientMockRecorder) GetWebhookAnnotations(ctx, webhookName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookAnnotations", reflect.TypeOf((*MockCertificateClient)(nil).GetWebhookAnnotations), ctx, webhookName)
+}
+
 // GetWebhookCABundle mocks base method.
 func (m *MockCertificateClient) GetWebhookCABundle(ctx context.Context, webhookName string) (string, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,316 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package leader runs singleton components on one replica at a time. The
+// replicas campaign for a coordination.k8s.io/v1 Lease, and only the holder of
+// the lease runs the wrapped component.
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewDeadline = 10 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+// ErrInvalidConfig is returned when the configuration of an elector is invalid.
+var ErrInvalidConfig = errors.New("invalid leader election configuration")
+
+var (
+	isLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "leader_election_is_leader",
+		Help: "Whether this replica holds the lease, and runs the singleton components",
+	}, []string{"lease"})
+	leaderIdentity = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "leader_election_leader",
+		Help: "Identity of the replica holding the lease, as observed by this replica",
+	}, []string{"lease", "identity"})
+	leaderTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "leader_election_transitions_total",
+		Help: "Number of times this replica acquired or lost the lease",
+	}, []string{"lease", "transition"})
+)
+
+// Config configures the lease an elector campaigns for.
+type Config struct {
+	// LeaseName and Namespace identify the lease. Replicas running the same
+	// component use the same lease.
+	LeaseName string
+	Namespace string
+	// Identity identifies this replica as the holder of the lease. It
+	// defaults to the hostname, which is the pod name.
+	Identity string
+
+	// LeaseDuration is how long the other replicas wait before taking over a
+	// lease which is not renewed. RenewDeadline is how long the leader keeps
+	// retrying to renew it before giving up, and RetryPeriod how often the
+	// replicas try to acquire or renew it.
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+func (c *Config) validate() error {
+	if c.LeaseName == "" || c.Namespace == "" {
+		return fmt.Errorf("%w: the lease name and namespace are required", ErrInvalidConfig)
+	}
+	if c.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("%w: failed to get the hostname: %w", ErrInvalidConfig, err)
+		}
+		c.Identity = hostname
+	}
+	if c.LeaseDuration <= 0 {
+		c.LeaseDuration = DefaultLeaseDuration
+	}
+	if c.RenewDeadline <= 0 {
+		c.RenewDeadline = DefaultRenewDeadline
+	}
+	if c.RetryPeriod <= 0 {
+		c.RetryPeriod = DefaultRetryPeriod
+	}
+	return nil
+}
+
+// Elector runs a component only while this replica holds the lease. It
+// implements types.Runnable: Run starts campaigning, and Shutdown stops the
+// component and releases the lease, so another replica takes over without
+// waiting for it to expire.
+//
+// The component is run again each time the lease is acquired, so it must
+// support being run after it was shut down, as the housekeeper, the pusher
+// and the informers do. Components which cannot be run again are wrapped
+// with WithOnLost, to exit instead. Shutdown may be called before Run, when
+// the lease is lost while the component starts.
+type Elector struct {
+	cfg      Config
+	client   kubernetes.Interface
+	runnable types.Runnable
+	onLost   func()
+
+	mu          sync.Mutex
+	running     bool
+	originalCtx context.Context
+	cancel      context.CancelFunc
+	done        chan struct{}
+
+	leading    atomic.Bool
+	runnableMu sync.Mutex
+}
+
+// Option configures an Elector.
+type Option func(*Elector)
+
+// WithOnLost sets a function called after the component was shut down
+// because the lease was lost, such as when the API server could not be
+// reached before the renew deadline. It is not called on Shutdown. It lets
+// components which cannot be run again exit the process instead.
+func WithOnLost(fn func()) Option {
+	return func(e *Elector) {
+		e.onLost = fn
+	}
+}
+
+// New creates an elector running the component while this replica holds the
+// lease.
+func New(ctx context.Context, client kubernetes.Interface, cfg Config, runnable types.Runnable, opts ...Option) (*Elector, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	e := &Elector{
+		cfg:         cfg,
+		client:      client,
+		runnable:    runnable,
+		originalCtx: ctx,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	// Fail on invalid durations now rather than when campaigning.
+	if _, err := e.newLeaderElector(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return e, nil
+}
+
+// Run starts campaigning for the lease. It returns immediately.
+func (e *Elector) Run() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.running {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(e.originalCtx)
+	e.cancel = cancel
+	e.done = make(chan struct{})
+	go e.campaign(ctx, e.done)
+
+	e.running = true
+	return nil
+}
+
+// IsRunning returns true while the elector campaigns for the lease, whether
+// or not it holds it.
+func (e *Elector) IsRunning() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.running
+}
+
+// IsLeader returns true while this replica holds the lease.
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Shutdown stops the component if this replica leads, then releases the
+// lease. The component is stopped first so that it never runs on two
+// replicas at once.
+func (e *Elector) Shutdown() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.running {
+		return nil
+	}
+
+	err := e.stop(e.originalCtx, false)
+	e.cancel()
+	<-e.done
+	e.running = false
+	return err
+}
+
+func (e *Elector) campaign(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	for {
+		le, err := e.newLeaderElector(ctx)
+		if err != nil {
+			// The configuration was checked by New.
+			log.Ctx(ctx).Err(err).Str("lease", e.cfg.LeaseName).Msg("failed to create the leader elector")
+			return
+		}
+		// Run returns when the lease is lost or the context is canceled.
+		le.Run(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.cfg.RetryPeriod):
+		}
+	}
+}
+
+func (e *Elector) newLeaderElector(ctx context.Context) (*leaderelection.LeaderElector, error) {
+	return leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{Name: e.cfg.LeaseName, Namespace: e.cfg.Namespace},
+			Client:    e.client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: e.cfg.Identity,
+			},
+		},
+		Name:            e.cfg.LeaseName,
+		LeaseDuration:   e.cfg.LeaseDuration,
+		RenewDeadline:   e.cfg.RenewDeadline,
+		RetryPeriod:     e.cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) { e.start(leaderCtx) },
+			OnStoppedLeading: func() { e.stopped(ctx) },
+			OnNewLeader:      func(identity string) { e.observe(ctx, identity) },
+		},
+	})
+}
+
+// start runs the component after the lease was acquired.
+func (e *Elector) start(ctx context.Context) {
+	e.runnableMu.Lock()
+	if ctx.Err() != nil {
+		e.runnableMu.Unlock()
+		return
+	}
+	e.leading.Store(true)
+	e.runnableMu.Unlock()
+
+	isLeader.WithLabelValues(e.cfg.LeaseName).Set(1)
+	leaderTransitions.WithLabelValues(e.cfg.LeaseName, "acquired").Inc()
+	log.Ctx(ctx).Info().Str("lease", e.cfg.LeaseName).Str("identity", e.cfg.Identity).Msg("acquired the lease, starting")
+
+	// Run may block until the component is shut down, so it is called without
+	// the lock, and the lease may be lost meanwhile.
+	if err := e.runnable.Run(); err != nil {
+		log.Ctx(ctx).Err(err).Str("lease", e.cfg.LeaseName).Msg("failed to run the leader component")
+	}
+
+	// When the lease was lost before the component was running, stop shut it
+	// down before Run started it, so shut it down again. When the lease was
+	// acquired again meanwhile, the component runs for the new term.
+	e.runnableMu.Lock()
+	defer e.runnableMu.Unlock()
+	if !e.leading.Load() && e.runnable.IsRunning() {
+		if err := e.runnable.Shutdown(); err != nil {
+			log.Ctx(ctx).Err(err).Str("lease", e.cfg.LeaseName).Msg("failed to shut down the leader component")
+		}
+	}
+}
+
+// stopped is called when the elector stops leading, or stops campaigning
+// without having led.
+func (e *Elector) stopped(ctx context.Context) {
+	if !e.leading.Load() {
+		return
+	}
+	lost := ctx.Err() == nil
+	if err := e.stop(ctx, lost); err != nil {
+		log.Ctx(ctx).Err(err).Str("lease", e.cfg.LeaseName).Msg("failed to shut down the leader component")
+	}
+	if lost && e.onLost != nil {
+		e.onLost()
+	}
+}
+
+// stop shuts down the component if it runs.
+func (e *Elector) stop(ctx context.Context, lost bool) error {
+	e.runnableMu.Lock()
+	defer e.runnableMu.Unlock()
+	if !e.leading.Swap(false) {
+		return nil
+	}
+
+	isLeader.WithLabelValues(e.cfg.LeaseName).Set(0)
+	if lost {
+		leaderTransitions.WithLabelValues(e.cfg.LeaseName, "lost").Inc()
+		log.Ctx(ctx).Warn().Str("lease", e.cfg.LeaseName).Str("identity", e.cfg.Identity).Msg("lost the lease, stopping")
+	} else {
+		leaderTransitions.WithLabelValues(e.cfg.LeaseName, "released").Inc()
+		log.Ctx(ctx).Info().Str("lease", e.cfg.LeaseName).Str("identity", e.cfg.Identity).Msg("releasing the lease, stopping")
+	}
+	return e.runnable.Shutdown()
+}
+
+func (e *Elector) observe(ctx context.Context, identity string) {
+	leaderIdentity.DeletePartialMatch(prometheus.Labels{"lease": e.cfg.LeaseName})
+	leaderIdentity.WithLabelValues(e.cfg.LeaseName, identity).Set(1)
+	log.Ctx(ctx).Info().Str("lease", e.cfg.LeaseName).Str("leader", identity).Msg("observed a new leader")
+}
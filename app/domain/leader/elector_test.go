@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package leader_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/leader"
+)
+
+// component is a restartable types.Runnable counting its runs.
+type component struct {
+	mu      sync.Mutex
+	running bool
+	runs    int
+
+	// starting, when set, is received from before each run starts.
+	starting chan struct{}
+}
+
+func (c *component) Run() error {
+	if c.starting != nil {
+		<-c.starting
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = true
+	c.runs++
+	return nil
+}
+
+func (c *component) IsRunning() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.running
+}
+
+func (c *component) Shutdown() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.running = false
+	return nil
+}
+
+func config(identity string) leader.Config {
+	return leader.Config{
+		LeaseName:     "cloudzero-test",
+		Namespace:     "cloudzero",
+		Identity:      identity,
+		LeaseDuration: 2 * time.Second,
+		RenewDeadline: time.Second,
+		RetryPeriod:   50 * time.Millisecond,
+	}
+}
+
+func newElector(t *testing.T, client kubernetes.Interface, identity string, c *component, opts ...leader.Option) *leader.Elector {
+	t.Helper()
+	e, err := leader.New(context.Background(), client, config(identity), c, opts...)
+	require.NoError(t, err)
+	require.NoError(t, e.Run())
+	t.Cleanup(func() { _ = e.Shutdown() })
+	return e
+}
+
+func holder(t *testing.T, client kubernetes.Interface) string {
+	t.Helper()
+	lease, err := client.CoordinationV1().Leases("cloudzero").Get(context.Background(), "cloudzero-test", metav1.GetOptions{})
+	if err != nil || lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+func TestElector_Handover(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	firstComponent := &component{}
+	first := newElector(t, client, "replica-0", firstComponent)
+	require.Eventually(t, first.IsLeader, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, firstComponent.IsRunning, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "replica-0", holder(t, client))
+
+	secondComponent := &component{}
+	second := newElector(t, client, "replica-1", secondComponent)
+	assert.Never(t, second.IsLeader, 300*time.Millisecond, 10*time.Millisecond, "only one replica leads")
+	assert.False(t, secondComponent.IsRunning())
+
+	// Shutting down releases the lease, so the other replica takes over
+	// without waiting for it to expire.
+	require.NoError(t, first.Shutdown())
+	assert.False(t, firstComponent.IsRunning())
+	assert.False(t, first.IsRunning())
+	require.Eventually(t, second.IsLeader, time.Second, 10*time.Millisecond)
+	require.Eventually(t, secondComponent.IsRunning, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "replica-1", holder(t, client))
+}
+
+func TestElector_Lost(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var unavailable atomic.Bool
+	client.PrependReactor("*", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if unavailable.Load() {
+			return true, nil, errors.New("connection refused")
+		}
+		return false, nil, nil
+	})
+
+	lost := make(chan struct{}, 1)
+	c := &component{}
+	e := newElector(t, client, "replica-0", c, leader.WithOnLost(func() { lost <- struct{}{} }))
+	require.Eventually(t, e.IsLeader, 5*time.Second, 10*time.Millisecond)
+
+	// The API server becomes unreachable, so the lease cannot be renewed.
+	unavailable.Store(true)
+
+	select {
+	case <-lost:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lease was not lost")
+	}
+	assert.False(t, e.IsLeader())
+	assert.False(t, c.IsRunning())
+	assert.True(t, e.IsRunning(), "the replica campaigns again")
+	assert.Equal(t, 1, c.runs)
+}
+
+func TestElector_LostWhileStarting(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var unavailable atomic.Bool
+	client.PrependReactor("*", "leases", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if unavailable.Load() {
+			return true, nil, errors.New("connection refused")
+		}
+		return false, nil, nil
+	})
+
+	lost := make(chan struct{}, 1)
+	c := &component{starting: make(chan struct{})}
+	e := newElector(t, client, "replica-0", c, leader.WithOnLost(func() { lost <- struct{}{} }))
+	require.Eventually(t, e.IsLeader, 5*time.Second, 10*time.Millisecond)
+
+	// The lease is lost before the component is running.
+	unavailable.Store(true)
+	select {
+	case <-lost:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lease was not lost")
+	}
+
+	// It must not be left running on a replica which does not lead.
+	close(c.starting)
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.runs == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return !c.IsRunning() }, time.Second, 10*time.Millisecond)
+	assert.False(t, e.IsLeader())
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	_, err := leader.New(context.Background(), client, leader.Config{Namespace: "cloudzero"}, &component{})
+	assert.ErrorIs(t, err, leader.ErrInvalidConfig)
+
+	cfg := config("replica-0")
+	cfg.RenewDeadline = cfg.LeaseDuration
+	_, err = leader.New(context.Background(), client, cfg, &component{})
+	assert.ErrorIs(t, err, leader.ErrInvalidConfig)
+}
@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
@@ -119,6 +120,12 @@ type MetricCollector struct {
 	// clock provides time abstraction for testing and consistent timestamping.
 	clock types.TimeProvider
 
+	// guard checks the timestamps of the samples received against the clock.
+	guard *TimestampGuard
+
+	// coverage records which metrics were received for each node and scrape job.
+	coverage *CoverageTracker
+
 	// cancelFunc enables graceful shutdown of background processing goroutines.
 	cancelFunc context.CancelFunc
 
@@ -149,6 +156,8 @@ func NewMetricCollector(s *config.Settings, clock types.TimeProvider, costStore
 		filter:             filter,
 		transformer:        transform.NewMetricTransformer(),
 		clock:              clock,
+		guard:              NewTimestampGuard(s.Ingest),
+		coverage:           NewCoverageTracker(),
 		cancelFunc:         cancel,
 	}
 	go collector.rotateCachePeriodically(ctx)
@@ -158,7 +167,8 @@ func NewMetricCollector(s *config.Settings, clock types.TimeProvider, costStore
 // PutMetrics processes a Prometheus remote_write request and stores classified metrics.
 // This method handles decompression, protocol version detection, metric classification,
 // and routing to appropriate storage backends while maintaining compatibility statistics.
-func (d *MetricCollector) PutMetrics(ctx context.Context, contentType, encodingType string, body []byte) (*WriteResponseStats, error) {
+// The source identifies the client for the timestamp guard.
+func (d *MetricCollector) PutMetrics(ctx context.Context, contentType, encodingType string, source RemoteWriteSource, body []byte) (*WriteResponseStats, error) {
 	var (
 		metrics      []types.Metric
 		stats        *WriteResponseStats
@@ -169,7 +179,7 @@ func (d *MetricCollector) PutMetrics(ctx context.Context, contentType, encodingT
 	if contentType == "" {
 		contentType = appProtoContentType
 	}
-	contentType, err = parseProtoMsg(contentType)
+	contentType, err = ParseProtoMsg(contentType)
 	if err != nil {
 		return nil, err
 	}
@@ -183,12 +193,12 @@ func (d *MetricCollector) PutMetrics(ctx context.Context, contentType, encodingT
 
 	switch contentType {
 	case v1ContentType:
-		metrics, err = d.DecodeV1(decompressed)
+		metrics, err = d.DecodeV1(ctx, source, decompressed)
 		if err != nil {
 			return nil, ErrJSONUnmarshal
 		}
 	case v2ContentType:
-		metrics, stats, err = d.DecodeV2(decompressed)
+		metrics, stats, err = d.DecodeV2(ctx, source, decompressed)
 		if err != nil {
 			return &WriteResponseStats{}, ErrJSONUnmarshal
 		}
@@ -217,6 +227,8 @@ func (d *MetricCollector) PutMetrics(ctx context.Context, contentType, encodingT
 		return stats, fmt.Errorf("failed to transform metrics: %w", err)
 	}
 
+	d.coverage.Record(metrics, d.filter.IsCost, d.clock.GetCurrentTime())
+
 	costMetrics, observabilityMetrics, droppedMetrics := d.filter.Filter(metrics)
 
 	metricsReceived.WithLabelValues().Add(float64(len(metrics)))
@@ -278,6 +290,13 @@ func (m metricCounter) Add(metricName string, metricValue string) {
 	m[metricName][metricValue]++
 }
 
+// Coverage reports the series received by the collector within the window,
+// which is capped at CoverageRetention.
+func (d *MetricCollector) Coverage(window time.Duration) *types.CoverageReport {
+	since := d.clock.GetCurrentTime().Add(-min(window, CoverageRetention))
+	return &types.CoverageReport{Since: since, Series: d.coverage.Since(since)}
+}
+
 // Flush triggers the flushing of accumulated metrics.
 func (d *MetricCollector) Flush(ctx context.Context) error {
 	if err := d.costStore.Flush(); err != nil {
@@ -304,8 +323,8 @@ func (d *MetricCollector) rotateCachePeriodically(ctx context.Context) {
 	}
 }
 
-// parseProtoMsg parses the content type and extracts the proto message version.
-func parseProtoMsg(contentType string) (string, error) {
+// ParseProtoMsg parses the content type and extracts the proto message version.
+func ParseProtoMsg(contentType string) (string, error) {
 	contentType = strings.TrimSpace(contentType)
 
 	parts := strings.Split(contentType, ";")
@@ -335,13 +354,17 @@ func formatFloat(value float64) string {
 }
 
 // DecodeV1 decompresses and decodes a Protobuf v1 WriteRequest, then converts it to a slice of Metric structs.
-func (d *MetricCollector) DecodeV1(data []byte) ([]types.Metric, error) {
+// Samples outside the tolerances of the timestamp guard are dropped or clamped.
+func (d *MetricCollector) DecodeV1(ctx context.Context, source RemoteWriteSource, data []byte) ([]types.Metric, error) {
 	// Parse Protobuf v1 WriteRequest
 	var writeReq prompb.WriteRequest
 	if err := proto.Unmarshal(data, &writeReq); err != nil {
 		return nil, err
 	}
 
+	check := d.guard.Begin(source, d.clock.GetCurrentTime())
+	defer check.Done(ctx)
+
 	// Convert to []types.Metric
 	var metrics []types.Metric
 	for _, ts := range writeReq.Timeseries {
@@ -352,12 +375,16 @@ func (d *MetricCollector) DecodeV1(data []byte) ([]types.Metric, error) {
 		}
 
 		for _, sample := range ts.Samples {
+			sampleTime, ok := check.Sample(labelsMap, sample.Timestamp)
+			if !ok {
+				continue
+			}
 			metric := types.Metric{
 				ID:             uuid.New(),
 				ClusterName:    d.settings.ClusterName,
 				CloudAccountID: d.settings.CloudAccountID,
 				CreatedAt:      d.clock.GetCurrentTime(),
-				TimeStamp:      timestamp.Time(sample.Timestamp),
+				TimeStamp:      timestamp.Time(sampleTime),
 				Value:          formatFloat(sample.Value),
 			}
 			metric.ImportLabels(labelsMap)
@@ -373,13 +400,17 @@ func (d *MetricCollector) DecodeV1(data []byte) ([]types.Metric, error) {
 }
 
 // DecodeV2 decompresses and decodes a Protobuf v2 WriteRequest, then converts it to a slice of Metric structs and collects stats.
-func (d *MetricCollector) DecodeV2(data []byte) ([]types.Metric, *WriteResponseStats, error) {
+// Samples outside the tolerances of the timestamp guard are dropped or clamped, and dropped samples are not counted as written.
+func (d *MetricCollector) DecodeV2(ctx context.Context, source RemoteWriteSource, data []byte) ([]types.Metric, *WriteResponseStats, error) {
 	// Parse Protobuf v2 WriteRequest
 	var writeReq writev2.Request
 	if err := proto.Unmarshal(data, &writeReq); err != nil {
 		return nil, &WriteResponseStats{}, err
 	}
 
+	check := d.guard.Begin(source, d.clock.GetCurrentTime())
+	defer check.Done(ctx)
+
 	// Initialize statistics
 	stats := WriteResponseStats{}
 
@@ -402,12 +433,16 @@ func (d *MetricCollector) DecodeV2(data []byte) ([]types.Metric, *WriteResponseS
 
 		// Process samples
 		for _, sample := range ts.Samples {
+			sampleTime, ok := check.Sample(labelsMap, sample.Timestamp)
+			if !ok {
+				continue
+			}
 			metric := types.Metric{
 				ID:             uuid.New(),
 				ClusterName:    d.settings.ClusterName,
 				CloudAccountID: d.settings.CloudAccountID,
 				CreatedAt:      d.clock.GetCurrentTime(),
-				TimeStamp:      timestamp.Time(sample.Timestamp),
+				TimeStamp:      timestamp.Time(sampleTime),
 				Value:          formatFloat(sample.Value),
 			}
 			metric.ImportLabels(labelsMap)
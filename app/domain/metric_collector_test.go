@@ -15,6 +15,7 @@ import (
 	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
 	"github.com/cloudzero/cloudzero-agent/app/domain"
 	"github.com/cloudzero/cloudzero-agent/app/domain/testdata"
+	"github.com/cloudzero/cloudzero-agent/app/types"
 	"github.com/cloudzero/cloudzero-agent/app/types/mocks"
 )
 
@@ -46,7 +47,7 @@ func TestPutMetrics(t *testing.T) {
 
 		payload, _, _, err := testdata.BuildWriteRequest(testdata.WriteRequestFixture.Timeseries, nil, nil, nil, nil, "snappy")
 		require.NoError(t, err)
-		stats, err := d.PutMetrics(ctx, "application/x-protobuf", "snappy", payload)
+		stats, err := d.PutMetrics(ctx, "application/x-protobuf", "snappy", domain.RemoteWriteSource{}, payload)
 		assert.NoError(t, err)
 		assert.Nil(t, stats)
 	})
@@ -69,8 +70,56 @@ func TestPutMetrics(t *testing.T) {
 		)
 		assert.NoError(t, err)
 
-		stats, err := d.PutMetrics(ctx, "application/x-protobuf;proto=io.prometheus.write.v2.Request", "snappy", payload)
+		stats, err := d.PutMetrics(ctx, "application/x-protobuf;proto=io.prometheus.write.v2.Request", "snappy", domain.RemoteWriteSource{}, payload)
 		assert.NoError(t, err)
 		assert.NotNil(t, stats)
 	})
+
+	t.Run("Timestamp Guard", func(t *testing.T) {
+		// The samples of the fixtures are from 1970
+		guarded := &config.Settings{
+			CloudAccountID: cfg.CloudAccountID,
+			Region:         cfg.Region,
+			ClusterName:    cfg.ClusterName,
+			Ingest:         config.Ingest{TimestampGuard: config.TimestampGuardClamp},
+		}
+
+		var stored []types.Metric
+		storage := mocks.NewMockStore(ctrl)
+		storage.EXPECT().Put(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, metrics ...types.Metric) error {
+			stored = append(stored, metrics...)
+			return nil
+		})
+		storage.EXPECT().Flush().Return(nil)
+		d, err := domain.NewMetricCollector(guarded, mockClock, storage, nil)
+		require.NoError(t, err)
+		defer d.Close()
+
+		payload, _, _, err := testdata.BuildWriteRequest(testdata.WriteRequestFixture.Timeseries, nil, nil, nil, nil, "snappy")
+		require.NoError(t, err)
+		_, err = d.PutMetrics(ctx, "application/x-protobuf", "snappy", domain.RemoteWriteSource{Address: "10.0.0.1"}, payload)
+		require.NoError(t, err)
+		require.NotEmpty(t, stored)
+		for _, metric := range stored {
+			assert.Equal(t, initialTime, metric.TimeStamp.UTC(), "clamped to the time the sample was received")
+		}
+
+		guarded.Ingest.TimestampGuard = config.TimestampGuardReject
+		d, err = domain.NewMetricCollector(guarded, mockClock, nil, nil)
+		require.NoError(t, err)
+		defer d.Close()
+
+		payload, _, _, err = testdata.BuildV2WriteRequest(
+			testdata.WriteV2RequestFixture.Timeseries,
+			testdata.WriteV2RequestFixture.Symbols,
+			nil,
+			nil,
+			nil,
+			"snappy",
+		)
+		require.NoError(t, err)
+		stats, err := d.PutMetrics(ctx, "application/x-protobuf;proto=io.prometheus.write.v2.Request", "snappy", domain.RemoteWriteSource{Address: "10.0.0.1"}, payload)
+		require.NoError(t, err)
+		assert.Zero(t, stats.Samples, "rejected samples are not written")
+	})
 }
@@ -65,6 +65,11 @@ func NewMetricFilter(cfg *config.Metrics) (*MetricFilter, error) {
 	return mf, nil
 }
 
+// IsCost reports whether a metric with the given name is a cost metric.
+func (mf *MetricFilter) IsCost(name string) bool {
+	return mf == nil || mf.cost == nil || mf.cost.Test(name)
+}
+
 // Filter processes the supplied metrics through the filter. It returns three
 // slices: the first being the list of cost metrics, the second being the list
 // of observability metrics, and the third being the list of dropped metrics.
@@ -79,7 +84,7 @@ func (mf *MetricFilter) Filter(metrics []types.Metric) (costMetrics []types.Metr
 	for _, metric := range metrics {
 		var matchedCost, matchedObservability bool
 
-		if mf.cost == nil || mf.cost.Test(metric.MetricName) {
+		if mf.IsCost(metric.MetricName) {
 			costMetric := metric
 
 			if mf.costLabels != nil {
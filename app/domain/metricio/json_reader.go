@@ -8,13 +8,12 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 
-	"github.com/andybalholm/brotli"
 	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
 )
 
-// JSONReader reads metrics from JSON or Brotli-compressed JSON files.
+// JSONReader reads metrics from JSON or compressed JSON files.
 type JSONReader struct {
 	batchSize int
 }
@@ -27,8 +26,9 @@ func NewJSONReader(batchSize int) *JSONReader {
 	return &JSONReader{batchSize: batchSize}
 }
 
-// ReadJSONFile reads metrics from a JSON or JSON.br file.
-// It detects compression from the file extension and calls the callback with batches of metrics.
+// ReadJSONFile reads metrics from a JSON or compressed JSON file, such as
+// .json.br, .json.zst or .json.sz. It detects the codec from the file
+// extension and calls the callback with batches of metrics.
 func (r *JSONReader) ReadJSONFile(path string, callback func([]types.Metric) error) error {
 	file, err := os.Open(path)
 	if err != nil {
@@ -36,10 +36,15 @@ func (r *JSONReader) ReadJSONFile(path string, callback func([]types.Metric) err
 	}
 	defer file.Close()
 
-	// Determine if file is Brotli-compressed based on extension
+	// Determine the codec of the file based on extension
 	var reader io.Reader = file
-	if strings.HasSuffix(path, ".br") {
-		reader = brotli.NewReader(file)
+	if codec, ok := compress.FromPath(path); ok {
+		decompressor, err := compress.NewReader(file, codec)
+		if err != nil {
+			return err
+		}
+		defer decompressor.Close()
+		reader = decompressor
 	}
 
 	return r.decodeJSONStream(reader, callback)
@@ -100,7 +105,7 @@ func (r *JSONReader) decodeJSONStream(reader io.Reader, callback func([]types.Me
 	return nil
 }
 
-// ReadAllMetrics reads all metrics from a JSON or JSON.br file into memory.
+// ReadAllMetrics reads all metrics from a JSON or compressed JSON file into memory.
 // This is a convenience method for smaller files.
 func (r *JSONReader) ReadAllMetrics(path string) ([]types.Metric, error) {
 	var allMetrics []types.Metric
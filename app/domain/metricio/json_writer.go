@@ -9,11 +9,10 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 	"sync"
 
-	"github.com/andybalholm/brotli"
 	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
 )
 
 const (
@@ -24,10 +23,10 @@ const (
 	NoCompression = -1
 )
 
-// JSONWriter writes metrics to JSON or Brotli-compressed JSON.
+// JSONWriter writes metrics to JSON or compressed JSON.
 type JSONWriter struct {
 	dest       io.Writer
-	compressor *brotli.Writer
+	compressor io.WriteCloser
 	encoder    *json.Encoder
 	writer     io.Writer
 	first      bool
@@ -39,13 +38,27 @@ type JSONWriter struct {
 // If compressionLevel >= 0, Brotli compression is applied at that level.
 // If compressionLevel < 0 (e.g., NoCompression), no compression is used.
 func NewJSONWriterToWriter(dest io.Writer, compressionLevel int) (*JSONWriter, error) {
+	if compressionLevel < 0 {
+		return NewJSONWriterWithCodec(dest, "", compressionLevel)
+	}
+	return NewJSONWriterWithCodec(dest, compress.Brotli, compressionLevel)
+}
+
+// NewJSONWriterWithCodec creates a new JSONWriter that writes to the given
+// io.Writer, compressed with the codec at the level. An empty codec disables
+// compression; a level out of the range of the codec uses its default level.
+func NewJSONWriterWithCodec(dest io.Writer, codec compress.Codec, level int) (*JSONWriter, error) {
 	w := &JSONWriter{
 		dest:  dest,
 		first: true,
 	}
 
-	if compressionLevel >= 0 {
-		w.compressor = brotli.NewWriterLevel(dest, compressionLevel)
+	if codec != "" {
+		compressor, err := compress.NewWriter(dest, codec, level)
+		if err != nil {
+			return nil, err
+		}
+		w.compressor = compressor
 		w.writer = w.compressor
 	} else {
 		w.writer = dest
@@ -62,24 +75,36 @@ func NewJSONWriterToWriter(dest io.Writer, compressionLevel int) (*JSONWriter, e
 }
 
 // NewJSONWriter creates a new JSONWriter that writes to the specified path.
-// If the path ends with .br, Brotli compression is used at the default level.
+// If the path ends with the extension of a codec, such as .br, .zst or .sz,
+// the output is compressed with it at the default level.
 func NewJSONWriter(path string) (*JSONWriter, error) {
-	compressed := strings.HasSuffix(path, ".br")
-	if compressed {
+	if _, ok := compress.FromPath(path); ok {
 		return NewJSONWriterWithCompression(path, DefaultCompressionLevel)
 	}
 	return NewJSONWriterWithCompression(path, NoCompression)
 }
 
 // NewJSONWriterWithCompression creates a new JSONWriter that writes to the specified path
-// with a specific compression level. Use NoCompression (-1) to disable compression.
+// with a specific compression level. The codec is picked from the extension of
+// the path, Brotli by default. Use NoCompression (-1) to disable compression.
 func NewJSONWriterWithCompression(path string, compressionLevel int) (*JSONWriter, error) {
+	codec, ok := compress.FromPath(path)
+	switch {
+	case compressionLevel < 0:
+		codec = ""
+	case !ok:
+		codec = compress.Brotli
+	case codec != compress.Brotli:
+		// The levels of the other codecs do not match those of Brotli.
+		compressionLevel = -1
+	}
+
 	file, err := os.Create(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create output file %s: %w", path, err)
 	}
 
-	w, err := NewJSONWriterToWriter(file, compressionLevel)
+	w, err := NewJSONWriterWithCodec(file, codec, compressionLevel)
 	if err != nil {
 		file.Close()
 		return nil, err
@@ -226,6 +226,8 @@ func TestJSONWriter_RoundTrip(t *testing.T) {
 	}{
 		{"uncompressed", filepath.Join(tmpDir, "roundtrip.json")},
 		{"compressed", filepath.Join(tmpDir, "roundtrip.json.br")},
+		{"zstd", filepath.Join(tmpDir, "roundtrip.json.zst")},
+		{"snappy", filepath.Join(tmpDir, "roundtrip.json.sz")},
 	}
 
 	for _, tc := range testCases {
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
 	"github.com/parquet-go/parquet-go"
 )
 
@@ -35,13 +36,21 @@ func NewParquetReader(batchSize int) *ParquetReader {
 }
 
 // IsSupportedFile returns true if the file has a supported extension for metric data.
-// Supported formats: .csv, .parquet, .json, .json.br
+// Supported formats: .csv, .parquet, .json, and .json compressed with a codec
+// of the compress package (.json.br, .json.zst, .json.sz)
 func IsSupportedFile(path string) bool {
 	lower := strings.ToLower(path)
 	return strings.HasSuffix(lower, ".csv") ||
 		strings.HasSuffix(lower, ".parquet") ||
 		strings.HasSuffix(lower, ".json") ||
-		strings.HasSuffix(lower, ".json.br")
+		IsCompressedJSONFile(path)
+}
+
+// IsCompressedJSONFile returns true if the file is compressed JSON, such as
+// the files flushed by the collector.
+func IsCompressedJSONFile(path string) bool {
+	codec, ok := compress.FromPath(path)
+	return ok && strings.HasSuffix(strings.ToLower(path), ".json"+codec.Extension())
 }
 
 // FindFiles recursively finds all files in the given directory that match the filter.
@@ -83,7 +92,7 @@ func FindFiles(dir string, filter func(path string) bool) ([]string, error) {
 }
 
 // FindSupportedFiles recursively finds all supported metric files in the given directory.
-// Supported formats: .csv, .parquet, .json, .json.br
+// Supported formats: those of IsSupportedFile
 func FindSupportedFiles(dir string) ([]string, error) {
 	return FindFiles(dir, IsSupportedFile)
 }
@@ -216,6 +216,9 @@ func TestIsSupportedFile(t *testing.T) {
 		{"data.JSON", true},
 		{"data.json.br", true},
 		{"data.JSON.BR", true},
+		{"data.json.zst", true},
+		{"data.json.sz", true},
+		{"data.csv.zst", false},
 		{"data.txt", false},
 		{"data.parquet.gz", false},
 		{"/path/to/metrics.parquet", true},
@@ -32,9 +32,20 @@ type RemoteWriter struct {
 	maxRetries int
 }
 
+// RemoteWriterOption configures a RemoteWriter.
+type RemoteWriterOption func(*RemoteWriter)
+
+// WithTransport sets the transport requests are sent with, such as one
+// going through the egress proxy.
+func WithTransport(rt http.RoundTripper) RemoteWriterOption {
+	return func(w *RemoteWriter) {
+		w.client.Transport = rt
+	}
+}
+
 // NewRemoteWriter creates a new RemoteWriter.
-func NewRemoteWriter(url string) *RemoteWriter {
-	return &RemoteWriter{
+func NewRemoteWriter(url string, opts ...RemoteWriterOption) *RemoteWriter {
+	w := &RemoteWriter{
 		url: url,
 		client: &http.Client{
 			Timeout: DefaultTimeout,
@@ -42,6 +53,10 @@ func NewRemoteWriter(url string) *RemoteWriter {
 		timeout:    DefaultTimeout,
 		maxRetries: DefaultMaxRetries,
 	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
 }
 
 // Write sends a batch of TimeSeries to the remote_write endpoint.
@@ -87,6 +87,28 @@ func WithVerifyConnection() Option {
 	})
 }
 
+// WithClientVerification configures a server to require a certificate from
+// every client, and to verify it against the reconciler's CA pool, so the
+// client CA rotates along with the server certificate (mTLS).
+// tls.Config.ClientCAs is ignored; the CA bundle is supplied by the provider,
+// for example the ca path of WithCertificatesPaths.
+//
+// Example:
+//
+//	tlsConfig := TLSConfig(
+//	    WithCertificatesPaths("/path/to/cert.pem", "/path/to/key.pem", "/path/to/client-ca.pem"),
+//	    WithClientVerification(),
+//	)
+func WithClientVerification() Option {
+	return optionFunc(func(r *reconciler) {
+		r.verifyClients = true
+		// Request any certificate, so the handshake reaches VerifyConnection
+		// which verifies it with the rotated CA pool.
+		r.config.ClientAuth = tls.RequireAnyClientCert
+		r.config.VerifyConnection = r.verifyConnection
+	})
+}
+
 // WithProvider sets the TLSProvider for the reconciler.
 // The provider is responsible for retrieving the latest certificates upon receiving a reload signal.
 //
@@ -239,6 +261,34 @@ func WithDurationReload(dur time.Duration) Option {
 	})
 }
 
+// WithAnyReload combines reload options, reloading the certificates when any
+// of them triggers. Every option is checked on each call, so none of them
+// misses its trigger because an earlier one fired.
+//
+// Example:
+//
+//	tlsConfig := TLSConfig(WithAnyReload(WithSIGHUPReload(signalChan), WithDurationReload(time.Minute)))
+func WithAnyReload(opts ...Option) Option {
+	return optionFunc(func(r *reconciler) {
+		reloads := make([]func() bool, 0, len(opts))
+		for _, opt := range opts {
+			scratch := newReconciler()
+			opt.apply(scratch)
+			reloads = append(reloads, scratch.reload)
+		}
+
+		r.reload = func() bool {
+			reload := false
+			for _, f := range reloads {
+				if f() {
+					reload = true
+				}
+			}
+			return reload
+		}
+	})
+}
+
 // WithOnReload registers a callback function to be invoked after a certificate reload.
 // This can be used for additional actions such as rotating session tickets or logging.
 //
@@ -367,6 +417,10 @@ type reconciler struct {
 	// onReload is a callback function invoked after a successful certificate reload.
 	// It can be used for additional actions like rotating session tickets or logging.
 	onReload func(*tls.Config)
+
+	// verifyClients indicates that the server verifies client certificates
+	// against the CA pool. It is set by WithClientVerification.
+	verifyClients bool
 }
 
 // getCertificate retrieves the current TLS certificate for server-side TLS configurations.
@@ -414,11 +468,22 @@ func (r *reconciler) verifyConnection(cs tls.ConnectionState) error {
 		return err
 	}
 
+	// Client certificates are verified when requested by the configuration.
+	verifyClients := r.verifyClients || r.config.ClientAuth >= tls.VerifyClientCertIfGiven
+
 	// Skip verification if client certificates are not required and none are provided.
-	if r.config.ClientAuth < tls.VerifyClientCertIfGiven &&
-		len(cs.PeerCertificates) == 0 {
+	if !verifyClients && len(cs.PeerCertificates) == 0 {
 		return nil
 	}
+	if r.verifyClients {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("tlsreconciler: client certificate required")
+		}
+		// Without a CA pool the system roots would be trusted instead.
+		if pool == nil {
+			return errors.New("tlsreconciler: no client CA to verify the client certificate")
+		}
+	}
 
 	// Set up verification options.
 	opts := x509.VerifyOptions{
@@ -433,7 +498,7 @@ func (r *reconciler) verifyConnection(cs tls.ConnectionState) error {
 	}
 
 	// Specify key usages based on client authentication settings.
-	if r.config.ClientAuth >= tls.VerifyClientCertIfGiven {
+	if verifyClients {
 		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
 	}
 
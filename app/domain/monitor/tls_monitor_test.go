@@ -42,6 +42,21 @@ func TestWithSigHupReload(t *testing.T) {
 	require.False(t, r.reload())
 }
 
+func TestWithAnyReload(t *testing.T) {
+	c := make(chan os.Signal, 1)
+	opt := WithAnyReload(WithSIGHUPReload(c), WithDurationReload(time.Millisecond*100))
+	r := newReconciler()
+	opt.apply(r)
+
+	require.False(t, r.reload())
+	c <- syscall.SIGHUP
+	require.True(t, r.reload())
+	require.False(t, r.reload())
+	time.Sleep(time.Millisecond * 100)
+	require.True(t, r.reload())
+	require.False(t, r.reload())
+}
+
 func TestOptions(t *testing.T) {
 	tests := []struct {
 		opt    Option
@@ -99,6 +114,14 @@ func TestOptions(t *testing.T) {
 				require.NotNil(t, r.onReload)
 			},
 		},
+		{
+			opt: WithClientVerification(),
+			assert: func(r *reconciler) {
+				require.True(t, r.verifyClients)
+				require.Equal(t, tls.RequireAnyClientCert, r.config.ClientAuth)
+				require.NotNil(t, r.config.VerifyConnection)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -192,11 +215,12 @@ func TestReconcilerVerifyConnection(t *testing.T) {
 	require.NoError(t, err)
 
 	tests := []struct {
-		p        TLSProvider
-		cs       tls.ConnectionState
-		auth     tls.ClientAuthType
-		time     func() time.Time
-		contains string
+		p             TLSProvider
+		cs            tls.ConnectionState
+		auth          tls.ClientAuthType
+		verifyClients bool
+		time          func() time.Time
+		contains      string
 	}{
 		{
 			p:        fileSystemProvider{},
@@ -217,6 +241,33 @@ func TestReconcilerVerifyConnection(t *testing.T) {
 			auth:     tls.RequireAndVerifyClientCert,
 			contains: "incompatible key usage",
 		},
+		{
+			p: p,
+			cs: tls.ConnectionState{
+				PeerCertificates: certs,
+			},
+			time: func() time.Time {
+				return time.Date(2017, 11, 20, 0, 0, 0, 0, time.UTC)
+			},
+			auth:          tls.RequireAnyClientCert,
+			verifyClients: true,
+			contains:      "incompatible key usage",
+		},
+		{
+			p:             p,
+			auth:          tls.RequireAnyClientCert,
+			verifyClients: true,
+			contains:      "client certificate required",
+		},
+		{
+			p: fileSystemProvider{"", "./testdata/cert", "./testdata/key"},
+			cs: tls.ConnectionState{
+				PeerCertificates: certs,
+			},
+			auth:          tls.RequireAnyClientCert,
+			verifyClients: true,
+			contains:      "no client CA",
+		},
 		{
 			p: p,
 			cs: tls.ConnectionState{
@@ -233,6 +284,7 @@ func TestReconcilerVerifyConnection(t *testing.T) {
 		r.p = tt.p
 		r.config.Time = tt.time
 		r.config.ClientAuth = tt.auth
+		r.verifyClients = tt.verifyClients
 
 		err := r.verifyConnection(tt.cs)
 
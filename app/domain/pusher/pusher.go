@@ -10,6 +10,7 @@ package pusher
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
 	"github.com/cloudzero/cloudzero-agent/app/types"
 )
 
@@ -111,6 +113,7 @@ type MetricsPusher struct {
 	sentMaxBytes int
 	maxRetries   int
 	settings     *config.Settings
+	client       *http.Client
 
 	// flow controle
 	originalCtx context.Context
@@ -126,7 +129,14 @@ func New(
 	store types.ResourceStore,
 	clock types.TimeProvider,
 	settings *config.Settings,
-) types.Runnable {
+) (types.Runnable, error) {
+	// The client goes through the egress proxy, and presents the client
+	// certificate to collectors requiring mTLS.
+	client, err := transport.NewClient(settings.Egress, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure the egress transport: %w", err)
+	}
+
 	remoteWriteStatsOnce.Do(func() {
 		prometheus.MustRegister(
 			RemoteWriteTimeseriesSent,
@@ -142,6 +152,7 @@ func New(
 	newCtx, cancel := context.WithCancel(ctx)
 	return &MetricsPusher{
 		settings:     settings,
+		client:       client,
 		originalCtx:  ctx,
 		ctx:          newCtx,
 		cancel:       cancel,
@@ -152,7 +163,7 @@ func New(
 		sendInterval: settings.RemoteWrite.SendInterval,
 		sentMaxBytes: settings.RemoteWrite.MaxBytesPerSend,
 		maxRetries:   settings.RemoteWrite.MaxRetries,
-	}
+	}, nil
 }
 
 func (h *MetricsPusher) ResetStats() {
@@ -330,5 +341,5 @@ func (h *MetricsPusher) formatMetrics(records []*types.ResourceTags) []prompb.Ti
 }
 
 func (h *MetricsPusher) pushMetrics(remoteWriteURL string, timeSeries []prompb.TimeSeries) error {
-	return PushMetrics(h.ctx, remoteWriteURL, timeSeries, h.maxRetries, h.sendTimeout)
+	return PushMetrics(h.ctx, h.client, remoteWriteURL, timeSeries, h.maxRetries, h.sendTimeout)
 }
@@ -42,7 +42,8 @@ func setupTest(t *testing.T, clock types.TimeProvider, store types.ResourceStore
 		},
 	}
 
-	p := pusher.New(context.Background(), store, clock, settings)
+	p, err := pusher.New(context.Background(), store, clock, settings)
+	require.NoError(t, err)
 	rw := p.(*pusher.MetricsPusher)
 	rw.ResetStats()
 	return rw, server.URL
@@ -42,7 +42,7 @@ func FormatMetrics(records []*types.ResourceTags) []prompb.TimeSeries {
 
 // PushMetrics serializes timeseries to a snappy-compressed protobuf and
 // POSTs them to the remote_write endpoint with retry + exponential backoff.
-func PushMetrics(ctx context.Context, url string, timeSeries []prompb.TimeSeries, maxRetries int, sendTimeout time.Duration) error {
+func PushMetrics(ctx context.Context, client *http.Client, url string, timeSeries []prompb.TimeSeries, maxRetries int, sendTimeout time.Duration) error {
 	writeRequest := &prompb.WriteRequest{
 		Timeseries: timeSeries,
 	}
@@ -74,7 +74,6 @@ func PushMetrics(ctx context.Context, url string, timeSeries []prompb.TimeSeries
 		req.Header.Set("Content-Type", "application/x-protobuf")
 		req.Header.Set("Content-Encoding", "snappy")
 
-		client := &http.Client{}
 		resp, err = client.Do(req)
 		if err != nil {
 			log.Ctx(reqCtx).Err(err).Msg("post metric failure")
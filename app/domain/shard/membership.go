@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Member is a collector replica.
+type Member struct {
+	// Name identifies the replica on the ring. It is the name of its pod, so
+	// a replica restarted with a new address keeps its ranges.
+	Name string
+	// Address is the IP address the replica is reached at.
+	Address string
+}
+
+// Discovery lists the collector replicas.
+type Discovery interface {
+	Members(ctx context.Context) ([]Member, error)
+}
+
+// StaticDiscovery is a fixed list of replicas.
+type StaticDiscovery []Member
+
+func (d StaticDiscovery) Members(context.Context) ([]Member, error) {
+	return d, nil
+}
+
+// EndpointsDiscovery lists the ready endpoints of the headless Service of the
+// collector replicas, using its EndpointSlices.
+type EndpointsDiscovery struct {
+	client    kubernetes.Interface
+	namespace string
+	service   string
+}
+
+// NewEndpointsDiscovery creates a discovery of the endpoints of the service.
+func NewEndpointsDiscovery(client kubernetes.Interface, namespace, service string) *EndpointsDiscovery {
+	return &EndpointsDiscovery{client: client, namespace: namespace, service: service}
+}
+
+func (d *EndpointsDiscovery) Members(ctx context.Context) ([]Member, error) {
+	slices, err := d.client.DiscoveryV1().EndpointSlices(d.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + d.service,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the endpoints of service %s/%s: %w", d.namespace, d.service, err)
+	}
+
+	seen := map[string]bool{}
+	var members []Member
+	for _, slice := range slices.Items {
+		for _, e := range slice.Endpoints {
+			// Terminating and unready replicas are left out, so their ranges
+			// move before they stop accepting writes.
+			if e.Conditions.Ready != nil && !*e.Conditions.Ready {
+				continue
+			}
+			if len(e.Addresses) == 0 {
+				continue
+			}
+			m := Member{Name: e.Addresses[0], Address: e.Addresses[0]}
+			if e.TargetRef != nil && e.TargetRef.Kind == "Pod" && e.TargetRef.Name != "" {
+				m.Name = e.TargetRef.Name
+			}
+			if seen[m.Name] {
+				continue
+			}
+			seen[m.Name] = true
+			members = append(members, m)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	return members, nil
+}
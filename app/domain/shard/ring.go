@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package shard spreads the series written to the aggregator across collector
+// replicas. Each replica owns a range of a consistent-hash ring; a Router
+// hashes the series of each remote_write request and forwards them to their
+// owners. Replicas store and ship their series independently, so adding a
+// replica only moves the series of the ranges it takes over, and no data needs
+// to be migrated: new samples of a moved series are simply written by its new
+// owner.
+package shard
+
+import (
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// Ring is an immutable consistent-hash ring of members. Each member is placed
+// at several points of the ring (virtual nodes), so the ranges are balanced,
+// and a member joining or leaving only moves the keys of its own ranges.
+type Ring struct {
+	members []string
+	points  []point
+}
+
+type point struct {
+	hash   uint64
+	member string
+}
+
+// NewRing creates a ring of the members, each placed at virtualNodes points.
+func NewRing(members []string, virtualNodes int) *Ring {
+	if virtualNodes <= 0 {
+		virtualNodes = 1
+	}
+
+	members = slices.Clone(members)
+	slices.Sort(members)
+	members = slices.Compact(members)
+
+	r := &Ring{
+		members: members,
+		points:  make([]point, 0, len(members)*virtualNodes),
+	}
+	for _, m := range members {
+		for i := 0; i < virtualNodes; i++ {
+			r.points = append(r.points, point{hash: hash(m + "#" + strconv.Itoa(i)), member: m})
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		if r.points[i].hash == r.points[j].hash {
+			return r.points[i].member < r.points[j].member
+		}
+		return r.points[i].hash < r.points[j].hash
+	})
+	return r
+}
+
+// Members returns the sorted members of the ring.
+func (r *Ring) Members() []string {
+	return slices.Clone(r.members)
+}
+
+// Empty returns true when the ring has no members.
+func (r *Ring) Empty() bool {
+	return len(r.members) == 0
+}
+
+// Owner returns the member owning the key: the member of the first point at
+// or after the hash of the key, wrapping around. It returns an empty string
+// when the ring is empty.
+func (r *Ring) Owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].hash >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].member
+}
+
+// Equal returns true when both rings have the same members.
+func (r *Ring) Equal(other *Ring) bool {
+	return other != nil && slices.Equal(r.members, other.members)
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	// FNV spreads similar strings poorly over the high bits, which decide the
+	// position on the ring, so mix the result.
+	return mix(h.Sum64())
+}
+
+// mix is the finalizer of MurmurHash3.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
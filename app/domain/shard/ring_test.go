@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shard_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/shard"
+)
+
+func keys(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = shard.SeriesKey(map[string]string{
+			"namespace": fmt.Sprintf("ns-%d", i%17),
+			"pod":       fmt.Sprintf("pod-%d", i),
+		}, []string{"namespace", "pod"})
+	}
+	return out
+}
+
+func TestRing_Balance(t *testing.T) {
+	ring := shard.NewRing([]string{"collector-0", "collector-1", "collector-2", "collector-3"}, 128)
+
+	counts := map[string]int{}
+	for _, k := range keys(20000) {
+		counts[ring.Owner(k)]++
+	}
+	require.Len(t, counts, 4)
+	for member, n := range counts {
+		// Each member owns a quarter of the keys, within 25%.
+		assert.InDelta(t, 5000, n, 1250, member)
+	}
+}
+
+func TestRing_Rebalance(t *testing.T) {
+	before := shard.NewRing([]string{"collector-0", "collector-1", "collector-2"}, 128)
+	after := shard.NewRing([]string{"collector-0", "collector-1", "collector-2", "collector-3"}, 128)
+
+	moved := 0
+	all := keys(20000)
+	for _, k := range all {
+		if before.Owner(k) != after.Owner(k) {
+			// Keys only move to the new member.
+			require.Equal(t, "collector-3", after.Owner(k))
+			moved++
+		}
+	}
+	assert.InDelta(t, len(all)/4, moved, float64(len(all))/16)
+}
+
+func TestRing_Members(t *testing.T) {
+	ring := shard.NewRing([]string{"b", "a", "b"}, 4)
+	assert.Equal(t, []string{"a", "b"}, ring.Members())
+	assert.True(t, ring.Equal(shard.NewRing([]string{"a", "b"}, 4)))
+	assert.False(t, ring.Equal(shard.NewRing([]string{"a"}, 4)))
+
+	empty := shard.NewRing(nil, 4)
+	assert.True(t, empty.Empty())
+	assert.Empty(t, empty.Owner("key"))
+}
+
+func TestSeriesKey(t *testing.T) {
+	labels := []string{"namespace", "pod"}
+
+	// The series of a pod share a key, whatever their other labels.
+	assert.Equal(t,
+		shard.SeriesKey(map[string]string{"__name__": "cpu", "namespace": "a", "pod": "p"}, labels),
+		shard.SeriesKey(map[string]string{"__name__": "memory", "namespace": "a", "pod": "p", "container": "c"}, labels),
+	)
+	assert.NotEqual(t,
+		shard.SeriesKey(map[string]string{"namespace": "a", "pod": "p"}, labels),
+		shard.SeriesKey(map[string]string{"namespace": "a", "pod": "q"}, labels),
+	)
+
+	// Series without any of the labels are keyed by all their labels.
+	assert.NotEqual(t,
+		shard.SeriesKey(map[string]string{"__name__": "node_cpu", "node": "n1"}, labels),
+		shard.SeriesKey(map[string]string{"__name__": "node_cpu", "node": "n2"}, labels),
+	)
+}
@@ -0,0 +1,320 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	remoteapi "github.com/prometheus/client_golang/exp/api/remote"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+	"github.com/rs/zerolog/log"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain"
+	"github.com/cloudzero/cloudzero-agent/app/logging/instr"
+)
+
+var (
+	// ErrNoMembers is returned when there is no replica to forward to.
+	ErrNoMembers = errors.New("no collector replica is available")
+
+	// ErrInvalidRequest is returned when a request cannot be decoded.
+	ErrInvalidRequest = errors.New("invalid remote_write request")
+)
+
+// ForwardError is returned when a replica rejects the series forwarded to it.
+type ForwardError struct {
+	Member     string
+	StatusCode int
+	Message    string
+}
+
+func (e *ForwardError) Error() string {
+	return fmt.Sprintf("replica %s rejected the series with status %d: %s", e.Member, e.StatusCode, e.Message)
+}
+
+// PermanentStatus returns the status to answer a failed request with when
+// retrying it cannot succeed: every replica which failed rejected its series
+// with a 4xx other than 429. It returns false when the request should be
+// retried.
+func PermanentStatus(err error) (int, bool) {
+	errs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	status := 0
+	for _, e := range errs {
+		var forwardErr *ForwardError
+		if !errors.As(e, &forwardErr) ||
+			forwardErr.StatusCode < http.StatusBadRequest ||
+			forwardErr.StatusCode >= http.StatusInternalServerError ||
+			forwardErr.StatusCode == http.StatusTooManyRequests {
+			return 0, false
+		}
+		status = forwardErr.StatusCode
+	}
+	return status, status != 0
+}
+
+// forwardedHeaders are the request headers passed on to the replicas.
+var forwardedHeaders = []string{
+	"Authorization",
+	"Content-Type",
+	"Content-Encoding",
+	"User-Agent",
+	"X-Prometheus-Remote-Write-Version",
+	"X-Forwarded-For",
+}
+
+var (
+	ringMembers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "router_ring_members",
+		Help: "Number of collector replicas on the hash ring",
+	})
+	ringRebalances = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "router_ring_rebalances_total",
+		Help: "Number of times the members of the hash ring changed",
+	})
+	seriesForwarded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "router_series_forwarded_total",
+		Help: "Number of series forwarded to each collector replica",
+	}, []string{"member"})
+	forwardErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "router_forward_errors_total",
+		Help: "Number of requests which could not be forwarded to each collector replica",
+	}, []string{"member"})
+)
+
+// Router splits remote_write requests by the owner of each series on the
+// hash ring, and forwards each part to its owner. A request succeeds when
+// every part was accepted; otherwise the client retries the whole request,
+// and the replicas which accepted their part receive it again.
+type Router struct {
+	cfg       config.Sharding
+	discovery Discovery
+	client    *http.Client
+
+	ring      atomic.Pointer[Ring]
+	addresses atomic.Pointer[map[string]string]
+}
+
+// Option configures a Router.
+type Option func(*Router)
+
+// WithHTTPClient sets the client requests are forwarded with, such as a
+// client trusting the certificates of replicas served in https mode.
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *Router) {
+		r.client = client
+	}
+}
+
+// NewRouter creates a router over the replicas listed by the discovery. The
+// ring is empty until Refresh is called.
+func NewRouter(cfg config.Sharding, discovery Discovery, opts ...Option) *Router {
+	r := &Router{
+		cfg:       cfg,
+		discovery: discovery,
+		client:    &http.Client{Timeout: cfg.ForwardTimeout, Transport: instr.NewTransport(http.DefaultTransport)},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.ring.Store(NewRing(nil, cfg.VirtualNodes))
+	r.addresses.Store(&map[string]string{})
+	return r
+}
+
+// Ring returns the current hash ring.
+func (r *Router) Ring() *Ring {
+	return r.ring.Load()
+}
+
+// Refresh lists the replicas and rebuilds the ring when they changed.
+func (r *Router) Refresh(ctx context.Context) error {
+	members, err := r.discovery.Members(ctx)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(members))
+	addresses := make(map[string]string, len(members))
+	for _, m := range members {
+		names = append(names, m.Name)
+		addresses[m.Name] = m.Address
+	}
+	// Addresses change when a replica restarts, its ranges do not.
+	r.addresses.Store(&addresses)
+
+	ring := NewRing(names, r.cfg.VirtualNodes)
+	if ring.Equal(r.ring.Load()) {
+		return nil
+	}
+	previous := r.ring.Swap(ring)
+	ringMembers.Set(float64(len(ring.Members())))
+	ringRebalances.Inc()
+	log.Ctx(ctx).Info().
+		Strs("members", ring.Members()).
+		Strs("previous", previous.Members()).
+		Msg("rebalanced the series across the collector replicas")
+	return nil
+}
+
+// Run refreshes the ring periodically until the context is canceled.
+func (r *Router) Run(ctx context.Context) {
+	if err := r.Refresh(ctx); err != nil {
+		log.Ctx(ctx).Err(err).Msg("failed to discover the collector replicas")
+	}
+
+	ticker := time.NewTicker(r.cfg.RefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil {
+				log.Ctx(ctx).Err(err).Msg("failed to discover the collector replicas")
+			}
+		}
+	}
+}
+
+// Route splits a remote_write request and forwards the series to their
+// owners. The header is the header of the request, whose content type and
+// encoding select how the body is decoded.
+func (r *Router) Route(ctx context.Context, header http.Header, body []byte) error {
+	ring := r.ring.Load()
+	if ring.Empty() {
+		return ErrNoMembers
+	}
+	owner := func(labels map[string]string) string {
+		return ring.Owner(SeriesKey(labels, r.cfg.Labels))
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/x-protobuf"
+	}
+	msgType, err := domain.ParseProtoMsg(contentType)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	snappyEncoded := header.Get("Content-Encoding") == domain.SnappyBlockCompression
+	data := body
+	if snappyEncoded {
+		if data, err = snappy.Decode(nil, body); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+	}
+
+	parts := map[string]proto.Message{}
+	series := map[string]int{}
+	switch msgType {
+	case string(remoteapi.WriteV1MessageType):
+		var req prompb.WriteRequest
+		if err := proto.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		for o, part := range splitV1(&req, owner) {
+			parts[o], series[o] = part, len(part.Timeseries)
+		}
+	case string(remoteapi.WriteV2MessageType):
+		var req writev2.Request
+		if err := proto.Unmarshal(data, &req); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		split, err := splitV2(&req, owner)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+		for o, part := range split {
+			parts[o], series[o] = part, len(part.Timeseries)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported content type %s", ErrInvalidRequest, contentType)
+	}
+
+	addresses := *r.addresses.Load()
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for member, part := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := r.forward(ctx, member, addresses[member], header, part, snappyEncoded)
+			if err != nil {
+				forwardErrors.WithLabelValues(member).Inc()
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			seriesForwarded.WithLabelValues(member).Add(float64(series[member]))
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (r *Router) forward(ctx context.Context, member, address string, header http.Header, part proto.Message, snappyEncoded bool) error {
+	if address == "" {
+		return fmt.Errorf("replica %s has no address", member)
+	}
+
+	data, err := proto.Marshal(part)
+	if err != nil {
+		return fmt.Errorf("failed to encode the series of replica %s: %w", member, err)
+	}
+	if snappyEncoded {
+		data = snappy.Encode(nil, data)
+	}
+
+	scheme := r.cfg.Scheme
+	if scheme == "" {
+		scheme = config.ServerModeHTTP
+	}
+	url := scheme + "://" + net.JoinHostPort(address, strconv.FormatUint(uint64(r.cfg.Port), 10)) + r.cfg.Path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create the request to replica %s: %w", member, err)
+	}
+	for _, h := range forwardedHeaders {
+		if v := header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to forward the series to replica %s: %w", member, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &ForwardError{Member: member, StatusCode: resp.StatusCode, Message: string(bytes.TrimSpace(msg))}
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,328 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shard_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/shard"
+)
+
+const (
+	v1ContentType = "application/x-protobuf"
+	v2ContentType = "application/x-protobuf;proto=io.prometheus.write.v2.Request"
+)
+
+// replica is a fake collector recording the series it receives, by pod.
+type replica struct {
+	mu     sync.Mutex
+	status int
+	pods   map[string]int
+	auth   []string
+}
+
+func (r *replica) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	data, err := snappy.Decode(nil, body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.auth = append(r.auth, req.Header.Get("Authorization"))
+	if req.Header.Get("Content-Type") == v2ContentType {
+		var wr writev2.Request
+		if err := proto.Unmarshal(data, &wr); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, ts := range wr.Timeseries {
+			for i := 0; i < len(ts.LabelsRefs); i += 2 {
+				if wr.Symbols[ts.LabelsRefs[i]] == "pod" {
+					r.pods[wr.Symbols[ts.LabelsRefs[i+1]]]++
+				}
+			}
+		}
+	} else {
+		var wr prompb.WriteRequest
+		if err := proto.Unmarshal(data, &wr); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, ts := range wr.Timeseries {
+			for _, l := range ts.Labels {
+				if l.Name == "pod" {
+					r.pods[l.Value]++
+				}
+			}
+		}
+	}
+	if r.status != 0 {
+		w.WriteHeader(r.status)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newRouter starts the replicas, and returns a router over them whose client
+// dials each replica by its address.
+func newRouter(t *testing.T, replicas map[string]*replica) *shard.Router {
+	t.Helper()
+	servers := map[string]string{}
+	var members shard.StaticDiscovery
+	i := 0
+	for name, r := range replicas {
+		r.pods = map[string]int{}
+		srv := httptest.NewServer(r)
+		t.Cleanup(srv.Close)
+		address := fmt.Sprintf("10.0.0.%d", i)
+		servers[address] = srv.Listener.Addr().String()
+		members = append(members, shard.Member{Name: name, Address: address})
+		i++
+	}
+
+	dialer := &net.Dialer{}
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, _ := net.SplitHostPort(addr)
+			return dialer.DialContext(ctx, network, servers[host])
+		},
+	}}
+
+	cfg := config.Sharding{
+		Enabled: true, Service: "collector", Namespace: "cloudzero", Port: 8080,
+		Path: "/collector", Labels: []string{"namespace", "pod"}, VirtualNodes: 64,
+		RefreshInterval: time.Minute, ForwardTimeout: time.Second,
+	}
+	router := shard.NewRouter(cfg, members, shard.WithHTTPClient(client))
+	require.NoError(t, router.Refresh(context.Background()))
+	return router
+}
+
+const pods = 50
+
+func v1Request(t *testing.T) []byte {
+	t.Helper()
+	req := &prompb.WriteRequest{}
+	for i := 0; i < pods; i++ {
+		for _, name := range []string{"container_cpu_usage_seconds_total", "container_memory_working_set_bytes"} {
+			req.Timeseries = append(req.Timeseries, prompb.TimeSeries{
+				Labels: []prompb.Label{
+					{Name: "__name__", Value: name},
+					{Name: "namespace", Value: "default"},
+					{Name: "pod", Value: fmt.Sprintf("pod-%d", i)},
+				},
+				Samples: []prompb.Sample{{Value: 1, Timestamp: 1000}},
+			})
+		}
+	}
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+	return snappy.Encode(nil, data)
+}
+
+func v2Request(t *testing.T) []byte {
+	t.Helper()
+	symbols := writev2.NewSymbolTable()
+	req := &writev2.Request{}
+	for i := 0; i < pods; i++ {
+		for _, name := range []string{"container_cpu_usage_seconds_total", "container_memory_working_set_bytes"} {
+			refs := []uint32{
+				symbols.Symbolize("__name__"), symbols.Symbolize(name),
+				symbols.Symbolize("namespace"), symbols.Symbolize("default"),
+				symbols.Symbolize("pod"), symbols.Symbolize(fmt.Sprintf("pod-%d", i)),
+			}
+			req.Timeseries = append(req.Timeseries, writev2.TimeSeries{
+				LabelsRefs: refs,
+				Samples:    []writev2.Sample{{Value: 1, Timestamp: 1000}},
+				Metadata:   writev2.Metadata{HelpRef: symbols.Symbolize("help of " + name)},
+			})
+		}
+	}
+	req.Symbols = symbols.Symbols()
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+	return snappy.Encode(nil, data)
+}
+
+func header(contentType string) http.Header {
+	h := http.Header{}
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Encoding", "snappy")
+	h.Set("Authorization", "Bearer token")
+	return h
+}
+
+func TestRouter_Route(t *testing.T) {
+	for name, tt := range map[string]struct {
+		contentType string
+		body        func(t *testing.T) []byte
+	}{
+		"v1": {contentType: v1ContentType, body: v1Request},
+		"v2": {contentType: v2ContentType, body: v2Request},
+	} {
+		t.Run(name, func(t *testing.T) {
+			replicas := map[string]*replica{"collector-0": {}, "collector-1": {}, "collector-2": {}}
+			router := newRouter(t, replicas)
+
+			require.NoError(t, router.Route(context.Background(), header(tt.contentType), tt.body(t)))
+
+			seen := map[string]string{}
+			for name, r := range replicas {
+				assert.NotEmpty(t, r.pods, "every replica owns some pods")
+				for pod, n := range r.pods {
+					// Both series of the pod went to the same replica, once.
+					assert.Equal(t, 2, n, pod)
+					assert.Empty(t, seen[pod], "pod %s was sent to %s and %s", pod, seen[pod], name)
+					seen[pod] = name
+					assert.Equal(t, router.Ring().Owner(shard.SeriesKey(map[string]string{"namespace": "default", "pod": pod}, []string{"namespace", "pod"})), name)
+				}
+				for _, auth := range r.auth {
+					assert.Equal(t, "Bearer token", auth)
+				}
+			}
+			assert.Len(t, seen, pods)
+		})
+	}
+}
+
+func TestRouter_HTTPS(t *testing.T) {
+	r := &replica{pods: map[string]int{}}
+	srv := httptest.NewTLSServer(r)
+	defer srv.Close()
+	host, port, err := net.SplitHostPort(srv.Listener.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.ParseUint(port, 10, 32)
+	require.NoError(t, err)
+
+	cfg := config.Sharding{
+		Scheme: config.ServerModeHTTPS, Port: uint(portNum), Path: "/collector",
+		Labels: []string{"namespace", "pod"}, VirtualNodes: 8,
+	}
+	router := shard.NewRouter(cfg, shard.StaticDiscovery{{Name: "collector-0", Address: host}}, shard.WithHTTPClient(srv.Client()))
+	require.NoError(t, router.Refresh(context.Background()))
+
+	require.NoError(t, router.Route(context.Background(), header(v1ContentType), v1Request(t)))
+	assert.Len(t, r.pods, pods, "the series are forwarded to the replica over https")
+}
+
+func TestRouter_Errors(t *testing.T) {
+	t.Run("no members", func(t *testing.T) {
+		router := shard.NewRouter(config.Sharding{VirtualNodes: 8}, shard.StaticDiscovery{})
+		require.NoError(t, router.Refresh(context.Background()))
+		assert.ErrorIs(t, router.Route(context.Background(), header(v1ContentType), v1Request(t)), shard.ErrNoMembers)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		router := newRouter(t, map[string]*replica{"collector-0": {}})
+		err := router.Route(context.Background(), header(v1ContentType), []byte("not snappy"))
+		assert.ErrorIs(t, err, shard.ErrInvalidRequest)
+		err = router.Route(context.Background(), header("text/plain"), v1Request(t))
+		assert.ErrorIs(t, err, shard.ErrInvalidRequest)
+	})
+
+	t.Run("rejected by a replica", func(t *testing.T) {
+		router := newRouter(t, map[string]*replica{
+			"collector-0": {status: http.StatusUnauthorized},
+			"collector-1": {},
+		})
+		err := router.Route(context.Background(), header(v1ContentType), v1Request(t))
+		require.Error(t, err)
+		status, ok := shard.PermanentStatus(err)
+		assert.True(t, ok)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("failed replica", func(t *testing.T) {
+		router := newRouter(t, map[string]*replica{
+			"collector-0": {status: http.StatusUnauthorized},
+			"collector-1": {status: http.StatusInternalServerError},
+		})
+		err := router.Route(context.Background(), header(v1ContentType), v1Request(t))
+		require.Error(t, err)
+		_, ok := shard.PermanentStatus(err)
+		assert.False(t, ok, "the request is retried when a replica may accept it later")
+	})
+}
+
+func TestEndpointsDiscovery(t *testing.T) {
+	ready, notReady := true, false
+	endpoint := func(ip, pod string, isReady *bool) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{ip},
+			Conditions: discoveryv1.EndpointConditions{Ready: isReady},
+			TargetRef:  &corev1.ObjectReference{Kind: "Pod", Name: pod},
+		}
+	}
+	slice := func(name, service string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "cloudzero",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: service},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints:   endpoints,
+		}
+	}
+
+	client := fake.NewSimpleClientset(
+		slice("collector-a", "collector",
+			endpoint("10.0.0.2", "collector-1", &ready),
+			endpoint("10.0.0.1", "collector-0", nil),
+		),
+		slice("collector-b", "collector",
+			endpoint("10.0.0.3", "collector-2", &notReady),
+			endpoint("10.0.0.1", "collector-0", &ready),
+		),
+		slice("other", "other", endpoint("10.0.0.9", "other-0", &ready)),
+	)
+
+	members, err := shard.NewEndpointsDiscovery(client, "cloudzero", "collector").Members(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []shard.Member{
+		{Name: "collector-0", Address: "10.0.0.1"},
+		{Name: "collector-1", Address: "10.0.0.2"},
+	}, members)
+}
+
+func TestRouter_Refresh(t *testing.T) {
+	discovery := &shard.StaticDiscovery{{Name: "collector-0", Address: "10.0.0.1"}}
+	router := shard.NewRouter(config.Sharding{VirtualNodes: 8}, discovery)
+	require.NoError(t, router.Refresh(context.Background()))
+	first := router.Ring()
+	assert.Equal(t, []string{"collector-0"}, first.Members())
+
+	// A restarted replica keeps its place on the ring.
+	*discovery = shard.StaticDiscovery{{Name: "collector-0", Address: "10.0.0.7"}}
+	require.NoError(t, router.Refresh(context.Background()))
+	assert.Same(t, first, router.Ring())
+
+	*discovery = append(*discovery, shard.Member{Name: "collector-1", Address: "10.0.0.8"})
+	require.NoError(t, router.Refresh(context.Background()))
+	assert.Equal(t, []string{"collector-0", "collector-1"}, router.Ring().Members())
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shard
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/prometheus/prompb"
+	writev2 "github.com/prometheus/prometheus/prompb/io/prometheus/write/v2"
+)
+
+// errInvalidLabelRef is returned when a v2 request references a symbol it
+// does not have.
+var errInvalidLabelRef = errors.New("invalid label reference indices")
+
+// SeriesKey returns the key a series is hashed by: the values of the shard
+// labels it has, or all of its labels when it has none of them. Keying by the
+// labels of the workload keeps the series of a pod together on one shard.
+func SeriesKey(labels map[string]string, shardLabels []string) string {
+	var b strings.Builder
+	for _, name := range shardLabels {
+		if v, ok := labels[name]; ok {
+			b.WriteString(name)
+			b.WriteByte('=')
+			b.WriteString(v)
+			b.WriteByte(0xff)
+		}
+	}
+	if b.Len() > 0 {
+		return b.String()
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(labels[name])
+		b.WriteByte(0xff)
+	}
+	return b.String()
+}
+
+// splitV1 splits a remote_write v1 request by the owner of its series. The
+// metadata of the request is sent to every owner.
+func splitV1(req *prompb.WriteRequest, owner func(labels map[string]string) string) map[string]*prompb.WriteRequest {
+	out := map[string]*prompb.WriteRequest{}
+	for _, ts := range req.Timeseries {
+		labels := make(map[string]string, len(ts.Labels))
+		for _, l := range ts.Labels {
+			labels[l.Name] = l.Value
+		}
+
+		o := owner(labels)
+		part, ok := out[o]
+		if !ok {
+			part = &prompb.WriteRequest{Metadata: req.Metadata}
+			out[o] = part
+		}
+		part.Timeseries = append(part.Timeseries, ts)
+	}
+	return out
+}
+
+// splitV2 splits a remote_write v2 request by the owner of its series. Each
+// part gets its own symbol table holding only the symbols it references.
+func splitV2(req *writev2.Request, owner func(labels map[string]string) string) (map[string]*writev2.Request, error) {
+	symbol := func(ref uint32) (string, error) {
+		if int(ref) >= len(req.Symbols) {
+			return "", errInvalidLabelRef
+		}
+		return req.Symbols[ref], nil
+	}
+
+	type part struct {
+		req     *writev2.Request
+		symbols writev2.SymbolsTable
+	}
+	parts := map[string]*part{}
+
+	for _, ts := range req.Timeseries {
+		if len(ts.LabelsRefs)%2 != 0 {
+			return nil, errInvalidLabelRef
+		}
+		labels := make(map[string]string, len(ts.LabelsRefs)/2)
+		for i := 0; i < len(ts.LabelsRefs); i += 2 {
+			name, err := symbol(ts.LabelsRefs[i])
+			if err != nil {
+				return nil, err
+			}
+			value, err := symbol(ts.LabelsRefs[i+1])
+			if err != nil {
+				return nil, err
+			}
+			labels[name] = value
+		}
+
+		o := owner(labels)
+		p, ok := parts[o]
+		if !ok {
+			p = &part{req: &writev2.Request{}, symbols: writev2.NewSymbolTable()}
+			parts[o] = p
+		}
+
+		// Rewrite every reference of the series into the symbol table of
+		// its part.
+		remap := func(refs []uint32) ([]uint32, error) {
+			out := make([]uint32, len(refs))
+			for i, ref := range refs {
+				s, err := symbol(ref)
+				if err != nil {
+					return nil, err
+				}
+				out[i] = p.symbols.Symbolize(s)
+			}
+			return out, nil
+		}
+
+		var err error
+		if ts.LabelsRefs, err = remap(ts.LabelsRefs); err != nil {
+			return nil, err
+		}
+		exemplars := make([]writev2.Exemplar, len(ts.Exemplars))
+		for i, e := range ts.Exemplars {
+			if e.LabelsRefs, err = remap(e.LabelsRefs); err != nil {
+				return nil, err
+			}
+			exemplars[i] = e
+		}
+		ts.Exemplars = exemplars
+		refs, err := remap([]uint32{ts.Metadata.HelpRef, ts.Metadata.UnitRef})
+		if err != nil {
+			return nil, err
+		}
+		ts.Metadata.HelpRef, ts.Metadata.UnitRef = refs[0], refs[1]
+
+		p.req.Timeseries = append(p.req.Timeseries, ts)
+	}
+
+	out := make(map[string]*writev2.Request, len(parts))
+	for o, p := range parts {
+		p.req.Symbols = p.symbols.Symbols()
+		out[o] = p.req
+	}
+	return out, nil
+}
@@ -70,6 +70,7 @@ func (m *MetricShipper) AbandonFiles(ctx context.Context, payload []*AbandonAPIP
 		req.Header.Set("Authorization", m.setting.GetAPIKey())
 		req.Header.Set(ShipperIDRequestHeader, shipperID)
 		req.Header.Set(AppVersionRequestHeader, build.GetVersion())
+		m.setFingerprintHeader(req)
 
 		// Make sure we set the query parameters for count, cloud_account_id, region, cluster_name
 		q := req.URL.Query()
@@ -92,6 +93,7 @@ func (m *MetricShipper) AbandonFiles(ctx context.Context, payload []*AbandonAPIP
 		if err := InspectHTTPResponse(ctx, resp); err != nil {
 			return err
 		}
+		m.checkFingerprintResponse(ctx, resp)
 
 		defer resp.Body.Close()
 
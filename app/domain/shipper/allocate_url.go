@@ -103,6 +103,7 @@ func (m *MetricShipper) AllocatePresignedURLs(ctx context.Context, files []types
 		req.Header.Set("Authorization", m.setting.GetAPIKey())
 		req.Header.Set(ShipperIDRequestHeader, shipperID)
 		req.Header.Set(AppVersionRequestHeader, build.GetVersion())
+		m.setFingerprintHeader(req)
 
 		// Make sure we set the query parameters for count, expiration, cloud_account_id, region, cluster_name
 		q := req.URL.Query()
@@ -126,6 +127,7 @@ func (m *MetricShipper) AllocatePresignedURLs(ctx context.Context, files []types
 		if err := InspectHTTPResponse(ctx, resp); err != nil {
 			return err
 		}
+		m.checkFingerprintResponse(ctx, resp)
 
 		defer resp.Body.Close()
 
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shipper
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+// canaryClockSkew is how far the clock of the sender of a canary may be ahead
+// of the aggregator. Files flushed earlier than this before the canary was
+// sent cannot hold it and are not read.
+const canaryClockSkew = time.Minute
+
+// TraceCanary looks for a canary in the flushed cost files, both those pending
+// upload and those already uploaded. sentAt is the timestamp of the canary
+// sample, which is used to skip older files and to recognise a canary whose
+// ID label was dropped by the label filters.
+func (m *MetricShipper) TraceCanary(ctx context.Context, id string, sentAt time.Time) (*types.CanaryTrace, error) {
+	trace := &types.CanaryTrace{ID: id}
+
+	// Pending files are searched first, so a file moved to the uploaded
+	// directory while searching is still found there.
+	for _, dir := range []string{"", UploadedSubDirectory} {
+		paths, err := m.store.GetFiles(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the files: %w", err)
+		}
+
+		for _, path := range paths {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if !strings.HasPrefix(filepath.Base(path), disk.CostContentIdentifier+"_") {
+				continue
+			}
+			_, flushedAt, err := disk.FileTimeRange(path)
+			if err != nil || flushedAt.Before(sentAt.Add(-canaryClockSkew)) {
+				continue
+			}
+
+			metrics, err := disk.ReadMetricsFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			for _, metric := range metrics {
+				if metric.MetricName != types.CanaryMetricName {
+					continue
+				}
+				label, ok := metric.Labels[types.CanaryIDLabel]
+				switch {
+				case ok && label == id:
+				case !ok && metric.TimeStamp.Equal(sentAt):
+					trace.LabelDropped = true
+				default:
+					continue
+				}
+
+				trace.File = filepath.Base(path)
+				trace.ReceivedAt = metric.CreatedAt
+				trace.FlushedAt = flushedAt
+				trace.Uploaded = dir == UploadedSubDirectory
+				return trace, nil
+			}
+		}
+	}
+	return trace, nil
+}
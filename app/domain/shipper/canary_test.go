@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shipper_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/shipper"
+	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+func TestShipper_Unit_TraceCanary(t *testing.T) {
+	ctx := context.Background()
+	sentAt := time.Now().Truncate(time.Millisecond)
+	receivedAt := sentAt.Add(50 * time.Millisecond)
+
+	canary := func(labels map[string]string) types.Metric {
+		return types.Metric{
+			ID:         uuid.New(),
+			MetricName: types.CanaryMetricName,
+			CreatedAt:  receivedAt,
+			TimeStamp:  sentAt,
+			Labels:     labels,
+			Value:      "1",
+		}
+	}
+
+	setup := func(t *testing.T, metrics ...types.Metric) (*shipper.MetricShipper, string) {
+		t.Helper()
+		dir := t.TempDir()
+		settings := &config.Settings{Database: config.Database{StoragePath: dir}}
+
+		costStore, err := disk.NewDiskStore(settings.Database, disk.WithContentIdentifier(disk.CostContentIdentifier))
+		require.NoError(t, err)
+		require.NoError(t, costStore.Put(ctx, metrics...))
+		require.NoError(t, costStore.Flush())
+
+		store, err := disk.NewDiskStore(settings.Database)
+		require.NoError(t, err)
+		s, err := shipper.NewMetricShipper(ctx, settings, store)
+		require.NoError(t, err)
+		return s, dir
+	}
+
+	t.Run("flushed and uploaded", func(t *testing.T) {
+		s, dir := setup(t,
+			canary(map[string]string{types.CanaryIDLabel: "other"}),
+			canary(map[string]string{types.CanaryIDLabel: "canary"}),
+		)
+
+		trace, err := s.TraceCanary(ctx, "canary", sentAt)
+		require.NoError(t, err)
+		require.True(t, trace.Flushed())
+		assert.False(t, trace.Uploaded)
+		assert.False(t, trace.LabelDropped)
+		assert.True(t, trace.ReceivedAt.Equal(receivedAt))
+		assert.False(t, trace.FlushedAt.Before(sentAt))
+
+		uploaded := filepath.Join(dir, shipper.UploadedSubDirectory)
+		require.NoError(t, os.MkdirAll(uploaded, 0o755))
+		require.NoError(t, os.Rename(filepath.Join(dir, trace.File), filepath.Join(uploaded, trace.File)))
+
+		trace, err = s.TraceCanary(ctx, "canary", sentAt)
+		require.NoError(t, err)
+		assert.True(t, trace.Flushed())
+		assert.True(t, trace.Uploaded)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		s, _ := setup(t, canary(map[string]string{types.CanaryIDLabel: "other"}))
+
+		trace, err := s.TraceCanary(ctx, "canary", sentAt)
+		require.NoError(t, err)
+		assert.Equal(t, "canary", trace.ID)
+		assert.False(t, trace.Flushed())
+
+		// files flushed long before the canary was sent are not read
+		trace, err = s.TraceCanary(ctx, "other", sentAt.Add(time.Hour))
+		require.NoError(t, err)
+		assert.False(t, trace.Flushed())
+	})
+
+	t.Run("label dropped", func(t *testing.T) {
+		s, _ := setup(t, canary(map[string]string{}))
+
+		trace, err := s.TraceCanary(ctx, "canary", sentAt)
+		require.NoError(t, err)
+		assert.True(t, trace.Flushed())
+		assert.True(t, trace.LabelDropped)
+	})
+}
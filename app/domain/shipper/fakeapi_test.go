@@ -0,0 +1,222 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shipper_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/shipper"
+	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/mock/cloudzeroapi"
+)
+
+const fakeAPIKey = "test-api-key"
+
+// newFakeAPIShipper creates a shipper talking to a fake of the CloudZero API,
+// over a disk store in a temporary directory.
+func newFakeAPIShipper(t *testing.T, opts cloudzeroapi.Options) (*shipper.MetricShipper, *cloudzeroapi.Server, string) {
+	t.Helper()
+	opts.APIKey = fakeAPIKey
+	api := cloudzeroapi.New(opts)
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	tmpDir := getTmpDir(t)
+	settings := getMockSettings(server.URL, tmpDir)
+	settings.Cloudzero.UseHTTP = true
+	settings.Cloudzero.HTTPMaxWait = 10 * time.Millisecond
+	settings.Cloudzero.APIKeyPath = filepath.Join(tmpDir, ".cz-api-key")
+	require.NoError(t, os.WriteFile(settings.Cloudzero.APIKeyPath, []byte(fakeAPIKey), 0o600))
+	require.NoError(t, settings.SetAPIKey())
+
+	store, err := disk.NewDiskStore(settings.Database)
+	require.NoError(t, err)
+	metricShipper, err := shipper.NewMetricShipper(context.Background(), settings, store)
+	require.NoError(t, err)
+	return metricShipper, api, tmpDir
+}
+
+// requireUploaded checks the files are in the fake with the content the
+// shipper sends for them, and were moved to the uploaded directory.
+func requireUploaded(t *testing.T, api *cloudzeroapi.Server, uploadedDir string, files ...types.File) {
+	t.Helper()
+	for _, file := range files {
+		object, ok := api.Object(shipper.GetRemoteFileID(file))
+		require.True(t, ok, "%s was not uploaded", file.UniqueID())
+		uploaded, err := disk.NewMetricFile(filepath.Join(uploadedDir, filepath.Base(file.Location())))
+		require.NoError(t, err, "%s was not marked uploaded", file.UniqueID())
+		data, err := io.ReadAll(uploaded)
+		require.NoError(t, err)
+		assert.Equal(t, data, object.Data)
+	}
+}
+
+func TestShipper_Unit_FakeAPI_HandleRequest(t *testing.T) {
+	metricShipper, api, tmpDir := newFakeAPIShipper(t, cloudzeroapi.Options{})
+	files := createTestFiles(t, tmpDir, 3)
+
+	require.NoError(t, metricShipper.HandleRequest(t.Context(), files))
+
+	requireUploaded(t, api, metricShipper.GetUploadedDir(), files...)
+	assert.Len(t, api.Objects(), 3)
+	assert.Empty(t, api.Abandoned())
+
+	allocation := api.Requests()[0]
+	assert.Equal(t, cloudzeroapi.EndpointUpload, allocation.Endpoint)
+	assert.Contains(t, allocation.URL, "cluster_name=test-cluster")
+	assert.Equal(t, fakeAPIKey, allocation.Header.Get("Authorization"))
+	assert.NotEmpty(t, allocation.Header.Get(shipper.ShipperIDRequestHeader))
+}
+
+func TestShipper_Unit_FakeAPI_Replay(t *testing.T) {
+	metricShipper, api, tmpDir := newFakeAPIShipper(t, cloudzeroapi.Options{})
+	first := createTestFiles(t, tmpDir, 1)
+	require.NoError(t, metricShipper.HandleRequest(t.Context(), first))
+
+	// Ask for the uploaded file again, along with one the shipper never had
+	replayed := shipper.GetRemoteFileID(first[0])
+	api.QueueReplay(replayed, "metrics_1_2.parquet")
+	second := createTestFiles(t, tmpDir, 1)
+	require.NoError(t, metricShipper.HandleRequest(t.Context(), second))
+
+	requireUploaded(t, api, metricShipper.GetUploadedDir(), first[0], second[0])
+	uploads := 0
+	for _, object := range api.Objects() {
+		if object.ReferenceID == replayed {
+			uploads++
+		}
+	}
+	assert.Equal(t, 2, uploads, "the replayed file is uploaded again")
+	assert.Equal(t, []cloudzeroapi.Abandoned{
+		{ReferenceID: "metrics_1_2.parquet", Reason: "failed to find this file locally"},
+	}, api.Abandoned())
+}
+
+func TestShipper_Unit_FakeAPI_PartialFailure(t *testing.T) {
+	metricShipper, api, tmpDir := newFakeAPIShipper(t, cloudzeroapi.Options{})
+	files := createTestFiles(t, tmpDir, 3)
+
+	// One object fails on every attempt, and another is not allocated
+	api.Inject(cloudzeroapi.Fault{
+		Endpoint:     cloudzeroapi.EndpointObject,
+		Status:       http.StatusInternalServerError,
+		ReferenceIDs: []string{shipper.GetRemoteFileID(files[1])},
+	})
+	api.Inject(cloudzeroapi.Fault{
+		Endpoint:     cloudzeroapi.EndpointUpload,
+		ReferenceIDs: []string{shipper.GetRemoteFileID(files[2])},
+	})
+	require.NoError(t, metricShipper.HandleRequest(t.Context(), files))
+
+	requireUploaded(t, api, metricShipper.GetUploadedDir(), files[0])
+	for _, file := range files[1:] {
+		_, ok := api.Object(shipper.GetRemoteFileID(file))
+		assert.False(t, ok)
+		assert.FileExists(t, file.Location(), "the file is left to be sent again")
+	}
+
+	// Once the API recovers, the rest goes through on the next pass
+	api.ClearFaults()
+	retry := make([]types.File, 0, 2)
+	for _, file := range files[1:] {
+		f, err := disk.NewMetricFile(file.Location())
+		require.NoError(t, err)
+		retry = append(retry, f)
+	}
+	require.NoError(t, metricShipper.HandleRequest(t.Context(), retry))
+	requireUploaded(t, api, metricShipper.GetUploadedDir(), files...)
+}
+
+func TestShipper_Unit_FakeAPI_TransientFailure(t *testing.T) {
+	metricShipper, api, tmpDir := newFakeAPIShipper(t, cloudzeroapi.Options{})
+	files := createTestFiles(t, tmpDir, 1)
+
+	api.Inject(cloudzeroapi.Fault{Endpoint: cloudzeroapi.EndpointUpload, Status: http.StatusServiceUnavailable, Times: 1})
+	api.Inject(cloudzeroapi.Fault{Endpoint: cloudzeroapi.EndpointObject, Status: http.StatusBadGateway, Times: 1})
+	require.NoError(t, metricShipper.HandleRequest(t.Context(), files))
+
+	requireUploaded(t, api, metricShipper.GetUploadedDir(), files...)
+	statuses := []int{}
+	for _, req := range api.Requests() {
+		statuses = append(statuses, req.Status)
+	}
+	assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusBadGateway, http.StatusOK}, statuses)
+}
+
+func TestShipper_Unit_FakeAPI_Unauthorized(t *testing.T) {
+	metricShipper, api, tmpDir := newFakeAPIShipper(t, cloudzeroapi.Options{})
+	files := createTestFiles(t, tmpDir, 1)
+
+	api.Inject(cloudzeroapi.Fault{Endpoint: cloudzeroapi.EndpointUpload, Status: http.StatusUnauthorized})
+	err := metricShipper.HandleRequest(t.Context(), files)
+	require.ErrorIs(t, err, shipper.ErrUnauthorized)
+	assert.Empty(t, api.Objects())
+	assert.FileExists(t, files[0].Location())
+}
+
+func TestShipper_Unit_FakeAPI_Latency(t *testing.T) {
+	metricShipper, api, tmpDir := newFakeAPIShipper(t, cloudzeroapi.Options{})
+	files := createTestFiles(t, tmpDir, 1)
+
+	// The allocation outlasts the send timeout on every attempt
+	api.Inject(cloudzeroapi.Fault{Endpoint: cloudzeroapi.EndpointUpload, Latency: 1500 * time.Millisecond})
+	err := metricShipper.HandleRequest(t.Context(), files)
+	require.ErrorIs(t, err, shipper.ErrHTTPRequestFailed)
+	assert.Empty(t, api.Objects())
+}
+
+func TestShipper_Unit_FakeAPI_ExpiredURL(t *testing.T) {
+	now := time.Now()
+	metricShipper, api, tmpDir := newFakeAPIShipper(t, cloudzeroapi.Options{
+		URLExpiry: time.Minute,
+		Now:       func() time.Time { return now },
+	})
+	files := createTestFiles(t, tmpDir, 1)
+
+	response, err := metricShipper.AllocatePresignedURLs(t.Context(), files)
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+
+	err = metricShipper.UploadFile(t.Context(), &shipper.UploadFileRequest{
+		File:         files[0],
+		PresignedURL: response.Allocation[shipper.GetRemoteFileID(files[0])],
+	})
+	require.ErrorIs(t, err, shipper.ErrExpiredURL)
+	assert.Empty(t, api.Objects())
+}
+
+func TestShipper_Unit_FakeAPI_PurgeUploaded(t *testing.T) {
+	metricShipper, api, tmpDir := newFakeAPIShipper(t, cloudzeroapi.Options{})
+	files := createTestFiles(t, tmpDir, 2)
+
+	api.Inject(cloudzeroapi.Fault{
+		Endpoint:     cloudzeroapi.EndpointObject,
+		Status:       http.StatusForbidden,
+		ReferenceIDs: []string{shipper.GetRemoteFileID(files[1])},
+	})
+	require.NoError(t, metricShipper.HandleRequest(t.Context(), files))
+	requireUploaded(t, api, metricShipper.GetUploadedDir(), files[0])
+
+	metrics, err := shipper.InitMetrics()
+	require.NoError(t, err)
+	dm := &shipper.DiskManager{Metrics: metrics, StoragePath: tmpDir}
+	removed, err := dm.PurgeFilesBefore(t.Context(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	// Only the uploaded file is purged, the one which failed is kept
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, filepath.Join(metricShipper.GetUploadedDir(), filepath.Base(files[0].Location())))
+	assert.FileExists(t, files[1].Location())
+}
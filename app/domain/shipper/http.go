@@ -12,7 +12,9 @@ import (
 	"time" // Keep for HTTPinitialRetryDelay etc. if used in client config
 
 	config "github.com/cloudzero/cloudzero-agent/app/config/gator" // Assuming this is for your span logger
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
 	"github.com/cloudzero/cloudzero-agent/app/inspector"
+	"github.com/cloudzero/cloudzero-agent/app/logging/instr"
 	"github.com/hashicorp/go-retryablehttp"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -78,11 +80,20 @@ func retryableHTTPKVsToMap(keysAndValues ...interface{}) map[string]interface{}
 // Ensure it implements the LeveledLogger interface if you want more control
 var _ retryablehttp.LeveledLogger = (*ZerologRetryableHTTPAdapter)(nil)
 
-func NewHTTPClient(ctx context.Context, s *config.Settings) *retryablehttp.Client {
+// NewHTTPClient creates the client of the CloudZero API and of the uploads. It
+// goes through the egress proxy and trusts the CA bundle of the configuration.
+func NewHTTPClient(ctx context.Context, s *config.Settings) (*retryablehttp.Client, error) {
+	egress, err := transport.New(s.Egress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure the egress transport: %w", err)
+	}
+
 	httpClient := retryablehttp.NewClient()
 	httpClient.Logger = NewZerologRetryableHTTPAdapter(log.Ctx(ctx), log.Ctx(ctx).GetLevel())
 	httpClient.HTTPClient = &http.Client{
 		Timeout: s.Cloudzero.SendTimeout,
+		// Each attempt is traced, and propagates the trace of the upload
+		Transport: instr.NewTransport(egress),
 	}
 	httpClient.RetryMax = s.Cloudzero.HTTPMaxRetries
 	httpClient.RetryWaitMax = s.Cloudzero.HTTPMaxWait
@@ -105,7 +116,7 @@ func NewHTTPClient(ctx context.Context, s *config.Settings) *retryablehttp.Clien
 		return nil, errors.Join(fmt.Errorf("giving up after %d attempt(s): %w", numTries, err), ErrHTTPRequestFailed)
 	}
 
-	return httpClient
+	return httpClient, nil
 }
 
 func InspectHTTPResponse(ctx context.Context, resp *http.Response) error {
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shipper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+// identityFileName is the file in the storage path recording the fingerprints
+// seen for the account and cluster name.
+const identityFileName = ".cluster-identity.json"
+
+// initClusterIdentity computes the fingerprint of the cluster. Failing to do
+// so only means requests are sent without it, so it is logged and ignored.
+func (m *MetricShipper) initClusterIdentity(ctx context.Context) {
+	fingerprint, err := k8s.GetClusterFingerprint(ctx)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("failed to compute the cluster fingerprint, requests are sent without it")
+		return
+	}
+	if err := m.SetClusterFingerprint(ctx, fingerprint); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("failed to record the cluster fingerprint")
+	}
+}
+
+// SetClusterFingerprint sets the fingerprint sent with requests to the
+// CloudZero API, and records it as seen.
+func (m *MetricShipper) SetClusterFingerprint(ctx context.Context, fingerprint string) error {
+	m.identityMu.Lock()
+	m.clusterFingerprint = fingerprint
+	m.identityMu.Unlock()
+
+	return m.observeFingerprint(ctx, fingerprint, types.FingerprintSourceLocal)
+}
+
+// ClusterIdentity returns the fingerprint of the cluster, and the fingerprints
+// seen for its account and cluster name.
+func (m *MetricShipper) ClusterIdentity() (*types.ClusterIdentity, error) {
+	m.identityMu.Lock()
+	defer m.identityMu.Unlock()
+	return m.loadClusterIdentity()
+}
+
+// setFingerprintHeader adds the fingerprint of the cluster to a request, when
+// it is known.
+func (m *MetricShipper) setFingerprintHeader(req *retryablehttp.Request) {
+	m.identityMu.Lock()
+	defer m.identityMu.Unlock()
+	if m.clusterFingerprint != "" {
+		req.Header.Set(types.ClusterFingerprintHeader, m.clusterFingerprint)
+	}
+}
+
+// checkFingerprintResponse records the fingerprint the CloudZero API has on
+// record for the account and cluster name, when it returned one.
+//
+// Another cluster using the same account and cluster name can only be seen
+// through this header: the local records are always those of this cluster.
+// Until the CloudZero API returns the header on upload allocations, no
+// conflict is detected.
+func (m *MetricShipper) checkFingerprintResponse(ctx context.Context, resp *http.Response) {
+	fingerprint := resp.Header.Get(types.ClusterFingerprintHeader)
+	if fingerprint == "" {
+		return
+	}
+	if err := m.observeFingerprint(ctx, fingerprint, types.FingerprintSourceAPI); err != nil {
+		log.Ctx(ctx).Warn().Err(err).Msg("failed to record the cluster fingerprint returned by the CloudZero API")
+	}
+}
+
+// observeFingerprint records a fingerprint as seen now, and updates the
+// number of conflicting fingerprints. A new local fingerprint expires the
+// local records of the previous ones, see types.ClusterIdentity.Observe.
+func (m *MetricShipper) observeFingerprint(ctx context.Context, fingerprint, source string) error {
+	m.identityMu.Lock()
+	defer m.identityMu.Unlock()
+
+	identity, err := m.loadClusterIdentity()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	identity.Observe(fingerprint, source, now)
+
+	conflicts := identity.Conflicts(now)
+	metricClusterFingerprintConflicts.WithLabelValues().Set(float64(len(conflicts)))
+	if identity.Fingerprint != "" && fingerprint != identity.Fingerprint {
+		log.Ctx(ctx).Error().
+			Str("clusterName", m.setting.ClusterName).
+			Str("fingerprint", identity.Fingerprint).
+			Str("otherFingerprint", fingerprint).
+			Str("source", source).
+			Msg("another cluster uses the same account and cluster name, their cost data is merged: give each cluster a unique clusterName")
+	}
+
+	data, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the cluster identity: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(m.GetBaseDir(), identityFileName), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write the cluster identity: %w", err)
+	}
+	return nil
+}
+
+// loadClusterIdentity reads the recorded fingerprints. It must be called with
+// identityMu held.
+func (m *MetricShipper) loadClusterIdentity() (*types.ClusterIdentity, error) {
+	identity := &types.ClusterIdentity{Seen: []types.FingerprintRecord{}}
+
+	data, err := os.ReadFile(filepath.Join(m.GetBaseDir(), identityFileName))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read the cluster identity: %w", err)
+	default:
+		if err := json.Unmarshal(data, identity); err != nil {
+			return nil, fmt.Errorf("failed to decode the cluster identity: %w", err)
+		}
+	}
+
+	identity.Fingerprint = m.clusterFingerprint
+	return identity, nil
+}
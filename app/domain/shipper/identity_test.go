@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package shipper_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/shipper"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+// fingerprintRoundTripper captures the fingerprint sent with a request, and
+// replies with the fingerprint the CloudZero API has on record.
+type fingerprintRoundTripper struct {
+	sent     string
+	recorded string
+	body     any
+}
+
+func (f *fingerprintRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.sent = req.Header.Get(types.ClusterFingerprintHeader)
+	enc, err := json.Marshal(f.body)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	if f.recorded != "" {
+		header.Set(types.ClusterFingerprintHeader, f.recorded)
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewBuffer(enc)), Header: header}, nil
+}
+
+func TestShipper_Unit_ClusterIdentity(t *testing.T) {
+	files := createTestFiles(t, getTmpDir(t), 1)
+	allocation := map[string]string{shipper.GetRemoteFileID(files[0]): "https://example.com/file"}
+
+	newShipper := func(t *testing.T, dir string, rt http.RoundTripper) *shipper.MetricShipper {
+		t.Helper()
+		s, err := shipper.NewMetricShipper(context.Background(), getMockSettings("https://example.com/api", dir), nil)
+		require.NoError(t, err)
+		s.HTTPClient.HTTPClient.Transport = rt
+		return s
+	}
+
+	t.Run("no fingerprint", func(t *testing.T) {
+		rt := &fingerprintRoundTripper{body: allocation}
+		_, err := newShipper(t, getTmpDir(t), rt).AllocatePresignedURLs(t.Context(), files)
+		require.NoError(t, err)
+		assert.Empty(t, rt.sent)
+	})
+
+	t.Run("same fingerprint", func(t *testing.T) {
+		rt := &fingerprintRoundTripper{body: allocation, recorded: "cluster-a"}
+		s := newShipper(t, getTmpDir(t), rt)
+		require.NoError(t, s.SetClusterFingerprint(t.Context(), "cluster-a"))
+
+		_, err := s.AllocatePresignedURLs(t.Context(), files)
+		require.NoError(t, err)
+		assert.Equal(t, "cluster-a", rt.sent)
+
+		identity, err := s.ClusterIdentity()
+		require.NoError(t, err)
+		assert.Equal(t, "cluster-a", identity.Fingerprint)
+		assert.Len(t, identity.Seen, 2)
+		assert.Empty(t, identity.Conflicts(identity.Seen[0].LastSeen))
+	})
+
+	t.Run("conflicting fingerprint", func(t *testing.T) {
+		rt := &fingerprintRoundTripper{body: allocation, recorded: "cluster-b"}
+		dir := getTmpDir(t)
+		s := newShipper(t, dir, rt)
+		require.NoError(t, s.SetClusterFingerprint(t.Context(), "cluster-a"))
+
+		_, err := s.AllocatePresignedURLs(t.Context(), files)
+		require.NoError(t, err)
+
+		// the record survives a restart
+		identity, err := newShipper(t, dir, rt).ClusterIdentity()
+		require.NoError(t, err)
+		assert.Empty(t, identity.Fingerprint)
+		var fingerprints []string
+		for _, r := range identity.Seen {
+			fingerprints = append(fingerprints, r.Source+":"+r.Fingerprint)
+		}
+		assert.ElementsMatch(t, []string{"local:cluster-a", "api:cluster-b"}, fingerprints)
+
+		identity, err = s.ClusterIdentity()
+		require.NoError(t, err)
+		conflicts := identity.Conflicts(identity.Seen[0].LastSeen)
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, "cluster-b", conflicts[0].Fingerprint)
+	})
+
+	t.Run("changed local fingerprint", func(t *testing.T) {
+		rt := &fingerprintRoundTripper{body: allocation}
+		dir := getTmpDir(t)
+		require.NoError(t, newShipper(t, dir, rt).SetClusterFingerprint(t.Context(), "cluster-a"))
+
+		// the cluster was rebuilt in place, its previous fingerprint is not
+		// another cluster
+		s := newShipper(t, dir, rt)
+		require.NoError(t, s.SetClusterFingerprint(t.Context(), "cluster-a2"))
+
+		identity, err := s.ClusterIdentity()
+		require.NoError(t, err)
+		require.Len(t, identity.Seen, 1)
+		assert.Equal(t, "cluster-a2", identity.Seen[0].Fingerprint)
+		assert.Empty(t, identity.Conflicts(identity.Seen[0].LastSeen))
+	})
+}
@@ -232,6 +232,16 @@ var (
 		},
 		[]string{"error_status_code"},
 	)
+
+	// Cluster Identity
+	// ----------------------------------------------------------
+	metricClusterFingerprintConflicts = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "shipper_cluster_fingerprint_conflicts",
+			Help: "Number of other clusters recently seen with the same account and cluster name",
+		},
+		[]string{},
+	)
 )
 
 func InitMetrics() (*instr.PrometheusMetrics, error) {
@@ -277,6 +287,9 @@ func InitMetrics() (*instr.PrometheusMetrics, error) {
 			metricDiskCleanupSuccessTotal,
 			metricDiskCleanupPercentage,
 			metricDiskHandleErrorTotal,
+
+			// cluster identity
+			metricClusterFingerprintConflicts,
 		),
 	)
 }
@@ -40,6 +40,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
@@ -96,6 +97,10 @@ type MetricShipper struct {
 	// or explicit shutdown requests from the application lifecycle manager.
 	cancel context.CancelFunc
 
+	// running is set while Run executes the service loop, so the shipper can be
+	// supervised as a types.Runnable, such as by a leader elector.
+	running atomic.Bool
+
 	// HTTPClient provides the configured HTTP client for CloudZero API communication.
 	// Includes retry logic, timeout configuration, authentication, and connection pooling
 	// optimized for reliable metric upload operations in production environments.
@@ -116,6 +121,14 @@ type MetricShipper struct {
 	// in multi-instance deployments and provides audit trails for billing reconciliation.
 	// Uses hostname if available, otherwise generates a UUID for unique identification.
 	shipperID string
+
+	// clusterFingerprint identifies the cluster on requests to the CloudZero API, so
+	// that clusters sharing an account and cluster name can be told apart. Empty until
+	// computed at startup, or when it could not be computed.
+	clusterFingerprint string
+
+	// identityMu guards clusterFingerprint and the record of fingerprints seen.
+	identityMu sync.Mutex
 }
 
 // NewMetricShipper creates a fully configured MetricShipper instance for CloudZero metric upload operations.
@@ -150,7 +163,11 @@ func NewMetricShipper(ctx context.Context, s *config.Settings, store types.Reada
 	ctx, cancel := context.WithCancel(ctx)
 
 	// Initialize an HTTP client with the specified timeout
-	httpClient := NewHTTPClient(ctx, s)
+	httpClient, err := NewHTTPClient(ctx, s)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
 
 	// create the metrics listener
 	metrics, err := InitMetrics()
@@ -214,6 +231,9 @@ func (m *MetricShipper) GetMetricHandler() http.Handler {
 // The method blocks until shutdown is requested, making it suitable for use as
 // the main execution path for shipper-focused services or containers.
 func (m *MetricShipper) Run() error {
+	m.running.Store(true)
+	defer m.running.Store(false)
+
 	// create the required directories for this application
 	if err := os.MkdirAll(m.GetUploadedDir(), filePermissions); err != nil {
 		return errors.Join(ErrCreateDirectory, fmt.Errorf("failed to create the uploaded directory: %w", err))
@@ -231,6 +251,8 @@ func (m *MetricShipper) Run() error {
 
 	log.Ctx(m.ctx).Info().Msg("Shipper service starting ...")
 
+	m.initClusterIdentity(m.ctx)
+
 	// run at the start
 	if err := m.runShipper(m.ctx); err != nil {
 		log.Ctx(m.ctx).Err(err).Msg("Failed to run shipper")
@@ -412,8 +434,11 @@ func (m *MetricShipper) HandleRequest(ctx context.Context, files []types.File) e
 
 			// search the file tree for the replay request files
 			for replayRefID, replayURL := range urlResponse.Replay {
-				if paths, err := m.store.Find(ctx, GetRootFileID(replayRefID), ".json.br"); err == nil {
+				if paths, err := m.store.Find(ctx, GetRootFileID(replayRefID), ""); err == nil {
 					for _, path := range paths {
+						if !disk.IsMetricsFile(path) {
+							continue
+						}
 						if file, err := disk.NewMetricFile(path); err == nil {
 							requests = append(requests, &UploadFileRequest{
 								File:         file,
@@ -502,6 +527,11 @@ func (m *MetricShipper) Shutdown() error {
 	return nil
 }
 
+// IsRunning returns true while the service loop runs.
+func (m *MetricShipper) IsRunning() bool {
+	return m.running.Load()
+}
+
 // Flush will attempt to process all files
 // and push them to the remote
 func (m *MetricShipper) Flush(ctx context.Context) {
@@ -4,7 +4,9 @@
 package shipper_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -16,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/parquet-go/parquet-go"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -25,6 +28,7 @@ import (
 	"github.com/cloudzero/cloudzero-agent/app/domain/shipper"
 	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
 	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
 )
 
 func TestShipper_Unit_PerformShipping(t *testing.T) {
@@ -411,6 +415,54 @@ func TestShipper_Unit_UploadFile_Success(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestShipper_Unit_UploadFile_Codecs checks that the codec a file is stored
+// with never reaches the upload: every file is sent as the same Parquet
+// payload.
+func TestShipper_Unit_UploadFile_Codecs(t *testing.T) {
+	for _, codec := range compress.Codecs {
+		t.Run(string(codec), func(t *testing.T) {
+			tmpDir := getTmpDir(t)
+
+			var uploaded []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				uploaded = body
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			settings := getMockSettings(server.URL, tmpDir)
+			metricShipper, err := shipper.NewMetricShipper(context.Background(), settings, nil)
+			require.NoError(t, err)
+
+			path := filepath.Join(tmpDir, "metrics_1_2.json"+codec.Extension())
+			out, err := os.Create(path)
+			require.NoError(t, err)
+			compressor, err := compress.NewWriter(out, codec, -1)
+			require.NoError(t, err)
+			require.NoError(t, json.NewEncoder(compressor).Encode(testMetrics))
+			require.NoError(t, compressor.Close())
+			require.NoError(t, out.Close())
+
+			file, err := disk.NewMetricFile(path)
+			require.NoError(t, err)
+			defer file.Close()
+
+			err = metricShipper.UploadFile(context.Background(), &shipper.UploadFileRequest{
+				File:         file,
+				PresignedURL: server.URL,
+			})
+			require.NoError(t, err)
+
+			require.True(t, bytes.HasPrefix(uploaded, []byte("PAR1")), "the upload is not a Parquet file")
+			reader := parquet.NewGenericReader[types.ParquetMetric](bytes.NewReader(uploaded))
+			defer reader.Close()
+			assert.Equal(t, len(testMetrics), int(reader.NumRows()))
+		})
+	}
+}
+
 func TestShipper_Unit_UploadFile_HTTPError(t *testing.T) {
 	tmpDir := getTmpDir(t)
 	mockURL := "https://s3.amazonaws.com/bucket/file.parquet?signature=abc123"
@@ -441,6 +493,54 @@ func TestShipper_Unit_UploadFile_HTTPError(t *testing.T) {
 	assert.ErrorIs(t, err, shipper.ErrHTTPUnknown)
 }
 
+func TestShipper_Unit_UploadFile_Forbidden(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr error
+	}{
+		{
+			name:    "expired",
+			body:    `<Error><Code>AccessDenied</Code><Message>Request has expired</Message></Error>`,
+			wantErr: shipper.ErrExpiredURL,
+		},
+		{
+			name:    "denied",
+			body:    `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`,
+			wantErr: shipper.ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := getTmpDir(t)
+			mockURL := "https://s3.amazonaws.com/bucket/file.parquet?signature=abc123"
+
+			mockRoundTripper := &MockRoundTripper{
+				status:                 http.StatusForbidden,
+				mockResponseBodyString: tt.body,
+			}
+
+			settings := getMockSettings(mockURL, tmpDir)
+
+			metricShipper, err := shipper.NewMetricShipper(context.Background(), settings, nil)
+			require.NoError(t, err)
+			metricShipper.HTTPClient.HTTPClient.Transport = mockRoundTripper
+
+			files := createTestFiles(t, tmpDir, 1)
+
+			err = metricShipper.UploadFile(context.Background(), &shipper.UploadFileRequest{
+				File:         files[0],
+				PresignedURL: mockURL,
+			})
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.wantErr != shipper.ErrExpiredURL {
+				assert.NotErrorIs(t, err, shipper.ErrExpiredURL)
+			}
+		})
+	}
+}
+
 func TestShipper_Unit_UploadFile_CreateRequestError(t *testing.T) {
 	// Use an invalid URL to force request creation error
 	tmpDir := getTmpDir(t)
@@ -55,16 +55,18 @@ func (m *MetricShipper) UploadFile(ctx context.Context, req *UploadFileRequest)
 				return err
 			}
 
-			// check for invalid urls
+			// check for invalid urls. S3 rejects an expired presigned URL
+			// with a 403 whose XML body says so; any other 403 is inspected
+			// below, so the body is put back once read.
 			if resp != nil && resp.StatusCode == http.StatusForbidden {
 				// check the message
-				if raw, err := io.ReadAll(resp.Body); err != nil {
-					// search in the xml response
-					if strings.Contains(string(raw), "Request has expired") {
-						resp.Body.Close()
-						return ErrExpiredURL
-					}
+				raw, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				// search in the xml response
+				if err == nil && strings.Contains(string(raw), "Request has expired") {
+					return ErrExpiredURL
 				}
+				resp.Body = io.NopCloser(bytes.NewReader(raw))
 			}
 
 			// inspect
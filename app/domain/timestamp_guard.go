@@ -0,0 +1,302 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+)
+
+// RemoteWriteVersionHeader is the header remote_write clients send the
+// version of the protocol in.
+const RemoteWriteVersionHeader = "X-Prometheus-Remote-Write-Version"
+
+const (
+	// skewWindow is the number of requests the clock offset of a source is
+	// estimated over, so a single delayed batch does not flag it.
+	skewWindow = 16
+
+	// sourceRetention is how long a source is remembered after its last
+	// request.
+	sourceRetention = time.Hour
+
+	// maxOffendingSeries is the number of offending label sets logged per
+	// request.
+	maxOffendingSeries = 5
+
+	// unknownSource names sources whose address is not known.
+	unknownSource = "unknown"
+)
+
+var (
+	// metricsTimestampOutOfBounds counts the samples received outside the
+	// tolerances of the guard, by source and scrape target.
+	metricsTimestampOutOfBounds = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "metrics_timestamp_out_of_bounds_total",
+			Help: "Total number of samples received with a timestamp outside the ingest tolerances",
+		},
+		[]string{"source", "job", "instance", "direction", "action"},
+	)
+
+	// remoteWriteSourceClockOffset is the typical offset of the newest
+	// samples of a source from the clock of the collector.
+	remoteWriteSourceClockOffset = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "remote_write_source_clock_offset_seconds",
+			Help: "Median offset of the newest samples of the requests of a remote_write source from the clock of the collector",
+		},
+		[]string{"source", "user_agent"},
+	)
+
+	// remoteWriteSourceClockSkewed flags the sources whose offset is beyond
+	// the skew threshold.
+	remoteWriteSourceClockSkewed = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "remote_write_source_clock_skewed",
+			Help: "Whether the clock of a remote_write source is skewed from the clock of the collector (1) or not (0)",
+		},
+		[]string{"source", "user_agent"},
+	)
+)
+
+// RemoteWriteSource identifies the client of a remote_write request.
+type RemoteWriteSource struct {
+	// Address is the host of the client, without the port.
+	Address string
+	// UserAgent is the User-Agent header of the request, such as
+	// Prometheus/3.1.0.
+	UserAgent string
+	// Version is the X-Prometheus-Remote-Write-Version header of the request.
+	Version string
+}
+
+func (s RemoteWriteSource) name() string {
+	if s.Address == "" {
+		return unknownSource
+	}
+	return s.Address
+}
+
+// sourceKey identifies a source in the guard.
+type sourceKey struct {
+	address, userAgent string
+}
+
+// sourceClock is what the guard knows about the clock of a source.
+type sourceClock struct {
+	// offsets are the offsets of the newest sample of the last requests,
+	// in a ring of skewWindow.
+	offsets  []time.Duration
+	next     int
+	skewed   bool
+	lastSeen time.Time
+}
+
+// median returns the median of the offsets.
+func (c *sourceClock) median() time.Duration {
+	sorted := slices.Clone(c.offsets)
+	slices.Sort(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// TimestampGuard checks the timestamps of the samples received against the
+// clock of the collector. Samples outside the tolerances are dropped or
+// clamped depending on the mode, and the clock offset of every source is
+// estimated from the newest samples of its requests, since a healthy source
+// sends samples which were scraped moments ago.
+type TimestampGuard struct {
+	mode            string
+	futureTolerance time.Duration
+	pastTolerance   time.Duration
+	skewThreshold   time.Duration
+
+	mu        sync.Mutex
+	sources   map[sourceKey]*sourceClock
+	lastPrune time.Time
+}
+
+// NewTimestampGuard creates a TimestampGuard. An empty mode turns the guard
+// off, while the clock offsets of the sources are still tracked.
+func NewTimestampGuard(cfg config.Ingest) *TimestampGuard {
+	g := &TimestampGuard{
+		mode:            cfg.TimestampGuard,
+		futureTolerance: cfg.FutureTolerance,
+		pastTolerance:   cfg.PastTolerance,
+		skewThreshold:   cfg.SkewThreshold,
+		sources:         map[sourceKey]*sourceClock{},
+	}
+	if g.futureTolerance <= 0 {
+		g.futureTolerance = config.DefaultIngestFutureTolerance
+	}
+	if g.pastTolerance <= 0 {
+		g.pastTolerance = config.DefaultIngestPastTolerance
+	}
+	if g.skewThreshold <= 0 {
+		g.skewThreshold = config.DefaultIngestSkewThreshold
+	}
+	return g
+}
+
+// offendingKey identifies the scrape target of samples outside the
+// tolerances.
+type offendingKey struct {
+	job, instance, direction string
+}
+
+// TimestampCheck checks the samples of one request, received at now.
+type TimestampCheck struct {
+	guard  *TimestampGuard
+	source RemoteWriteSource
+	now    time.Time
+	// lower and upper are the bounds of the accepted timestamps, in
+	// milliseconds.
+	lower, upper int64
+
+	newest    int64
+	samples   int
+	offending map[offendingKey]int
+	series    []map[string]string
+}
+
+// Begin starts checking the samples of a request of the source.
+func (g *TimestampGuard) Begin(source RemoteWriteSource, now time.Time) *TimestampCheck {
+	return &TimestampCheck{
+		guard:  g,
+		source: source,
+		now:    now,
+		lower:  now.Add(-g.pastTolerance).UnixMilli(),
+		upper:  now.Add(g.futureTolerance).UnixMilli(),
+	}
+}
+
+// Sample checks the timestamp of a sample of the series with the labels, in
+// milliseconds. It returns the timestamp to store the sample with, and false
+// when the sample is to be dropped.
+func (c *TimestampCheck) Sample(labels map[string]string, ts int64) (int64, bool) {
+	if c.samples == 0 || ts > c.newest {
+		c.newest = ts
+	}
+	c.samples++
+
+	var direction string
+	switch {
+	case c.guard.mode != config.TimestampGuardReject && c.guard.mode != config.TimestampGuardClamp:
+		return ts, true
+	case ts > c.upper:
+		direction = "future"
+	case ts < c.lower:
+		direction = "past"
+	default:
+		return ts, true
+	}
+
+	if c.offending == nil {
+		c.offending = map[offendingKey]int{}
+	}
+	c.offending[offendingKey{job: labels["job"], instance: labels["instance"], direction: direction}]++
+	if len(c.series) < maxOffendingSeries {
+		c.series = append(c.series, labels)
+	}
+
+	if c.guard.mode == config.TimestampGuardClamp {
+		return c.now.UnixMilli(), true
+	}
+	return 0, false
+}
+
+// Done records the samples outside the tolerances, and updates the clock
+// offset of the source.
+func (c *TimestampCheck) Done(ctx context.Context) {
+	if len(c.offending) > 0 {
+		total := 0
+		for key, count := range c.offending {
+			metricsTimestampOutOfBounds.WithLabelValues(c.source.name(), key.job, key.instance, key.direction, c.guard.mode).Add(float64(count))
+			total += count
+		}
+		log.Ctx(ctx).Warn().
+			Str("source", c.source.name()).
+			Str("userAgent", c.source.UserAgent).
+			Str("remoteWriteVersion", c.source.Version).
+			Str("action", c.guard.mode).
+			Int("samples", total).
+			Interface("series", c.series).
+			Msg("received samples with a timestamp outside the ingest tolerances")
+	}
+
+	if c.samples > 0 {
+		c.guard.observe(ctx, c.source, c.now, time.UnixMilli(c.newest).Sub(c.now))
+	}
+}
+
+// observe records the offset of the newest sample of a request of the source.
+func (g *TimestampGuard) observe(ctx context.Context, source RemoteWriteSource, now time.Time, offset time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	key := sourceKey{address: source.name(), userAgent: source.UserAgent}
+	clock, ok := g.sources[key]
+	if !ok {
+		clock = &sourceClock{}
+		g.sources[key] = clock
+	}
+	if len(clock.offsets) < skewWindow {
+		clock.offsets = append(clock.offsets, offset)
+	} else {
+		clock.offsets[clock.next] = offset
+		clock.next = (clock.next + 1) % skewWindow
+	}
+	clock.lastSeen = now
+
+	median := clock.median()
+	skewed := median > g.skewThreshold || median < -g.skewThreshold
+	remoteWriteSourceClockOffset.WithLabelValues(key.address, key.userAgent).Set(median.Seconds())
+	if !ok || skewed != clock.skewed {
+		value := 0.0
+		if skewed {
+			value = 1
+		}
+		remoteWriteSourceClockSkewed.WithLabelValues(key.address, key.userAgent).Set(value)
+
+		logger := log.Ctx(ctx).With().
+			Str("source", key.address).
+			Str("userAgent", key.userAgent).
+			Str("remoteWriteVersion", source.Version).
+			Dur("offset", median).
+			Logger()
+		switch {
+		case skewed:
+			logger.Warn().Msg("the clock of a remote_write source is skewed")
+		case clock.skewed:
+			logger.Info().Msg("the clock of a remote_write source is no longer skewed")
+		}
+		clock.skewed = skewed
+	}
+
+	g.prune(now)
+}
+
+// prune forgets the sources which have not sent anything within the
+// retention. The lock must be held.
+func (g *TimestampGuard) prune(now time.Time) {
+	if now.Sub(g.lastPrune) < sourceRetention/4 {
+		return
+	}
+	g.lastPrune = now
+	for key, clock := range g.sources {
+		if now.Sub(clock.lastSeen) > sourceRetention {
+			delete(g.sources, key)
+			remoteWriteSourceClockOffset.DeleteLabelValues(key.address, key.userAgent)
+			remoteWriteSourceClockSkewed.DeleteLabelValues(key.address, key.userAgent)
+		}
+	}
+}
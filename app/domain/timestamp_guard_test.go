@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+)
+
+func TestTimestampGuard_Modes(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	labels := map[string]string{"__name__": "up", "job": "guard-test", "instance": "node-1:9100"}
+	samples := []int64{
+		now.UnixMilli(),                                        // now
+		now.Add(-time.Hour).UnixMilli(),                        // within the past tolerance
+		now.Add(5 * time.Minute).UnixMilli(),                   // within the future tolerance
+		now.Add(3 * time.Hour).UnixMilli(),                     // in the future
+		time.Unix(0, 0).UnixMilli(),                            // in 1970
+		now.Add(-48 * time.Hour).UnixMilli(),                   // too old
+		now.Add(10*time.Minute + time.Millisecond).UnixMilli(), // just beyond the future tolerance
+	}
+
+	for _, tt := range []struct {
+		mode     string
+		expected []int64
+	}{
+		{config.TimestampGuardOff, samples},
+		{config.TimestampGuardReject, samples[:3]},
+		{config.TimestampGuardClamp, append(samples[:3:3], now.UnixMilli(), now.UnixMilli(), now.UnixMilli(), now.UnixMilli())},
+	} {
+		t.Run(tt.mode, func(t *testing.T) {
+			guard := NewTimestampGuard(config.Ingest{TimestampGuard: tt.mode, PastTolerance: 24 * time.Hour})
+			source := RemoteWriteSource{Address: "10.0.0.1-" + tt.mode}
+			future := metricsTimestampOutOfBounds.WithLabelValues(source.name(), "guard-test", "node-1:9100", "future", tt.mode)
+			past := metricsTimestampOutOfBounds.WithLabelValues(source.name(), "guard-test", "node-1:9100", "past", tt.mode)
+
+			check := guard.Begin(source, now)
+			var kept []int64
+			for _, ts := range samples {
+				if ts, ok := check.Sample(labels, ts); ok {
+					kept = append(kept, ts)
+				}
+			}
+			check.Done(context.Background())
+
+			assert.Equal(t, tt.expected, kept)
+			if tt.mode == config.TimestampGuardOff {
+				assert.Zero(t, testutil.ToFloat64(future))
+				return
+			}
+			assert.Equal(t, 2.0, testutil.ToFloat64(future))
+			assert.Equal(t, 2.0, testutil.ToFloat64(past))
+		})
+	}
+}
+
+func TestTimestampGuard_Skew(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard := NewTimestampGuard(config.Ingest{SkewThreshold: time.Minute})
+	source := RemoteWriteSource{Address: "10.0.0.2", UserAgent: "Prometheus/3.1.0", Version: "0.1.0"}
+	offset := remoteWriteSourceClockOffset.WithLabelValues("10.0.0.2", "Prometheus/3.1.0")
+	skewed := remoteWriteSourceClockSkewed.WithLabelValues("10.0.0.2", "Prometheus/3.1.0")
+
+	send := func(ahead time.Duration) {
+		check := guard.Begin(source, now)
+		check.Sample(nil, now.Add(ahead-15*time.Second).UnixMilli())
+		check.Sample(nil, now.Add(ahead).UnixMilli())
+		check.Done(context.Background())
+		now = now.Add(15 * time.Second)
+	}
+
+	// A healthy source sends samples scraped moments ago
+	for range skewWindow {
+		send(-5 * time.Second)
+	}
+	assert.Equal(t, -5.0, testutil.ToFloat64(offset))
+	assert.Zero(t, testutil.ToFloat64(skewed))
+
+	// A single delayed batch does not flag it
+	send(-10 * time.Minute)
+	assert.Zero(t, testutil.ToFloat64(skewed))
+
+	// A clock an hour ahead does, once it is most of the window
+	for range skewWindow/2 + 1 {
+		send(time.Hour)
+	}
+	assert.Equal(t, time.Hour.Seconds(), testutil.ToFloat64(offset))
+	assert.Equal(t, 1.0, testutil.ToFloat64(skewed))
+
+	// And it recovers once the clock is fixed
+	for range skewWindow {
+		send(-5 * time.Second)
+	}
+	assert.Zero(t, testutil.ToFloat64(skewed))
+
+	// Sources which stopped sending are forgotten
+	now = now.Add(2 * sourceRetention)
+	guard.observe(context.Background(), RemoteWriteSource{Address: "10.0.0.3"}, now, 0)
+	guard.mu.Lock()
+	defer guard.mu.Unlock()
+	assert.Len(t, guard.sources, 1)
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,14 +16,17 @@ import (
 	"go.uber.org/mock/gomock"
 
 	apiv1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/util/homedir"
 
 	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
 	"github.com/cloudzero/cloudzero-agent/app/domain/webhook"
 	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/backfiller"
+	webhookmocks "github.com/cloudzero/cloudzero-agent/app/domain/webhook/mocks"
 	"github.com/cloudzero/cloudzero-agent/app/storage/repo"
 	"github.com/cloudzero/cloudzero-agent/app/types"
 	"github.com/cloudzero/cloudzero-agent/app/types/mocks"
@@ -224,6 +228,40 @@ func TestBackfiller_FakeK8s_Start(t *testing.T) {
 	})
 }
 
+func TestBackfiller_RetriesThrottledLists(t *testing.T) {
+	settings := getDefaultSettings()
+	settings.Backfill.QPS = 1000
+	settings.Backfill.Burst = 10
+	settings.Backfill.MaxRetries = 3
+	settings.Backfill.RetryBackoff = time.Millisecond
+
+	mockCtl := gomock.NewController(t)
+	inner, err := webhook.NewWebhookFactory(mocks.NewMockResourceStore(mockCtl), settings, mocks.NewMockClock(time.Now()))
+	require.NoError(t, err)
+	controller := webhookmocks.NewRecordingController(inner)
+
+	clientset := fake.NewClientset(namespacedObjects()...)
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[action.GetNamespace()]++
+		if attempts[action.GetNamespace()] <= 2 {
+			return true, nil, k8serrors.NewTooManyRequests("slow down", 0)
+		}
+		return false, nil, nil
+	})
+
+	s := backfiller.NewKubernetesObjectEnumerator(clientset, controller, settings)
+	s.DisableServiceWait()
+	require.NoError(t, s.Start(context.Background()))
+
+	assert.Equal(t, 3, attempts["fresh"])
+	assert.True(t, controller.Reviewed("pod", "fresh", "new"))
+	assert.True(t, controller.Reviewed("pod", "done", "old"))
+}
+
 // getDefaultSettings returns a default configuration settings for the Backfiller.
 func getDefaultSettings() *config.Settings {
 	return &config.Settings{
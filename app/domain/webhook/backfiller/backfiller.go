@@ -14,10 +14,10 @@ import (
 	"net/http"
 	"reflect"
 	goruntime "runtime"
+	"slices"
 	"time"
 
 	"github.com/golang/snappy"
-	"github.com/google/uuid"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/protobuf/proto"
@@ -33,6 +33,7 @@ import (
 	networkingv1beta1 "k8s.io/api/networking/v1beta1"
 	storagev1 "k8s.io/api/storage/v1"
 	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -43,6 +44,7 @@ import (
 	"github.com/cloudzero/cloudzero-agent/app/domain/webhook"
 	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/handler"
 	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/helper"
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
 	"github.com/cloudzero/cloudzero-agent/app/types"
 	"github.com/cloudzero/cloudzero-agent/app/utils/parallel"
 )
@@ -70,17 +72,39 @@ type backfiller struct {
 	k8sClient   kubernetes.Interface
 	settings    *config.Settings
 	controller  webhook.WebhookController
+	checkpoints CheckpointStore
+	flush       func() error
+	throttle    *throttle
 	disableWait bool
 }
 
-func NewKubernetesObjectEnumerator(k8sClient kubernetes.Interface, controller webhook.WebhookController, settings *config.Settings) KubernetesObjectEnumerator {
-	return &backfiller{
-		k8sClient:  k8sClient,
-		settings:   settings,
-		controller: controller,
+// Option configures optional behaviour of the enumerator.
+type Option func(s *backfiller)
+
+// WithFlush sends the records buffered by the store the controller writes to
+// before every checkpoint is saved, so a resumed run never starts after
+// records which were not sent. Once a flush fails, the checkpoint is no
+// longer advanced and Start returns an error.
+func WithFlush(flush func() error) Option {
+	return func(s *backfiller) {
+		s.flush = flush
 	}
 }
 
+func NewKubernetesObjectEnumerator(k8sClient kubernetes.Interface, controller webhook.WebhookController, settings *config.Settings, opts ...Option) KubernetesObjectEnumerator {
+	s := &backfiller{
+		k8sClient:   k8sClient,
+		settings:    settings,
+		controller:  controller,
+		checkpoints: NewCheckpointStore(k8sClient, settings),
+		throttle:    newThrottle(settings.Backfill),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
 func (s *backfiller) DisableServiceWait() {
 	s.disableWait = true
 }
@@ -98,7 +122,11 @@ func (s *backfiller) Start(ctx context.Context) error {
 		}
 
 		// Wait for the collector service to become available to ensure discovered data can be sent successfully
-		if err := AwaitCollectorService(ctx, s.settings.Destination, MaxConnectionAttempts, ConnectionTimeout); err != nil {
+		client, err := transport.NewClient(s.settings.Egress, 0)
+		if err != nil {
+			return fmt.Errorf("failed to configure the egress transport: %w", err)
+		}
+		if err = AwaitCollectorService(ctx, client, s.settings.Destination, MaxConnectionAttempts, ConnectionTimeout); err != nil {
 			log.Error().Err(err).Msg("Failed to await collector service")
 			return errors.New("failed to await collector service")
 		}
@@ -107,8 +135,19 @@ func (s *backfiller) Start(ctx context.Context) error {
 
 	log.Info().Time("currentTime", time.Now().UTC()).Msg("Initiating backfill of existing Kubernetes resources")
 
-	// write all nodes in the cluster storage
-	s.enumerateNodes(ctx)
+	progress := newProgress(ctx, s.checkpoints, s.flush, s.settings.Backfill.CheckpointInterval)
+	since := progress.since(s.settings.Backfill.ChangedSince)
+	if since != "" {
+		log.Info().Str("resourceVersion", since).Msg("only publishing objects changed since resource version")
+	}
+
+	// List all namespaces up-front so the amount of work is known before the
+	// enumeration starts and the progress can be reported
+	allNamespaces, resourceVersion, err := s.listNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	progress.begin(resourceVersion)
 
 	var (
 		// shorthand clients to make code below simpler to read
@@ -129,133 +168,133 @@ func (s *backfiller) Start(ctx context.Context) error {
 		{
 			types.GroupApps, types.V1, types.KindDeployment,
 			helper.ConvertObject[*appsv1.Deployment],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1Client.Deployments(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupApps, types.V1Beta2, types.KindDeployment,
 			helper.ConvertObject[*appsv1beta2.Deployment],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1beta2Client.Deployments(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupApps, types.V1Beta1, types.KindDeployment,
 			helper.ConvertObject[*appsv1beta1.Deployment],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1beta1Client.Deployments(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupApps, types.V1, types.KindStatefulSet,
 			helper.ConvertObject[*appsv1.StatefulSet],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1Client.StatefulSets(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupApps, types.V1Beta2, types.KindStatefulSet,
 			helper.ConvertObject[*appsv1beta2.StatefulSet],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1beta2Client.StatefulSets(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupApps, types.V1Beta1, types.KindStatefulSet,
 			helper.ConvertObject[*appsv1beta1.StatefulSet],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1beta1Client.StatefulSets(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupApps, types.V1, types.KindDaemonSet,
 			helper.ConvertObject[*appsv1.DaemonSet],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1Client.DaemonSets(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupApps, types.V1Beta2, types.KindDaemonSet,
 			helper.ConvertObject[*appsv1beta2.DaemonSet],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1beta2Client.DaemonSets(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupApps, types.V1, types.KindReplicaSet,
 			helper.ConvertObject[*appsv1.ReplicaSet],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1Client.ReplicaSets(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupApps, types.V1Beta2, types.KindReplicaSet,
 			helper.ConvertObject[*appsv1beta2.ReplicaSet],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return appsv1beta2Client.ReplicaSets(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupCore, types.V1, types.KindPod,
 			helper.ConvertObject[*corev1.Pod],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return corev1Client.Pods(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupCore, types.V1, types.KindService,
 			helper.ConvertObject[*corev1.Service],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return corev1Client.Services(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupCore, types.V1, types.KindPersistentVolume,
 			helper.ConvertObject[*corev1.PersistentVolume],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return corev1Client.PersistentVolumes().List(ctx, opts)
 			},
 		},
 		{
 			types.GroupCore, types.V1, types.KindPersistentVolumeClaim,
 			helper.ConvertObject[*corev1.PersistentVolumeClaim],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return corev1Client.PersistentVolumeClaims(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupBatch, types.V1, types.KindJob,
 			helper.ConvertObject[*batchv1.Job],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return batchv1Client.Jobs(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupBatch, types.V1, types.KindCronJob,
 			helper.ConvertObject[*batchv1.CronJob],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return batchv1Client.CronJobs(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupBatch, types.V1Beta1, types.KindCronJob,
 			helper.ConvertObject[*batchv1beta1.CronJob],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return batchv1beta1Client.CronJobs(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupNet, types.V1, types.KindIngress,
 			helper.ConvertObject[*networkingv1.Ingress],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return networkingv1Client.Ingresses(namespace).List(ctx, opts)
 			},
 		},
 		{
 			types.GroupNet, types.V1Beta1, types.KindIngress,
 			helper.ConvertObject[*networkingv1beta1.Ingress],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return networkingv1beta1Client.Ingresses(namespace).List(ctx, opts)
 			},
 		},
@@ -264,14 +303,14 @@ func (s *backfiller) Start(ctx context.Context) error {
 		{
 			types.GroupStorage, types.V1, types.KindStorageClass,
 			helper.ConvertObject[*storagev1.StorageClass],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return storagev1Client.StorageClasses().List(ctx, opts)
 			},
 		},
 		{
 			types.GroupStorage, types.V1Beta1, types.KindStorageClass,
 			helper.ConvertObject[*storagev1beta1.StorageClass],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return storagev1betav1Client.StorageClasses().List(ctx, opts)
 			},
 		},
@@ -279,14 +318,14 @@ func (s *backfiller) Start(ctx context.Context) error {
 		{
 			types.GroupNet, types.V1, types.KindIngressClass,
 			helper.ConvertObject[*networkingv1.IngressClass],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return networkingv1Client.IngressClasses().List(ctx, opts)
 			},
 		},
 		{
 			types.GroupNet, types.V1Beta1, types.KindIngressClass,
 			helper.ConvertObject[*networkingv1beta1.IngressClass],
-			func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
+			func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error) {
 				return networkingv1beta1Client.IngressClasses().List(ctx, opts)
 			},
 		},
@@ -295,6 +334,7 @@ func (s *backfiller) Start(ctx context.Context) error {
 	}
 
 	// Notify use of enabled/disabled objects
+	enabled := make([]BackFillJobDescription[metav1.Object], 0, len(catalog))
 	for _, task := range catalog {
 		g, v, k := task.g, task.v, task.k
 		cfg := s.controller.GetConfigurationAccessor(g, v, k)
@@ -315,217 +355,295 @@ func (s *backfiller) Start(ctx context.Context) error {
 			Bool("annotationsEnabled", cfg.AnnotationsEnabled()).
 			Bool("annotationsEnabledForType", cfg.AnnotationsEnabledForType()).
 			Msg("scanning enabled")
+		enabled = append(enabled, task)
+	}
+	slices.SortStableFunc(enabled, func(a, b BackFillJobDescription[metav1.Object]) int {
+		return priority(a.k) - priority(b.k)
+	})
+
+	// Plan every namespace before dispatching any work so the reported
+	// progress covers the whole run. Namespaces completed by an interrupted
+	// run are skipped.
+	pending := make([]corev1.Namespace, 0, len(allNamespaces))
+	taskKeys := make(map[string][]string, len(allNamespaces))
+	for _, ns := range allNamespaces {
+		scope := namespaceScope(ns.GetName())
+		keys := make([]string, 0, len(enabled))
+		for _, task := range enabled {
+			keys = append(keys, TaskKey(scope, task.g, task.v, task.k))
+		}
+		if !progress.plan(scope, keys) {
+			log.Debug().Str("namespace", ns.GetName()).Msg("namespace completed by a previous run")
+			continue
+		}
+		pending = append(pending, ns)
+		taskKeys[ns.GetName()] = keys
 	}
 
+	// write all nodes in the cluster storage
+	s.enumerateNodes(ctx, progress, since)
+
 	// worker pool ensures we don't have unbounded growth
-	pool := parallel.New(min(goruntime.NumCPU(), MaxWorkersPerPool))
+	pool := parallel.New(s.concurrency())
 	defer pool.Close()
 	waiter := parallel.NewWaiter()
 
-	// cursor values
-	limit := s.settings.K8sClient.PaginationLimit
-	var _continue string
-	allNamespaces := []corev1.Namespace{}
-	for {
-		// List all namespaces
-		namespaces, err := s.k8sClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
-			Limit:    s.settings.K8sClient.PaginationLimit,
-			Continue: _continue,
-		})
-		if err != nil {
-			log.Err(err).Msg("Error listing namespaces")
-			return errors.New("failed to list namespaces")
-		}
-		allNamespaces = append(allNamespaces, namespaces.Items...)
+	// dispatch job post namespace validation AdmissionReview
+	for _, ns := range pending {
+		nsRecord := ns.DeepCopy()
+		pool.Run(
+			func() error {
+				s.publish(schema.GroupVersionKind{Version: "v1", Kind: "namespace"}, nsRecord, since)
+				return nil // Don't return error, we are not going to retry
+			},
+			waiter,
+		)
+	}
 
-		// For each namespace, gather all resources
-		for _, ns := range namespaces.Items {
-			// dispatch job post namespace validation AdmissionReview
+	// For Supported and Enabled GVR types - enumerate those resources and capture the resource metadata (labels/annotation).
+	// Work is dispatched kind by kind in priority order, so every namespace's pods are listed before any other kind.
+	for i, task := range enabled {
+		for _, ns := range pending {
 			namespace := ns.GetName()
-			nsRecord := ns.DeepCopy()
+			key := taskKeys[namespace][i]
+			cursor, done := progress.resume(key)
+			if done {
+				continue
+			}
+
 			pool.Run(
 				func() error {
-					log.Info().Str("group", "").Str("version", "v1").Str("kind", "namespace").Str("name", namespace).Msg("discovered")
-					ar, err2 := buildAdmissionReview(schema.GroupVersionKind{Version: "v1", Kind: "namespace"}, nsRecord)
-					if err2 != nil {
-						log.Error().Err(err2).Str("group", "").Str("version", "v1").Str("kind", "namespace").Str("name", namespace).Msg("failed to build admission review")
-						return nil // Don't return error, we are not going to retry
-					}
-					log.Info().Str("group", "").Str("version", "v1").Str("kind", "namespace").Str("name", namespace).Msg("published")
-					_, _ = s.controller.Review(context.Background(), ar)
-					return nil // Don't return error, we are not going to retry
+					s.enumerate(ctx, progress, task, namespace, key, cursor, since)
+					return nil
 				},
 				waiter,
 			)
+		}
+	}
+	waiter.Wait()
+	if err := progress.complete(ctx); err != nil {
+		return err
+	}
 
-			// For Supported and Enabled GVR types - enumerate those resources and capture the resource metadata (labels/annotation)
-			for _, task := range catalog {
-				g, v, k := task.g, task.v, task.k
-				cfg := s.controller.GetConfigurationAccessor(g, v, k)
-
-				if !((cfg.LabelsEnabled() && cfg.LabelsEnabledForType()) || (cfg.AnnotationsEnabled() && cfg.AnnotationsEnabledForType())) {
-					// we notified above - so just skip enumeration task
-					continue
-				}
-
-				pool.Run(
-					func() error {
-						var cursor string
-						for {
-							resources, err := task.List(namespace, metav1.ListOptions{Limit: limit, Continue: cursor})
-							if err != nil {
-								// Not really an error, but we can use this during
-								// debugging to tell us there are no resources of this type
-								log.Debug().
-									Err(err).
-									Str("namespace", namespace).
-									Str("group", g).Str("version", v).Str("kind", k).
-									Msg("no resources of this type found")
-								break
-							}
-
-							items := reflect.ValueOf(resources).Elem().FieldByName("Items")
-							count := items.Len()
-
-							for i := range count {
-								obj := items.Index(i).Addr().Interface()
-								if resource := task.Convert(obj); resource != nil {
-									name := resource.GetName()
-									log.Info().Str("group", g).Str("version", v).Str("kind", k).Str("namespace", namespace).Str("name", name).Msg("discovered")
-
-									if ar, err := buildAdmissionReview(schema.GroupVersionKind{Group: g, Version: v, Kind: k}, resource); err == nil {
-										log.Info().Str("group", g).Str("version", v).Str("kind", k).Str("namespace", namespace).Str("name", name).Msg("published")
-										_, _ = s.controller.Review(context.Background(), ar) // Post the review
-									} else {
-										log.Error().Err(err).Str("group", g).Str("version", v).Str("kind", k).Str("namespace", namespace).Str("name", name).Msg("failed to build admission review")
-									}
-								}
-							}
-
-							if resources.GetContinue() != "" {
-								cursor = resources.GetContinue()
-								continue
-							}
-
-							break
-						}
-						return nil
-					},
-					waiter,
-				)
-			}
+	log.Info().
+		Time("currentTime", time.Now().UTC()).
+		Int("namespacesCount", len(allNamespaces)).
+		Int("resumedNamespacesCount", len(allNamespaces)-len(pending)).
+		Msg("Backfill operation completed")
+	return nil
+}
+
+// concurrency returns the number of list operations run in parallel.
+func (s *backfiller) concurrency() int {
+	if s.settings.Backfill.Concurrency > 0 {
+		return s.settings.Backfill.Concurrency
+	}
+	return min(goruntime.NumCPU(), MaxWorkersPerPool)
+}
+
+// listNamespaces returns every namespace in the cluster, along with the
+// resourceVersion at which they were listed.
+func (s *backfiller) listNamespaces(ctx context.Context) ([]corev1.Namespace, string, error) {
+	var (
+		_continue       string
+		resourceVersion string
+		allNamespaces   = []corev1.Namespace{}
+	)
+	for {
+		namespaces, err := list(ctx, s.throttle, schema.GroupVersionKind{Version: "v1", Kind: "namespace"}, func(ctx context.Context) (*corev1.NamespaceList, error) {
+			return s.k8sClient.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+				Limit:    s.settings.K8sClient.PaginationLimit,
+				Continue: _continue,
+			})
+		})
+		if err != nil {
+			log.Err(err).Msg("Error listing namespaces")
+			return nil, "", errors.New("failed to list namespaces")
+		}
+		if resourceVersion == "" {
+			resourceVersion = namespaces.GetResourceVersion()
 		}
+		allNamespaces = append(allNamespaces, namespaces.Items...)
 
 		// Escape
 		if namespaces.GetContinue() != "" {
 			_continue = namespaces.GetContinue()
 			continue
 		}
+		return allNamespaces, resourceVersion, nil
+	}
+}
+
+// enumerate lists every page of a resource type in a namespace, starting at
+// the given continue token, and publishes the objects. Progress is recorded
+// after each page so an interrupted run can resume from the next one.
+func (s *backfiller) enumerate(ctx context.Context, progress *progress, task BackFillJobDescription[metav1.Object], namespace, key, cursor, since string) {
+	g, v, k := task.g, task.v, task.k
+	gvk := schema.GroupVersionKind{Group: g, Version: v, Kind: k}
+	limit := s.settings.K8sClient.PaginationLimit
+	for {
+		resources, err := list(ctx, s.throttle, gvk, func(ctx context.Context) (metav1.ListInterface, error) {
+			return task.List(ctx, namespace, metav1.ListOptions{Limit: limit, Continue: cursor})
+		})
+		if err != nil {
+			if cursor != "" && isExpired(err) {
+				// Continue tokens are only valid for a few minutes, so a
+				// token from an interrupted run has usually expired
+				log.Warn().
+					Str("namespace", namespace).
+					Str("group", g).Str("version", v).Str("kind", k).
+					Msg("continue token expired, restarting list")
+				cursor = ""
+				continue
+			}
+			// Not really an error, but we can use this during
+			// debugging to tell us there are no resources of this type
+			log.Debug().
+				Err(err).
+				Str("namespace", namespace).
+				Str("group", g).Str("version", v).Str("kind", k).
+				Msg("no resources of this type found")
+			break
+		}
+
+		items := reflect.ValueOf(resources).Elem().FieldByName("Items")
+		count := items.Len()
+
+		for i := range count {
+			obj := items.Index(i).Addr().Interface()
+			if resource := task.Convert(obj); resource != nil {
+				s.publish(gvk, resource, since)
+			}
+		}
+		progress.page(ctx, key, resources.GetContinue(), resources.GetResourceVersion(), count)
+
+		if resources.GetContinue() != "" {
+			cursor = resources.GetContinue()
+			continue
+		}
+
 		break
 	}
-	waiter.Wait()
-	log.Info().
-		Time("currentTime", time.Now().UTC()).
-		Int("namespacesCount", len(allNamespaces)).
-		Msg("Backfill operation completed")
-	return nil
+	progress.finish(ctx, key)
 }
 
-func (s *backfiller) enumerateNodes(ctx context.Context) {
+// publish posts an AdmissionReview for the resource to the controller,
+// unless it has not changed since the given resourceVersion.
+func (s *backfiller) publish(gvk schema.GroupVersionKind, resource metav1.Object, since string) {
+	g, v, k := gvk.Group, gvk.Version, gvk.Kind
+	namespace, name := resource.GetNamespace(), resource.GetName()
+
+	if !changedSince(resource.GetResourceVersion(), since) {
+		backfillObjectsTotal.WithLabelValues(k, "skipped").Inc()
+		log.Debug().Str("group", g).Str("version", v).Str("kind", k).Str("namespace", namespace).Str("name", name).Msg("unchanged")
+		return
+	}
+	log.Info().Str("group", g).Str("version", v).Str("kind", k).Str("namespace", namespace).Str("name", name).Msg("discovered")
+
+	ar, err := buildAdmissionReview(gvk, resource)
+	if err != nil {
+		log.Error().Err(err).Str("group", g).Str("version", v).Str("kind", k).Str("namespace", namespace).Str("name", name).Msg("failed to build admission review")
+		return // Don't return error, we are not going to retry
+	}
+	log.Info().Str("group", g).Str("version", v).Str("kind", k).Str("namespace", namespace).Str("name", name).Msg("published")
+	if _, err := s.controller.Review(context.Background(), ar); err != nil { // Post the review
+		log.Error().Err(err).Str("group", g).Str("version", v).Str("kind", k).Str("namespace", namespace).Str("name", name).Msg("failed to publish")
+		return
+	}
+	backfillObjectsTotal.WithLabelValues(k, "published").Inc()
+}
+
+func (s *backfiller) enumerateNodes(ctx context.Context, progress *progress, since string) {
 	// Check if node labels or annotations are enabled; if not, skip processing nodes
 	nodeConfigAccessor := handler.NewNodeConfigAccessor(s.settings)
 	if !nodeConfigAccessor.LabelsEnabledForType() && !nodeConfigAccessor.AnnotationsEnabledForType() {
 		return
 	}
 
+	key := TaskKey(clusterScope, types.GroupCore, types.V1, types.KindNode)
+	if !progress.plan(clusterScope, []string{key}) {
+		log.Info().Msg("nodes enumerated by a previous run")
+		return
+	}
+	_continue, done := progress.resume(key)
+	if done {
+		return
+	}
+
 	// Create a worker pool to limit concurrency and avoid unbounded growth
-	pool := parallel.New(min(goruntime.NumCPU(), MaxWorkersPerPool))
+	pool := parallel.New(s.concurrency())
 	defer pool.Close()
-	waiter := parallel.NewWaiter()
 
 	client := s.k8sClient.CoreV1()
-	var _continue string
 	log.Info().Msg("enumerating current cluster nodes")
 
 	for {
 		// List nodes in the cluster with pagination
-		nodes, err := client.Nodes().List(ctx, metav1.ListOptions{
-			Limit:    s.settings.K8sClient.PaginationLimit,
-			Continue: _continue,
+		nodes, err := list(ctx, s.throttle, schema.GroupVersionKind{Version: "v1", Kind: "node"}, func(ctx context.Context) (*corev1.NodeList, error) {
+			return client.Nodes().List(ctx, metav1.ListOptions{
+				Limit:    s.settings.K8sClient.PaginationLimit,
+				Continue: _continue,
+			})
 		})
 		if err != nil {
+			if _continue != "" && isExpired(err) {
+				log.Warn().Msg("node continue token expired, restarting list")
+				_continue = ""
+				continue
+			}
 			log.Printf("Error listing nodes: %v", err)
 			continue
 		}
 
 		// Process each node in the current batch
+		waiter := parallel.NewWaiter()
 		for _, o := range nodes.Items {
 			nodeRecord := o.DeepCopy()
 			pool.Run(
 				func() error {
-					name := nodeRecord.GetName()
-					log.Info().Str("group", "").Str("version", "v1").Str("kind", "node").Str("name", name).Msg("discovered")
-
 					// Create an AdmissionReview for the node and post it to the controller
-					ar, err2 := buildAdmissionReview(schema.GroupVersionKind{Version: "v1", Kind: "node"}, nodeRecord)
-					if err2 != nil {
-						log.Error().Err(err2).Str("group", "").Str("version", "v1").Str("kind", "node").Str("name", name).Msg("failed to build admission review")
-						return nil
-					}
-					log.Info().Str("group", "").Str("version", "v1").Str("kind", "node").Str("name", name).Msg("published")
-					_, _ = s.controller.Review(context.Background(), ar)
+					s.publish(schema.GroupVersionKind{Version: "v1", Kind: "node"}, nodeRecord, since)
 					return nil
 				},
 				waiter,
 			)
 		}
+
+		// Wait for the current batch to be published before recording it
+		waiter.Wait()
+		progress.page(ctx, key, nodes.Continue, nodes.ResourceVersion, len(nodes.Items))
+
 		// If there are no more nodes to process, exit the loop
 		if nodes.Continue == "" {
 			break
 		}
 		_continue = nodes.Continue
 	}
+	progress.finish(ctx, key)
+}
 
-	// Wait for all jobs in the current batch to complete
-	waiter.Wait()
+// isExpired reports whether a list failed because its continue token expired.
+func isExpired(err error) bool {
+	return k8serrors.IsResourceExpired(err) || k8serrors.IsGone(err)
 }
 
 // buildAdmissionReview is a generic function that creates an AdmissionReview object for a given resource.
 // It takes a resource of any type T and a GroupVersionKind (GVK) as input parameters.
 // The function encodes the resource into raw bytes and constructs an AdmissionReview object.
 func buildAdmissionReview[T metav1.Object](gvk schema.GroupVersionKind, o T) (*types.AdmissionReview, error) {
-	// Encode the resource object into raw bytes
-	raw, err := helper.EncodeToRawBytes(o)
+	ar, err := helper.NewAdmissionReview(gvk, types.OperationCreate, o, nil)
 	if err != nil {
 		log.Err(err).Str("group", gvk.Group).Str("version", gvk.Version).Str("kind", gvk.Kind).Msg("encode failure")
 		return nil, err
 	}
-
-	// Extract the name and namespace of the resource
-	name := o.GetName()
-	namespace := o.GetNamespace()
-
-	// Construct and return the AdmissionReview object
-	return &types.AdmissionReview{
-		ID:           uuid.New().String(),            // Generate a unique ID for the review
-		Name:         name,                           // Resource name
-		Namespace:    namespace,                      // Resource namespace
-		Version:      types.AdmissionReviewVersionV1, // AdmissionReview version
-		Operation:    types.OperationCreate,          // Operation type (e.g., Create)
-		NewObjectRaw: raw,                            // Encoded resource object
-		RequestGVK: &metav1.GroupVersionKind{ // GroupVersionKind of the resource
-			Group:   gvk.Group,
-			Version: gvk.Version,
-			Kind:    gvk.Kind,
-		},
-	}, nil
+	return ar, nil
 }
 
 // ObjectConverter is a type alias for a function that converts an object of any type to a specific metav1.Object type.
 type ObjectConverter[T metav1.Object] func(o any) T
 
 // ListFunc is a type alias for a function that lists resources in a namespace with specific list options.
-type ListFunc func(namespace string, opts metav1.ListOptions) (metav1.ListInterface, error)
+type ListFunc func(ctx context.Context, namespace string, opts metav1.ListOptions) (metav1.ListInterface, error)
 
 // BackFillJobDescription represents a job description for backfilling resources.
 // It includes the group, version, kind of the resource, a converter function, and a list function.
@@ -569,7 +687,7 @@ func AwaitWebhookService(ctx context.Context, namespace, serviceName string, max
 // AwaitCollectorService attempts to send a WriteRequest to the specified collector service endpoint
 // using an HTTP POST request. It retries the request up to a specified number of times with exponential
 // backoff and jitter in case of failures.
-func AwaitCollectorService(ctx context.Context, endpoint string, maxRetries int, timeout time.Duration) error {
+func AwaitCollectorService(ctx context.Context, client *http.Client, endpoint string, maxRetries int, timeout time.Duration) error {
 	data, err := proto.Marshal(protoadapt.MessageV2Of(&prompb.WriteRequest{
 		Timeseries: []prompb.TimeSeries{},
 	}))
@@ -595,7 +713,6 @@ func AwaitCollectorService(ctx context.Context, endpoint string, maxRetries int,
 		req.Header.Set("Content-Type", "application/x-protobuf")
 		req.Header.Set("Content-Encoding", "snappy")
 
-		client := &http.Client{}
 		resp, err = client.Do(req)
 		if resp != nil {
 			resp.Body.Close()
@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package backfiller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/utils/k8s"
+)
+
+// CheckpointConfigMapKey is the ConfigMap data key holding the checkpoint.
+const CheckpointConfigMapKey = "checkpoint.json"
+
+// Checkpoint records the progress of a backfill run.
+//
+// Work is divided into scopes (the cluster-scoped node listing, and one scope
+// per namespace) each holding one task per enabled kind. Once every task of a
+// scope is done the scope is added to Completed and its tasks are dropped, which
+// keeps the checkpoint small enough to be stored in a ConfigMap on large
+// clusters.
+type Checkpoint struct {
+	// ResourceVersion is the cluster resourceVersion observed when the run
+	// started.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// LastCompletedResourceVersion is the ResourceVersion of the most recent
+	// run which finished, used by the "changed since last run" mode.
+	LastCompletedResourceVersion string `json:"lastCompletedResourceVersion,omitempty"`
+	// Done reports whether the run finished. The next run starts from scratch.
+	Done      bool      `json:"done"`
+	StartedAt time.Time `json:"startedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	// Completed lists the scopes which have been fully processed.
+	Completed []string `json:"completed,omitempty"`
+	// Tasks holds the progress of the tasks of the scopes still in progress,
+	// keyed by TaskKey.
+	Tasks map[string]*TaskProgress `json:"tasks,omitempty"`
+}
+
+// TaskProgress is the position of a single paginated list operation.
+type TaskProgress struct {
+	// Continue is the continue token of the next page to list.
+	Continue string `json:"continue,omitempty"`
+	// ResourceVersion is the resourceVersion of the last listed page.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+	// Objects is the number of objects listed so far.
+	Objects int  `json:"objects"`
+	Done    bool `json:"done"`
+}
+
+// CheckpointStore persists backfill checkpoints between runs.
+type CheckpointStore interface {
+	// Load returns the stored checkpoint, or nil if there is none.
+	Load(ctx context.Context) (*Checkpoint, error)
+	// Save replaces the stored checkpoint.
+	Save(ctx context.Context, cp *Checkpoint) error
+}
+
+// NewCheckpointStore returns the checkpoint store configured in the settings,
+// or nil if checkpointing is disabled.
+func NewCheckpointStore(clientset kubernetes.Interface, settings *config.Settings) CheckpointStore {
+	switch {
+	case settings.Backfill.CheckpointConfigMap != "":
+		return NewConfigMapCheckpointStore(clientset, settings.Server.Namespace, settings.Backfill.CheckpointConfigMap)
+	case settings.Backfill.CheckpointFile != "":
+		return NewFileCheckpointStore(settings.Backfill.CheckpointFile)
+	default:
+		return nil
+	}
+}
+
+type fileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore stores checkpoints as JSON in a local file. The file
+// is replaced atomically on every save.
+func NewFileCheckpointStore(path string) CheckpointStore {
+	return &fileCheckpointStore{path: path}
+}
+
+func (f *fileCheckpointStore) Load(_ context.Context) (*Checkpoint, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	return decodeCheckpoint(data)
+}
+
+func (f *fileCheckpointStore) Save(_ context.Context, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return fmt.Errorf("creating checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("replacing checkpoint: %w", err)
+	}
+	return nil
+}
+
+type configMapCheckpointStore struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapCheckpointStore stores checkpoints in a ConfigMap, which
+// survives the backfill pod being rescheduled to another node.
+func NewConfigMapCheckpointStore(clientset kubernetes.Interface, namespace, name string) CheckpointStore {
+	return &configMapCheckpointStore{clientset: clientset, namespace: namespace, name: name}
+}
+
+func (c *configMapCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	cm, err := c.clientset.CoreV1().ConfigMaps(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading checkpoint configmap: %w", err)
+	}
+	data, ok := cm.Data[CheckpointConfigMapKey]
+	if !ok {
+		return nil, nil
+	}
+	return decodeCheckpoint([]byte(data))
+}
+
+func (c *configMapCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint: %w", err)
+	}
+	return k8s.UpdateConfigMap(ctx, c.clientset, c.namespace, c.name, map[string]string{CheckpointConfigMapKey: string(data)})
+}
+
+func decodeCheckpoint(data []byte) (*Checkpoint, error) {
+	cp := &Checkpoint{}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("decoding checkpoint: %w", err)
+	}
+	return cp, nil
+}
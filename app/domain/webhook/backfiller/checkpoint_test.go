@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package backfiller_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/backfiller"
+	webhookmocks "github.com/cloudzero/cloudzero-agent/app/domain/webhook/mocks"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/mocks"
+)
+
+func runBackfill(t *testing.T, settings *config.Settings, objects ...runtime.Object) *webhookmocks.RecordingController {
+	t.Helper()
+	mockCtl := gomock.NewController(t)
+	inner, err := webhook.NewWebhookFactory(mocks.NewMockResourceStore(mockCtl), settings, mocks.NewMockClock(time.Now()))
+	require.NoError(t, err)
+	controller := webhookmocks.NewRecordingController(inner)
+
+	s := backfiller.NewKubernetesObjectEnumerator(fake.NewClientset(objects...), controller, settings)
+	s.DisableServiceWait()
+	require.NoError(t, s.Start(context.Background()))
+	return controller
+}
+
+func namespacedObjects() []runtime.Object {
+	return []runtime.Object{
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "done", ResourceVersion: "5"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "fresh", ResourceVersion: "6"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "old", Namespace: "done", ResourceVersion: "7"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "fresh", ResourceVersion: "20"}},
+	}
+}
+
+func TestCheckpointStores(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]backfiller.CheckpointStore{
+		"file":      backfiller.NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json")),
+		"configmap": backfiller.NewConfigMapCheckpointStore(fake.NewClientset(), "cza", "backfill-checkpoint"),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			cp, err := store.Load(ctx)
+			require.NoError(t, err)
+			assert.Nil(t, cp)
+
+			want := &backfiller.Checkpoint{
+				ResourceVersion: "42",
+				Completed:       []string{"namespace/default"},
+				Tasks: map[string]*backfiller.TaskProgress{
+					"namespace/kube-system//v1/pod": {Continue: "token", ResourceVersion: "41", Objects: 500},
+				},
+			}
+			require.NoError(t, store.Save(ctx, want))
+			require.NoError(t, store.Save(ctx, want))
+
+			cp, err = store.Load(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, want, cp)
+		})
+	}
+}
+
+func TestBackfiller_ResumeFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	settings := getDefaultSettings()
+	settings.Backfill.CheckpointFile = filepath.Join(t.TempDir(), "checkpoint.json")
+	store := backfiller.NewFileCheckpointStore(settings.Backfill.CheckpointFile)
+
+	require.NoError(t, store.Save(ctx, &backfiller.Checkpoint{
+		ResourceVersion: "10",
+		Completed:       []string{"namespace/done"},
+		Tasks: map[string]*backfiller.TaskProgress{
+			backfiller.TaskKey("namespace/fresh", types.GroupApps, types.V1, types.KindDeployment): {Done: true},
+		},
+	}))
+
+	reviewed := runBackfill(t, settings, namespacedObjects()...)
+	assert.False(t, reviewed.Reviewed("namespace", "", "done"), "completed namespace must not be published again")
+	assert.False(t, reviewed.Reviewed("pod", "done", "old"), "completed namespace must not be listed again")
+	assert.True(t, reviewed.Reviewed("namespace", "", "fresh"))
+	assert.True(t, reviewed.Reviewed("pod", "fresh", "new"))
+
+	cp, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.True(t, cp.Done)
+	assert.Equal(t, "10", cp.ResourceVersion, "a resumed run keeps its original resource version")
+	assert.Empty(t, cp.Tasks)
+
+	// The next run starts from scratch
+	reviewed = runBackfill(t, settings, namespacedObjects()...)
+	assert.True(t, reviewed.Reviewed("pod", "done", "old"))
+	assert.True(t, reviewed.Reviewed("pod", "fresh", "new"))
+}
+
+func TestBackfiller_ChangedSince(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("explicit resource version", func(t *testing.T) {
+		settings := getDefaultSettings()
+		settings.Backfill.ChangedSince = "10"
+
+		reviewed := runBackfill(t, settings, namespacedObjects()...)
+		assert.False(t, reviewed.Reviewed("namespace", "", "done"))
+		assert.False(t, reviewed.Reviewed("pod", "done", "old"))
+		assert.True(t, reviewed.Reviewed("pod", "fresh", "new"))
+	})
+
+	t.Run("last completed run", func(t *testing.T) {
+		settings := getDefaultSettings()
+		settings.Backfill.ChangedSince = config.ChangedSinceLastRun
+		settings.Backfill.CheckpointFile = filepath.Join(t.TempDir(), "checkpoint.json")
+		store := backfiller.NewFileCheckpointStore(settings.Backfill.CheckpointFile)
+		require.NoError(t, store.Save(ctx, &backfiller.Checkpoint{ResourceVersion: "10", Done: true}))
+
+		reviewed := runBackfill(t, settings, namespacedObjects()...)
+		assert.False(t, reviewed.Reviewed("pod", "done", "old"))
+		assert.True(t, reviewed.Reviewed("pod", "fresh", "new"))
+
+		cp, err := store.Load(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "10", cp.LastCompletedResourceVersion)
+	})
+
+	t.Run("no previous run", func(t *testing.T) {
+		settings := getDefaultSettings()
+		settings.Backfill.ChangedSince = config.ChangedSinceLastRun
+
+		reviewed := runBackfill(t, settings, namespacedObjects()...)
+		assert.True(t, reviewed.Reviewed("pod", "done", "old"))
+		assert.True(t, reviewed.Reviewed("pod", "fresh", "new"))
+	})
+}
+
+func TestBackfiller_FlushBeforeCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	newEnumerator := func(t *testing.T, settings *config.Settings, flush func() error) backfiller.KubernetesObjectEnumerator {
+		t.Helper()
+		inner, err := webhook.NewWebhookFactory(mocks.NewMockResourceStore(gomock.NewController(t)), settings, mocks.NewMockClock(time.Now()))
+		require.NoError(t, err)
+		s := backfiller.NewKubernetesObjectEnumerator(fake.NewClientset(namespacedObjects()...), webhookmocks.NewRecordingController(inner), settings, backfiller.WithFlush(flush))
+		s.DisableServiceWait()
+		return s
+	}
+
+	t.Run("flushed", func(t *testing.T) {
+		settings := getDefaultSettings()
+		settings.Backfill.CheckpointFile = filepath.Join(t.TempDir(), "checkpoint.json")
+		flushes := 0
+		require.NoError(t, newEnumerator(t, settings, func() error { flushes++; return nil }).Start(ctx))
+
+		assert.Positive(t, flushes)
+		cp, err := backfiller.NewFileCheckpointStore(settings.Backfill.CheckpointFile).Load(ctx)
+		require.NoError(t, err)
+		assert.True(t, cp.Done)
+	})
+
+	t.Run("records not sent", func(t *testing.T) {
+		settings := getDefaultSettings()
+		settings.Backfill.CheckpointFile = filepath.Join(t.TempDir(), "checkpoint.json")
+		store := backfiller.NewFileCheckpointStore(settings.Backfill.CheckpointFile)
+		previous := &backfiller.Checkpoint{ResourceVersion: "10", Completed: []string{"namespace/done"}}
+		require.NoError(t, store.Save(ctx, previous))
+
+		err := newEnumerator(t, settings, func() error { return errors.New("collector unavailable") }).Start(ctx)
+		require.Error(t, err)
+
+		cp, err := store.Load(ctx)
+		require.NoError(t, err)
+		assert.False(t, cp.Done, "the run is resumed")
+		assert.Equal(t, previous.Completed, cp.Completed, "the checkpoint does not cover records which were not sent")
+	})
+}
@@ -0,0 +1,335 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package backfiller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+const clusterScope = "cluster"
+
+var (
+	backfillProgressRatio = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: types.ObservabilityMetric("backfill_progress_ratio"),
+			Help: "Fraction of backfill list operations completed in the current run",
+		},
+	)
+	backfillObjectsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: types.ObservabilityMetric("backfill_objects_total"),
+			Help: "Total number of objects listed by the backfill, by kind and whether they were published or skipped as unchanged",
+		},
+		[]string{"kind", "result"},
+	)
+	backfillCheckpointErrorsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: types.ObservabilityMetric("backfill_checkpoint_errors_total"),
+			Help: "Total number of backfill checkpoints which could not be loaded or saved",
+		},
+	)
+
+	registerProgressMetricsOnce sync.Once
+)
+
+// namespaceScope returns the checkpoint scope of a namespace.
+func namespaceScope(namespace string) string {
+	return "namespace/" + namespace
+}
+
+// TaskKey identifies a list operation of a kind within a scope.
+func TaskKey(scope, group, version, kind string) string {
+	return scope + "/" + group + "/" + version + "/" + kind
+}
+
+// progress tracks the tasks of a backfill run and persists them in a
+// checkpoint store. A nil store keeps the progress in memory only.
+//
+// The published records are buffered before they are sent, so flush, when
+// set, is called before every save: a checkpoint must not cover records which
+// were never sent. After a failed flush the checkpoint is no longer saved, and
+// the next run resumes from the last one which was.
+type progress struct {
+	store    CheckpointStore
+	flush    func() error
+	interval time.Duration
+	unsent   error
+
+	mu       sync.Mutex
+	cp       *Checkpoint
+	resumed  bool
+	scopes   map[string]int    // remaining tasks per scope
+	owners   map[string]string // task key to scope
+	total    int
+	done     int
+	lastSave time.Time
+}
+
+// newProgress loads the checkpoint of the previous run. An unfinished run is
+// resumed; otherwise a new run is started which remembers the resourceVersion
+// of the last completed one.
+func newProgress(ctx context.Context, store CheckpointStore, flush func() error, interval time.Duration) *progress {
+	registerProgressMetricsOnce.Do(func() {
+		prometheus.MustRegister(backfillProgressRatio, backfillObjectsTotal, backfillCheckpointErrorsTotal)
+	})
+	backfillProgressRatio.Set(0)
+
+	p := &progress{
+		store:    store,
+		flush:    flush,
+		interval: interval,
+		scopes:   map[string]int{},
+		owners:   map[string]string{},
+	}
+
+	var previous *Checkpoint
+	if store != nil {
+		var err error
+		if previous, err = store.Load(ctx); err != nil {
+			backfillCheckpointErrorsTotal.Inc()
+			log.Ctx(ctx).Warn().Err(err).Msg("failed to load backfill checkpoint, starting a new run")
+		}
+	}
+
+	switch {
+	case previous != nil && !previous.Done:
+		log.Ctx(ctx).Info().
+			Time("startedAt", previous.StartedAt).
+			Int("completedScopes", len(previous.Completed)).
+			Msg("resuming interrupted backfill")
+		if previous.Tasks == nil {
+			previous.Tasks = map[string]*TaskProgress{}
+		}
+		p.cp = previous
+		p.resumed = true
+	case previous != nil:
+		p.cp = &Checkpoint{LastCompletedResourceVersion: previous.ResourceVersion}
+	default:
+		p.cp = &Checkpoint{}
+	}
+	if p.cp.Tasks == nil {
+		p.cp.Tasks = map[string]*TaskProgress{}
+	}
+	return p
+}
+
+// since resolves the configured "changed since" resourceVersion.
+func (p *progress) since(changedSince string) string {
+	if changedSince != config.ChangedSinceLastRun {
+		return changedSince
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cp.LastCompletedResourceVersion
+}
+
+// begin records the start of the run. A resumed run keeps its original
+// resourceVersion, so the next "changed since" window also covers the
+// interruption.
+func (p *progress) begin(resourceVersion string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.resumed {
+		p.cp.ResourceVersion = resourceVersion
+		p.cp.StartedAt = time.Now().UTC()
+	}
+}
+
+// plan registers the tasks of a scope and returns false if the scope was
+// completed by the run being resumed.
+func (p *progress) plan(scope string, keys []string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if slices.Contains(p.cp.Completed, scope) {
+		return false
+	}
+
+	for _, key := range keys {
+		p.owners[key] = scope
+		p.total++
+		if task, ok := p.cp.Tasks[key]; ok && task.Done {
+			p.done++
+			continue
+		}
+		p.scopes[scope]++
+	}
+	if len(keys) > 0 && p.scopes[scope] == 0 {
+		p.completeScope(scope)
+	}
+	p.updateRatio()
+	return true
+}
+
+// resume returns the continue token at which a task should start, and whether
+// the task was already finished.
+func (p *progress) resume(key string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	task, ok := p.cp.Tasks[key]
+	if !ok {
+		return "", false
+	}
+	return task.Continue, task.Done
+}
+
+// page records a listed page. The continue token is that of the next page,
+// so a resumed run does not list the recorded page again.
+func (p *progress) page(ctx context.Context, key, cont, resourceVersion string, objects int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	task := p.task(key)
+	task.Continue = cont
+	task.ResourceVersion = resourceVersion
+	task.Objects += objects
+	p.save(ctx, false)
+}
+
+// finish marks a task as done.
+func (p *progress) finish(ctx context.Context, key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	task := p.task(key)
+	if task.Done {
+		return
+	}
+	task.Done = true
+	task.Continue = ""
+	p.done++
+
+	if scope, ok := p.owners[key]; ok {
+		p.scopes[scope]--
+		if p.scopes[scope] <= 0 {
+			p.completeScope(scope)
+		}
+	}
+	p.updateRatio()
+
+	log.Ctx(ctx).Debug().
+		Str("task", key).
+		Int("objects", task.Objects).
+		Float64("percentComplete", p.ratio()*100).
+		Msg("backfill task finished")
+	p.save(ctx, false)
+}
+
+// complete marks the run as done and saves the final checkpoint. It fails
+// when records of the run could not be sent, leaving the checkpoint to resume
+// from.
+func (p *progress) complete(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.unsent == nil {
+		p.cp.Done = true
+		p.cp.Completed = nil
+		p.cp.Tasks = nil
+	}
+	p.save(ctx, true)
+	if p.unsent != nil {
+		return fmt.Errorf("failed to send backfill records, the next run resumes from the last checkpoint: %w", p.unsent)
+	}
+	backfillProgressRatio.Set(1)
+	return nil
+}
+
+// percent returns the completed share of the planned tasks.
+func (p *progress) percent() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.ratio() * 100
+}
+
+func (p *progress) task(key string) *TaskProgress {
+	task, ok := p.cp.Tasks[key]
+	if !ok {
+		task = &TaskProgress{}
+		p.cp.Tasks[key] = task
+	}
+	return task
+}
+
+func (p *progress) completeScope(scope string) {
+	delete(p.scopes, scope)
+	p.cp.Completed = append(p.cp.Completed, scope)
+	for key, owner := range p.owners {
+		if owner == scope {
+			delete(p.cp.Tasks, key)
+		}
+	}
+}
+
+func (p *progress) ratio() float64 {
+	if p.total == 0 {
+		return 0
+	}
+	return float64(p.done) / float64(p.total)
+}
+
+func (p *progress) updateRatio() {
+	backfillProgressRatio.Set(p.ratio())
+}
+
+// save flushes the published records and writes the checkpoint, at most once
+// per interval unless forced. The caller must hold the lock. Failures are
+// logged; the backfill carries on without a checkpoint rather than aborting.
+func (p *progress) save(ctx context.Context, force bool) {
+	if p.unsent != nil {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(p.lastSave) < p.interval {
+		return
+	}
+	p.lastSave = now
+	if p.flush != nil {
+		if err := p.flush(); err != nil {
+			p.unsent = err
+			backfillCheckpointErrorsTotal.Inc()
+			log.Ctx(ctx).Error().Err(err).Msg("failed to send backfill records, the checkpoint is no longer advanced")
+			return
+		}
+	}
+	if p.store == nil {
+		return
+	}
+	p.cp.UpdatedAt = now.UTC()
+	if err := p.store.Save(ctx, p.cp); err != nil {
+		backfillCheckpointErrorsTotal.Inc()
+		log.Ctx(ctx).Warn().Err(err).Msg("failed to save backfill checkpoint")
+	}
+}
+
+// changedSince reports whether an object with the given resourceVersion is
+// newer than since. resourceVersions are opaque to clients, so this is only
+// best effort, and only used when Backfill.ChangedSinceBestEffort is set: it
+// holds where they are etcd revisions, as on every mainstream distribution.
+// When either value is not a number the object is treated as changed.
+func changedSince(resourceVersion, since string) bool {
+	if since == "" {
+		return true
+	}
+	rv, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return true
+	}
+	threshold, err := strconv.ParseUint(since, 10, 64)
+	if err != nil {
+		return true
+	}
+	return rv > threshold
+}
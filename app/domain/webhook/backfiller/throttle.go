@@ -0,0 +1,230 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package backfiller
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+const (
+	// minQPS is the lowest rate the limiter is lowered to while throttled.
+	minQPS = 1
+	// maxRetryDelay caps the delay between attempts of a throttled request.
+	maxRetryDelay = time.Minute
+)
+
+var (
+	backfillListDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    types.ObservabilityMetric("backfill_list_duration_seconds"),
+			Help:    "Latency of backfill list requests, by resource type and result",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+		},
+		[]string{"group", "version", "kind", "result"},
+	)
+	backfillListThrottledTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: types.ObservabilityMetric("backfill_list_throttled_total"),
+			Help: "Total number of backfill list requests rejected by the API server with HTTP 429",
+		},
+		[]string{"group", "version", "kind"},
+	)
+	backfillRateLimit = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: types.ObservabilityMetric("backfill_rate_limit_qps"),
+			Help: "Current client-side rate limit of backfill list requests",
+		},
+	)
+
+	registerThrottleMetricsOnce sync.Once
+)
+
+// kindPriority orders the enumeration so the most cost-relevant data arrives
+// first. Nodes and namespaces are always enumerated before any namespaced kind;
+// kinds not listed here follow in catalog order.
+var kindPriority = map[string]int{
+	types.KindNode:      0,
+	types.KindNamespace: 1,
+	types.KindPod:       2,
+}
+
+// priority returns the enumeration priority of a kind; lower goes first.
+func priority(kind string) int {
+	if p, ok := kindPriority[kind]; ok {
+		return p
+	}
+	return len(kindPriority)
+}
+
+// throttle applies the client-side rate limit to list requests and retries
+// requests throttled by the API server.
+//
+// The limit is adapted to the API server: every throttled request halves it,
+// down to minQPS, and every successful request raises it by a tenth of the
+// configured rate until the configured rate is reached again.
+//
+// The throttle is the only retry layer when the clientset is configured with
+// DisableClientRetries, so a list request is attempted at most maxRetries+1
+// times and every 429 slows down the limiter.
+type throttle struct {
+	limiter    *rate.Limiter // nil when the rate is unlimited
+	qps        rate.Limit
+	maxRetries int
+	backoff    time.Duration
+}
+
+func newThrottle(settings config.Backfill) *throttle {
+	registerThrottleMetricsOnce.Do(func() {
+		prometheus.MustRegister(backfillListDuration, backfillListThrottledTotal, backfillRateLimit)
+	})
+
+	t := &throttle{
+		maxRetries: max(settings.MaxRetries, 0),
+		backoff:    settings.RetryBackoff,
+	}
+	if t.backoff <= 0 {
+		t.backoff = time.Second
+	}
+	if settings.QPS > 0 {
+		t.qps = rate.Limit(settings.QPS)
+		t.limiter = rate.NewLimiter(t.qps, max(settings.Burst, 1))
+		backfillRateLimit.Set(settings.QPS)
+	}
+	return t
+}
+
+// retryAfterKey is the context key of the Retry-After hint of a list request.
+type retryAfterKey struct{}
+
+// DisableClientRetries makes the throttle the only retry layer of the
+// clientset built with cfg. client-go retries a request answered with HTTP 429
+// and a Retry-After header on its own, up to 10 times and without the throttle
+// seeing it, so the header is removed from these responses before client-go
+// reads it. The delay it suggests is handed to the throttle instead.
+func DisableClientRetries(cfg *rest.Config) {
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &retryAfterTransport{next: rt}
+	})
+}
+
+// retryAfterTransport removes the Retry-After header from responses with HTTP
+// 429, and records it in the hint of the request context.
+type retryAfterTransport struct {
+	next http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		return resp, err
+	}
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if hint, ok := req.Context().Value(retryAfterKey{}).(*atomic.Int64); ok {
+			if seconds, err := strconv.Atoi(value); err == nil {
+				hint.Store(int64(seconds))
+			}
+		}
+		resp.Header.Del("Retry-After")
+	}
+	return resp, nil
+}
+
+// list issues a list request once the rate limiter allows it, retrying while
+// the API server responds with HTTP 429. The latency of every attempt is
+// recorded per resource type. fn must issue the request with the context it
+// is given, which carries the Retry-After hint.
+func list[T any](ctx context.Context, t *throttle, gvk schema.GroupVersionKind, fn func(ctx context.Context) (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		if t.limiter != nil {
+			if err := t.limiter.Wait(ctx); err != nil {
+				var zero T
+				return zero, err
+			}
+		}
+
+		retryAfter := new(atomic.Int64)
+		start := time.Now()
+		res, err := fn(context.WithValue(ctx, retryAfterKey{}, retryAfter))
+		elapsed := time.Since(start)
+
+		switch {
+		case err == nil:
+			backfillListDuration.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, "success").Observe(elapsed.Seconds())
+			t.speedUp()
+			return res, nil
+		case !k8serrors.IsTooManyRequests(err):
+			backfillListDuration.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, "error").Observe(elapsed.Seconds())
+			return res, err
+		}
+
+		backfillListDuration.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, "throttled").Observe(elapsed.Seconds())
+		backfillListThrottledTotal.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Inc()
+		t.slowDown()
+		if attempt >= t.maxRetries {
+			return res, err
+		}
+
+		delay := t.delay(attempt, err, time.Duration(retryAfter.Load())*time.Second)
+		log.Warn().
+			Str("group", gvk.Group).Str("version", gvk.Version).Str("kind", gvk.Kind).
+			Int("attempt", attempt+1).
+			Dur("delay", delay).
+			Msg("list request throttled by the API server, backing off")
+
+		select {
+		case <-ctx.Done():
+			return res, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// delay returns how long to wait before retrying a throttled request. The
+// delay suggested by the API server, in the error or the Retry-After header,
+// wins; otherwise the delay grows exponentially with jitter.
+func (t *throttle) delay(attempt int, err error, retryAfter time.Duration) time.Duration {
+	if seconds, ok := k8serrors.SuggestsClientDelay(err); ok && seconds > 0 {
+		return min(time.Duration(seconds)*time.Second, maxRetryDelay)
+	}
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryDelay)
+	}
+	backoff := t.backoff << min(attempt, 16)
+	jitter := time.Duration(rand.Int63n(int64(t.backoff))) // #nosec G404
+	return min(backoff+jitter, maxRetryDelay)
+}
+
+func (t *throttle) slowDown() {
+	if t.limiter == nil {
+		return
+	}
+	limit := max(t.limiter.Limit()/2, min(minQPS, t.qps))
+	t.limiter.SetLimit(limit)
+	backfillRateLimit.Set(float64(limit))
+}
+
+func (t *throttle) speedUp() {
+	if t.limiter == nil || t.limiter.Limit() >= t.qps {
+		return
+	}
+	limit := min(t.limiter.Limit()+t.qps/10, t.qps)
+	t.limiter.SetLimit(limit)
+	backfillRateLimit.Set(float64(limit))
+}
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package backfiller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+func TestPriority(t *testing.T) {
+	order := []string{types.KindNode, types.KindNamespace, types.KindPod, types.KindDeployment}
+	for i := 1; i < len(order); i++ {
+		assert.Less(t, priority(order[i-1]), priority(order[i]), "%s before %s", order[i-1], order[i])
+	}
+	assert.Equal(t, priority(types.KindDeployment), priority(types.KindStatefulSet), "unlisted kinds keep catalog order")
+}
+
+func TestThrottle_SlowDownSpeedUp(t *testing.T) {
+	th := newThrottle(config.Backfill{QPS: 100, Burst: 10})
+
+	th.slowDown()
+	assert.Equal(t, rate.Limit(50), th.limiter.Limit())
+	for i := 0; i < 10; i++ {
+		th.slowDown()
+	}
+	assert.Equal(t, rate.Limit(minQPS), th.limiter.Limit(), "the limit never drops below minQPS")
+
+	th.speedUp()
+	assert.Equal(t, rate.Limit(11), th.limiter.Limit(), "each success adds a tenth of the configured rate")
+	for i := 0; i < 20; i++ {
+		th.speedUp()
+	}
+	assert.Equal(t, rate.Limit(100), th.limiter.Limit(), "the limit never exceeds the configured rate")
+
+	t.Run("below minQPS", func(t *testing.T) {
+		th := newThrottle(config.Backfill{QPS: 0.5, Burst: 1})
+		th.slowDown()
+		assert.Equal(t, rate.Limit(0.5), th.limiter.Limit(), "a configured rate below minQPS is kept")
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		th := newThrottle(config.Backfill{})
+		th.slowDown()
+		th.speedUp()
+		assert.Nil(t, th.limiter)
+	})
+}
+
+func TestThrottle_Delay(t *testing.T) {
+	th := newThrottle(config.Backfill{RetryBackoff: time.Second})
+
+	t.Run("retry after", func(t *testing.T) {
+		assert.Equal(t, 3*time.Second, th.delay(0, k8serrors.NewTooManyRequests("slow down", 3), 0))
+		assert.Equal(t, 3*time.Second, th.delay(5, k8serrors.NewTooManyRequests("slow down", 3), 0), "Retry-After wins over the backoff")
+		assert.Equal(t, maxRetryDelay, th.delay(0, k8serrors.NewTooManyRequests("slow down", 600), 0))
+	})
+
+	t.Run("retry after header", func(t *testing.T) {
+		assert.Equal(t, 2*time.Second, th.delay(5, k8serrors.NewTooManyRequests("slow down", 0), 2*time.Second), "the header wins over the backoff")
+		assert.Equal(t, 3*time.Second, th.delay(0, k8serrors.NewTooManyRequests("slow down", 3), 2*time.Second), "the error wins over the header")
+		assert.Equal(t, maxRetryDelay, th.delay(0, k8serrors.NewTooManyRequests("slow down", 0), time.Hour))
+	})
+
+	t.Run("exponential backoff", func(t *testing.T) {
+		for attempt, base := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+			delay := th.delay(attempt, k8serrors.NewTooManyRequests("slow down", 0), 0)
+			assert.GreaterOrEqual(t, delay, base)
+			assert.Less(t, delay, base+time.Second, "jitter stays below the initial backoff")
+		}
+		assert.Equal(t, maxRetryDelay, th.delay(10, k8serrors.NewTooManyRequests("slow down", 0), 0))
+	})
+}
+
+func TestThrottle_List(t *testing.T) {
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: types.KindPod}
+	throttled := k8serrors.NewTooManyRequests("slow down", 0)
+
+	tests := []struct {
+		name         string
+		maxRetries   int
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "success", maxRetries: 3, wantAttempts: 1},
+		{name: "retried", maxRetries: 3, errs: []error{throttled, throttled}, wantAttempts: 3},
+		{name: "retries exhausted", maxRetries: 1, errs: []error{throttled, throttled, throttled}, wantAttempts: 2, wantErr: throttled},
+		{name: "other errors are not retried", maxRetries: 3, errs: []error{errors.New("boom")}, wantAttempts: 1, wantErr: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := newThrottle(config.Backfill{QPS: 1000, Burst: 10, MaxRetries: tt.maxRetries, RetryBackoff: time.Millisecond})
+			attempts := 0
+			res, err := list(context.Background(), th, gvk, func(context.Context) (int, error) {
+				attempts++
+				if attempts <= len(tt.errs) {
+					return 0, tt.errs[attempts-1]
+				}
+				return 42, nil
+			})
+
+			assert.Equal(t, tt.wantAttempts, attempts)
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr.Error(), err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 42, res)
+		})
+	}
+
+	t.Run("cancelled while backing off", func(t *testing.T) {
+		th := newThrottle(config.Backfill{MaxRetries: 3, RetryBackoff: time.Hour})
+		ctx, cancel := context.WithCancel(context.Background())
+		_, err := list(ctx, th, gvk, func(context.Context) (int, error) {
+			cancel()
+			return 0, throttled
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestDisableClientRetries(t *testing.T) {
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: types.KindNamespace}
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"NamespaceList","apiVersion":"v1","items":[]}`))
+	}))
+	defer srv.Close()
+
+	cfg := &rest.Config{Host: srv.URL}
+	DisableClientRetries(cfg)
+	clientset, err := kubernetes.NewForConfig(cfg)
+	require.NoError(t, err)
+
+	th := newThrottle(config.Backfill{MaxRetries: 3, RetryBackoff: time.Hour})
+	attempts := 0
+	start := time.Now()
+	_, err = list(context.Background(), th, gvk, func(ctx context.Context) (metav1.ListInterface, error) {
+		attempts++
+		return clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, 2, attempts, "client-go does not retry on its own")
+	assert.Less(t, time.Since(start), time.Minute, "the Retry-After header is used instead of the backoff")
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package helper
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+// NewAdmissionReview builds a synthetic AdmissionReview for an object which
+// was not received through the admission webhook, such as objects discovered
+// by the backfiller or observed by an informer. The old object is optional and
+// only used for update and delete operations; for deletes the object itself is
+// carried in OldObjectRaw, matching what the API server sends.
+func NewAdmissionReview(gvk schema.GroupVersionKind, op types.AdmissionReviewOp, obj, old metav1.Object) (*types.AdmissionReview, error) {
+	ar := &types.AdmissionReview{
+		ID:        uuid.New().String(),
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Version:   types.AdmissionReviewVersionV1,
+		Operation: op,
+		RequestGVK: &metav1.GroupVersionKind{
+			Group:   gvk.Group,
+			Version: gvk.Version,
+			Kind:    gvk.Kind,
+		},
+	}
+
+	raw, err := EncodeToRawBytes(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s %s: %w", gvk.Kind, obj.GetName(), err)
+	}
+
+	if op == types.OperationDelete {
+		ar.OldObjectRaw = raw
+		return ar, nil
+	}
+	ar.NewObjectRaw = raw
+
+	if old != nil {
+		if ar.OldObjectRaw, err = EncodeToRawBytes(old); err != nil {
+			return nil, fmt.Errorf("failed to encode previous %s %s: %w", gvk.Kind, old.GetName(), err)
+		}
+	}
+	return ar, nil
+}
@@ -34,6 +34,11 @@ type Handler struct {
 	Update        AdmitFunc
 	Connect       AdmitFunc
 	Store         types.ResourceStore
+
+	// Policy, when set, is evaluated after the operation handler has allowed
+	// the request. Its decision, warnings and patch are merged into the
+	// response. Policy errors fail open.
+	Policy AdmitFunc
 }
 
 // Execute evaluates the request and try to execute the function for operation specified in the request.
@@ -90,9 +95,36 @@ func (h *Handler) Execute(ctx context.Context, r *types.AdmissionReview) (*types
 		return &types.AdmissionResponse{Allowed: true, Message: fmt.Sprintf("Invalid operation: %s", r.Operation)}, nil
 	}
 
+	if err == nil && res != nil && res.Allowed && h.Policy != nil {
+		err = instr.RunSpan(ctx, "executeAdmissionsReviewRequest_Policy", func(ctx context.Context, span *instr.Span) error {
+			res = applyPolicy(ctx, h.Policy, r, validatingObj, res)
+			return nil
+		})
+	}
+
 	return res, err
 }
 
+// applyPolicy evaluates the policy function and merges its decision into res.
+func applyPolicy(ctx context.Context, fn AdmitFunc, r *types.AdmissionReview, obj metav1.Object, res *types.AdmissionResponse) *types.AdmissionResponse {
+	pres, err := fn(ctx, r, obj)
+	if err != nil || pres == nil {
+		// RULE: policy failures never block admission
+		return res
+	}
+
+	merged := *res
+	merged.Allowed = pres.Allowed
+	if !pres.Allowed {
+		merged.Message = pres.Message
+	}
+	merged.Warnings = append(append([]string{}, res.Warnings...), pres.Warnings...)
+	if pres.Patch != nil {
+		merged.Patch = pres.Patch
+	}
+	return &merged
+}
+
 func middleware(ctx context.Context, fn AdmitFunc, r *types.AdmissionReview, obj metav1.Object) (*types.AdmissionResponse, error) {
 	// This is a setup which would allow registration of middleware functions
 	// which we could invoke before finally invoking the actual function.
@@ -130,6 +130,78 @@ func TestHandler_Execute(t *testing.T) {
 	}
 }
 
+func TestHandler_ExecutePolicy(t *testing.T) {
+	ctx := context.Background()
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "namespace",
+			Name:      "deployment",
+		},
+	}
+	req := &types.AdmissionReview{
+		Operation:    types.OperationCreate,
+		NewObjectRaw: getRawObject(appsv1.SchemeGroupVersion, deployment),
+	}
+
+	allow := func(ctx context.Context, r *types.AdmissionReview, obj metav1.Object) (*types.AdmissionResponse, error) {
+		return &types.AdmissionResponse{Allowed: true, Warnings: []string{"handler"}}, nil
+	}
+
+	tests := []struct {
+		name           string
+		policy         hook.AdmitFunc
+		expectAllow    bool
+		expectMsg      string
+		expectWarnings []string
+		expectPatch    []byte
+	}{
+		{
+			name: "policy denies",
+			policy: func(ctx context.Context, r *types.AdmissionReview, obj metav1.Object) (*types.AdmissionResponse, error) {
+				return &types.AdmissionResponse{Allowed: false, Message: "denied"}, nil
+			},
+			expectAllow:    false,
+			expectMsg:      "denied",
+			expectWarnings: []string{"handler"},
+		},
+		{
+			name: "policy warns and patches",
+			policy: func(ctx context.Context, r *types.AdmissionReview, obj metav1.Object) (*types.AdmissionResponse, error) {
+				return &types.AdmissionResponse{Allowed: true, Warnings: []string{"policy"}, Patch: []byte("[]")}, nil
+			},
+			expectAllow:    true,
+			expectWarnings: []string{"handler", "policy"},
+			expectPatch:    []byte("[]"),
+		},
+		{
+			name: "policy error fails open",
+			policy: func(ctx context.Context, r *types.AdmissionReview, obj metav1.Object) (*types.AdmissionResponse, error) {
+				return nil, assert.AnError
+			},
+			expectAllow:    true,
+			expectWarnings: []string{"handler"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &hook.Handler{
+				ObjectCreator: helper.NewStaticObjectCreator(&appsv1.Deployment{}),
+				Create:        allow,
+				Policy:        tt.policy,
+			}
+
+			result, err := h.Execute(ctx, req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectAllow, result.Allowed)
+			assert.Equal(t, tt.expectMsg, result.Message)
+			assert.Equal(t, tt.expectWarnings, result.Warnings)
+			assert.Equal(t, tt.expectPatch, result.Patch)
+		})
+	}
+}
+
 func getRawObject(s schema.GroupVersion, o runtime.Object) []byte {
 	scheme := runtime.NewScheme()
 	corev1.AddToScheme(scheme)
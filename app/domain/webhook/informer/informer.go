@@ -0,0 +1,258 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package informer feeds the webhook controller from Kubernetes shared
+// informers (list+watch) instead of admission requests.
+//
+// Some clusters, such as GKE Autopilot or locked-down EKS installations, do not
+// allow a ValidatingWebhookConfiguration to be created. In those clusters the
+// Watcher observes every supported resource type through client-go informers
+// and converts each add, update and delete event into a synthetic
+// AdmissionReview, which is passed to the same WebhookController, resource
+// handlers and ResourceStore used by the admission webhook. Updates which
+// change neither the labels nor the annotations of an object are not
+// reviewed, since that is all the handlers record.
+//
+// Only the preferred (GA) API version of each kind is watched: the API server
+// serves every stored object through it, and watching deprecated versions
+// would fail on clusters where they have been removed. Gateway API and CRD
+// kinds require dedicated clients and are not covered by the informer mode.
+package informer
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/helper"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+// resource describes a watched kind and how to obtain its shared informer.
+type resource struct {
+	gvk      schema.GroupVersionKind
+	informer func(f informers.SharedInformerFactory) cache.SharedIndexInformer
+}
+
+// catalog lists every kind observed in informer mode. Kinds use the same
+// lowercase naming as the webhook controller's dispatch map.
+var catalog = []resource{
+	{schema.GroupVersionKind{Group: types.GroupCore, Version: types.V1, Kind: types.KindNamespace}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Namespaces().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupCore, Version: types.V1, Kind: types.KindNode}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Nodes().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupCore, Version: types.V1, Kind: types.KindPod}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Pods().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupCore, Version: types.V1, Kind: types.KindService}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Services().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupCore, Version: types.V1, Kind: types.KindPersistentVolume}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().PersistentVolumes().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupCore, Version: types.V1, Kind: types.KindPersistentVolumeClaim}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().PersistentVolumeClaims().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupApps, Version: types.V1, Kind: types.KindDeployment}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().Deployments().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupApps, Version: types.V1, Kind: types.KindStatefulSet}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().StatefulSets().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupApps, Version: types.V1, Kind: types.KindDaemonSet}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().DaemonSets().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupApps, Version: types.V1, Kind: types.KindReplicaSet}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().ReplicaSets().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupBatch, Version: types.V1, Kind: types.KindJob}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().Jobs().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupBatch, Version: types.V1, Kind: types.KindCronJob}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().CronJobs().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupNet, Version: types.V1, Kind: types.KindIngress}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Networking().V1().Ingresses().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupNet, Version: types.V1, Kind: types.KindIngressClass}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Networking().V1().IngressClasses().Informer()
+	}},
+	{schema.GroupVersionKind{Group: types.GroupStorage, Version: types.V1, Kind: types.KindStorageClass}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Storage().V1().StorageClasses().Informer()
+	}},
+}
+
+// Watcher observes cluster resources with shared informers and submits every
+// change to the webhook controller. It implements types.Runnable, and can be
+// run again after it was shut down, such as when the lease of the leader
+// elector is acquired again.
+type Watcher struct {
+	controller   webhook.WebhookController
+	clientset    kubernetes.Interface
+	resyncPeriod time.Duration
+	factory      informers.SharedInformerFactory
+
+	mu          sync.Mutex
+	running     bool
+	originalCtx context.Context
+	ctx         context.Context
+	cancel      context.CancelFunc
+	synced      []cache.InformerSynced
+}
+
+// New creates a Watcher. Informers are created lazily in Run, and only for
+// kinds whose labels or annotations are enabled in the settings.
+func New(ctx context.Context, clientset kubernetes.Interface, controller webhook.WebhookController, settings *config.Settings) *Watcher {
+	return &Watcher{
+		controller:   controller,
+		clientset:    clientset,
+		resyncPeriod: settings.Watch.ResyncPeriod,
+		originalCtx:  ctx,
+	}
+}
+
+// Run registers the informers and starts watching. It returns immediately;
+// use WaitForCacheSync to block until the initial list has been processed.
+// Every run lists the resources again, so they are all reviewed again.
+func (w *Watcher) Run() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running {
+		return nil
+	}
+
+	// A factory cannot be started again once shut down, and its informers
+	// cannot be run twice, so each run uses new ones.
+	w.ctx, w.cancel = context.WithCancel(w.originalCtx)
+	w.factory = informers.NewSharedInformerFactory(w.clientset, w.resyncPeriod)
+	w.synced = nil
+
+	for _, res := range catalog {
+		cfg := w.controller.GetConfigurationAccessor(res.gvk.Group, res.gvk.Version, res.gvk.Kind)
+		if cfg == nil || !((cfg.LabelsEnabled() && cfg.LabelsEnabledForType()) || (cfg.AnnotationsEnabled() && cfg.AnnotationsEnabledForType())) {
+			log.Debug().Str("group", res.gvk.Group).Str("version", res.gvk.Version).Str("kind", res.gvk.Kind).Msg("watch disabled")
+			continue
+		}
+
+		informer := res.informer(w.factory)
+		registration, err := informer.AddEventHandler(w.eventHandler(res.gvk))
+		if err != nil {
+			w.cancel()
+			return err
+		}
+		w.synced = append(w.synced, registration.HasSynced)
+		log.Info().Str("group", res.gvk.Group).Str("version", res.gvk.Version).Str("kind", res.gvk.Kind).Msg("watch enabled")
+	}
+
+	w.factory.Start(w.ctx.Done())
+	w.running = true
+	return nil
+}
+
+// WaitForCacheSync blocks until every registered informer has delivered its
+// initial list to the controller, or the context is done.
+func (w *Watcher) WaitForCacheSync(ctx context.Context) bool {
+	w.mu.Lock()
+	synced := w.synced
+	w.mu.Unlock()
+
+	start := time.Now()
+	ok := cache.WaitForCacheSync(ctx.Done(), synced...)
+	log.Info().Bool("synced", ok).Dur("elapsed", time.Since(start)).Int("informers", len(synced)).Msg("informer caches synchronized")
+	return ok
+}
+
+// IsRunning reports whether the informers are running.
+func (w *Watcher) IsRunning() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.running
+}
+
+// Shutdown stops all informers and waits for their goroutines to exit.
+func (w *Watcher) Shutdown() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.running {
+		return nil
+	}
+	w.cancel()
+	w.factory.Shutdown()
+	w.running = false
+	return nil
+}
+
+// eventHandler converts informer notifications for the given kind into
+// admission reviews.
+func (w *Watcher) eventHandler(gvk schema.GroupVersionKind) cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) {
+			w.review(gvk, types.OperationCreate, obj, nil)
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			if !metadataChanged(oldObj, newObj) {
+				return
+			}
+			w.review(gvk, types.OperationUpdate, newObj, oldObj)
+		},
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			w.review(gvk, types.OperationDelete, obj, nil)
+		},
+	}
+}
+
+// metadataChanged reports whether an update changed the labels or annotations
+// of an object, which is all the resource handlers record. Status updates,
+// such as the pod and node heartbeats, and resyncs, which notify the cached
+// object again with the same ResourceVersion, are skipped.
+func metadataChanged(oldObj, newObj any) bool {
+	o, ok := oldObj.(metav1.Object)
+	if !ok {
+		return true
+	}
+	n, ok := newObj.(metav1.Object)
+	if !ok {
+		return true
+	}
+	if o.GetResourceVersion() != "" && o.GetResourceVersion() == n.GetResourceVersion() {
+		return false
+	}
+	return !maps.Equal(o.GetLabels(), n.GetLabels()) || !maps.Equal(o.GetAnnotations(), n.GetAnnotations())
+}
+
+func (w *Watcher) review(gvk schema.GroupVersionKind, op types.AdmissionReviewOp, obj, old any) {
+	o, ok := obj.(metav1.Object)
+	if !ok {
+		log.Warn().Str("kind", gvk.Kind).Msgf("unexpected informer object of type %T", obj)
+		return
+	}
+	var oldObj metav1.Object
+	if old != nil {
+		oldObj, _ = old.(metav1.Object)
+	}
+
+	ar, err := helper.NewAdmissionReview(gvk, op, o, oldObj)
+	if err != nil {
+		log.Error().Err(err).Str("group", gvk.Group).Str("version", gvk.Version).Str("kind", gvk.Kind).Str("namespace", o.GetNamespace()).Str("name", o.GetName()).Msg("failed to build admission review")
+		return
+	}
+
+	if _, err := w.controller.Review(w.ctx, ar); err != nil {
+		log.Error().Err(err).Str("kind", gvk.Kind).Str("namespace", o.GetNamespace()).Str("name", o.GetName()).Str("operation", string(op)).Msg("failed to review observed resource")
+	}
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package informer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/informer"
+	webhookmocks "github.com/cloudzero/cloudzero-agent/app/domain/webhook/mocks"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/mocks"
+)
+
+func TestWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	settings := &config.Settings{
+		Filters: config.Filters{
+			Labels: config.Labels{
+				Enabled:   true,
+				Resources: config.Resources{Pods: true, Namespaces: true},
+				Patterns:  []string{".*"},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	inner, err := webhook.NewWebhookFactory(mocks.NewMockResourceStore(mockCtl), settings, mocks.NewMockClock(time.Now()))
+	require.NoError(t, err)
+	controller := webhookmocks.NewRecordingController(inner)
+
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "default"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+	)
+
+	w := informer.New(ctx, clientset, controller, settings)
+	require.NoError(t, w.Run())
+	assert.True(t, w.IsRunning())
+	require.True(t, w.WaitForCacheSync(ctx))
+
+	assert.NotNil(t, controller.Find(types.KindNamespace, types.OperationCreate, "default"))
+	assert.NotNil(t, controller.Find(types.KindPod, types.OperationCreate, "existing"))
+	assert.Nil(t, controller.Find(types.KindNode, types.OperationCreate, "node-1"), "nodes are disabled in the settings")
+
+	pods := clientset.CoreV1().Pods("default")
+	_, err = pods.Create(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = pods.Update(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default", Labels: map[string]string{"team": "a"}}}, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, pods.Delete(ctx, "existing", metav1.DeleteOptions{}))
+
+	assert.Eventually(t, func() bool {
+		return controller.Find(types.KindPod, types.OperationCreate, "new") != nil &&
+			controller.Find(types.KindPod, types.OperationUpdate, "new") != nil &&
+			controller.Find(types.KindPod, types.OperationDelete, "existing") != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Status updates do not change the labels or annotations
+	_, err = pods.UpdateStatus(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default", Labels: map[string]string{"team": "a"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Never(t, func() bool {
+		return controller.Count(types.KindPod, types.OperationUpdate, "new") > 1
+	}, 200*time.Millisecond, 10*time.Millisecond, "status updates are not reviewed")
+
+	update := controller.Find(types.KindPod, types.OperationUpdate, "new")
+	assert.NotEmpty(t, update.NewObjectRaw)
+	assert.NotEmpty(t, update.OldObjectRaw)
+	assert.Equal(t, "default", update.Namespace)
+
+	deleted := controller.Find(types.KindPod, types.OperationDelete, "existing")
+	assert.Empty(t, deleted.NewObjectRaw)
+	assert.NotEmpty(t, deleted.OldObjectRaw)
+
+	require.NoError(t, w.Shutdown())
+	assert.False(t, w.IsRunning())
+}
+
+func TestWatcher_RunAfterShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	settings := &config.Settings{
+		Filters: config.Filters{
+			Labels: config.Labels{
+				Enabled:   true,
+				Resources: config.Resources{Pods: true},
+				Patterns:  []string{".*"},
+			},
+		},
+	}
+
+	mockCtl := gomock.NewController(t)
+	inner, err := webhook.NewWebhookFactory(mocks.NewMockResourceStore(mockCtl), settings, mocks.NewMockClock(time.Now()))
+	require.NoError(t, err)
+	controller := webhookmocks.NewRecordingController(inner)
+	clientset := fake.NewClientset()
+	pods := clientset.CoreV1().Pods("default")
+
+	w := informer.New(ctx, clientset, controller, settings)
+	require.NoError(t, w.Run())
+	require.True(t, w.WaitForCacheSync(ctx))
+	require.NoError(t, w.Shutdown())
+
+	// The lease is acquired again, so the watcher runs again
+	require.NoError(t, w.Run())
+	assert.True(t, w.IsRunning())
+	require.True(t, w.WaitForCacheSync(ctx))
+
+	_, err = pods.Create(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "after", Namespace: "default"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return controller.Count(types.KindPod, types.OperationCreate, "after") > 0
+	}, 5*time.Second, 10*time.Millisecond, "the informers watch again")
+	assert.Never(t, func() bool {
+		return controller.Count(types.KindPod, types.OperationCreate, "after") > 1
+	}, 200*time.Millisecond, 10*time.Millisecond, "each change is reviewed once")
+
+	require.NoError(t, w.Shutdown())
+}
+
+func TestWatcher_Resync(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	settings := &config.Settings{
+		Filters: config.Filters{
+			Labels: config.Labels{
+				Enabled:   true,
+				Resources: config.Resources{Pods: true},
+				Patterns:  []string{".*"},
+			},
+		},
+		Watch: config.Watch{Enabled: true, ResyncPeriod: 20 * time.Millisecond},
+	}
+
+	mockCtl := gomock.NewController(t)
+	inner, err := webhook.NewWebhookFactory(mocks.NewMockResourceStore(mockCtl), settings, mocks.NewMockClock(time.Now()))
+	require.NoError(t, err)
+	controller := webhookmocks.NewRecordingController(inner)
+	clientset := fake.NewClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "default", ResourceVersion: "1"}},
+	)
+
+	w := informer.New(ctx, clientset, controller, settings)
+	require.NoError(t, w.Run())
+	defer func() { _ = w.Shutdown() }()
+	require.True(t, w.WaitForCacheSync(ctx))
+
+	assert.Equal(t, 1, controller.Count(types.KindPod, types.OperationCreate, "existing"))
+	assert.Never(t, func() bool {
+		return controller.Count(types.KindPod, types.OperationUpdate, "existing") > 0
+	}, 200*time.Millisecond, 10*time.Millisecond, "resyncs of unchanged objects are not reviewed")
+}
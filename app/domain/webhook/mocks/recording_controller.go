@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mocks provides test doubles for the webhook domain.
+package mocks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+// RecordingController wraps a real controller and records every review it
+// receives, so the code feeding the controller can be asserted without a
+// resource store. Every review is allowed.
+type RecordingController struct {
+	webhook.WebhookController
+
+	mu      sync.Mutex
+	reviews []*types.AdmissionReview
+}
+
+// NewRecordingController wraps the controller.
+func NewRecordingController(inner webhook.WebhookController) *RecordingController {
+	return &RecordingController{WebhookController: inner}
+}
+
+// Review records the review.
+func (c *RecordingController) Review(ctx context.Context, ar *types.AdmissionReview) (*types.AdmissionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reviews = append(c.reviews, ar)
+	return &types.AdmissionResponse{Allowed: true}, nil
+}
+
+// Find returns the first review of the operation on the named object of the
+// kind, or nil.
+func (c *RecordingController) Find(kind string, op types.AdmissionReviewOp, name string) *types.AdmissionReview {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ar := range c.reviews {
+		if ar.RequestGVK.Kind == kind && ar.Operation == op && ar.Name == name {
+			return ar
+		}
+	}
+	return nil
+}
+
+// Count returns the number of reviews of the operation on the named object of
+// the kind.
+func (c *RecordingController) Count(kind string, op types.AdmissionReviewOp, name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, ar := range c.reviews {
+		if ar.RequestGVK.Kind == kind && ar.Operation == op && ar.Name == name {
+			n++
+		}
+	}
+	return n
+}
+
+// Reviewed reports whether the object of the kind in the namespace was
+// reviewed by any operation. Cluster-scoped objects have an empty namespace.
+func (c *RecordingController) Reviewed(kind, namespace, name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ar := range c.reviews {
+		if ar.RequestGVK.Kind == kind && ar.Namespace == namespace && ar.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package webhook
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/helper"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/hook"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+// mutationController serves the MutatingWebhookConfiguration used by the policy
+// mutate mode. It only evaluates the policy: resources are recorded by the
+// validating controller, so nothing is stored here and the request is not
+// counted in the webhook event metrics a second time.
+type mutationController struct {
+	handler  *hook.Handler
+	settings *config.Settings
+}
+
+// NewMutationController creates a WebhookController which admits every request
+// and returns the patches and warnings produced by the given policy.
+func NewMutationController(settings *config.Settings, policy hook.AdmitFunc) WebhookController {
+	return &mutationController{
+		handler: &hook.Handler{
+			ObjectCreator: helper.NewDynamicObjectCreator(),
+			Create:        hook.AllowAlways,
+			Update:        hook.AllowAlways,
+			Delete:        hook.AllowAlways,
+			Connect:       hook.AllowAlways,
+			Policy:        policy,
+		},
+		settings: settings,
+	}
+}
+
+func (mc *mutationController) GetSupported() map[string]map[string]map[string]metav1.Object {
+	return map[string]map[string]map[string]metav1.Object{}
+}
+
+func (mc *mutationController) IsSupported(_, _, _ string) bool {
+	return false
+}
+
+func (mc *mutationController) GetConfigurationAccessor(_, _, _ string) config.ConfigAccessor {
+	return nil
+}
+
+func (mc *mutationController) Review(ctx context.Context, ar *types.AdmissionReview) (*types.AdmissionResponse, error) {
+	return mc.handler.Execute(ctx, ar)
+}
+
+func (mc *mutationController) Settings() *config.Settings {
+	return mc.settings
+}
@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+// DefaultNamespaceCacheTTL is how long namespace labels are cached before
+// being fetched again from the API server.
+const DefaultNamespaceCacheTTL = time.Minute
+
+type namespaceEntry struct {
+	labels  map[string]string
+	fetched time.Time
+}
+
+// NamespaceLabelCache is a NamespaceLabeler which reads namespaces from the
+// Kubernetes API and caches their labels for a fixed period, keeping the
+// admission path off the API server for the common case.
+type NamespaceLabelCache struct {
+	clientset kubernetes.Interface
+	clock     types.TimeProvider
+	ttl       time.Duration
+
+	mu      sync.Mutex
+	entries map[string]namespaceEntry
+}
+
+// NewNamespaceLabelCache creates a NamespaceLabelCache. A non-positive ttl
+// selects DefaultNamespaceCacheTTL.
+func NewNamespaceLabelCache(clientset kubernetes.Interface, clock types.TimeProvider, ttl time.Duration) *NamespaceLabelCache {
+	if ttl <= 0 {
+		ttl = DefaultNamespaceCacheTTL
+	}
+	return &NamespaceLabelCache{
+		clientset: clientset,
+		clock:     clock,
+		ttl:       ttl,
+		entries:   make(map[string]namespaceEntry),
+	}
+}
+
+// NamespaceLabels returns the labels of the named namespace.
+func (c *NamespaceLabelCache) NamespaceLabels(ctx context.Context, name string) (map[string]string, error) {
+	now := c.clock.GetCurrentTime()
+
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && now.Sub(entry.fetched) < c.ttl {
+		return entry.labels, nil
+	}
+
+	ns, err := c.clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", name, err)
+	}
+
+	c.mu.Lock()
+	c.entries[name] = namespaceEntry{labels: ns.GetLabels(), fetched: now}
+	c.mu.Unlock()
+	return ns.GetLabels(), nil
+}
@@ -0,0 +1,268 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package policy enforces required cost-allocation labels on resources admitted
+// through the CloudZero Agent webhook.
+//
+// The Enforcer evaluates the configured config.Policy against each namespaced
+// resource and, depending on the mode, either attaches admission warnings,
+// rejects the request, or returns a JSON patch which defaults the missing labels
+// from the labels of the resource's namespace. In dry-run mode the decision is
+// only reported through metrics and the request is allowed untouched.
+//
+// The API server only applies the patches of a mutating webhook, so in mutate
+// mode the Enforcer is served by the mutation controller on its own endpoint
+// instead of being attached to the validating handlers.
+//
+// Enforcement is limited to CREATE operations. Updates are evaluated as well,
+// but never denied or mutated: the policy produces warnings only, so that
+// controllers reconciling pre-existing resources are not broken by a policy
+// rollout.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+const (
+	DecisionAllowed = "allowed"
+	DecisionExempt  = "exempt"
+	DecisionWarned  = "warned"
+	DecisionDenied  = "denied"
+	DecisionMutated = "mutated"
+)
+
+var (
+	metricsOnce sync.Once
+
+	metricPolicyDecisionTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: types.ObservabilityMetric("webhook_policy_decisions_total"),
+			Help: "Total number of cost-allocation policy decisions, filterable by mode, decision and dry_run",
+		},
+		[]string{"mode", "decision", "dry_run"},
+	)
+
+	metricPolicyMissingLabelTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: types.ObservabilityMetric("webhook_policy_missing_labels_total"),
+			Help: "Total number of required labels found missing on admitted resources, filterable by namespace, kind_resource and label",
+		},
+		[]string{"namespace", "kind_resource", "label"},
+	)
+)
+
+// NamespaceLabeler returns the labels of a namespace. It is used in mutate mode
+// to derive default values for missing labels.
+type NamespaceLabeler interface {
+	NamespaceLabels(ctx context.Context, name string) (map[string]string, error)
+}
+
+// Enforcer evaluates the cost-allocation label policy.
+type Enforcer struct {
+	policy     *config.Policy
+	namespaces NamespaceLabeler
+}
+
+// NewEnforcer creates an Enforcer for the given policy. The namespaces lookup is
+// only required in mutate mode; when nil, missing labels are reported as
+// warnings instead of being defaulted.
+func NewEnforcer(policy *config.Policy, namespaces NamespaceLabeler) *Enforcer {
+	metricsOnce.Do(func() {
+		prometheus.MustRegister(
+			metricPolicyDecisionTotal,
+			metricPolicyMissingLabelTotal,
+		)
+	})
+
+	return &Enforcer{
+		policy:     policy,
+		namespaces: namespaces,
+	}
+}
+
+// Admit evaluates the policy for the given admission request and object. It
+// has the signature of a hook.AdmitFunc so it can be attached to any handler.
+func (e *Enforcer) Admit(ctx context.Context, r *types.AdmissionReview, obj metav1.Object) (*types.AdmissionResponse, error) {
+	allow := &types.AdmissionResponse{Allowed: true}
+
+	if !e.policy.Enabled() || r.Namespace == "" {
+		return allow, nil
+	}
+	if r.Operation != types.OperationCreate && r.Operation != types.OperationUpdate {
+		return allow, nil
+	}
+
+	if e.policy.Exempt(r.Namespace, r.UserInfo.Username) {
+		e.record(DecisionExempt)
+		return allow, nil
+	}
+
+	missing := e.missingLabels(obj)
+	if len(missing) == 0 {
+		e.record(DecisionAllowed)
+		return allow, nil
+	}
+
+	kind := ""
+	if r.RequestGVK != nil {
+		kind = strings.ToLower(r.RequestGVK.Kind)
+	}
+	for _, label := range missing {
+		metricPolicyMissingLabelTotal.WithLabelValues(r.Namespace, kind, label).Inc()
+	}
+
+	res := e.decide(ctx, r, obj, missing)
+	if e.policy.DryRun {
+		log.Ctx(ctx).Info().
+			Str("namespace", r.Namespace).
+			Str("name", obj.GetName()).
+			Str("kind", kind).
+			Strs("missing", missing).
+			Bool("allowed", res.Allowed).
+			Msg("policy dry-run")
+		return allow, nil
+	}
+	return res, nil
+}
+
+// decide builds the response for a request which is missing labels and records
+// the decision.
+func (e *Enforcer) decide(ctx context.Context, r *types.AdmissionReview, obj metav1.Object, missing []string) *types.AdmissionResponse {
+	enforce := r.Operation == types.OperationCreate
+
+	switch {
+	case enforce && e.policy.Mode == config.PolicyModeDeny && e.policy.Enforced(r.Namespace):
+		e.record(DecisionDenied)
+		return &types.AdmissionResponse{
+			Allowed: false,
+			Message: fmt.Sprintf("missing required cost allocation labels: %s", strings.Join(missing, ", ")),
+		}
+
+	case enforce && e.policy.Mode == config.PolicyModeMutate:
+		defaults, unresolved := e.defaults(ctx, r.Namespace, missing)
+		if len(defaults) == 0 {
+			e.record(DecisionWarned)
+			return &types.AdmissionResponse{Allowed: true, Warnings: warnings(unresolved)}
+		}
+
+		patch, err := labelPatch(obj, defaults)
+		if err != nil {
+			log.Ctx(ctx).Warn().Err(err).Msg("failed to build policy patch")
+			e.record(DecisionWarned)
+			return &types.AdmissionResponse{Allowed: true, Warnings: warnings(missing)}
+		}
+
+		e.record(DecisionMutated)
+		return &types.AdmissionResponse{Allowed: true, Patch: patch, Warnings: warnings(unresolved)}
+	}
+
+	e.record(DecisionWarned)
+	return &types.AdmissionResponse{Allowed: true, Warnings: warnings(missing)}
+}
+
+// missingLabels returns the required labels which are absent or empty on obj,
+// in a stable order.
+func (e *Enforcer) missingLabels(obj metav1.Object) []string {
+	labels := obj.GetLabels()
+	missing := []string{}
+	for _, label := range e.policy.RequiredLabels {
+		if labels[label] == "" {
+			missing = append(missing, label)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// defaults resolves default values for the missing labels from the namespace
+// labels. Labels without a default are returned as unresolved.
+func (e *Enforcer) defaults(ctx context.Context, namespace string, missing []string) (map[string]string, []string) {
+	if e.namespaces == nil {
+		return nil, missing
+	}
+
+	nsLabels, err := e.namespaces.NamespaceLabels(ctx, namespace)
+	if err != nil {
+		log.Ctx(ctx).Warn().Err(err).Str("namespace", namespace).Msg("failed to look up namespace labels")
+		return nil, missing
+	}
+
+	defaults := map[string]string{}
+	unresolved := []string{}
+	for _, label := range missing {
+		source := label
+		if mapped, ok := e.policy.Defaults[label]; ok {
+			source = mapped
+		}
+		if value := nsLabels[source]; value != "" {
+			defaults[label] = value
+		} else {
+			unresolved = append(unresolved, label)
+		}
+	}
+	return defaults, unresolved
+}
+
+func (e *Enforcer) record(decision string) {
+	metricPolicyDecisionTotal.WithLabelValues(string(e.policy.Mode), decision, strconv.FormatBool(e.policy.DryRun)).Inc()
+}
+
+func warnings(missing []string) []string {
+	if len(missing) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(missing))
+	for _, label := range missing {
+		out = append(out, fmt.Sprintf("missing required cost allocation label %q", label))
+	}
+	return out
+}
+
+// patchOperation is a single RFC 6902 JSON patch operation.
+type patchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
+// labelPatch returns a JSON patch adding the given labels to obj.
+func labelPatch(obj metav1.Object, labels map[string]string) ([]byte, error) {
+	if len(obj.GetLabels()) == 0 {
+		return json.Marshal([]patchOperation{{Op: "add", Path: "/metadata/labels", Value: labels}})
+	}
+
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ops := make([]patchOperation, 0, len(labels))
+	for _, key := range keys {
+		ops = append(ops, patchOperation{
+			Op:    "add",
+			Path:  "/metadata/labels/" + escapePointer(key),
+			Value: labels[key],
+		})
+	}
+	return json.Marshal(ops)
+}
+
+// escapePointer escapes a JSON pointer reference token per RFC 6901.
+func escapePointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package policy_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/policy"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/mocks"
+)
+
+func makeReview(op types.AdmissionReviewOp, namespace, username string) *types.AdmissionReview {
+	return &types.AdmissionReview{
+		ID:         "uid",
+		Namespace:  namespace,
+		Operation:  op,
+		RequestGVK: &metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		UserInfo:   authenticationv1.UserInfo{Username: username},
+	}
+}
+
+func makePod(namespace string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace, Labels: labels}}
+}
+
+func newNamespaces(t *testing.T, labels map[string]string) policy.NamespaceLabeler {
+	t.Helper()
+	clientset := fake.NewClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: labels},
+	})
+	return policy.NewNamespaceLabelCache(clientset, mocks.NewMockClock(time.Now()), 0)
+}
+
+func TestEnforcer_Admit(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		policy       config.Policy
+		namespaces   map[string]string
+		review       *types.AdmissionReview
+		labels       map[string]string
+		wantAllowed  bool
+		wantWarnings int
+		wantPatch    string
+	}{
+		{
+			name:        "disabled",
+			policy:      config.Policy{Mode: config.PolicyModeDisabled, RequiredLabels: []string{"team"}},
+			review:      makeReview(types.OperationCreate, "payments", ""),
+			wantAllowed: true,
+		},
+		{
+			name:        "labels present",
+			policy:      config.Policy{Mode: config.PolicyModeDeny, RequiredLabels: []string{"team"}},
+			review:      makeReview(types.OperationCreate, "payments", ""),
+			labels:      map[string]string{"team": "core"},
+			wantAllowed: true,
+		},
+		{
+			name:         "warn",
+			policy:       config.Policy{Mode: config.PolicyModeWarn, RequiredLabels: []string{"team", "cost-center"}},
+			review:       makeReview(types.OperationCreate, "payments", ""),
+			wantAllowed:  true,
+			wantWarnings: 2,
+		},
+		{
+			name:        "deny",
+			policy:      config.Policy{Mode: config.PolicyModeDeny, RequiredLabels: []string{"team"}},
+			review:      makeReview(types.OperationCreate, "payments", ""),
+			wantAllowed: false,
+		},
+		{
+			name:         "deny outside enforced namespaces warns",
+			policy:       config.Policy{Mode: config.PolicyModeDeny, RequiredLabels: []string{"team"}, Namespaces: []string{"billing"}},
+			review:       makeReview(types.OperationCreate, "payments", ""),
+			wantAllowed:  true,
+			wantWarnings: 1,
+		},
+		{
+			name:         "deny on update warns",
+			policy:       config.Policy{Mode: config.PolicyModeDeny, RequiredLabels: []string{"team"}},
+			review:       makeReview(types.OperationUpdate, "payments", ""),
+			wantAllowed:  true,
+			wantWarnings: 1,
+		},
+		{
+			name:        "dry run",
+			policy:      config.Policy{Mode: config.PolicyModeDeny, DryRun: true, RequiredLabels: []string{"team"}},
+			review:      makeReview(types.OperationCreate, "payments", ""),
+			wantAllowed: true,
+		},
+		{
+			name:        "exempt namespace",
+			policy:      config.Policy{Mode: config.PolicyModeDeny, RequiredLabels: []string{"team"}, Exemptions: config.PolicyExemptions{Namespaces: []string{"payments"}}},
+			review:      makeReview(types.OperationCreate, "payments", ""),
+			wantAllowed: true,
+		},
+		{
+			name:        "exempt service account",
+			policy:      config.Policy{Mode: config.PolicyModeDeny, RequiredLabels: []string{"team"}, Exemptions: config.PolicyExemptions{ServiceAccounts: []string{"kube-system:replicaset-controller"}}},
+			review:      makeReview(types.OperationCreate, "payments", "system:serviceaccount:kube-system:replicaset-controller"),
+			wantAllowed: true,
+		},
+		{
+			name:        "mutate without labels",
+			policy:      config.Policy{Mode: config.PolicyModeMutate, RequiredLabels: []string{"team"}, Defaults: map[string]string{"team": "owner"}},
+			namespaces:  map[string]string{"owner": "payments-team"},
+			review:      makeReview(types.OperationCreate, "payments", ""),
+			wantAllowed: true,
+			wantPatch:   `[{"op":"add","path":"/metadata/labels","value":{"team":"payments-team"}}]`,
+		},
+		{
+			name:         "mutate with existing labels",
+			policy:       config.Policy{Mode: config.PolicyModeMutate, RequiredLabels: []string{"example.com/team", "cost-center"}},
+			namespaces:   map[string]string{"example.com/team": "payments-team"},
+			review:       makeReview(types.OperationCreate, "payments", ""),
+			labels:       map[string]string{"app": "api"},
+			wantAllowed:  true,
+			wantWarnings: 1,
+			wantPatch:    `[{"op":"add","path":"/metadata/labels/example.com~1team","value":"payments-team"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enforcer := policy.NewEnforcer(&tt.policy, newNamespaces(t, tt.namespaces))
+
+			res, err := enforcer.Admit(ctx, tt.review, makePod(tt.review.Namespace, tt.labels))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAllowed, res.Allowed)
+			assert.Len(t, res.Warnings, tt.wantWarnings)
+			if !tt.wantAllowed {
+				assert.Contains(t, res.Message, "team")
+			}
+			if tt.wantPatch == "" {
+				assert.Empty(t, res.Patch)
+			} else {
+				assert.JSONEq(t, tt.wantPatch, string(res.Patch))
+				var ops []map[string]any
+				assert.NoError(t, json.Unmarshal(res.Patch, &ops))
+			}
+		})
+	}
+}
+
+func TestNamespaceLabelCache(t *testing.T) {
+	ctx := context.Background()
+	clock := mocks.NewMockClock(time.Now())
+	clientset := fake.NewClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"team": "a"}},
+	})
+	cache := policy.NewNamespaceLabelCache(clientset, clock, time.Minute)
+
+	labels, err := cache.NamespaceLabels(ctx, "payments")
+	require.NoError(t, err)
+	assert.Equal(t, "a", labels["team"])
+
+	ns, err := clientset.CoreV1().Namespaces().Get(ctx, "payments", metav1.GetOptions{})
+	require.NoError(t, err)
+	ns.Labels["team"] = "b"
+	_, err = clientset.CoreV1().Namespaces().Update(ctx, ns, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	labels, err = cache.NamespaceLabels(ctx, "payments")
+	require.NoError(t, err)
+	assert.Equal(t, "a", labels["team"], "cached value should be served within the TTL")
+
+	clock.AdvanceTime(2 * time.Minute)
+	labels, err = cache.NamespaceLabels(ctx, "payments")
+	require.NoError(t, err)
+	assert.Equal(t, "b", labels["team"])
+
+	_, err = cache.NamespaceLabels(ctx, "missing")
+	assert.Error(t, err)
+}
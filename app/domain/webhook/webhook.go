@@ -261,6 +261,23 @@ type webhookController struct {
 	// metadata timestamps, and operational metrics. Enables deterministic testing
 	// of time-sensitive operations and consistent behavior across time zones.
 	clock types.TimeProvider
+
+	// policy evaluates required cost allocation labels after a resource handler
+	// has admitted a request. It is nil unless enabled through WithPolicy, so the
+	// backfiller and other non-admission callers never enforce policy.
+	policy hook.AdmitFunc
+}
+
+// Option configures optional behaviour of the webhook controller.
+type Option func(wc *webhookController)
+
+// WithPolicy attaches a cost allocation policy to every registered resource
+// handler. The policy is consulted only after the handler allows the request,
+// and its warnings, denials and patches are merged into the response.
+func WithPolicy(policy hook.AdmitFunc) Option {
+	return func(wc *webhookController) {
+		wc.policy = policy
+	}
 }
 
 // NewWebhookFactory constructs a fully configured WebhookController for CloudZero admission control.
@@ -293,6 +310,7 @@ type webhookController struct {
 //   - store: ResourceStore for persisting cost allocation metadata
 //   - settings: Dynamic configuration for cost allocation policies and feature toggles
 //   - clock: TimeProvider for consistent timestamps and testing determinism
+//   - opts: Optional behaviour such as WithPolicy for label policy enforcement
 //
 // Error conditions:
 //
@@ -303,7 +321,7 @@ type webhookController struct {
 //   - Handler registration uses efficient map initialization
 //   - Prometheus metrics are registered once using sync.Once
 //   - Dispatch map structure enables O(1) handler lookup during request processing
-func NewWebhookFactory(store types.ResourceStore, settings *config.Settings, clock types.TimeProvider) (WebhookController, error) {
+func NewWebhookFactory(store types.ResourceStore, settings *config.Settings, clock types.TimeProvider, opts ...Option) (WebhookController, error) {
 	wc := &webhookController{
 		dispatch: make(map[string]map[string]map[string]*hook.Handler),
 		defaultHandler: &hook.Handler{
@@ -318,6 +336,9 @@ func NewWebhookFactory(store types.ResourceStore, settings *config.Settings, clo
 		settings: settings,
 		clock:    clock,
 	}
+	for _, opt := range opts {
+		opt(wc)
+	}
 
 	// expose metrics for resource kinds
 	webhookStatsOnce.Do(func() {
@@ -432,6 +453,7 @@ func (wc *webhookController) register(group, version, resource string, h *hook.H
 	if wc.dispatch[group][version] == nil {
 		wc.dispatch[group][version] = make(map[string]*hook.Handler)
 	}
+	h.Policy = wc.policy
 	wc.dispatch[group][version][resource] = h
 }
 
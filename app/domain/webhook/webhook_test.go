@@ -101,6 +101,37 @@ func TestWebhookController_IsGVKGetSupported(t *testing.T) {
 	assert.False(t, controller.IsSupported(types.GroupCore, types.V1, "unknownKind"))
 }
 
+func TestMutationControllerReview(t *testing.T) {
+	patch := []byte(`[{"op":"add","path":"/metadata/labels/team","value":"payments"}]`)
+	var reviewed []string
+	policy := func(_ context.Context, _ *types.AdmissionReview, obj metav1.Object) (*types.AdmissionResponse, error) {
+		reviewed = append(reviewed, obj.GetName())
+		return &types.AdmissionResponse{Allowed: true, Patch: patch, Warnings: []string{"defaulted team"}}, nil
+	}
+
+	controller := webhook.NewMutationController(&config.Settings{}, policy)
+	assert.False(t, controller.IsSupported(types.GroupCore, types.V1, types.KindPod))
+
+	// The policy applies to every kind, with no resource handler involved
+	pod := makePodObjectRequest(metav1.ObjectMeta{})
+	pod.NewObjectRaw = getRawObject(corev1.SchemeGroupVersion, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default"},
+	})
+	configMap := makeUnsupportedRequest()
+	configMap.NewObjectRaw = getRawObject(corev1.SchemeGroupVersion, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+	})
+
+	for _, ar := range []*types.AdmissionReview{pod, configMap} {
+		result, err := controller.Review(context.Background(), ar)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, patch, result.Patch)
+		assert.Equal(t, []string{"defaulted team"}, result.Warnings)
+	}
+	assert.Equal(t, []string{"test-pod", "test-config"}, reviewed)
+}
+
 func makePodObjectRequest(o metav1.ObjectMeta) *types.AdmissionReview {
 	return &types.AdmissionReview{
 		Operation: types.OperationCreate,
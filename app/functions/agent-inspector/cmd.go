@@ -8,6 +8,7 @@ import (
 	"runtime"
 
 	"github.com/cloudzero/cloudzero-agent/app/build"
+	"github.com/cloudzero/cloudzero-agent/app/inspector/recording"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 )
@@ -19,9 +20,10 @@ const (
 )
 
 var cliServerConfig = serverConfig{
-	listenPort:     cliServerConfigListenPort,
-	destinationURL: cliServerConfigDestinationURL,
-	logLevel:       cliServerConfigLogLevelDefault,
+	listenPort:        cliServerConfigListenPort,
+	destinationURL:    cliServerConfigDestinationURL,
+	logLevel:          cliServerConfigLogLevelDefault,
+	recordMaxBodySize: recording.DefaultMaxBodySize,
 }
 
 type zerologLevel struct{}
@@ -59,4 +61,7 @@ func init() {
 	rootCmd.PersistentFlags().Uint16VarP(&cliServerConfig.listenPort, "port", "p", cliServerConfigListenPort, "Port to listen on")
 	rootCmd.PersistentFlags().StringVarP(&cliServerConfig.destinationURL, "destination", "d", cliServerConfigDestinationURL, "Destination URL to proxy requests to")
 	rootCmd.PersistentFlags().VarP(&cliParamLogLevel, "log-level", "l", "Log level (panic, fatal, error, warn, info, debug, trace)")
+	rootCmd.PersistentFlags().StringVar(&cliServerConfig.recordPath, "record", "", "HAR file to record requests and responses to, with credentials redacted")
+	rootCmd.PersistentFlags().IntVar(&cliServerConfig.recordMaxBodySize, "record-max-body-size", recording.DefaultMaxBodySize, "Size in bytes above which bodies are omitted from the recording, or -1 to record every body")
+	rootCmd.PersistentFlags().StringVar(&cliServerConfig.replayPath, "replay", "", "HAR file to replay recorded responses from, in place of the destination")
 }
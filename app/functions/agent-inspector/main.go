@@ -5,6 +5,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
@@ -14,6 +15,7 @@ import (
 
 	_ "github.com/KimMachineGun/automemlimit"
 	"github.com/cloudzero/cloudzero-agent/app/inspector"
+	"github.com/cloudzero/cloudzero-agent/app/inspector/recording"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -22,6 +24,15 @@ type serverConfig struct {
 	destinationURL string
 	listenPort     uint16
 	logLevel       zerolog.Level
+
+	// recordPath is the HAR file requests and responses are recorded to.
+	recordPath string
+	// recordMaxBodySize is the size above which bodies are omitted from the
+	// recording.
+	recordMaxBodySize int
+	// replayPath is the HAR file responses are replayed from, in place of
+	// the destination.
+	replayPath string
 }
 
 const (
@@ -38,29 +49,30 @@ func runServer(cfg *serverConfig) error {
 
 	czInspector := inspector.New()
 
-	proxy := &httputil.ReverseProxy{
-		Rewrite: func(pr *httputil.ProxyRequest) {
-			logger.Debug().
-				Str("method", pr.In.Method).
-				Str("destination", fmt.Sprintf("%s://%s/%s", targetURL.Scheme, targetURL.Host, pr.Out.URL.Path)).
-				Int64("length", int64(pr.In.ContentLength)).
-				Msg("rewrite request")
-
-			pr.SetURL(targetURL)
-			pr.Out.Host = targetURL.Host
-		},
-		ModifyResponse: func(resp *http.Response) error {
-			return czInspector.Inspect(context.Background(), resp, logger)
-		},
-		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
-			logger.Error().Err(err).Msg("proxy error")
-			w.WriteHeader(http.StatusBadGateway)
-		},
+	var handler http.Handler
+	switch {
+	case cfg.replayPath != "" && cfg.recordPath != "":
+		return errors.New("cannot both record and replay")
+	case cfg.replayPath != "":
+		archive, err := recording.LoadArchive(cfg.replayPath)
+		if err != nil {
+			return err
+		}
+		logger.Info().
+			Str("recording", cfg.replayPath).
+			Int("entries", len(archive.Log.Entries)).
+			Msg("replaying recorded responses")
+		handler = recording.NewReplayer(archive)
+	default:
+		handler, err = newProxy(targetURL, czInspector, cfg.recordPath, cfg.recordMaxBodySize, logger)
+		if err != nil {
+			return err
+		}
 	}
 
 	server := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.listenPort),
-		Handler:           proxy,
+		Handler:           handler,
 		ReadHeaderTimeout: httpServerReadHeaderTimeout,
 	}
 
@@ -77,6 +89,41 @@ func runServer(cfg *serverConfig) error {
 	return nil
 }
 
+// newProxy returns a proxy to the target which inspects the responses, and
+// records them to recordPath when it is set.
+func newProxy(targetURL *url.URL, czInspector *inspector.Inspector, recordPath string, maxBodySize int, logger zerolog.Logger) (http.Handler, error) {
+	var transport http.RoundTripper
+	if recordPath != "" {
+		recorder, err := recording.NewRecorder(nil, recordPath, recording.WithMaxBodySize(maxBodySize))
+		if err != nil {
+			return nil, err
+		}
+		logger.Info().Str("recording", recordPath).Msg("recording requests and responses")
+		transport = recorder
+	}
+
+	return &httputil.ReverseProxy{
+		Transport: transport,
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			logger.Debug().
+				Str("method", pr.In.Method).
+				Str("destination", fmt.Sprintf("%s://%s/%s", targetURL.Scheme, targetURL.Host, pr.Out.URL.Path)).
+				Int64("length", int64(pr.In.ContentLength)).
+				Msg("rewrite request")
+
+			pr.SetURL(targetURL)
+			pr.Out.Host = targetURL.Host
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			return czInspector.Inspect(context.Background(), resp, logger)
+		},
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			logger.Error().Err(err).Msg("proxy error")
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}, nil
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
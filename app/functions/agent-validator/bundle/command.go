@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package bundle contains a CLI for creating a support bundle.
+package bundle
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	gator "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	webhook "github.com/cloudzero/cloudzero-agent/app/config/webhook"
+	"github.com/cloudzero/cloudzero-agent/app/domain/bundle"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/catalog"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/runner"
+	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+const (
+	flagSince      = "since"
+	flagOutput     = "output"
+	flagStatusURL  = "status-url"
+	flagMetricsURL = "metrics-url"
+	flagNamespace  = "namespace"
+
+	requestTimeout = 30 * time.Second
+)
+
+var configAlias = []string{"f"}
+
+func NewCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "bundle",
+		Usage: "collects configuration, status, disk usage, metrics, logs and Kubernetes summaries into a tar.gz for support",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{Name: config.FlagConfigFile, Aliases: configAlias, Usage: "input " + config.FlagDescConfFile},
+			&cli.StringSliceFlag{Name: config.FlagConfigFileAggregator, Usage: "List of locations for aggregator config files"},
+			&cli.StringSliceFlag{Name: config.FlagConfigFileWebhook, Usage: "List of locations for webhook config files"},
+			&cli.DurationFlag{Name: flagSince, Usage: "window of the logs, uploaded files and events to include", Value: 24 * time.Hour},
+			&cli.StringFlag{Name: flagOutput, Aliases: []string{"o"}, Usage: "path of the bundle, or - for stdout (default: cloudzero-agent-bundle-<time>.tar.gz)"},
+			&cli.StringFlag{Name: flagStatusURL, Usage: "URL of the /status endpoint of a diagnostics daemon; the post-start checks are run when not set"},
+			&cli.StringSliceFlag{Name: flagMetricsURL, Usage: "component=URL of a metrics endpoint to snapshot, in addition to those of the collector, shipper and webhook server"},
+			&cli.StringFlag{Name: flagNamespace, Usage: "namespace of the agent, overriding services.namespace"},
+		},
+		Action: run,
+	}
+}
+
+func run(c *cli.Context) error {
+	ctx := c.Context
+
+	var cfg *config.Settings
+	if files := c.StringSlice(config.FlagConfigFile); len(files) > 0 {
+		var err error
+		if cfg, err = config.NewSettings(files...); err != nil {
+			return fmt.Errorf("failed to load the validator configuration: %w", err)
+		}
+		if err = cfg.Validate(); err != nil {
+			return fmt.Errorf("invalid validator configuration: %w", err)
+		}
+	}
+
+	sections := []bundle.Section{}
+	if cfg != nil {
+		sections = append(sections, bundle.ConfigSection("validator", cfg))
+	}
+	if files := c.StringSlice(config.FlagConfigFileAggregator); len(files) > 0 {
+		// the disk is only available where the aggregator runs
+		settings, err := gator.NewSettings(files...)
+		sections = append(sections, configSection("aggregator", settings, err))
+		if err == nil {
+			sections = append(sections, bundle.DiskSection(settings))
+		}
+	}
+	if files := c.StringSlice(config.FlagConfigFileWebhook); len(files) > 0 {
+		settings, err := webhook.NewSettings(files...)
+		sections = append(sections, configSection("webhook", settings, err))
+	}
+	sections = append(sections,
+		bundle.StatusSection(statusFetcher(c.String(flagStatusURL), cfg)),
+		bundle.MetricsSection(metricsClient(), metricsURLs(c, cfg)),
+	)
+	sections = append(sections, kubernetesSections(c, cfg)...)
+
+	out, name, err := output(c.String(flagOutput))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	manifest, err := bundle.Write(ctx, out, time.Now().Add(-c.Duration(flagSince)), sections...)
+	if err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+
+	for _, e := range manifest.Errors {
+		logrus.WithField("section", e.Section).Warn(e.Error)
+	}
+	logrus.WithFields(logrus.Fields{
+		"bundle": name,
+		"files":  len(manifest.Files),
+		"errors": len(manifest.Errors),
+	}).Info("bundle created")
+	return nil
+}
+
+func configSection(name string, settings bundle.YAMLer, err error) bundle.Section {
+	if err != nil {
+		return failedSection("config/"+name, fmt.Errorf("failed to load the configuration: %w", err))
+	}
+	return bundle.ConfigSection(name, settings)
+}
+
+// statusFetcher returns the latest status of a diagnostics daemon when its URL
+// is given, or otherwise runs the post-start checks.
+func statusFetcher(statusURL string, cfg *config.Settings) func(context.Context) (*status.ClusterStatus, error) {
+	return func(ctx context.Context) (*status.ClusterStatus, error) {
+		if statusURL != "" {
+			ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+			defer cancel()
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, statusURL, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return nil, err
+			}
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("received %d from %s", resp.StatusCode, statusURL)
+			}
+			var cs status.ClusterStatus
+			if err := protojson.Unmarshal(body, &cs); err != nil {
+				return nil, fmt.Errorf("failed to decode the status: %w", err)
+			}
+			return &cs, nil
+		}
+
+		if cfg == nil {
+			return nil, fmt.Errorf("either --%s or --%s is required", flagStatusURL, config.FlagConfigFile)
+		}
+		report, err := runner.NewRunner(cfg, catalog.NewCatalog(ctx, cfg), config.ContextStageStart).Run(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var cs *status.ClusterStatus
+		report.ReadFromReport(func(s *status.ClusterStatus) {
+			cs = s
+		})
+		return cs, nil
+	}
+}
+
+// metricsURLs returns the metrics endpoints of the components known from the
+// validator configuration, plus those given on the command line.
+func metricsURLs(c *cli.Context, cfg *config.Settings) map[string]string {
+	urls := map[string]string{}
+	if cfg != nil {
+		urls = cfg.MetricsURLs()
+	}
+	for _, v := range c.StringSlice(flagMetricsURL) {
+		name, u, ok := strings.Cut(v, "=")
+		if !ok {
+			logrus.WithField(flagMetricsURL, v).Warn("ignoring metrics URL not in the form component=URL")
+			continue
+		}
+		urls[name] = u
+	}
+	return urls
+}
+
+// metricsClient returns the client used to snapshot metrics. The webhook
+// server uses a self-signed certificate, and metrics are not sensitive, so the
+// certificate is not verified.
+func metricsClient() *http.Client {
+	return &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402
+		},
+	}
+}
+
+// kubernetesSections returns the sections read with the Kubernetes API: the
+// summaries of the cluster and the container logs of the agent's namespace.
+func kubernetesSections(c *cli.Context, cfg *config.Settings) []bundle.Section {
+	namespace := c.String(flagNamespace)
+	if namespace == "" && cfg != nil {
+		namespace = cfg.Services.Namespace
+	}
+	if namespace == "" {
+		ns, err := k8s.GetNamespace()
+		if err != nil {
+			return []bundle.Section{failedSection("kubernetes", fmt.Errorf("the namespace of the agent is unknown, set --%s: %w", flagNamespace, err))}
+		}
+		namespace = ns
+	}
+
+	clientset, err := k8s.GetClient()
+	if err != nil {
+		return []bundle.Section{failedSection("kubernetes", err)}
+	}
+	return []bundle.Section{
+		bundle.KubernetesSection(clientset, namespace),
+		bundle.LogsSection(clientset, namespace),
+	}
+}
+
+// failedSection records an error which prevented a section from being
+// collected at all.
+func failedSection(name string, err error) bundle.Section {
+	return bundle.Section{
+		Name: name,
+		Collect: func(context.Context, *bundle.Writer) error {
+			return err
+		},
+	}
+}
+
+// output opens the file the bundle is written to.
+func output(path string) (io.WriteCloser, string, error) {
+	switch path {
+	case "-":
+		return nopCloser{os.Stdout}, "stdout", nil
+	case "":
+		path = "cloudzero-agent-bundle-" + time.Now().UTC().Format("20060102T150405Z") + ".tar.gz"
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create the bundle: %w", err)
+	}
+	return f, path, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
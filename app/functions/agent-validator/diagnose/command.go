@@ -5,16 +5,24 @@
 package diagnose
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os/signal"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 	"google.golang.org/protobuf/encoding/protojson"
 
 	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/catalog"
 	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic/runner"
 	logging "github.com/cloudzero/cloudzero-agent/app/logging/validator"
@@ -49,11 +57,11 @@ func NewCommand() *cli.Command {
 			{
 				Name:  "run",
 				Usage: "run a specific check or checks",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringSliceFlag{Name: "check", Usage: "comma seperated or multi-value list of check(s) to run", Required: true},
 					&cli.StringSliceFlag{Name: config.FlagConfigFile, Aliases: configAlias, Usage: configFileDesc, Required: true},
 					&cli.BoolFlag{Name: "post", Usage: "if set to true, telemetry will be pushed", Required: false},
-				},
+				}, reportFlags(OutputJSON)...),
 				Action: func(c *cli.Context) error {
 					requestedCheckNames := c.StringSlice("check")
 					if len(requestedCheckNames) == 0 {
@@ -98,27 +106,39 @@ func NewCommand() *cli.Command {
 						logrus.WithError(err).Warn("diagnostics encountered error (continuing)")
 					}
 
+					var exitErr error
 					report.ReadFromReport(func(cs *status.ClusterStatus) {
-						if b, err := protojson.Marshal(cs); err == nil {
-							fmt.Println(string(b))
+						if err := writeReport(c.App.Writer, c.String(flagOutput), "run", cs); err != nil {
+							logrus.WithError(err).Error("failed to write the report")
 						}
+						exitErr = exitError("run", cs, c.Bool(flagFailOnWarning))
 					})
 
 					if c.Bool("post") {
-						client := http.DefaultClient
-						if err := telemetry.Post(ctx, client, cfg, report); err != nil {
+						client := runner.NewClient(cfg)
+						outbox := telemetry.NewOutbox(ctx, cfg, client)
+						if err := outbox.Post(ctx, client, cfg, report); err != nil {
 							logrus.WithError(err).Warn("failed to post status")
 						}
 					}
-					return nil
+					return exitErr
 				},
 			},
 			{
-				Name:  config.ContextStageInit,
-				Usage: "runs pre-start diagnostic tests",
+				Name:  "daemon",
+				Usage: "re-runs the scheduled checks until stopped, serving the latest status on /status and metrics on /metrics",
 				Flags: []cli.Flag{
 					&cli.StringSliceFlag{Name: config.FlagConfigFile, Aliases: configAlias, Usage: configFileDesc, Required: true},
+					&cli.StringFlag{Name: "address", Usage: "address to listen on, overriding daemon.address"},
 				},
+				Action: runDaemon,
+			},
+			{
+				Name:  config.ContextStageInit,
+				Usage: "runs pre-start diagnostic tests",
+				Flags: append([]cli.Flag{
+					&cli.StringSliceFlag{Name: config.FlagConfigFile, Aliases: configAlias, Usage: configFileDesc, Required: true},
+				}, reportFlags(OutputTable)...),
 				Action: func(c *cli.Context) error {
 					return runDiagnostics(c, config.ContextStageInit)
 				},
@@ -126,9 +146,9 @@ func NewCommand() *cli.Command {
 			{
 				Name:  config.ContextStageStart,
 				Usage: "runs post-start diagnostic tests",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringSliceFlag{Name: config.FlagConfigFile, Aliases: configAlias, Usage: configFileDesc, Required: true},
-				},
+				}, reportFlags(OutputTable)...),
 				Action: func(c *cli.Context) error {
 					return runDiagnostics(c, config.ContextStageStart)
 				},
@@ -136,11 +156,11 @@ func NewCommand() *cli.Command {
 			{
 				Name:  config.ContextStateConfigLoad,
 				Usage: "checks current configs",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringSliceFlag{Name: config.FlagConfigFile, Aliases: configAlias, Usage: configFileDesc, Required: true},
 					&cli.StringSliceFlag{Name: config.FlagConfigFileWebhook, Usage: "List of locations for webhook config files", Required: true},
 					&cli.StringSliceFlag{Name: config.FlagConfigFileAggregator, Usage: "List of locations for aggregator config files", Required: true},
-				},
+				}, reportFlags(OutputTable)...),
 				Action: func(c *cli.Context) error {
 					return runDiagnostics(c, config.ContextStateConfigLoad)
 				},
@@ -148,9 +168,9 @@ func NewCommand() *cli.Command {
 			{
 				Name:  config.ContextStageStop,
 				Usage: "runs pre-stop diagnostic tests",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringSliceFlag{Name: config.FlagConfigFile, Aliases: configAlias, Usage: configFileDesc, Required: true},
-				},
+				}, reportFlags(OutputTable)...),
 				Action: func(c *cli.Context) error {
 					return runDiagnostics(c, config.ContextStageStop)
 				},
@@ -189,8 +209,12 @@ func runDiagnostics(c *cli.Context, stage string) error {
 		logrus.WithError(err).Warn("diagnostics encountered runtime error")
 	}
 
+	var exitErr error
 	report.ReadFromReport(func(cs *status.ClusterStatus) {
-		printNonEmptyClusterStatus(cs)
+		if err := writeReport(c.App.Writer, c.String(flagOutput), stage, cs); err != nil {
+			logrus.WithError(err).Error("failed to write the report")
+		}
+		exitErr = exitError(stage, cs, c.Bool(flagFailOnWarning))
 		if b, err := protojson.Marshal(cs); err == nil {
 			if cfg.Logging.Location != "" {
 				logrus.WithField("report", string(b)).Info("reporting status")
@@ -199,8 +223,9 @@ func runDiagnostics(c *cli.Context, stage string) error {
 	})
 
 	if !cfg.Cloudzero.DisableTelemetry {
-		client := http.DefaultClient
-		if err := telemetry.Post(ctx, client, cfg, report); err != nil {
+		client := runner.NewClient(cfg)
+		outbox := telemetry.NewOutbox(ctx, cfg, client)
+		if err := outbox.Post(ctx, client, cfg, report); err != nil {
 			logrus.WithError(err).Warn("failed to post status")
 		}
 	}
@@ -209,7 +234,7 @@ func runDiagnostics(c *cli.Context, stage string) error {
 	if engine.ShouldFail() {
 		report.ReadFromReport(func(cs *status.ClusterStatus) {
 			for _, check := range cs.Checks {
-				if !check.Passing {
+				if diagnostic.Failed(check) {
 					logrus.WithFields(logrus.Fields{
 						"check": check.Name,
 						"error": check.Error,
@@ -217,32 +242,100 @@ func runDiagnostics(c *cli.Context, stage string) error {
 				}
 			}
 		})
-		return fmt.Errorf("one or more required diagnostic checks failed for stage %s", stage)
 	}
 
-	return nil
+	return exitErr
 }
 
-func printNonEmptyClusterStatus(cs *status.ClusterStatus) {
+func runDaemon(c *cli.Context) error {
+	ctx, stop := signal.NotifyContext(c.Context, syscall.SIGTERM)
+	defer stop()
+
+	configs := c.StringSlice(config.FlagConfigFile)
+	if len(configs) == 0 {
+		return errors.New("no configuration files specified")
+	}
+
+	cfg, err := config.NewSettings(configs...)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load configuration")
+	}
+	if err = cfg.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid configuration")
+	}
+	if cfg.Logging.Location != "" {
+		logging.SetUpLogging(cfg.Logging.Level, logging.LogFormatJSON)
+		_ = logging.LogToFile(cfg.Logging.Location)
+	}
+	if address := c.String("address"); address != "" {
+		cfg.Daemon.Address = address
+	}
+
+	daemon := runner.NewDaemon(cfg, catalog.NewCatalog(ctx, cfg))
+
+	mux := http.NewServeMux()
+	mux.Handle("/status", daemon)
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := &http.Server{
+		Addr:              cfg.Daemon.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logrus.WithField("address", cfg.Daemon.Address).Info("serving diagnostics status")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	daemonCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		if err, ok := <-serveErr; ok {
+			logrus.WithError(err).Error("status server failed")
+			cancel()
+		}
+	}()
+
+	_, err = daemon.Run(daemonCtx)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	_ = server.Shutdown(shutdownCtx)
+
+	return err
+}
+
+func printNonEmptyClusterStatus(w io.Writer, cs *status.ClusterStatus) {
 	if cs == nil || len(cs.Checks) == 0 {
 		return
 	}
 
-	printClusterStatusHeader()
+	printClusterStatusHeader(w)
 	for _, check := range cs.Checks {
-		printClusterStatusRow(check)
+		printClusterStatusRow(w, check)
 	}
 }
 
-func printClusterStatusHeader() {
-	fmt.Println("Checks:")
-	fmt.Printf("%-30s %-10s %-50s\n", "Name", "Passing", "Error")
+func printClusterStatusHeader(w io.Writer) {
+	fmt.Fprintln(w, "Checks:")
+	fmt.Fprintf(w, "%-30s %-10s %-50s\n", "Name", "Passing", "Error")
 	//revive:disable-next-line
-	fmt.Printf("%-30s %-10s %-50s\n", strings.Repeat("-", 30), strings.Repeat("-", 10), strings.Repeat("-", 50))
+	fmt.Fprintf(w, "%-30s %-10s %-50s\n", strings.Repeat("-", 30), strings.Repeat("-", 10), strings.Repeat("-", 50))
 }
 
-func printClusterStatusRow(check *status.StatusCheck) {
+func printClusterStatusRow(w io.Writer, check *status.StatusCheck) {
 	if check.Name != "" {
-		fmt.Printf("%-30s %-10v %-50s\n", check.Name, check.Passing, check.Error)
+		passing := strconv.FormatBool(check.Passing)
+		if !check.Passing && !diagnostic.Failed(check) {
+			passing = "n/a"
+		}
+		fmt.Fprintf(w, "%-30s %-10s %-50s\n", check.Name, passing, check.Error)
 	}
 }
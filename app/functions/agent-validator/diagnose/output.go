@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package diagnose
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"sigs.k8s.io/yaml"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+// Output formats of the diagnose commands.
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputJUnit = "junit"
+)
+
+// Exit codes of the diagnose commands, which let pipelines tell a cluster with
+// a failing required check from one which only needs attention. Other errors,
+// such as an invalid configuration, exit with 1.
+const (
+	// ExitFatal is used when a required check failed.
+	ExitFatal = 2
+	// ExitWarning is used when only optional checks failed, and the
+	// --fail-on-warning flag was set.
+	ExitWarning = 3
+)
+
+const (
+	flagOutput        = "output"
+	flagFailOnWarning = "fail-on-warning"
+)
+
+var outputFormats = []string{OutputTable, OutputJSON, OutputYAML, OutputJUnit}
+
+func reportFlags(defaultOutput string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    flagOutput,
+			Aliases: []string{"o"},
+			Usage:   "format of the report written to stdout: " + strings.Join(outputFormats, ", "),
+			Value:   defaultOutput,
+			Action: func(_ *cli.Context, v string) error {
+				if !slices.Contains(outputFormats, v) {
+					return fmt.Errorf("unsupported output format %q, expected one of %s", v, strings.Join(outputFormats, ", "))
+				}
+				return nil
+			},
+		},
+		&cli.BoolFlag{
+			Name:  flagFailOnWarning,
+			Usage: fmt.Sprintf("exit with %d when an optional check failed", ExitWarning),
+		},
+	}
+}
+
+// writeReport writes the status in the given format.
+func writeReport(w io.Writer, format, stage string, cs *status.ClusterStatus) error {
+	switch format {
+	case OutputTable:
+		printNonEmptyClusterStatus(w, cs)
+		return nil
+	case OutputJSON, OutputYAML:
+		data, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(cs)
+		if err != nil {
+			return fmt.Errorf("failed to marshal the report: %w", err)
+		}
+		if format == OutputYAML {
+			if data, err = yaml.JSONToYAML(data); err != nil {
+				return fmt.Errorf("failed to convert the report to YAML: %w", err)
+			}
+		}
+		_, err = fmt.Fprintln(w, strings.TrimSpace(string(data)))
+		return err
+	case OutputJUnit:
+		return writeJUnit(w, stage, cs)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
+// exitError returns the error the command should exit with for the report,
+// or nil when it should succeed.
+func exitError(stage string, cs *status.ClusterStatus, failOnWarning bool) error {
+	var fatal, warnings []string
+	for _, check := range cs.Checks {
+		if !diagnostic.Failed(check) {
+			continue
+		}
+		switch config.CheckType(check.Type) {
+		case config.CheckTypeRequired:
+			fatal = append(fatal, check.Name)
+		case config.CheckTypeOptional:
+			warnings = append(warnings, check.Name)
+		}
+	}
+
+	switch {
+	case len(fatal) > 0:
+		return cli.Exit(fmt.Sprintf("required diagnostic checks failed for stage %s: %s", stage, strings.Join(fatal, ", ")), ExitFatal)
+	case len(warnings) > 0 && failOnWarning:
+		return cli.Exit(fmt.Sprintf("optional diagnostic checks failed for stage %s: %s", stage, strings.Join(warnings, ", ")), ExitWarning)
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the report as a JUnit XML test suite with one test case
+// per check. The failure type of a failing check is its check type, so that
+// warnings can be told from fatal failures. Inconclusive checks are skipped.
+func writeJUnit(w io.Writer, stage string, cs *status.ClusterStatus) error {
+	suite := junitTestSuite{
+		Name: "cloudzero-agent-validator." + stage,
+		Properties: []junitProperty{
+			{Name: "cluster", Value: cs.Name},
+			{Name: "account", Value: cs.Account},
+			{Name: "region", Value: cs.Region},
+			{Name: "chart_version", Value: cs.ChartVersion},
+			{Name: "validator_version", Value: cs.ValidatorVersion},
+		},
+	}
+
+	var total time.Duration
+	for _, check := range cs.Checks {
+		if check.Name == "" {
+			continue
+		}
+		duration := time.Duration(check.DurationMs) * time.Millisecond
+		total += duration
+
+		tc := junitTestCase{Name: check.Name, Classname: suite.Name, Time: seconds(duration)}
+		switch {
+		case check.Passing:
+		case !diagnostic.Failed(check):
+			tc.Skipped = &junitSkipped{Message: check.Error}
+		default:
+			text := check.Error
+			if check.Remediation != "" {
+				text += "\n\nRemediation: " + check.Remediation
+			}
+			if check.DocUrl != "" {
+				text += "\nSee: " + check.DocUrl
+			}
+			tc.Failure = &junitFailure{Message: check.Error, Type: check.Type, Text: text}
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = seconds(total)
+
+	data, err := xml.MarshalIndent(junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the report: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package diagnose
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"sigs.k8s.io/yaml"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/diagnostic"
+	"github.com/cloudzero/cloudzero-agent/app/types/status"
+)
+
+func testReport() *status.ClusterStatus {
+	return &status.ClusterStatus{
+		Name:    "test-cluster",
+		Account: "123456789012",
+		Checks: []*status.StatusCheck{
+			{Name: config.DiagnosticAPIKey, Passing: true, Type: string(config.CheckTypeRequired), DurationMs: 120},
+			{Name: config.DiagnosticKMS, Error: "connection refused", Type: string(config.CheckTypeOptional), DurationMs: 1500, Remediation: "start kube-state-metrics"},
+		},
+	}
+}
+
+func TestWriteReport_JSONAndYAML(t *testing.T) {
+	for _, format := range []string{OutputJSON, OutputYAML} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeReport(&buf, format, config.ContextStageInit, testReport()))
+
+			data := buf.Bytes()
+			if format == OutputYAML {
+				var err error
+				data, err = yaml.YAMLToJSON(data)
+				require.NoError(t, err)
+			}
+			var decoded status.ClusterStatus
+			require.NoError(t, protojson.Unmarshal(data, &decoded))
+			assert.True(t, proto.Equal(testReport(), &decoded))
+		})
+	}
+}
+
+func TestWriteReport_JUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeReport(&buf, OutputJUnit, config.ContextStageInit, testReport()))
+
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 2, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	assert.Equal(t, "1.620", suites.Time)
+	require.Len(t, suites.Suites, 1)
+
+	suite := suites.Suites[0]
+	assert.Equal(t, "cloudzero-agent-validator.pre-start", suite.Name)
+	require.Len(t, suite.Cases, 2)
+	assert.Nil(t, suite.Cases[0].Failure)
+	assert.Equal(t, "0.120", suite.Cases[0].Time)
+
+	failure := suite.Cases[1].Failure
+	require.NotNil(t, failure)
+	assert.Equal(t, "connection refused", failure.Message)
+	assert.Equal(t, string(config.CheckTypeOptional), failure.Type)
+	assert.Contains(t, failure.Text, "start kube-state-metrics")
+}
+
+func TestExitError(t *testing.T) {
+	exitCode := func(err error) int {
+		if err == nil {
+			return 0
+		}
+		var coder cli.ExitCoder
+		require.ErrorAs(t, err, &coder)
+		return coder.ExitCode()
+	}
+
+	report := testReport()
+	assert.Equal(t, 0, exitCode(exitError(config.ContextStageInit, report, false)))
+	assert.Equal(t, ExitWarning, exitCode(exitError(config.ContextStageInit, report, true)))
+
+	report.Checks[0].Passing = false
+	assert.Equal(t, ExitFatal, exitCode(exitError(config.ContextStageInit, report, false)))
+	assert.Equal(t, ExitFatal, exitCode(exitError(config.ContextStageInit, report, true)))
+
+	// informative checks never fail the command
+	report = &status.ClusterStatus{Checks: []*status.StatusCheck{{Name: "info", Type: string(config.CheckTypeInformative)}}}
+	assert.Equal(t, 0, exitCode(exitError(config.ContextStageInit, report, true)))
+}
+
+func TestInconclusiveChecks(t *testing.T) {
+	check := diagnostic.Inconclusive(config.DiagnosticClusterIdentity, "the fingerprint is not sent by the API")
+	check.Type = string(config.CheckTypeRequired)
+	report := &status.ClusterStatus{Checks: []*status.StatusCheck{check}}
+
+	assert.False(t, check.Passing)
+	assert.False(t, diagnostic.Failed(check))
+	assert.NoError(t, exitError(config.ContextStageInit, report, true), "inconclusive checks never fail the command")
+
+	var buf bytes.Buffer
+	require.NoError(t, writeReport(&buf, OutputJUnit, config.ContextStageInit, report))
+	var suites junitTestSuites
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	assert.Equal(t, 0, suites.Failures)
+	require.Len(t, suites.Suites[0].Cases, 1)
+	require.NotNil(t, suites.Suites[0].Cases[0].Skipped)
+	assert.Equal(t, check.Error, suites.Suites[0].Cases[0].Skipped.Message)
+}
@@ -16,6 +16,7 @@ import (
 
 	_ "github.com/KimMachineGun/automemlimit"
 	"github.com/cloudzero/cloudzero-agent/app/build"
+	"github.com/cloudzero/cloudzero-agent/app/functions/agent-validator/bundle"
 	configcmd "github.com/cloudzero/cloudzero-agent/app/functions/agent-validator/config"
 	diagcmd "github.com/cloudzero/cloudzero-agent/app/functions/agent-validator/diagnose"
 	"github.com/cloudzero/cloudzero-agent/app/functions/agent-validator/install"
@@ -46,6 +47,7 @@ func main() {
 		configcmd.NewCommand(ctx),
 		diagcmd.NewCommand(),
 		install.NewCommand(),
+		bundle.NewCommand(),
 	)
 
 	err := app.Run(os.Args)
@@ -26,6 +26,10 @@ var (
 	algorithm         string
 	enableLabels      bool
 	enableAnnotations bool
+	mode              string
+	renewBefore       string
+	caConfigMap       string
+	caConfigMapKey    string
 )
 
 func main() {
@@ -43,7 +47,12 @@ for CloudZero Agent components, particularly webhook servers.
 
 The tool can generate certificates with configurable algorithms (RSA, ECDSA, Ed25519),
 key sizes, and validity periods. It automatically updates Kubernetes secrets and
-webhook configurations with the new certificates.`,
+webhook configurations with the new certificates, and rotates them before they
+expire.
+
+In external mode no certificate is generated. The serving certificate is issued
+by cert-manager or a corporate CA; certifik8s validates it and injects or
+verifies the CA bundle of the webhook configuration.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Default to generate command for backward compatibility
 		return generateCmd.RunE(cmd, args)
@@ -56,12 +65,6 @@ var generateCmd = &cobra.Command{
 	Long: `Generate a new TLS certificate with the specified parameters and update
 Kubernetes resources accordingly.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Parse validity duration
-		duration, err := time.ParseDuration(validityDuration)
-		if err != nil {
-			return fmt.Errorf("invalid validity duration '%s': %w", validityDuration, err)
-		}
-
 		// Create Kubernetes client
 		k8sClient, err := k8s.NewCertificateClient()
 		if err != nil {
@@ -71,32 +74,57 @@ Kubernetes resources accordingly.`,
 		// Create certificate service
 		certService := certificate.NewCertificateService(k8sClient)
 
-		// Generate certificate
-		certData, err := certService.GenerateCertificate(
-			context.Background(),
-			serviceName,
-			namespace,
-			keySize,
-			duration,
-			algorithm,
-		)
+		switch mode {
+		case certificate.ModeExternal:
+			status, err := certService.ReconcileExternal(context.Background(), certificate.ExternalOptions{
+				ServiceName:    serviceName,
+				Namespace:      namespace,
+				SecretName:     secretName,
+				WebhookName:    webhookName,
+				CAConfigMap:    caConfigMap,
+				CAConfigMapKey: caConfigMapKey,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to validate external certificate: %w", err)
+			}
+			fmt.Printf("External certificate is valid until %s (CA from %s, injected: %t)\n",
+				status.NotAfter.UTC().Format(time.RFC3339), status.CASource, status.CAInjected)
+			return nil
+		case certificate.ModeSelfSigned:
+		default:
+			return fmt.Errorf("unsupported mode '%s' (use %s or %s)", mode, certificate.ModeSelfSigned, certificate.ModeExternal)
+		}
+
+		// Parse validity duration
+		duration, err := time.ParseDuration(validityDuration)
 		if err != nil {
-			return fmt.Errorf("failed to generate certificate: %w", err)
+			return fmt.Errorf("invalid validity duration '%s': %w", validityDuration, err)
+		}
+		renew, err := time.ParseDuration(renewBefore)
+		if err != nil {
+			return fmt.Errorf("invalid renew before duration '%s': %w", renewBefore, err)
 		}
 
-		// Update Kubernetes resources
-		err = certService.UpdateResources(
-			context.Background(),
-			namespace,
-			secretName,
-			webhookName,
-			certData,
-		)
+		// Generate the certificate unless the current one is still good
+		rotated, status, err := certService.EnsureCertificate(context.Background(), certificate.RotationOptions{
+			ServiceName: serviceName,
+			Namespace:   namespace,
+			SecretName:  secretName,
+			WebhookName: webhookName,
+			KeySize:     keySize,
+			Validity:    duration,
+			Algorithm:   algorithm,
+			RenewBefore: renew,
+		})
 		if err != nil {
-			return fmt.Errorf("failed to update resources: %w", err)
+			return fmt.Errorf("failed to generate certificate: %w", err)
 		}
 
-		fmt.Println("Certificate generated and resources updated successfully")
+		if rotated {
+			fmt.Println("Certificate generated and resources updated successfully")
+		} else {
+			fmt.Printf("Certificate is valid until %s, not rotating\n", status.NotAfter.UTC().Format(time.RFC3339))
+		}
 		return nil
 	},
 }
@@ -128,6 +156,9 @@ in the Kubernetes secret.`,
 
 		if isValid {
 			fmt.Println("Certificate is valid and properly configured")
+			if status, err := certService.InspectCertificate(context.Background(), namespace, secretName); err == nil {
+				fmt.Printf("Certificate expires at %s\n", status.NotAfter.UTC().Format(time.RFC3339))
+			}
 		} else {
 			fmt.Println("Certificate is missing or improperly configured")
 		}
@@ -154,11 +185,15 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&namespace, "namespace", "", "Kubernetes namespace")
 	rootCmd.PersistentFlags().StringVar(&secretName, "secret-name", "", "Name of the TLS secret")
 	rootCmd.PersistentFlags().StringVar(&webhookName, "webhook-name", "", "Name of the webhook configuration")
+	rootCmd.PersistentFlags().StringVar(&mode, "mode", certificate.ModeSelfSigned, "Certificate mode (self-signed, external)")
 
 	// Generate command flags
 	generateCmd.Flags().IntVar(&keySize, "key-size", 2048, "Key size in bits (RSA: 2048+, ECDSA: 256/384/521, Ed25519: ignored)")
 	generateCmd.Flags().StringVar(&validityDuration, "validity-duration", "876000h", "Certificate validity period (e.g., '1h', '365d', '876000h')")
 	generateCmd.Flags().StringVar(&algorithm, "algorithm", "RSA", "Certificate algorithm (RSA, ECDSA, Ed25519)")
+	generateCmd.Flags().StringVar(&renewBefore, "renew-before", "720h", "Rotate a self-signed certificate expiring within this duration")
+	generateCmd.Flags().StringVar(&caConfigMap, "ca-configmap", "", "ConfigMap holding the CA bundle of an external certificate")
+	generateCmd.Flags().StringVar(&caConfigMapKey, "ca-configmap-key", certificate.DefaultCAConfigMapKey, "Key of the CA bundle in the CA ConfigMap")
 	generateCmd.Flags().BoolVar(&enableLabels, "enable-labels", false, "Enable label collection")
 	generateCmd.Flags().BoolVar(&enableAnnotations, "enable-annotations", false, "Enable annotation collection")
 
@@ -19,6 +19,7 @@ import (
 
 	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
 	http "github.com/cloudzero/cloudzero-agent/app/http/client"
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
 	"github.com/cloudzero/cloudzero-agent/app/types/clusterconfig"
 	"github.com/cloudzero/cloudzero-agent/app/utils/scout"
 	"github.com/hashicorp/go-retryablehttp"
@@ -240,8 +241,13 @@ func post(
 		return errors.New("missing cloudzero api key")
 	}
 
-	// create an http client
+	// create an http client going through the egress proxy
+	egress, err := transport.New(cfg.Egress)
+	if err != nil {
+		return fmt.Errorf("failed to configure the egress transport: %w", err)
+	}
 	client := retryablehttp.NewClient()
+	client.HTTPClient = &net.Client{Transport: egress}
 
 	// encode the data
 	data, err := proto.Marshal(cc)
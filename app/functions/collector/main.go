@@ -26,7 +26,9 @@ import (
 	"github.com/cloudzero/cloudzero-agent/app/domain"
 	"github.com/cloudzero/cloudzero-agent/app/handlers"
 	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
+	"github.com/cloudzero/cloudzero-agent/app/http/serving"
 	"github.com/cloudzero/cloudzero-agent/app/logging"
+	"github.com/cloudzero/cloudzero-agent/app/logging/instr"
 	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
 	"github.com/cloudzero/cloudzero-agent/app/types"
 	"github.com/cloudzero/cloudzero-agent/app/utils"
@@ -68,6 +70,9 @@ func main() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load settings")
 	}
+	if err := settings.UseShardStorage(); err != nil {
+		log.Fatal().Err(err).Msg("failed to set up the shard storage")
+	}
 
 	clock := &utils.Clock{}
 
@@ -81,6 +86,21 @@ func main() {
 	zerolog.DefaultContextLogger = logger
 	ctx = logger.WithContext(ctx)
 
+	stopTracing := func() {}
+	if settings.Tracing.Enabled {
+		stopTracing, err = instr.InitTracing(ctx, instr.TracingConfig{
+			ServiceName:    "cloudzero-collector",
+			ServiceVersion: build.GetVersion(),
+			Protocol:       settings.Tracing.Protocol,
+			Endpoint:       settings.Tracing.Endpoint,
+			SampleRatio:    settings.Tracing.SampleRatio,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to initialize tracing")
+		}
+	}
+	defer stopTracing()
+
 	// print settings on debug
 	if logger.GetLevel() <= zerolog.DebugLevel {
 		enc, err := json.MarshalIndent(settings, "", "  ") //nolint:govet // I actively and vehemently disagree with `shadowing` of `err` in golang
@@ -127,6 +147,7 @@ func main() {
 	// Handle shutdown events gracefully
 	go func() {
 		HandleShutdownEvents(ctx, settings, costMetricStore, observabilityMetricStore)
+		stopTracing()
 		os.Exit(0)
 	}()
 
@@ -165,9 +186,28 @@ func main() {
 		return nil
 	})
 
+	trustedProxies, err := settings.Server.TrustedProxyPrefixes()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid trusted proxies")
+	}
+	remoteWriteOpts := []handlers.RemoteWriteAPIOption{
+		handlers.WithErrorRateTracker(collectorErrorRate),
+		handlers.WithTrustedProxies(trustedProxies),
+	}
+	authn, err := serving.NewAuthenticator(ctx, settings.Server.Auth)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize authentication")
+	}
+	var coverageOpts []handlers.CoverageAPIOption
+	if authn != nil {
+		remoteWriteOpts = append(remoteWriteOpts, handlers.WithAuthentication(authn, settings.Server.Auth.AllowedIdentities))
+		coverageOpts = append(coverageOpts, handlers.WithCoverageAuthentication(authn, settings.Server.Auth.AllowedIdentities))
+	}
+
 	apis := []server.API{
-		handlers.NewRemoteWriteAPI("/collector", domain, handlers.WithErrorRateTracker(collectorErrorRate)),
+		handlers.NewRemoteWriteAPI("/collector", domain, remoteWriteOpts...),
 		handlers.NewPromMetricsAPI("/metrics"),
+		handlers.NewCoverageAPI("/coverage", domain, coverageOpts...),
 		handlers.NewLivezAPI("/livez", collectorErrorRate, errorRateThreshold, errorRateMinFailures, errorRateLivenessCooldown),
 	}
 
@@ -176,12 +216,12 @@ func main() {
 	}
 
 	// Expose the service
-	logger.Info().Msg("Starting service")
+	logger.Info().Str("mode", settings.Server.Mode).Str("auth", settings.Server.Auth.Mode).Msg("Starting service")
 	server.New(build.Version()).
 		WithAddress(fmt.Sprintf(":%d", settings.Server.Port)).
 		WithMiddleware(mw...).
 		WithAPIs(apis...).
-		WithListener(server.HTTPListener()).
+		WithListener(serving.NewListener(ctx, settings.Server)).
 		Run(ctx)
 	logger.Info().Msg("Service stopping")
 }
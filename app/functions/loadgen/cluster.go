@@ -0,0 +1,272 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/prometheus/prompb"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// nodeCPUCores and nodeMemoryBytes are the capacity of every simulated
+	// node.
+	nodeCPUCores    = 16
+	nodeMemoryBytes = 64 << 30
+
+	// gpuFramebufferMiB is the framebuffer of every simulated GPU.
+	gpuFramebufferMiB = 81920
+)
+
+// reviewUser is the user the admission reviews are attributed to.
+var reviewUser = authenticationv1.UserInfo{Username: "system:serviceaccount:kube-system:replicaset-controller"}
+
+// node is a simulated node.
+type node struct {
+	name string
+	gpu  bool
+}
+
+// pod is a simulated pod with a single container.
+type pod struct {
+	namespace string
+	name      string
+	uid       string
+	node      *node
+	created   time.Time
+	// cpuRequest is in cores and memoryRequest in bytes.
+	cpuRequest    float64
+	memoryRequest int64
+	// cpuSeconds is the CPU usage counter of the container.
+	cpuSeconds float64
+}
+
+// cluster simulates the nodes and pods the metrics and admission reviews
+// describe. It is not safe for concurrent use.
+type cluster struct {
+	name       string
+	rng        *rand.Rand
+	namespaces []string
+	nodes      []*node
+	pods       []*pod
+	// nextPod numbers the pods, so replacements get new names.
+	nextPod int
+}
+
+// newCluster creates a cluster of nodes and pods spread over the namespaces,
+// the first gpuNodes nodes having a GPU each.
+func newCluster(name string, nodes, gpuNodes, pods, namespaces int, seed int64, now time.Time) *cluster {
+	c := &cluster{name: name, rng: rand.New(rand.NewSource(seed))} //nolint:gosec // deterministic load, not security
+	for i := range max(namespaces, 1) {
+		c.namespaces = append(c.namespaces, fmt.Sprintf("loadgen-%d", i+1))
+	}
+	for i := range nodes {
+		c.nodes = append(c.nodes, &node{name: fmt.Sprintf("loadgen-node-%d", i+1), gpu: i < gpuNodes})
+	}
+	for range pods {
+		c.pods = append(c.pods, c.newPod(now))
+	}
+	return c
+}
+
+// newPod creates a pod in a random namespace, on a random node.
+func (c *cluster) newPod(now time.Time) *pod {
+	c.nextPod++
+	namespace := c.namespaces[c.rng.Intn(len(c.namespaces))]
+	return &pod{
+		namespace:     namespace,
+		name:          fmt.Sprintf("%s-pod-%d", namespace, c.nextPod),
+		uid:           uuid.NewString(),
+		node:          c.nodes[c.rng.Intn(len(c.nodes))],
+		created:       now,
+		cpuRequest:    float64(1+c.rng.Intn(20)) / 10,
+		memoryRequest: int64(1+c.rng.Intn(16)) * 128 << 20,
+	}
+}
+
+// churn replaces the share of the pods, from 0 to 1, with new ones, and
+// returns the deleted and created pods.
+func (c *cluster) churn(share float64, now time.Time) (deleted, created []*pod) {
+	count := min(int(float64(len(c.pods))*share+0.5), len(c.pods))
+	for _, i := range c.rng.Perm(len(c.pods))[:count] {
+		deleted = append(deleted, c.pods[i])
+		replacement := c.newPod(now)
+		created = append(created, replacement)
+		c.pods[i] = replacement
+	}
+	return deleted, created
+}
+
+// series returns the cAdvisor, kube-state-metrics and DCGM series of the
+// cluster at the time, advancing the CPU counters by the interval.
+func (c *cluster) series(now time.Time, interval time.Duration) []prompb.TimeSeries {
+	timestamp := now.UnixMilli()
+	var result []prompb.TimeSeries
+	add := func(name string, value float64, labels ...string) {
+		result = append(result, newSeries(name, value, timestamp, append(labels, "cluster_name", c.name)...))
+	}
+
+	for _, n := range c.nodes {
+		add("kube_node_info", 1, "node", n.name, "kernel_version", "6.1.0", "os_image", "loadgen")
+		add("kube_node_status_capacity", nodeCPUCores, "node", n.name, "resource", "cpu", "unit", "core")
+		add("kube_node_status_capacity", nodeMemoryBytes, "node", n.name, "resource", "memory", "unit", "byte")
+	}
+
+	nodeCPU := map[*node]float64{}
+	nodeMemory := map[*node]float64{}
+	for _, p := range c.pods {
+		p.cpuSeconds += p.cpuRequest * (0.5 + c.rng.Float64()/2) * interval.Seconds()
+		memory := float64(p.memoryRequest) * (0.5 + c.rng.Float64()/2)
+		nodeCPU[p.node] += p.cpuSeconds
+		nodeMemory[p.node] += memory
+
+		container := []string{"namespace", p.namespace, "pod", p.name, "container", "app", "node", p.node.name}
+		add("container_cpu_usage_seconds_total", p.cpuSeconds, append(container, "image", "loadgen:latest")...)
+		add("container_memory_working_set_bytes", memory, append(container, "image", "loadgen:latest")...)
+		add("kube_pod_info", 1, "namespace", p.namespace, "pod", p.name, "uid", p.uid, "node", p.node.name)
+		add("kube_pod_labels", 1, "namespace", p.namespace, "pod", p.name, "label_app", "loadgen")
+		add("kube_pod_container_resource_requests", p.cpuRequest, append(container, "resource", "cpu", "unit", "core")...)
+		add("kube_pod_container_resource_requests", float64(p.memoryRequest), append(container, "resource", "memory", "unit", "byte")...)
+
+		if p.node.gpu {
+			gpu := append(container, "gpu", "0", "UUID", "GPU-"+p.uid, "Hostname", p.node.name, "modelName", "NVIDIA H100 80GB HBM3")
+			used := float64(c.rng.Intn(gpuFramebufferMiB))
+			add("DCGM_FI_DEV_GPU_UTIL", float64(c.rng.Intn(101)), gpu...)
+			add("DCGM_FI_DEV_FB_USED", used, gpu...)
+			add("DCGM_FI_DEV_FB_FREE", gpuFramebufferMiB-used, gpu...)
+		}
+	}
+
+	// Node level series of cAdvisor, which have no container.
+	for _, n := range c.nodes {
+		add("container_cpu_usage_seconds_total", nodeCPU[n], "id", "/", "node", n.name)
+		add("container_memory_working_set_bytes", nodeMemory[n], "id", "/", "node", n.name)
+	}
+	return result
+}
+
+// newSeries creates a series with a single sample. The labels are name and
+// value pairs.
+func newSeries(name string, value float64, timestamp int64, labels ...string) prompb.TimeSeries {
+	ts := prompb.TimeSeries{
+		Labels:  []prompb.Label{{Name: "__name__", Value: name}},
+		Samples: []prompb.Sample{{Value: value, Timestamp: timestamp}},
+	}
+	for i := 0; i+1 < len(labels); i += 2 {
+		ts.Labels = append(ts.Labels, prompb.Label{Name: labels[i], Value: labels[i+1]})
+	}
+	sort.Slice(ts.Labels, func(i, j int) bool { return ts.Labels[i].Name < ts.Labels[j].Name })
+	return ts
+}
+
+// namespaceReviews returns the creation reviews of the namespaces.
+func (c *cluster) namespaceReviews() ([][]byte, error) {
+	var result [][]byte
+	for _, name := range c.namespaces {
+		ns := &corev1.Namespace{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"app.kubernetes.io/part-of": "loadgen"}},
+		}
+		review, err := admissionReview(admissionv1.Create, "namespaces", "Namespace", "", name, ns, nil)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, review)
+	}
+	return result, nil
+}
+
+// nodeReviews returns the creation reviews of the nodes.
+func (c *cluster) nodeReviews() ([][]byte, error) {
+	var result [][]byte
+	for _, n := range c.nodes {
+		obj := &corev1.Node{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+			ObjectMeta: metav1.ObjectMeta{Name: n.name, Labels: map[string]string{"kubernetes.io/hostname": n.name}},
+			Status: corev1.NodeStatus{Capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    *resource.NewQuantity(nodeCPUCores, resource.DecimalSI),
+				corev1.ResourceMemory: *resource.NewQuantity(nodeMemoryBytes, resource.BinarySI),
+			}},
+		}
+		review, err := admissionReview(admissionv1.Create, "nodes", "Node", "", n.name, obj, nil)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, review)
+	}
+	return result, nil
+}
+
+// podReview returns the creation or deletion review of the pod.
+func podReview(p *pod, operation admissionv1.Operation) ([]byte, error) {
+	obj := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              p.name,
+			Namespace:         p.namespace,
+			UID:               k8stypes.UID(p.uid),
+			CreationTimestamp: metav1.NewTime(p.created),
+			Labels:            map[string]string{"app": "loadgen", "team": p.namespace},
+			Annotations:       map[string]string{"cloudzero.com/loadgen": "true"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName: p.node.name,
+			Containers: []corev1.Container{{
+				Name:  "app",
+				Image: "loadgen:latest",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    *resource.NewMilliQuantity(int64(p.cpuRequest*1000), resource.DecimalSI),
+					corev1.ResourceMemory: *resource.NewQuantity(p.memoryRequest, resource.BinarySI),
+				}},
+			}},
+		},
+	}
+	if operation == admissionv1.Delete {
+		return admissionReview(operation, "pods", "Pod", p.namespace, p.name, nil, obj)
+	}
+	return admissionReview(operation, "pods", "Pod", p.namespace, p.name, obj, nil)
+}
+
+// admissionReview encodes the review the API server sends the webhook for the
+// operation on the object. Deletions carry the old object only.
+func admissionReview(operation admissionv1.Operation, resourceName, kind, namespace, name string, obj, oldObj runtime.Object) ([]byte, error) {
+	req := &admissionv1.AdmissionRequest{
+		UID:       k8stypes.UID(uuid.NewString()),
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: kind},
+		Resource:  metav1.GroupVersionResource{Version: "v1", Resource: resourceName},
+		Name:      name,
+		Namespace: namespace,
+		Operation: operation,
+		UserInfo:  reviewUser,
+	}
+	var err error
+	if obj != nil {
+		if req.Object.Raw, err = json.Marshal(obj); err != nil {
+			return nil, fmt.Errorf("failed to encode the %s: %w", kind, err)
+		}
+	}
+	if oldObj != nil {
+		if req.OldObject.Raw, err = json.Marshal(oldObj); err != nil {
+			return nil, fmt.Errorf("failed to encode the %s: %w", kind, err)
+		}
+	}
+
+	review := &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: admissionv1.SchemeGroupVersion.String(), Kind: "AdmissionReview"},
+		Request:  req,
+	}
+	return json.Marshal(review)
+}
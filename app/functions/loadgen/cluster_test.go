@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCluster_Series(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newCluster("test", 4, 1, 20, 3, 1, now)
+	require.Len(t, c.nodes, 4)
+	require.Len(t, c.pods, 20)
+	assert.Len(t, c.namespaces, 3)
+
+	gpuPods := 0
+	for _, p := range c.pods {
+		if p.node.gpu {
+			gpuPods++
+		}
+	}
+
+	counts := map[string]int{}
+	series := c.series(now, time.Minute)
+	for _, ts := range series {
+		require.Len(t, ts.Samples, 1)
+		assert.Equal(t, now.UnixMilli(), ts.Samples[0].Timestamp)
+		for _, label := range ts.Labels {
+			if label.Name == "__name__" {
+				counts[label.Value]++
+			}
+		}
+	}
+	assert.Equal(t, 4, counts["kube_node_info"])
+	assert.Equal(t, 8, counts["kube_node_status_capacity"])
+	assert.Equal(t, 20+4, counts["container_cpu_usage_seconds_total"])
+	assert.Equal(t, 20, counts["kube_pod_info"])
+	assert.Equal(t, 40, counts["kube_pod_container_resource_requests"])
+	assert.Equal(t, gpuPods, counts["DCGM_FI_DEV_GPU_UTIL"])
+	assert.Equal(t, gpuPods, counts["DCGM_FI_DEV_FB_FREE"])
+
+	// The CPU counters only go up.
+	before := c.pods[0].cpuSeconds
+	c.series(now.Add(time.Minute), time.Minute)
+	assert.Greater(t, c.pods[0].cpuSeconds, before)
+}
+
+func TestCluster_Churn(t *testing.T) {
+	now := time.Now()
+	c := newCluster("test", 2, 0, 40, 2, 1, now)
+
+	deleted, created := c.churn(0.25, now)
+	assert.Len(t, deleted, 10)
+	assert.Len(t, created, 10)
+	assert.Len(t, c.pods, 40)
+
+	names := map[string]bool{}
+	for _, p := range c.pods {
+		assert.False(t, names[p.name], "pod names are unique")
+		names[p.name] = true
+	}
+	for _, p := range created {
+		assert.True(t, names[p.name])
+	}
+
+	deleted, created = c.churn(0, now)
+	assert.Empty(t, deleted)
+	assert.Empty(t, created)
+}
+
+func TestPodReview(t *testing.T) {
+	c := newCluster("test", 1, 0, 1, 1, 1, time.Now())
+	p := c.pods[0]
+
+	for _, operation := range []admissionv1.Operation{admissionv1.Create, admissionv1.Delete} {
+		data, err := podReview(p, operation)
+		require.NoError(t, err)
+
+		var review admissionv1.AdmissionReview
+		require.NoError(t, json.Unmarshal(data, &review))
+		assert.Equal(t, "AdmissionReview", review.Kind)
+		require.NotNil(t, review.Request)
+		assert.Equal(t, operation, review.Request.Operation)
+		assert.Equal(t, p.namespace, review.Request.Namespace)
+
+		raw := review.Request.Object.Raw
+		if operation == admissionv1.Delete {
+			assert.Empty(t, raw)
+			raw = review.Request.OldObject.Raw
+		}
+		var obj corev1.Pod
+		require.NoError(t, json.Unmarshal(raw, &obj))
+		assert.Equal(t, p.name, obj.Name)
+		assert.Equal(t, p.node.name, obj.Spec.NodeName)
+	}
+}
@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// recorder records the outcome and latency of the requests sent to a target.
+// It is safe for concurrent use.
+type recorder struct {
+	mu        sync.Mutex
+	requests  int
+	failures  int
+	items     int
+	dropped   int
+	latencies []time.Duration
+}
+
+// record records a request carrying the items, which are dropped when the
+// request failed.
+func (r *recorder) record(items int, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests++
+	r.items += items
+	r.latencies = append(r.latencies, latency)
+	if err != nil {
+		r.failures++
+		r.dropped += items
+	}
+}
+
+// stats summarizes the requests recorded over the elapsed time.
+func (r *recorder) stats(elapsed time.Duration) targetStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := targetStats{
+		Requests: r.requests,
+		Failures: r.failures,
+		Items:    r.items,
+		Dropped:  r.dropped,
+		P50:      percentile(r.latencies, 0.50),
+		P99:      percentile(r.latencies, 0.99),
+		Max:      percentile(r.latencies, 1),
+	}
+	if elapsed > 0 {
+		s.Throughput = float64(r.items-r.dropped) / elapsed.Seconds()
+	}
+	return s
+}
+
+// percentile returns the latency below which the share q of the latencies
+// fall, using the nearest rank.
+func percentile(latencies []time.Duration, q float64) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	rank := int(q*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
+
+// remoteWriteSender sends series to the remote_write endpoint of the
+// collector. Unlike metricio.RemoteWriter, it does not retry, so the latency
+// and failures are the ones of each request.
+type remoteWriteSender struct {
+	url    string
+	client *http.Client
+	rec    *recorder
+}
+
+// send sends the series in a single request.
+func (s *remoteWriteSender) send(ctx context.Context, series []prompb.TimeSeries) error {
+	data, err := (&prompb.WriteRequest{Timeseries: series}).Marshal()
+	if err != nil {
+		s.rec.record(len(series), 0, err)
+		return err
+	}
+
+	start := time.Now()
+	err = s.post(ctx, snappy.Encode(nil, data))
+	s.rec.record(len(series), time.Since(start), err)
+	return err
+}
+
+func (s *remoteWriteSender) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// webhookSender sends admission reviews to the validation endpoint of the
+// webhook.
+type webhookSender struct {
+	url    string
+	client *http.Client
+	rec    *recorder
+}
+
+// send sends the review, which fails unless the webhook allows it.
+func (s *webhookSender) send(ctx context.Context, review []byte) {
+	start := time.Now()
+	err := s.post(ctx, review)
+	s.rec.record(1, time.Since(start), err)
+}
+
+func (s *webhookSender) post(ctx context.Context, review []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(review))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var response admissionv1.AdmissionReview
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode the admission response: %w", err)
+	}
+	if response.Response == nil || !response.Response.Allowed {
+		return errors.New("the admission review was not allowed")
+	}
+	return nil
+}
@@ -0,0 +1,384 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package main provides the loadgen CLI tool, which simulates a cluster to
+// drive the collector and the webhook under realistic load.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/parallel"
+)
+
+const (
+	requestTimeout    = 30 * time.Second
+	readHeaderTimeout = 10 * time.Second
+	drainPollInterval = time.Second
+)
+
+var (
+	collectorURL string
+	webhookURL   string
+	uploadListen string
+	insecure     bool
+
+	clusterName    string
+	nodes          int
+	gpuNodes       int
+	pods           int
+	namespaces     int
+	seed           int64
+	duration       time.Duration
+	scrapeInterval time.Duration
+	churnInterval  time.Duration
+	churnPercent   float64
+	batchSize      int
+	concurrency    int
+
+	drain          time.Duration
+	memoryInterval time.Duration
+	jsonOutput     bool
+	logLevel       string
+)
+
+func main() {
+	rootCmd := &cobra.Command{
+		Use:   "loadgen [options]",
+		Short: "Drive the collector and webhook with a simulated cluster",
+		Long: `Loadgen simulates a cluster of nodes and pods with churn. It sends the
+admission reviews of the pods created and deleted to the webhook, and the
+matching cAdvisor, kube-state-metrics and DCGM series to the collector over
+remote_write, on the scrape interval.
+
+It serves a local stand-in of the CloudZero upload API the shipper can be
+pointed at, and reports the throughput, latency, memory and dropped data of
+the run.`,
+		RunE: run,
+	}
+
+	// Targets
+	rootCmd.Flags().StringVar(&collectorURL, "collector-url", "", "remote_write URL of the collector, such as http://localhost:8080/collector")
+	rootCmd.Flags().StringVar(&webhookURL, "webhook-url", "", "Validation URL of the webhook, such as https://localhost:8443/validate")
+	rootCmd.Flags().StringVar(&uploadListen, "upload-listen", ":8081", "Address of the stand-in upload API (empty to disable)")
+	rootCmd.Flags().BoolVar(&insecure, "insecure-skip-verify", false, "Skip the verification of the TLS certificates of the targets")
+
+	// Simulated cluster
+	rootCmd.Flags().StringVar(&clusterName, "cluster-name", "loadgen", "Name of the simulated cluster")
+	rootCmd.Flags().IntVar(&nodes, "nodes", 10, "Number of nodes")
+	rootCmd.Flags().IntVar(&gpuNodes, "gpu-nodes", 0, "Number of the nodes with a GPU, whose pods emit DCGM series")
+	rootCmd.Flags().IntVar(&pods, "pods", 300, "Number of pods")
+	rootCmd.Flags().IntVar(&namespaces, "namespaces", 10, "Number of namespaces the pods are spread over")
+	rootCmd.Flags().Int64Var(&seed, "seed", 1, "Seed of the simulation")
+
+	// Schedule
+	rootCmd.Flags().DurationVar(&duration, "duration", 5*time.Minute, "Duration of the load")
+	rootCmd.Flags().DurationVar(&scrapeInterval, "scrape-interval", 30*time.Second, "Interval the series are sent at")
+	rootCmd.Flags().DurationVar(&churnInterval, "churn-interval", time.Minute, "Interval pods are replaced at (0 to disable)")
+	rootCmd.Flags().Float64Var(&churnPercent, "churn-percent", 5, "Percentage of the pods replaced every churn interval")
+	rootCmd.Flags().IntVar(&batchSize, "batch-size", 500, "Number of series per remote_write request")
+	rootCmd.Flags().IntVar(&concurrency, "concurrency", 8, "Number of concurrent requests to the targets")
+
+	// Reporting
+	rootCmd.Flags().DurationVar(&drain, "drain", 0, "Time to wait after the load for the shipper to upload what was sent")
+	rootCmd.Flags().DurationVar(&memoryInterval, "memory-interval", 5*time.Second, "Interval the memory of the targets is sampled at (0 to disable)")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the report as JSON")
+	rootCmd.Flags().StringVar(&logLevel, "log-level", "warn", "Log level (trace, debug, info, warn, error)")
+
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func run(_ *cobra.Command, _ []string) error {
+	level, err := zerolog.ParseLevel(logLevel)
+	if err != nil {
+		level = zerolog.WarnLevel
+	}
+	zerolog.SetGlobalLevel(level)
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	if collectorURL == "" && webhookURL == "" {
+		return errors.New("must specify --collector-url, --webhook-url or both")
+	}
+	if nodes <= 0 || pods < 0 {
+		return errors.New("--nodes must be positive and --pods not negative")
+	}
+	if gpuNodes < 0 || gpuNodes > nodes {
+		return errors.New("--gpu-nodes must be between 0 and --nodes")
+	}
+	if scrapeInterval <= 0 || duration <= 0 {
+		return errors.New("--scrape-interval and --duration must be positive")
+	}
+	if churnPercent < 0 || churnPercent > 100 {
+		return errors.New("--churn-percent must be between 0 and 100")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	client := newClient()
+	r := &report{Nodes: nodes, Pods: pods, Memory: map[string]memoryStats{}}
+
+	// Start the stand-in upload API
+	upload := newUploadAPI()
+	if uploadListen != "" {
+		listener, err := net.Listen("tcp", uploadListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", uploadListen, err)
+		}
+		server := &http.Server{Handler: upload.Handler(), ReadHeaderTimeout: readHeaderTimeout}
+		go func() {
+			if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error().Err(err).Msg("upload API stopped")
+			}
+		}()
+		defer server.Close()
+		log.Info().Str("address", listener.Addr().String()).Msg("upload API listening")
+	}
+
+	// Sample the memory of the targets until the report is printed
+	samplerCtx, stopSamplers := context.WithCancel(ctx)
+	defer stopSamplers()
+	samplers := map[string]*memorySampler{}
+	if memoryInterval > 0 {
+		for name, target := range map[string]string{"collector": collectorURL, "webhook": webhookURL} {
+			if target == "" {
+				continue
+			}
+			metricsURL, err := metricsURL(target)
+			if err != nil {
+				return fmt.Errorf("invalid %s URL: %w", name, err)
+			}
+			samplers[name] = newMemorySampler(client, metricsURL)
+			go samplers[name].run(samplerCtx, memoryInterval)
+		}
+	}
+
+	collector := &remoteWriteSender{url: collectorURL, client: client, rec: &recorder{}}
+	webhook := &webhookSender{url: webhookURL, client: client, rec: &recorder{}}
+	accepted := &sampleCounter{counts: map[string]int{}}
+	sent := &sampleCounter{counts: map[string]int{}}
+
+	start := time.Now()
+	err = generate(ctx, collector, webhook, sent, accepted)
+	elapsed := time.Since(start)
+	if err != nil {
+		return err
+	}
+
+	// Give the shipper time to upload what was accepted
+	if drain > 0 && uploadListen != "" && collectorURL != "" {
+		waitForUploads(ctx, upload, accepted, drain)
+	}
+	stopSamplers()
+
+	r.Duration = elapsed
+	if collectorURL != "" {
+		s := collector.rec.stats(elapsed)
+		r.Collector = &s
+	}
+	if webhookURL != "" {
+		s := webhook.rec.stats(elapsed)
+		r.Webhook = &s
+	}
+	r.Upload = upload.rec.stats(time.Since(start))
+	r.Uploaded = upload.stats()
+	r.Sent = sent.snapshot()
+	r.Missing = missingSamples(accepted.snapshot(), r.Uploaded.Metrics)
+	for name, sampler := range samplers {
+		r.Memory[name] = sampler.snapshot()
+	}
+
+	if jsonOutput {
+		return r.printJSON(os.Stdout)
+	}
+	r.print(os.Stdout)
+	return nil
+}
+
+// generate drives the targets with the simulated cluster until the duration
+// elapses or the context is done, and waits for the requests in flight.
+func generate(ctx context.Context, collector *remoteWriteSender, webhook *webhookSender, sent, accepted *sampleCounter) error {
+	// The requests in flight when the duration elapses are not canceled.
+	loadCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	c := newCluster(clusterName, nodes, gpuNodes, pods, namespaces, seed, time.Now())
+	manager := parallel.New(concurrency)
+	waiter := parallel.NewWaiter()
+	defer func() {
+		manager.Close()
+		waiter.Wait()
+	}()
+
+	sendReviews := func(reviews [][]byte) {
+		if webhookURL == "" {
+			return
+		}
+		for _, review := range reviews {
+			manager.Run(func() error {
+				webhook.send(ctx, review)
+				return nil
+			}, waiter)
+		}
+	}
+	sendSeries := func(series []prompb.TimeSeries) {
+		if collectorURL == "" {
+			return
+		}
+		for i := 0; i < len(series); i += max(batchSize, 1) {
+			batch := series[i:min(i+max(batchSize, 1), len(series))]
+			sent.add(batch)
+			manager.Run(func() error {
+				if err := collector.send(ctx, batch); err == nil {
+					accepted.add(batch)
+				}
+				return nil
+			}, waiter)
+		}
+	}
+
+	// The cluster as it stands when the load starts
+	var initial [][]byte
+	for _, reviews := range []func() ([][]byte, error){c.namespaceReviews, c.nodeReviews} {
+		r, err := reviews()
+		if err != nil {
+			return err
+		}
+		initial = append(initial, r...)
+	}
+	for _, p := range c.pods {
+		review, err := podReview(p, admissionv1.Create)
+		if err != nil {
+			return err
+		}
+		initial = append(initial, review)
+	}
+	sendReviews(initial)
+	sendSeries(c.series(time.Now(), scrapeInterval))
+
+	scrape := time.NewTicker(scrapeInterval)
+	defer scrape.Stop()
+	var churn <-chan time.Time
+	if churnInterval > 0 && churnPercent > 0 {
+		ticker := time.NewTicker(churnInterval)
+		defer ticker.Stop()
+		churn = ticker.C
+	}
+
+	for {
+		select {
+		case <-loadCtx.Done():
+			return nil
+		case now := <-scrape.C:
+			sendSeries(c.series(now, scrapeInterval))
+		case now := <-churn:
+			deleted, created := c.churn(churnPercent/100, now)
+			var reviews [][]byte
+			for _, p := range deleted {
+				review, err := podReview(p, admissionv1.Delete)
+				if err != nil {
+					return err
+				}
+				reviews = append(reviews, review)
+			}
+			for _, p := range created {
+				review, err := podReview(p, admissionv1.Create)
+				if err != nil {
+					return err
+				}
+				reviews = append(reviews, review)
+			}
+			log.Info().Int("deleted", len(deleted)).Int("created", len(created)).Msg("churned pods")
+			sendReviews(reviews)
+		}
+	}
+}
+
+// waitForUploads waits until every metric name uploaded is complete, or the
+// timeout elapses.
+func waitForUploads(ctx context.Context, upload *uploadAPI, accepted *sampleCounter, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for {
+		stats := upload.stats()
+		if stats.Files > 0 && len(missingSamples(accepted.snapshot(), stats.Metrics)) == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newClient returns the client the targets are sent requests with.
+func newClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = concurrency
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // opt-in, for self-signed webhook certificates
+	}
+	return &http.Client{Transport: transport, Timeout: requestTimeout}
+}
+
+// metricsURL returns the URL of the Prometheus metrics of the server of the
+// target.
+func metricsURL(target string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+	u.Path = "/metrics"
+	u.RawQuery = ""
+	return u.String(), nil
+}
+
+// sampleCounter counts the samples per metric name. It is safe for concurrent
+// use.
+type sampleCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *sampleCounter) add(series []prompb.TimeSeries) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ts := range series {
+		for _, label := range ts.Labels {
+			if label.Name == "__name__" {
+				c.counts[label.Value] += len(ts.Samples)
+				break
+			}
+		}
+	}
+}
+
+func (c *sampleCounter) snapshot() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.counts)
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+func TestGenerate(t *testing.T) {
+	var series, creates, deletes atomic.Int64
+	collectorServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		data, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+		var req prompb.WriteRequest
+		require.NoError(t, req.Unmarshal(data))
+		series.Add(int64(len(req.Timeseries)))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer collectorServer.Close()
+
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review admissionv1.AdmissionReview
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&review))
+		if review.Request.Kind.Kind == "Pod" {
+			switch review.Request.Operation {
+			case admissionv1.Create:
+				creates.Add(1)
+			case admissionv1.Delete:
+				deletes.Add(1)
+			}
+		}
+		review.Response = &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+		review.Request = nil
+		_ = json.NewEncoder(w).Encode(review)
+	}))
+	defer webhookServer.Close()
+
+	collectorURL, webhookURL = collectorServer.URL, webhookServer.URL
+	clusterName, nodes, gpuNodes, pods, namespaces, seed = "test", 2, 1, 10, 2, 1
+	duration, scrapeInterval, churnInterval, churnPercent = 350*time.Millisecond, 100*time.Millisecond, 100*time.Millisecond, 20
+	batchSize, concurrency = 25, 4
+
+	collector := &remoteWriteSender{url: collectorURL, client: newClient(), rec: &recorder{}}
+	webhook := &webhookSender{url: webhookURL, client: newClient(), rec: &recorder{}}
+	sent := &sampleCounter{counts: map[string]int{}}
+	accepted := &sampleCounter{counts: map[string]int{}}
+	require.NoError(t, generate(context.Background(), collector, webhook, sent, accepted))
+
+	collectorStats := collector.rec.stats(duration)
+	assert.Zero(t, collectorStats.Failures)
+	assert.Equal(t, series.Load(), int64(collectorStats.Items))
+	assert.Equal(t, sent.snapshot(), accepted.snapshot())
+	assert.GreaterOrEqual(t, sent.snapshot()["kube_pod_info"], 3*pods, "series are sent on every scrape")
+
+	webhookStats := webhook.rec.stats(duration)
+	assert.Zero(t, webhookStats.Failures)
+	assert.Positive(t, deletes.Load(), "pods are churned")
+	assert.Equal(t, int64(pods), creates.Load()-deletes.Load())
+}
@@ -0,0 +1,248 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// residentMemoryMetric is the resident memory of a process, as exported by
+// the Prometheus Go client.
+const residentMemoryMetric = "process_resident_memory_bytes"
+
+// targetStats summarizes the requests sent to, or received from, a
+// component.
+type targetStats struct {
+	Requests int `json:"requests"`
+	Failures int `json:"failures"`
+	// Items are the series, reviews or uploaded metrics carried by the
+	// requests, and Dropped the ones carried by the failed requests.
+	Items      int           `json:"items"`
+	Dropped    int           `json:"dropped"`
+	Throughput float64       `json:"throughput_per_second"`
+	P50        time.Duration `json:"p50_ns"`
+	P99        time.Duration `json:"p99_ns"`
+	Max        time.Duration `json:"max_ns"`
+}
+
+// memoryStats are the samples of the resident memory of a component.
+type memoryStats struct {
+	URL     string `json:"url"`
+	Samples int    `json:"samples"`
+	Last    int64  `json:"last_bytes"`
+	Peak    int64  `json:"peak_bytes"`
+	// Error is the last failure to sample the memory.
+	Error string `json:"error,omitempty"`
+}
+
+// memorySampler samples the resident memory of a component from its
+// Prometheus metrics endpoint.
+type memorySampler struct {
+	client *http.Client
+
+	mu    sync.Mutex
+	stats memoryStats
+}
+
+func newMemorySampler(client *http.Client, url string) *memorySampler {
+	return &memorySampler{client: client, stats: memoryStats{URL: url}}
+}
+
+// run samples the memory at the interval until the context is done.
+func (s *memorySampler) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.sample(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *memorySampler) sample(ctx context.Context) {
+	value, err := s.scrape(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		if ctx.Err() == nil {
+			s.stats.Error = err.Error()
+		}
+		return
+	}
+	s.stats.Samples++
+	s.stats.Last = value
+	s.stats.Peak = max(s.stats.Peak, value)
+}
+
+func (s *memorySampler) scrape(ctx context.Context) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.stats.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return parseResidentMemory(resp.Body)
+}
+
+// parseResidentMemory returns the resident memory from metrics in the
+// Prometheus text format.
+func parseResidentMemory(r io.Reader) (int64, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != residentMemoryMetric {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s value %q: %w", residentMemoryMetric, fields[1], err)
+		}
+		return int64(value), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no %s metric found", residentMemoryMetric)
+}
+
+func (s *memorySampler) snapshot() memoryStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// report is the outcome of a run.
+type report struct {
+	Duration time.Duration `json:"duration_ns"`
+	Nodes    int           `json:"nodes"`
+	Pods     int           `json:"pods"`
+
+	Collector *targetStats `json:"collector,omitempty"`
+	Webhook   *targetStats `json:"webhook,omitempty"`
+	Upload    targetStats  `json:"upload"`
+	Uploaded  uploadStats  `json:"uploaded"`
+
+	Memory map[string]memoryStats `json:"memory,omitempty"`
+
+	// Sent counts the samples sent per metric name, and Missing the ones
+	// accepted by the collector but never uploaded.
+	Sent    map[string]int `json:"sent"`
+	Missing map[string]int `json:"missing"`
+}
+
+// missingSamples returns, per metric name, how many of the samples accepted by
+// the collector were not uploaded. The metric names nothing was uploaded for
+// are left out: they are filtered or transformed by the collector rather than
+// lost.
+func missingSamples(accepted, uploaded map[string]int) map[string]int {
+	missing := map[string]int{}
+	for name, count := range accepted {
+		if uploaded[name] == 0 {
+			continue
+		}
+		if count > uploaded[name] {
+			missing[name] = count - uploaded[name]
+		}
+	}
+	return missing
+}
+
+// print writes the report as text.
+func (r *report) print(w io.Writer) {
+	fmt.Fprintf(w, "Simulated %d nodes and %d pods for %s\n\n", r.Nodes, r.Pods, r.Duration.Round(time.Second))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "TARGET\tREQUESTS\tFAILURES\tITEMS\tDROPPED\tITEMS/S\tP50\tP99\tMAX\t")
+	row := func(name string, s *targetStats) {
+		if s == nil {
+			return
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1f\t%s\t%s\t%s\t\n", name, s.Requests, s.Failures, s.Items, s.Dropped,
+			s.Throughput, s.P50.Round(time.Microsecond), s.P99.Round(time.Microsecond), s.Max.Round(time.Microsecond))
+	}
+	row("collector (series)", r.Collector)
+	row("webhook (reviews)", r.Webhook)
+	row("upload API (metrics)", &r.Upload)
+	_ = tw.Flush()
+
+	fmt.Fprintf(w, "\nUploaded %d files (%d bytes), %d abandoned, %d undecodable\n",
+		r.Uploaded.Files, r.Uploaded.Bytes, r.Uploaded.Abandoned, r.Uploaded.Undecodable)
+
+	if len(r.Memory) > 0 {
+		fmt.Fprintln(w)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "COMPONENT\tPEAK RSS\tLAST RSS\tSAMPLES\tERROR")
+		for _, name := range sortedKeys(r.Memory) {
+			m := r.Memory[name]
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", name, formatBytes(m.Peak), formatBytes(m.Last), m.Samples, m.Error)
+		}
+		_ = tw.Flush()
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tSENT\tUPLOADED\tMISSING")
+	names := sortedKeys(r.Sent)
+	for _, name := range sortedKeys(r.Uploaded.Metrics) {
+		if _, ok := r.Sent[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	for _, name := range names {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n", name, r.Sent[name], r.Uploaded.Metrics[name], r.Missing[name])
+	}
+	_ = tw.Flush()
+}
+
+// printJSON writes the report as JSON.
+func (r *report) printJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// formatBytes formats a size in binary units.
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
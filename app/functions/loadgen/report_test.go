@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 0.5))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 0.99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 1))
+	assert.Equal(t, time.Duration(0), percentile(nil, 0.99))
+	assert.Equal(t, 100*time.Millisecond, latencies[0], "the latencies are left unsorted")
+}
+
+func TestParseResidentMemory(t *testing.T) {
+	value, err := parseResidentMemory(strings.NewReader(`# HELP process_resident_memory_bytes Resident memory size in bytes.
+# TYPE process_resident_memory_bytes gauge
+process_resident_memory_bytes 1.2345678e+07
+process_virtual_memory_bytes 9e+09
+`))
+	require.NoError(t, err)
+	assert.Equal(t, int64(12345678), value)
+
+	_, err = parseResidentMemory(strings.NewReader("go_goroutines 12\n"))
+	assert.ErrorContains(t, err, "no process_resident_memory_bytes metric found")
+}
+
+func TestMissingSamples(t *testing.T) {
+	missing := missingSamples(
+		map[string]int{"kube_pod_info": 10, "container_cpu_usage_seconds_total": 20, "DCGM_FI_DEV_FB_USED": 5},
+		map[string]int{"kube_pod_info": 10, "container_cpu_usage_seconds_total": 15},
+	)
+	assert.Equal(t, map[string]int{"container_cpu_usage_seconds_total": 5}, missing)
+}
+
+func TestReport_Print(t *testing.T) {
+	r := &report{
+		Duration:  time.Minute,
+		Nodes:     2,
+		Pods:      10,
+		Collector: &targetStats{Requests: 4, Items: 100, Throughput: 1.5, P99: 3 * time.Millisecond},
+		Uploaded:  uploadStats{Files: 1, Metrics: map[string]int{"kube_pod_info": 8}},
+		Memory:    map[string]memoryStats{"collector": {Peak: 64 << 20, Last: 60 << 20, Samples: 3}},
+		Sent:      map[string]int{"kube_pod_info": 10},
+		Missing:   map[string]int{"kube_pod_info": 2},
+	}
+
+	var out bytes.Buffer
+	r.print(&out)
+	assert.Contains(t, out.String(), "Simulated 2 nodes and 10 pods for 1m0s")
+	assert.Contains(t, out.String(), "collector (series)")
+	assert.NotContains(t, out.String(), "webhook (reviews)")
+	assert.Contains(t, out.String(), "64.0MiB")
+	assert.Regexp(t, `kube_pod_info\s+10\s+8\s+2`, out.String())
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/metricio"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
+	"github.com/cloudzero/cloudzero-agent/mock/cloudzeroapi"
+)
+
+// metricsReadBatchSize is the batch size the uploaded files are decoded with.
+const metricsReadBatchSize = 1000
+
+// uploadAPI is a local stand-in of the CloudZero upload API, serving the fake
+// of the API and counting the metrics of the files the shipper uploads to it.
+// It is safe for concurrent use.
+type uploadAPI struct {
+	api *cloudzeroapi.Server
+	rec *recorder
+
+	mu sync.Mutex
+	// uploaded counts the uploaded metrics per metric name.
+	uploaded    map[string]int
+	files       int
+	bytes       int64
+	undecodable int
+}
+
+func newUploadAPI() *uploadAPI {
+	u := &uploadAPI{
+		rec:      &recorder{},
+		uploaded: map[string]int{},
+	}
+	u.api = cloudzeroapi.New(cloudzeroapi.Options{
+		URLExpiry:   time.Hour,
+		DiscardData: true,
+		OnUpload:    u.count,
+	})
+	return u
+}
+
+// Handler returns the handler of the upload API and of the presigned URLs.
+func (u *uploadAPI) Handler() http.Handler {
+	return u.api
+}
+
+// count counts the metrics of an uploaded file.
+func (u *uploadAPI) count(object cloudzeroapi.Object) {
+	start := time.Now()
+	counts, err := countMetrics(object.Data)
+	if err != nil {
+		log.Warn().Err(err).Str("id", object.ReferenceID).Msg("failed to decode an uploaded file")
+	}
+
+	u.mu.Lock()
+	u.files++
+	u.bytes += int64(object.Size)
+	if err != nil {
+		u.undecodable++
+	}
+	total := 0
+	for name, count := range counts {
+		u.uploaded[name] += count
+		total += count
+	}
+	u.mu.Unlock()
+
+	u.rec.record(total, time.Since(start), nil)
+}
+
+// countMetrics returns the number of metrics per metric name of a compressed
+// JSON metric file.
+func countMetrics(data []byte) (map[string]int, error) {
+	reader, _, err := compress.Detect(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	counts := map[string]int{}
+	err = metricio.NewJSONReader(metricsReadBatchSize).ReadFromReader(reader, func(metrics []types.Metric) error {
+		for _, m := range metrics {
+			counts[m.MetricName]++
+		}
+		return nil
+	})
+	return counts, err
+}
+
+// uploadStats is a snapshot of what the stand-in received.
+type uploadStats struct {
+	Files       int            `json:"files"`
+	Bytes       int64          `json:"bytes"`
+	Abandoned   int            `json:"abandoned"`
+	Undecodable int            `json:"undecodable"`
+	Metrics     map[string]int `json:"metrics"`
+}
+
+// stats returns a snapshot of what the stand-in received.
+func (u *uploadAPI) stats() uploadStats {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return uploadStats{
+		Files:       u.files,
+		Bytes:       u.bytes,
+		Abandoned:   len(u.api.Abandoned()),
+		Undecodable: u.undecodable,
+		Metrics:     maps.Clone(u.uploaded),
+	}
+}
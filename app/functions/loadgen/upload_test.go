@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/metricio"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
+	"github.com/cloudzero/cloudzero-agent/mock/cloudzeroapi"
+)
+
+func TestUploadAPI(t *testing.T) {
+	api := newUploadAPI()
+	server := httptest.NewServer(api.Handler())
+	defer server.Close()
+
+	// Allocate a presigned URL, as the shipper does
+	resp, err := http.Post(server.URL+cloudzeroapi.BasePath+"/upload?count=1&cluster_name=test&cloud_account_id=a&region=r", "application/json",
+		bytes.NewBufferString(`{"shipperId":"s","files":[{"reference_id":"file-1"}]}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var urls map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&urls))
+	require.Contains(t, urls, "file-1")
+
+	// Upload a file of metrics to it
+	var file bytes.Buffer
+	writer, err := metricio.NewJSONWriterWithCodec(&file, compress.Zstd, -1)
+	require.NoError(t, err)
+	for _, name := range []string{"kube_pod_info", "kube_pod_info", "kube_node_info"} {
+		require.NoError(t, writer.WriteOne(types.Metric{MetricName: name, TimeStamp: time.Now(), Value: "1"}))
+	}
+	require.NoError(t, writer.Close())
+
+	req, err := http.NewRequest(http.MethodPut, urls["file-1"], bytes.NewReader(file.Bytes()))
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Post(server.URL+cloudzeroapi.BasePath+"/abandon", "application/json",
+		bytes.NewBufferString(`[{"reference_id":"file-2","reason":"expired"}]`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	stats := api.stats()
+	assert.Equal(t, 1, stats.Files)
+	assert.Equal(t, int64(file.Len()), stats.Bytes)
+	assert.Equal(t, 1, stats.Abandoned)
+	assert.Zero(t, stats.Undecodable)
+	assert.Equal(t, map[string]int{"kube_pod_info": 2, "kube_node_info": 1}, stats.Metrics)
+}
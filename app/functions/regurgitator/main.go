@@ -13,6 +13,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"syscall"
@@ -20,6 +21,7 @@ import (
 
 	_ "github.com/KimMachineGun/automemlimit"
 	"github.com/cloudzero/cloudzero-agent/app/domain/metricio"
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
 	"github.com/cloudzero/cloudzero-agent/app/types"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
@@ -31,16 +33,18 @@ import (
 )
 
 const (
-	channelBufferSize   = 8096
-	defaultBatchSize    = 10000
-	maxRetries          = 10
-	maxBackoffDuration  = 30 * time.Second
-	readHeaderTimeout   = 10 * time.Second
-	extensionBrotli     = ".br"
-	extensionCSV        = ".csv"
-	extensionJSON       = ".json"
-	extensionJSONBrotli = ".json.br"
-	extensionParquet    = ".parquet"
+	channelBufferSize  = 8096
+	defaultBatchSize   = 10000
+	maxRetries         = 10
+	maxBackoffDuration = 30 * time.Second
+	readHeaderTimeout  = 10 * time.Second
+	extensionCSV       = ".csv"
+	extensionJSON      = ".json"
+	extensionParquet   = ".parquet"
+
+	// extensionJSONCompressed stands for the extensions of compressed JSON,
+	// such as .json.br, .json.zst and .json.sz.
+	extensionJSONCompressed = ".json.*"
 )
 
 var (
@@ -51,6 +55,15 @@ var (
 	writers   int
 	dryRun    bool
 	logLevel  string
+	egress    transport.Config
+
+	matches      []string
+	start        string
+	end          string
+	dropLabels   []string
+	keepLabels   []string
+	aggregate    string
+	stageWorkers int
 )
 
 // Writer is the interface for output writers.
@@ -68,10 +81,14 @@ type remoteWriterAdapter struct {
 	backoff   *backoff.Backoff
 }
 
-func newRemoteWriterAdapter(ctx context.Context, url string) *remoteWriterAdapter {
+func newRemoteWriterAdapter(ctx context.Context, url string) (*remoteWriterAdapter, error) {
+	rt, err := transport.New(egress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure the egress transport: %w", err)
+	}
 	return &remoteWriterAdapter{
 		ctx:       ctx,
-		writer:    metricio.NewRemoteWriter(url),
+		writer:    metricio.NewRemoteWriter(url, metricio.WithTransport(rt)),
 		batchSize: defaultBatchSize,
 		batch:     make([]prompb.TimeSeries, 0, defaultBatchSize),
 		backoff: &backoff.Backoff{
@@ -80,7 +97,7 @@ func newRemoteWriterAdapter(ctx context.Context, url string) *remoteWriterAdapte
 			Factor: 2,
 			Jitter: true,
 		},
-	}
+	}, nil
 }
 
 func (w *remoteWriterAdapter) WriteOne(metric types.Metric) error {
@@ -225,6 +242,21 @@ values are treated as file paths with format determined by extension.`,
 	rootCmd.Flags().IntVar(&writers, "writers", 1, "Number of parallel writers per output")
 	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Parse input without writing output")
 
+	// Stages between the readers and the writers
+	rootCmd.Flags().StringArrayVar(&matches, "match", nil, `Label selector metrics must match, such as 'namespace="payments"' (can be repeated, any may match)`)
+	rootCmd.Flags().StringVar(&start, "start", "", "Drop metrics before this time (RFC 3339 or YYYY-MM-DD)")
+	rootCmd.Flags().StringVar(&end, "end", "", "Drop metrics at or after this time (RFC 3339 or YYYY-MM-DD)")
+	rootCmd.Flags().StringSliceVar(&dropLabels, "drop-labels", nil, "Labels removed from the metrics")
+	rootCmd.Flags().StringSliceVar(&keepLabels, "keep-labels", nil, "Labels kept on the metrics, removing the others")
+	rootCmd.Flags().StringVar(&aggregate, "aggregate", "", `Aggregation of the metrics, such as "sum by (namespace) every 1h" (sum, avg or max)`)
+	rootCmd.Flags().IntVar(&stageWorkers, "stage-workers", runtime.GOMAXPROCS(0), "Number of parallel workers applying the stages")
+
+	// Connections to remote_write endpoints
+	rootCmd.Flags().StringVar(&egress.ProxyURL, "proxy-url", "", "Proxy remote_write requests are sent through (default: HTTPS_PROXY)")
+	rootCmd.Flags().StringVar(&egress.CABundlePath, "ca-bundle", "", "PEM bundle of CA certificates trusted in addition to the system roots")
+	rootCmd.Flags().StringVar(&egress.ClientCertPath, "client-cert", "", "PEM client certificate presented to remote_write endpoints")
+	rootCmd.Flags().StringVar(&egress.ClientKeyPath, "client-key", "", "PEM key of the client certificate")
+
 	// Logging
 	rootCmd.Flags().StringVar(&logLevel, "log-level", "warn", "Log level (trace, debug, info, warn, error)")
 
@@ -251,6 +283,10 @@ func run(cmd *cobra.Command, args []string) error {
 	if listen != "" && len(inputs) > 0 {
 		return errors.New("cannot use both --listen and input files")
 	}
+	pipeline, err := newStages(matches, start, end, dropLabels, keepLabels, aggregate)
+	if err != nil {
+		return err
+	}
 
 	// Create a cancellable context (kept as fallback, but closing channel should suffice)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -339,12 +375,19 @@ func run(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Start the stage workers, if any - their output closes once inputCh is
+	// closed and drained
+	var stagedCh <-chan types.Metric = inputCh
+	if !pipeline.empty() {
+		stagedCh = pipeline.run(ctx, inputCh, stageWorkers)
+	}
+
 	// Start fan-out goroutine - exits when input channel closes
 	var fanOutWg sync.WaitGroup
 	fanOutWg.Add(1)
 	go func() {
 		defer fanOutWg.Done()
-		FanOut(ctx, inputCh, fanOutChs)
+		FanOut(ctx, stagedCh, fanOutChs)
 	}()
 
 	// Start readers - they close inputCh when done or when shutdown is signaled
@@ -374,12 +417,16 @@ func createWriter(ctx context.Context, output string) (Writer, error) {
 	}
 
 	if isRemoteWriteURL(output) {
-		return newRemoteWriterAdapter(ctx, output), nil
+		writer, err := newRemoteWriterAdapter(ctx, output)
+		if err != nil {
+			return nil, err
+		}
+		return writer, nil
 	}
 
 	ext := strings.ToLower(filepath.Ext(output))
-	if ext == extensionBrotli && strings.HasSuffix(output, extensionJSONBrotli) {
-		ext = extensionJSONBrotli
+	if metricio.IsCompressedJSONFile(output) {
+		ext = extensionJSONCompressed
 	}
 
 	switch ext {
@@ -395,7 +442,7 @@ func createWriter(ctx context.Context, output string) (Writer, error) {
 			return nil, err
 		}
 		return &jsonWriterAdapter{writer: jw}, nil
-	case extensionJSONBrotli:
+	case extensionJSONCompressed:
 		jw, err := metricio.NewJSONWriter(output)
 		if err != nil {
 			return nil, err
@@ -628,8 +675,8 @@ func isSupportedInputFile(path string) bool {
 // readFile reads metrics from a single file and sends them to the channel.
 func readFile(path string, ch chan<- types.Metric) error {
 	ext := strings.ToLower(filepath.Ext(path))
-	if strings.HasSuffix(path, extensionJSONBrotli) {
-		ext = extensionJSONBrotli
+	if metricio.IsCompressedJSONFile(path) {
+		ext = extensionJSONCompressed
 	}
 
 	switch ext {
@@ -637,7 +684,7 @@ func readFile(path string, ch chan<- types.Metric) error {
 		return readCSVFile(path, ch)
 	case extensionParquet:
 		return readParquetFile(path, ch)
-	case extensionJSON, extensionJSONBrotli:
+	case extensionJSON, extensionJSONCompressed:
 		return readJSONFile(path, ch)
 	default:
 		return fmt.Errorf("unsupported input format: %s", ext)
@@ -0,0 +1,596 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+const (
+	labelName           = "__name__"
+	labelNode           = "node"
+	labelClusterName    = "cluster_name"
+	labelCloudAccountID = "cloud_account_id"
+
+	aggregateSum = "sum"
+	aggregateAvg = "avg"
+	aggregateMax = "max"
+)
+
+// aggregateExpr is the syntax of --aggregate, such as "sum by (namespace)
+// every 1h".
+var aggregateExpr = regexp.MustCompile(`^(sum|avg|max)(?:\s+by\s*\(([^)]*)\))?(?:\s+every\s+(\S+))?$`)
+
+// matcher compares a label with a value, as in a Prometheus selector.
+type matcher struct {
+	name  string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+// matches reports whether the value of the label satisfies the matcher. A
+// missing label has an empty value.
+func (m matcher) matches(value string) bool {
+	switch m.op {
+	case "=":
+		return value == m.value
+	case "!=":
+		return value != m.value
+	case "=~":
+		return m.re.MatchString(value)
+	default:
+		return !m.re.MatchString(value)
+	}
+}
+
+// selector matches the metrics whose labels satisfy all its matchers.
+type selector []matcher
+
+// matches reports whether the labels satisfy every matcher of the selector.
+func (s selector) matches(labels map[string]string) bool {
+	for _, m := range s {
+		if !m.matches(labels[m.name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseSelector parses a Prometheus-style selector, such as
+// `container_cpu_usage_seconds_total{namespace="payments",pod=~"api-.*"}`.
+// The braces may be omitted when there is no metric name, as in
+// namespace=payments, and the quotes when the value has no commas.
+func parseSelector(s string) (selector, error) {
+	s = strings.TrimSpace(s)
+	var sel selector
+
+	body := s
+	if open := strings.IndexByte(s, '{'); open >= 0 {
+		if !strings.HasSuffix(s, "}") {
+			return nil, fmt.Errorf("invalid selector %q: missing closing brace", s)
+		}
+		if name := strings.TrimSpace(s[:open]); name != "" {
+			sel = append(sel, matcher{name: labelName, op: "=", value: name})
+		}
+		body = s[open+1 : len(s)-1]
+	} else if isLabelName(s) {
+		return selector{{name: labelName, op: "=", value: s}}, nil
+	}
+
+	for _, part := range splitMatchers(body) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		m, err := parseMatcher(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector %q: %w", s, err)
+		}
+		sel = append(sel, m)
+	}
+	if len(sel) == 0 {
+		return nil, fmt.Errorf("invalid selector %q: no matchers", s)
+	}
+	return sel, nil
+}
+
+// parseMatcher parses a single matcher, such as pod=~"api-.*".
+func parseMatcher(s string) (matcher, error) {
+	end := 0
+	for end < len(s) && isLabelChar(s[end], end == 0) {
+		end++
+	}
+	m := matcher{name: s[:end]}
+	if m.name == "" {
+		return m, fmt.Errorf("missing label name in %q", s)
+	}
+
+	rest := strings.TrimSpace(s[end:])
+	for _, op := range []string{"=~", "!~", "!=", "="} {
+		if strings.HasPrefix(rest, op) {
+			m.op = op
+			rest = strings.TrimSpace(rest[len(op):])
+			break
+		}
+	}
+	if m.op == "" {
+		return m, fmt.Errorf("missing operator in %q", s)
+	}
+
+	value, err := unquote(rest)
+	if err != nil {
+		return m, fmt.Errorf("invalid value in %q: %w", s, err)
+	}
+	m.value = value
+
+	if m.op == "=~" || m.op == "!~" {
+		// Anchored, as in Prometheus.
+		m.re, err = regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return m, fmt.Errorf("invalid regular expression in %q: %w", s, err)
+		}
+	}
+	return m, nil
+}
+
+// splitMatchers splits the body of a selector on the commas outside of
+// quoted values.
+func splitMatchers(s string) []string {
+	var (
+		parts []string
+		quote byte
+		start int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == ',':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote returns the value with its quotes, if any, removed.
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] {
+		return s, nil
+	}
+	switch s[0] {
+	case '"':
+		return strconv.Unquote(s)
+	case '\'', '`':
+		return s[1 : len(s)-1], nil
+	}
+	return s, nil
+}
+
+func isLabelChar(c byte, first bool) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+func isLabelName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isLabelChar(s[i], i == 0) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// aggregation combines the values of the metrics sharing a name, the by
+// labels and a time window into a single metric.
+type aggregation struct {
+	op string
+	by []string
+	// every is the size of the windows; all the samples of a group fall in a
+	// single window when zero.
+	every time.Duration
+}
+
+// parseAggregation parses an aggregation, such as "sum by (namespace) every
+// 1h".
+func parseAggregation(s string) (*aggregation, error) {
+	parts := aggregateExpr.FindStringSubmatch(strings.TrimSpace(s))
+	if parts == nil {
+		return nil, fmt.Errorf("invalid aggregation %q, expected sum|avg|max [by (label, ...)] [every <duration>]", s)
+	}
+
+	agg := &aggregation{op: parts[1]}
+	for _, label := range strings.Split(parts[2], ",") {
+		if label = strings.TrimSpace(label); label != "" && label != labelName {
+			agg.by = append(agg.by, label)
+		}
+	}
+	if parts[3] != "" {
+		every, err := time.ParseDuration(parts[3])
+		if err != nil || every <= 0 {
+			return nil, fmt.Errorf("invalid aggregation window %q", parts[3])
+		}
+		agg.every = every
+	}
+	return agg, nil
+}
+
+// group is the partial aggregate of the metrics of a group.
+type group struct {
+	labels    map[string]string
+	timestamp time.Time
+	sum       float64
+	max       float64
+	count     int
+}
+
+// aggregator accumulates the partial aggregates of the metrics it is given.
+// Each stage worker has its own, merged once the input is drained.
+type aggregator struct {
+	agg     *aggregation
+	groups  map[string]*group
+	skipped int
+}
+
+func newAggregator(agg *aggregation) *aggregator {
+	return &aggregator{agg: agg, groups: map[string]*group{}}
+}
+
+// add accumulates the metric into its group. Metrics without a numeric value
+// are skipped.
+func (a *aggregator) add(m types.Metric) {
+	value, err := strconv.ParseFloat(m.Value, 64)
+	if err != nil || math.IsNaN(value) {
+		a.skipped++
+		return
+	}
+
+	labels := metricLabels(m)
+	timestamp := m.TimeStamp.UTC()
+	if a.agg.every > 0 {
+		timestamp = timestamp.Truncate(a.agg.every)
+	}
+
+	var key strings.Builder
+	key.WriteString(labels[labelName])
+	for _, label := range a.agg.by {
+		key.WriteByte(0xff)
+		key.WriteString(labels[label])
+	}
+	if a.agg.every > 0 {
+		key.WriteByte(0xff)
+		key.WriteString(strconv.FormatInt(timestamp.UnixMilli(), 10))
+	}
+
+	a.merge(key.String(), &group{
+		labels:    groupLabels(labels, a.agg.by),
+		timestamp: timestamp,
+		sum:       value,
+		max:       value,
+		count:     1,
+	})
+}
+
+// merge combines a partial aggregate into the group with the key.
+func (a *aggregator) merge(key string, g *group) {
+	existing, ok := a.groups[key]
+	if !ok {
+		a.groups[key] = g
+		return
+	}
+	existing.sum += g.sum
+	existing.max = max(existing.max, g.max)
+	existing.count += g.count
+	if g.timestamp.After(existing.timestamp) {
+		existing.timestamp = g.timestamp
+	}
+}
+
+// mergeAll combines the partial aggregates of another aggregator.
+func (a *aggregator) mergeAll(other *aggregator) {
+	for key, g := range other.groups {
+		a.merge(key, g)
+	}
+	a.skipped += other.skipped
+}
+
+// metrics returns a metric per group, ordered by group.
+func (a *aggregator) metrics() []types.Metric {
+	keys := make([]string, 0, len(a.groups))
+	for key := range a.groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	now := time.Now().UTC()
+	result := make([]types.Metric, 0, len(keys))
+	for _, key := range keys {
+		g := a.groups[key]
+		var value float64
+		switch a.agg.op {
+		case aggregateSum:
+			value = g.sum
+		case aggregateAvg:
+			value = g.sum / float64(g.count)
+		case aggregateMax:
+			value = g.max
+		}
+		result = append(result, metricFromLabels(g.labels, g.timestamp, strconv.FormatFloat(value, 'f', -1, 64), now))
+	}
+	return result
+}
+
+// groupLabels returns the name and the by labels of a group.
+func groupLabels(labels map[string]string, by []string) map[string]string {
+	result := map[string]string{labelName: labels[labelName]}
+	for _, label := range by {
+		if value := labels[label]; value != "" {
+			result[label] = value
+		}
+	}
+	return result
+}
+
+// metricLabels returns all the labels of the metric, including the ones held
+// in fields of its own.
+func metricLabels(m types.Metric) map[string]string {
+	labels := m.FullLabels()
+	if m.ClusterName != "" {
+		labels[labelClusterName] = m.ClusterName
+	}
+	if m.CloudAccountID != "" {
+		labels[labelCloudAccountID] = m.CloudAccountID
+	}
+	return labels
+}
+
+// metricFromLabels builds a metric from the labels returned by metricLabels.
+func metricFromLabels(labels map[string]string, timestamp time.Time, value string, createdAt time.Time) types.Metric {
+	m := types.Metric{
+		ID:             uuid.New(),
+		ClusterName:    labels[labelClusterName],
+		CloudAccountID: labels[labelCloudAccountID],
+		MetricName:     labels[labelName],
+		NodeName:       labels[labelNode],
+		CreatedAt:      createdAt,
+		TimeStamp:      timestamp,
+		Labels:         map[string]string{},
+		Value:          value,
+	}
+	for name, v := range labels {
+		switch name {
+		case labelName, labelNode, labelClusterName, labelCloudAccountID:
+		default:
+			m.Labels[name] = v
+		}
+	}
+	return m
+}
+
+// stages are the transformations applied to the metrics between the readers
+// and the writers, in order: selection, time bounds, labels and aggregation.
+type stages struct {
+	// selectors select the metrics matching any of them, or all metrics when
+	// empty.
+	selectors []selector
+	// start and end bound the timestamps of the metrics to [start, end) when
+	// set.
+	start, end  time.Time
+	dropLabels  []string
+	keepLabels  []string
+	aggregation *aggregation
+}
+
+// newStages creates the stages from the values of the flags.
+func newStages(matches []string, start, end string, dropLabels, keepLabels []string, aggregate string) (*stages, error) {
+	s := &stages{dropLabels: dropLabels, keepLabels: keepLabels}
+
+	for _, match := range matches {
+		sel, err := parseSelector(match)
+		if err != nil {
+			return nil, err
+		}
+		s.selectors = append(s.selectors, sel)
+	}
+
+	var err error
+	if s.start, err = parseTime(start); err != nil {
+		return nil, fmt.Errorf("invalid --start: %w", err)
+	}
+	if s.end, err = parseTime(end); err != nil {
+		return nil, fmt.Errorf("invalid --end: %w", err)
+	}
+	if !s.start.IsZero() && !s.end.IsZero() && !s.start.Before(s.end) {
+		return nil, errors.New("--start must be before --end")
+	}
+
+	if len(dropLabels) > 0 && len(keepLabels) > 0 {
+		return nil, errors.New("cannot use both --drop-labels and --keep-labels")
+	}
+	if slices.Contains(dropLabels, labelName) {
+		return nil, errors.New("the metric name cannot be dropped")
+	}
+
+	if aggregate != "" {
+		if s.aggregation, err = parseAggregation(aggregate); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// parseTime parses an RFC 3339 time or a date, in UTC. An empty value is the
+// zero time.
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a date", s)
+	}
+	return t, nil
+}
+
+// empty reports whether the stages leave the metrics untouched.
+func (s *stages) empty() bool {
+	return len(s.selectors) == 0 && s.start.IsZero() && s.end.IsZero() &&
+		len(s.dropLabels) == 0 && len(s.keepLabels) == 0 && s.aggregation == nil
+}
+
+// process applies the stages before the aggregation to the metric, and
+// reports whether it is kept.
+func (s *stages) process(m types.Metric) (types.Metric, bool) {
+	if len(s.selectors) > 0 {
+		labels := metricLabels(m)
+		if !slices.ContainsFunc(s.selectors, func(sel selector) bool { return sel.matches(labels) }) {
+			return m, false
+		}
+	}
+
+	if !s.start.IsZero() && m.TimeStamp.Before(s.start) {
+		return m, false
+	}
+	if !s.end.IsZero() && !m.TimeStamp.Before(s.end) {
+		return m, false
+	}
+
+	if len(s.dropLabels) > 0 || len(s.keepLabels) > 0 {
+		m = s.relabel(m)
+	}
+	return m, true
+}
+
+// relabel returns the metric without the dropped labels, or with only the
+// kept ones. The metric name is always kept.
+func (s *stages) relabel(m types.Metric) types.Metric {
+	keep := func(name string) bool {
+		if len(s.keepLabels) > 0 {
+			return slices.Contains(s.keepLabels, name)
+		}
+		return !slices.Contains(s.dropLabels, name)
+	}
+
+	// The labels may be shared with the other samples of the series, so they
+	// are copied rather than modified.
+	labels := make(map[string]string, len(m.Labels))
+	for name, value := range m.Labels {
+		if keep(name) {
+			labels[name] = value
+		}
+	}
+	m.Labels = labels
+	if !keep(labelNode) {
+		m.NodeName = ""
+	}
+	if !keep(labelClusterName) {
+		m.ClusterName = ""
+	}
+	if !keep(labelCloudAccountID) {
+		m.CloudAccountID = ""
+	}
+	return m
+}
+
+// run applies the stages to the metrics of the input with parallel workers,
+// and sends the result to the returned channel. The aggregates are sent once
+// the input is closed, after which the channel is closed.
+func (s *stages) run(ctx context.Context, input <-chan types.Metric, workers int) <-chan types.Metric {
+	output := make(chan types.Metric, channelBufferSize)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		merged *aggregator
+	)
+	if s.aggregation != nil {
+		merged = newAggregator(s.aggregation)
+	}
+
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var partial *aggregator
+			if s.aggregation != nil {
+				partial = newAggregator(s.aggregation)
+				defer func() {
+					mu.Lock()
+					defer mu.Unlock()
+					merged.mergeAll(partial)
+				}()
+			}
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case metric, ok := <-input:
+					if !ok {
+						return
+					}
+					metric, keep := s.process(metric)
+					if !keep {
+						continue
+					}
+					if partial != nil {
+						partial.add(metric)
+						continue
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case output <- metric:
+					}
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(output)
+		wg.Wait()
+		if merged == nil {
+			return
+		}
+
+		if merged.skipped > 0 {
+			log.Warn().Int("metrics", merged.skipped).Msg("skipped metrics without a numeric value from the aggregation")
+		}
+		for _, metric := range merged.metrics() {
+			select {
+			case <-ctx.Done():
+				return
+			case output <- metric:
+			}
+		}
+	}()
+
+	return output
+}
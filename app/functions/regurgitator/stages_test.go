@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudzero/cloudzero-agent/app/types"
+)
+
+func testMetric(name, namespace, value string, timestamp time.Time) types.Metric {
+	return types.Metric{
+		ClusterName: "cluster",
+		MetricName:  name,
+		NodeName:    "node-1",
+		TimeStamp:   timestamp,
+		Labels:      map[string]string{"namespace": namespace, "pod": namespace + "-api-0"},
+		Value:       value,
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	m := testMetric("container_cpu_usage_seconds_total", "payments", "1", time.Now())
+	labels := metricLabels(m)
+
+	tests := []struct {
+		selector string
+		matches  bool
+		errText  string
+	}{
+		{selector: "namespace=payments", matches: true},
+		{selector: `{namespace="payments", pod=~"payments-.*"}`, matches: true},
+		{selector: `container_cpu_usage_seconds_total{namespace!="billing"}`, matches: true},
+		{selector: "container_cpu_usage_seconds_total", matches: true},
+		{selector: "cluster_name=cluster", matches: true},
+		{selector: `node!~"node-.*"`},
+		{selector: `pod=~"api"`},
+		{selector: `{namespace="payments,billing"}`},
+		{selector: "missing=", matches: true},
+		{selector: "{namespace}", errText: "missing operator"},
+		{selector: `{pod=~"("}`, errText: "invalid regular expression"},
+		{selector: "{namespace=payments", errText: "missing closing brace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := parseSelector(tt.selector)
+			if tt.errText != "" {
+				assert.ErrorContains(t, err, tt.errText)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.matches, sel.matches(labels))
+		})
+	}
+}
+
+func TestParseAggregation(t *testing.T) {
+	agg, err := parseAggregation("sum by (namespace, pod) every 1h")
+	require.NoError(t, err)
+	assert.Equal(t, &aggregation{op: aggregateSum, by: []string{"namespace", "pod"}, every: time.Hour}, agg)
+
+	agg, err = parseAggregation("max")
+	require.NoError(t, err)
+	assert.Equal(t, &aggregation{op: aggregateMax}, agg)
+
+	_, err = parseAggregation("count by (namespace)")
+	assert.ErrorContains(t, err, "invalid aggregation")
+	_, err = parseAggregation("avg every -1h")
+	assert.ErrorContains(t, err, "invalid aggregation window")
+}
+
+func TestNewStages(t *testing.T) {
+	s, err := newStages(nil, "", "", nil, nil, "")
+	require.NoError(t, err)
+	assert.True(t, s.empty())
+
+	_, err = newStages(nil, "2026-01-02", "2026-01-01", nil, nil, "")
+	assert.ErrorContains(t, err, "--start must be before --end")
+	_, err = newStages(nil, "yesterday", "", nil, nil, "")
+	assert.ErrorContains(t, err, "invalid --start")
+	_, err = newStages(nil, "", "", []string{"pod"}, []string{"namespace"}, "")
+	assert.ErrorContains(t, err, "cannot use both")
+	_, err = newStages(nil, "", "", []string{"__name__"}, nil, "")
+	assert.ErrorContains(t, err, "metric name cannot be dropped")
+}
+
+func TestStages_Process(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s, err := newStages(
+		[]string{"namespace=payments", "namespace=billing"},
+		"2026-01-01T00:00:00Z", "2026-01-01T02:00:00Z",
+		[]string{"pod", "node"}, nil, "",
+	)
+	require.NoError(t, err)
+
+	m := testMetric("cpu", "payments", "1", base)
+	out, ok := s.process(m)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"namespace": "payments"}, out.Labels)
+	assert.Empty(t, out.NodeName)
+	assert.Equal(t, "cluster", out.ClusterName)
+	assert.Contains(t, m.Labels, "pod", "the labels of the input are left untouched")
+
+	_, ok = s.process(testMetric("cpu", "billing", "1", base.Add(time.Hour)))
+	assert.True(t, ok)
+	_, ok = s.process(testMetric("cpu", "search", "1", base))
+	assert.False(t, ok)
+	_, ok = s.process(testMetric("cpu", "payments", "1", base.Add(-time.Second)))
+	assert.False(t, ok)
+	_, ok = s.process(testMetric("cpu", "payments", "1", base.Add(2*time.Hour)))
+	assert.False(t, ok)
+
+	s, err = newStages(nil, "", "", nil, []string{"namespace"}, "")
+	require.NoError(t, err)
+	out, ok = s.process(testMetric("cpu", "payments", "1", base))
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"namespace": "payments"}, out.Labels)
+	assert.Equal(t, "cpu", out.MetricName)
+	assert.Empty(t, out.ClusterName)
+}
+
+func TestStages_Run(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		aggregate string
+		want      []string
+	}{
+		{aggregate: "sum by (namespace) every 1h", want: []string{"billing 00:00 4", "payments 00:00 3", "payments 01:00 10"}},
+		{aggregate: "avg by (namespace) every 1h", want: []string{"billing 00:00 4", "payments 00:00 1.5", "payments 01:00 10"}},
+		{aggregate: "max by (namespace)", want: []string{"billing 00:30 4", "payments 01:15 10"}},
+		{aggregate: "sum", want: []string{" 01:15 17"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.aggregate, func(t *testing.T) {
+			s, err := newStages(nil, "", "", nil, nil, tt.aggregate)
+			require.NoError(t, err)
+
+			input := make(chan types.Metric, 10)
+			input <- testMetric("cpu", "payments", "1", base)
+			input <- testMetric("cpu", "payments", "2", base.Add(45*time.Minute))
+			input <- testMetric("cpu", "payments", "10", base.Add(75*time.Minute))
+			input <- testMetric("cpu", "billing", "4", base.Add(30*time.Minute))
+			input <- testMetric("cpu", "billing", "NaN", base)
+			input <- testMetric("cpu", "billing", "n/a", base)
+			close(input)
+
+			var got []string
+			for m := range s.run(context.Background(), input, 3) {
+				assert.Equal(t, "cpu", m.MetricName)
+				got = append(got, m.Labels["namespace"]+" "+m.TimeStamp.Format("15:04")+" "+m.Value)
+			}
+			sort.Strings(got)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
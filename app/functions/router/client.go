@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"net/http"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/http/serving"
+	"github.com/cloudzero/cloudzero-agent/app/logging/instr"
+)
+
+// newForwardClient returns the client the series are forwarded to the
+// collector replicas with. Replicas served in https mode are verified with
+// the CA of the sharding settings, and are presented its client certificate.
+func newForwardClient(ctx context.Context, cfg config.Sharding) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Scheme == config.ServerModeHTTPS {
+		tlsConfig, err := serving.NewClientTLSConfig(ctx, cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{Timeout: cfg.ForwardTimeout, Transport: instr.NewTransport(transport)}, nil
+}
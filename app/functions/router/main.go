@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package main implements the router of a sharded aggregator. It receives the
+// Prometheus remote_write requests in front of the collector replicas, and
+// forwards each series to the replica owning it on the hash ring.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/go-obvious/server"
+	"github.com/go-obvious/server/healthz"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	_ "github.com/KimMachineGun/automemlimit"
+	"github.com/cloudzero/cloudzero-agent/app/build"
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
+	"github.com/cloudzero/cloudzero-agent/app/domain/shard"
+	"github.com/cloudzero/cloudzero-agent/app/handlers"
+	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
+	"github.com/cloudzero/cloudzero-agent/app/http/serving"
+	"github.com/cloudzero/cloudzero-agent/app/logging"
+	"github.com/cloudzero/cloudzero-agent/app/logging/instr"
+)
+
+func main() {
+	var configFile string
+	flag.StringVar(&configFile, "config", configFile, "Path to the configuration file")
+	flag.Parse()
+
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+		log.Fatal().Err(err).Msg("configuration file does not exist")
+	}
+
+	settings, err := config.NewSettings(configFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load settings")
+	}
+	if !settings.Sharding.Enabled {
+		log.Fatal().Msg("the router requires sharding to be enabled")
+	}
+
+	logger, err := logging.NewLogger(
+		logging.WithLevel(settings.Logging.Level),
+	)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create the logger")
+	}
+	zerolog.DefaultContextLogger = logger
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx = logger.WithContext(ctx)
+
+	stopTracing := func() {}
+	if settings.Tracing.Enabled {
+		stopTracing, err = instr.InitTracing(ctx, instr.TracingConfig{
+			ServiceName:    "cloudzero-router",
+			ServiceVersion: build.GetVersion(),
+			Protocol:       settings.Tracing.Protocol,
+			Endpoint:       settings.Tracing.Endpoint,
+			SampleRatio:    settings.Tracing.SampleRatio,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to initialize tracing")
+		}
+	}
+	defer stopTracing()
+
+	client, err := k8s.GetClient()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create the Kubernetes client")
+	}
+	forwardClient, err := newForwardClient(ctx, settings.Sharding)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to create the client of the collector replicas")
+	}
+	discovery := shard.NewEndpointsDiscovery(client, settings.Sharding.Namespace, settings.Sharding.Service)
+	router := shard.NewRouter(settings.Sharding, discovery, shard.WithHTTPClient(forwardClient))
+	go router.Run(ctx)
+
+	healthz.Register("router-ring", func() error {
+		if router.Ring().Empty() {
+			return errors.New("no collector replica is available")
+		}
+		return nil
+	})
+
+	mw := []server.Middleware{
+		middleware.LoggingMiddlewareWrapper,
+		middleware.PromHTTPMiddleware,
+	}
+
+	// The router authenticates its clients like the collector replicas do.
+	var routerOpts []handlers.RouterAPIOption
+	authn, err := serving.NewAuthenticator(ctx, settings.Server.Auth)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initialize authentication")
+	}
+	if authn != nil {
+		routerOpts = append(routerOpts, handlers.WithRouterAuthentication(authn, settings.Server.Auth.AllowedIdentities))
+	}
+
+	apis := []server.API{
+		handlers.NewRouterAPI("/collector", router, routerOpts...),
+		handlers.NewPromMetricsAPI("/metrics"),
+	}
+	if settings.Server.Profiling {
+		apis = append(apis, handlers.NewProfilingAPI("/debug/pprof/"))
+	}
+
+	logger.Info().
+		Str("service", settings.Sharding.Namespace+"/"+settings.Sharding.Service).
+		Str("scheme", settings.Sharding.Scheme).
+		Strs("labels", settings.Sharding.Labels).
+		Str("mode", settings.Server.Mode).
+		Str("auth", settings.Server.Auth.Mode).
+		Msg("Starting service")
+	server.New(build.Version()).
+		WithAddress(fmt.Sprintf(":%d", settings.Server.Port)).
+		WithMiddleware(mw...).
+		WithAPIs(apis...).
+		WithListener(serving.NewListener(ctx, settings.Server)).
+		Run(ctx)
+	logger.Info().Msg("Service stopping")
+}
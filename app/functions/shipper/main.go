@@ -20,12 +20,16 @@ import (
 	_ "github.com/KimMachineGun/automemlimit"
 	"github.com/cloudzero/cloudzero-agent/app/build"
 	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
+	"github.com/cloudzero/cloudzero-agent/app/domain/leader"
 	"github.com/cloudzero/cloudzero-agent/app/domain/monitor"
 	"github.com/cloudzero/cloudzero-agent/app/domain/shipper"
 	"github.com/cloudzero/cloudzero-agent/app/handlers"
 	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
 	"github.com/cloudzero/cloudzero-agent/app/logging"
+	"github.com/cloudzero/cloudzero-agent/app/logging/instr"
 	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
+	"github.com/cloudzero/cloudzero-agent/app/types"
 )
 
 const (
@@ -46,6 +50,9 @@ func main() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to load settings")
 	}
+	if err := settings.UseShardStorage(); err != nil {
+		log.Fatal().Err(err).Msg("failed to set up the shard storage")
+	}
 
 	ctx := context.Background()
 
@@ -66,6 +73,21 @@ func main() {
 	zerolog.DefaultContextLogger = logger
 	ctx = logger.WithContext(ctx)
 
+	stopTracing := func() {}
+	if settings.Tracing.Enabled {
+		stopTracing, err = instr.InitTracing(ctx, instr.TracingConfig{
+			ServiceName:    "cloudzero-shipper",
+			ServiceVersion: build.GetVersion(),
+			Protocol:       settings.Tracing.Protocol,
+			Endpoint:       settings.Tracing.Endpoint,
+			SampleRatio:    settings.Tracing.SampleRatio,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("failed to initialize tracing")
+		}
+	}
+	defer stopTracing()
+
 	store, err := disk.NewDiskStore(settings.Database)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to initialize database")
@@ -92,13 +114,26 @@ func main() {
 		return
 	}
 
+	// When replicas share a volume, only the shipper holding the lease
+	// uploads its files.
+	var runnable types.Runnable = domain
+	if settings.LeaderElection.Enabled {
+		runnable, err = newElector(ctx, settings, domain)
+		if err != nil {
+			logger.Err(err).Msg("failed to create the leader elector")
+			exitCode = 1
+			return
+		}
+	}
+
 	// MUST BE AFTER DOMAIN
 	go func() {
-		HandleShutdownEvents(ctx, settings, domain)
+		HandleShutdownEvents(ctx, settings, runnable)
+		stopTracing()
 		os.Exit(0)
 	}()
 	go func() {
-		if err := domain.Run(); err != nil {
+		if err := runnable.Run(); err != nil {
 			logger.Err(err).Msg("failed to run metric shipper")
 		}
 	}()
@@ -136,6 +171,27 @@ func main() {
 	}()
 }
 
+// newElector wraps the shipper in a leader elector. The shipper cannot be run
+// again once shut down, so losing the lease exits, and the restarted container
+// campaigns again.
+func newElector(ctx context.Context, settings *config.Settings, domain *shipper.MetricShipper) (*leader.Elector, error) {
+	client, err := k8s.GetClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the kubernetes client: %w", err)
+	}
+	return leader.New(ctx, client, leader.Config{
+		LeaseName:     settings.LeaderElection.LeaseName,
+		Namespace:     settings.LeaderElection.Namespace,
+		Identity:      settings.LeaderElection.Identity,
+		LeaseDuration: settings.LeaderElection.LeaseDuration,
+		RenewDeadline: settings.LeaderElection.RenewDeadline,
+		RetryPeriod:   settings.LeaderElection.RetryPeriod,
+	}, domain, leader.WithOnLost(func() {
+		log.Ctx(ctx).Error().Msg("lost the shipper lease, exiting")
+		os.Exit(1)
+	}))
+}
+
 func waitForCollectorShutdown(ctx context.Context, shutdownFile string, maxWait time.Duration) bool {
 	// Timeline example for 10s timeout:
 	// t=0ms:    deadline=10000ms, check file, sleep 100ms
@@ -163,7 +219,7 @@ func waitForCollectorShutdown(ctx context.Context, shutdownFile string, maxWait
 	return false
 }
 
-func HandleShutdownEvents(ctx context.Context, settings *config.Settings, domain *shipper.MetricShipper) {
+func HandleShutdownEvents(ctx context.Context, settings *config.Settings, domain types.Runnable) {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-signalChan
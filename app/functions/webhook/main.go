@@ -22,15 +22,19 @@ import (
 	"github.com/cloudzero/cloudzero-agent/app/build"
 	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
 	"github.com/cloudzero/cloudzero-agent/app/domain/housekeeper"
+	"github.com/cloudzero/cloudzero-agent/app/domain/leader"
 	"github.com/cloudzero/cloudzero-agent/app/domain/monitor"
 	"github.com/cloudzero/cloudzero-agent/app/domain/pusher"
 	"github.com/cloudzero/cloudzero-agent/app/domain/webhook"
 	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/backfiller"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/informer"
+	"github.com/cloudzero/cloudzero-agent/app/domain/webhook/policy"
 	"github.com/cloudzero/cloudzero-agent/app/handlers"
 	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
 	"github.com/cloudzero/cloudzero-agent/app/logging"
 	"github.com/cloudzero/cloudzero-agent/app/storage/repo"
 	"github.com/cloudzero/cloudzero-agent/app/storage/streaming"
+	"github.com/cloudzero/cloudzero-agent/app/types"
 	"github.com/cloudzero/cloudzero-agent/app/utils"
 	"github.com/cloudzero/cloudzero-agent/app/utils/k8s"
 )
@@ -87,16 +91,21 @@ func main() {
 
 	if backfill {
 		log.Info().Msg("Starting backfill mode")
-		streamStore := streaming.New(settings, clock)
+		streamStore, err2 := streaming.New(settings, clock)
+		if err2 != nil {
+			log.Fatal().Err(err2).Msg("failed to create the streaming store")
+		}
 		wd, err2 := webhook.NewWebhookFactory(streamStore, settings, clock)
 		if err2 != nil {
 			log.Fatal().Err(err2).Msg("failed to create webhook domain controller")
 		}
-		k8sClient, err2 := k8s.NewClient(settings.K8sClient.KubeConfig)
+		// Throttled list requests are only retried by the backfiller, which
+		// slows down every request which follows.
+		k8sClient, err2 := k8s.NewClient(settings.K8sClient.KubeConfig, backfiller.DisableClientRetries)
 		if err2 != nil {
 			log.Fatal().Err(err2).Msg("Failed to build k8s client")
 		}
-		enum := backfiller.NewKubernetesObjectEnumerator(k8sClient, wd, settings)
+		enum := backfiller.NewKubernetesObjectEnumerator(k8sClient, wd, settings, backfiller.WithFlush(streamStore.Flush))
 		if backfillNoWait {
 			enum.DisableServiceWait()
 		}
@@ -120,7 +129,10 @@ func main() {
 	defer cancel()
 
 	// create remote metrics writer
-	dataPusher := pusher.New(ctx, store, clock, settings)
+	dataPusher, err := pusher.New(ctx, store, clock, settings)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create remote metrics writer") //nolint:gocritic // It's okay if the `defer cancel()` doesn't run since we're exiting.
+	}
 	if err = dataPusher.Run(); err != nil {
 		log.Fatal().Err(err).Msg("failed to start remote metrics writer") //nolint:gocritic // It's okay if the `defer cancel()` doesn't run since we're exiting.
 	}
@@ -132,6 +144,10 @@ func main() {
 		}
 	}()
 
+	// The pusher and the housekeeper are not elected: each replica's in-memory
+	// store only holds the changes that replica received, so a single elected
+	// replica would drop what the others admitted and leave their records
+	// unpruned.
 	hk := housekeeper.New(ctx, store, clock, settings)
 	if err = hk.Run(); err != nil {
 		log.Fatal().Err(err).Msg("failed to start database housekeeper")
@@ -142,7 +158,40 @@ func main() {
 		}
 	}()
 
-	wd, err := webhook.NewWebhookFactory(store, settings, clock)
+	var opts []webhook.Option
+	var mutator webhook.WebhookController
+	switch {
+	case settings.Policy.Enabled() && settings.Watch.Enabled:
+		// Informer mode observes changes after they were made, so there is no
+		// admission request to warn about or deny.
+		log.Warn().
+			Str("mode", string(settings.Policy.Mode)).
+			Msg("the cost allocation label policy only applies to admission requests, it is not enforced in informer mode")
+	case settings.Policy.Enabled() && settings.Policy.Mode == config.PolicyModeMutate:
+		log.Info().
+			Str("mode", string(settings.Policy.Mode)).
+			Bool("dryRun", settings.Policy.DryRun).
+			Strs("requiredLabels", settings.Policy.RequiredLabels).
+			Msg("Enabling cost allocation label policy")
+		// The API server only applies patches returned by a mutating webhook,
+		// so the policy is served on its own endpoint, registered by the
+		// MutatingWebhookConfiguration, instead of on /validate.
+		k8sClient, err2 := k8s.NewClient(settings.K8sClient.KubeConfig)
+		if err2 != nil {
+			log.Fatal().Err(err2).Msg("Failed to build k8s client")
+		}
+		namespaces := policy.NewNamespaceLabelCache(k8sClient, clock, policy.DefaultNamespaceCacheTTL)
+		mutator = webhook.NewMutationController(settings, policy.NewEnforcer(&settings.Policy, namespaces).Admit)
+	case settings.Policy.Enabled():
+		log.Info().
+			Str("mode", string(settings.Policy.Mode)).
+			Bool("dryRun", settings.Policy.DryRun).
+			Strs("requiredLabels", settings.Policy.RequiredLabels).
+			Msg("Enabling cost allocation label policy")
+		opts = append(opts, webhook.WithPolicy(policy.NewEnforcer(&settings.Policy, nil).Admit))
+	}
+
+	wd, err := webhook.NewWebhookFactory(store, settings, clock, opts...)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to create webhook domain controller")
 	}
@@ -158,10 +207,72 @@ func main() {
 		middleware.PromHTTPMiddleware,
 	}
 
+	// --- informer mode ---
+	// Resource changes are observed with list+watch instead of admission
+	// requests, so no webhook endpoint or TLS certificate is needed; only the
+	// metrics endpoint is served.
+	if settings.Watch.Enabled {
+		k8sClient, err2 := k8s.NewClient(settings.K8sClient.KubeConfig)
+		if err2 != nil {
+			log.Fatal().Err(err2).Msg("Failed to build k8s client")
+		}
+		watcher := informer.New(ctx, k8sClient, wd, settings)
+		var informers types.Runnable = watcher
+		if settings.LeaderElection.Enabled {
+			// Only the replica holding the lease watches, so each change is
+			// sent once however many replicas run. The watcher starts new
+			// informers each time the lease is acquired again.
+			informers, err = leader.New(ctx, k8sClient, leader.Config{
+				LeaseName:     settings.LeaderElection.LeaseName,
+				Namespace:     settings.LeaderElection.Namespace,
+				Identity:      settings.LeaderElection.Identity,
+				LeaseDuration: settings.LeaderElection.LeaseDuration,
+				RenewDeadline: settings.LeaderElection.RenewDeadline,
+				RetryPeriod:   settings.LeaderElection.RetryPeriod,
+			}, watcher)
+			if err != nil {
+				log.Fatal().Err(err).Msg("failed to create the leader elector of the resource informers")
+			}
+		}
+		if err = informers.Run(); err != nil {
+			log.Fatal().Err(err).Msg("failed to start resource informers")
+		}
+		defer func() {
+			if innerErr := informers.Shutdown(); innerErr != nil {
+				log.Err(innerErr).Msg("failed to shut down resource informers")
+			}
+		}()
+		if !settings.LeaderElection.Enabled {
+			go watcher.WaitForCacheSync(ctx)
+		}
+
+		apis := []server.API{handlers.NewPromMetricsAPI("/metrics")}
+		if settings.Server.Profiling {
+			apis = append(apis, handlers.NewProfilingAPI("/debug/pprof/"))
+		}
+
+		log.Ctx(ctx).Info().Dur("resyncPeriod", settings.Watch.ResyncPeriod).Msg("Starting service in informer mode")
+		server.New(build.Version()).
+			WithAddress(fmt.Sprintf(":%d", settings.Server.Port)).
+			WithMiddleware(mw...).
+			WithAPIs(apis...).
+			WithListener(server.HTTPListener()).
+			Run(ctx)
+		log.Ctx(ctx).Info().Msg("Server stopped")
+		return
+	}
+
+	if settings.LeaderElection.Enabled {
+		log.Ctx(ctx).Warn().Msg("leader election only applies to informer mode, every replica handles its admission requests")
+	}
+
 	apis := []server.API{
 		handlers.NewValidationWebhookAPI("/validate", wd),
 		handlers.NewPromMetricsAPI("/metrics"),
 	}
+	if mutator != nil {
+		apis = append(apis, handlers.NewValidationWebhookAPI("/mutate", mutator))
+	}
 	if settings.Server.Profiling {
 		apis = append(apis, handlers.NewProfilingAPI("/debug/pprof/"))
 	}
@@ -172,7 +283,13 @@ func main() {
 	signal.Notify(sigc, syscall.SIGHUP)
 
 	// Options
-	sig := monitor.WithSIGHUPReload(sigc)
+	// Reload on SIGHUP, and periodically to pick up a certificate rotated by
+	// certifik8s
+	reloads := []monitor.Option{monitor.WithSIGHUPReload(sigc)}
+	if settings.Certificate.ReloadInterval > 0 {
+		reloads = append(reloads, monitor.WithDurationReload(settings.Certificate.ReloadInterval))
+	}
+	reload := monitor.WithAnyReload(reloads...)
 	certs := monitor.WithCertificatesPaths(settings.Certificate.Cert, settings.Certificate.Key, "")
 	verify := monitor.WithVerifyConnection()
 	cb := monitor.WithOnReload(func(_ *tls.Config) {
@@ -182,7 +299,7 @@ func main() {
 		time.Duration(settings.Server.ReadTimeout),
 		time.Duration(settings.Server.WriteTimeout),
 		time.Duration(settings.Server.IdleTimeout),
-		func() *tls.Config { return monitor.TLSConfig(sig, certs, verify, cb) },
+		func() *tls.Config { return monitor.TLSConfig(reload, certs, verify, cb) },
 	)
 
 	log.Ctx(ctx).Info().Msg("Starting service")
@@ -0,0 +1,95 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-obvious/server"
+	"github.com/go-obvious/server/api"
+	"github.com/go-obvious/server/request"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain"
+	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
+)
+
+// defaultCoverageWindow is the window reported when none is requested.
+const defaultCoverageWindow = 10 * time.Minute
+
+// CoverageAPI exposes which metrics the collector received for each node and
+// scrape job. The scrape coverage diagnostic compares it with the nodes of the
+// cluster to find nodes and jobs which are not being scraped.
+type CoverageAPI struct {
+	// api.Service provides the foundational HTTP server infrastructure from go-obvious/server.
+	api.Service
+
+	collector *domain.MetricCollector
+
+	// auth, if non-nil, authenticates the clients before the coverage is
+	// reported.
+	auth func(http.Handler) http.Handler
+}
+
+// CoverageAPIOption configures optional behavior on a CoverageAPI.
+type CoverageAPIOption func(*CoverageAPI)
+
+// WithCoverageAuthentication requires clients to be authenticated by authn,
+// and when allowed is not empty, to have one of the identities listed, as
+// WithAuthentication does for remote_write requests. The coverage names the
+// nodes and scrape jobs of the cluster, so it is protected like the metrics.
+func WithCoverageAuthentication(authn middleware.Authenticator, allowed []string) CoverageAPIOption {
+	return func(a *CoverageAPI) {
+		a.auth = middleware.AuthMiddleware(authn, allowed)
+	}
+}
+
+// NewCoverageAPI creates a CoverageAPI reporting the coverage seen by the
+// given collector.
+func NewCoverageAPI(base string, d *domain.MetricCollector, opts ...CoverageAPIOption) *CoverageAPI {
+	a := &CoverageAPI{
+		collector: d,
+		Service: api.Service{
+			APIName: "coverage",
+			Mounts:  map[string]*chi.Mux{},
+		},
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.Mounts[base] = a.Routes()
+	return a
+}
+
+// Register integrates the CoverageAPI with the CloudZero Agent HTTP server.
+func (a *CoverageAPI) Register(app server.Server) error {
+	return a.Service.Register(app)
+}
+
+// Routes configures HTTP request routing for the coverage endpoint.
+func (a *CoverageAPI) Routes() *chi.Mux {
+	r := chi.NewRouter()
+	if a.auth != nil {
+		r.Use(a.auth)
+	}
+	r.Get("/", a.GetCoverage)
+	return r
+}
+
+// GetCoverage returns the series received within the window given by the
+// `window` query parameter, a duration such as "15m" which defaults to 10
+// minutes and is capped at domain.CoverageRetention.
+func (a *CoverageAPI) GetCoverage(w http.ResponseWriter, r *http.Request) {
+	window := defaultCoverageWindow
+	if value := request.QS(r, "window"); value != "" {
+		var err error
+		window, err = time.ParseDuration(value)
+		if err != nil || window <= 0 {
+			request.Reply(r, w, "window must be a positive duration", http.StatusBadRequest)
+			return
+		}
+	}
+	request.Reply(r, w, a.collector.Coverage(window), http.StatusOK)
+}
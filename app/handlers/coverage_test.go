@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-obvious/server/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain"
+	"github.com/cloudzero/cloudzero-agent/app/domain/testdata"
+	"github.com/cloudzero/cloudzero-agent/app/handlers"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/types/mocks"
+)
+
+func TestCoverage_ReportsReceivedSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClock := mocks.NewMockClock(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC))
+	storage := mocks.NewMockStore(ctrl)
+	storage.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	storage.EXPECT().Flush().Return(nil).AnyTimes()
+
+	cfg := config.Settings{Cloudzero: config.Cloudzero{RotateInterval: 10 * time.Minute}}
+	d, err := domain.NewMetricCollector(&cfg, mockClock, storage, nil)
+	require.NoError(t, err)
+	defer d.Close()
+
+	payload, _, _, err := testdata.BuildWriteRequest(testdata.WriteRequestFixture.Timeseries, nil, nil, nil, nil, "snappy")
+	require.NoError(t, err)
+	_, err = d.PutMetrics(t.Context(), "application/x-protobuf", "snappy", domain.RemoteWriteSource{}, payload)
+	require.NoError(t, err)
+
+	api := handlers.NewCoverageAPI("/", d)
+
+	req, _ := http.NewRequest("GET", "/?window=5m", bytes.NewReader(nil))
+	resp, err := test.InvokeService(api.Service, "/", *req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var report types.CoverageReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	assert.True(t, report.Since.Equal(mockClock.GetCurrentTime().Add(-5*time.Minute)))
+	assert.NotEmpty(t, report.Series)
+
+	req, _ = http.NewRequest("GET", "/?window=bogus", bytes.NewReader(nil))
+	resp, err = test.InvokeService(api.Service, "/", *req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestCoverage_Authentication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClock := mocks.NewMockClock(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC))
+	storage := mocks.NewMockStore(ctrl)
+	storage.EXPECT().Flush().Return(nil).AnyTimes()
+
+	cfg := config.Settings{Cloudzero: config.Cloudzero{RotateInterval: 10 * time.Minute}}
+	d, err := domain.NewMetricCollector(&cfg, mockClock, storage, nil)
+	require.NoError(t, err)
+	defer d.Close()
+
+	authn := staticAuthenticator{"Bearer validator": "validator", "Bearer intruder": "intruder"}
+	api := handlers.NewCoverageAPI("/", d, handlers.WithCoverageAuthentication(authn, []string{"validator"}))
+
+	for _, tt := range []struct {
+		token string
+		want  int
+	}{
+		{token: "", want: http.StatusUnauthorized},
+		{token: "Bearer forged", want: http.StatusUnauthorized},
+		{token: "Bearer intruder", want: http.StatusForbidden},
+		{token: "Bearer validator", want: http.StatusOK},
+	} {
+		req, _ := http.NewRequest("GET", "/", bytes.NewReader(nil))
+		if tt.token != "" {
+			req.Header.Set("Authorization", tt.token)
+		}
+		resp, err := test.InvokeService(api.Service, "/", *req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, tt.want, resp.StatusCode, tt.token)
+	}
+}
@@ -7,7 +7,10 @@ package handlers
 import (
 	"io"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/netip"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-obvious/server"
@@ -17,6 +20,7 @@ import (
 
 	"github.com/cloudzero/cloudzero-agent/app/domain"
 	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
+	"github.com/cloudzero/cloudzero-agent/app/logging/instr"
 )
 
 // MaxPayloadSize defines the maximum allowed size for Prometheus remote_write requests.
@@ -94,6 +98,14 @@ type RemoteWriteAPI struct {
 	// sustained 5xx rate without any code inside this handler needing to know
 	// about the health check itself.
 	errorRateTracker *middleware.ErrorRateTracker
+
+	// auth, if non-nil, authenticates the clients of the API before their
+	// metrics are ingested.
+	auth func(http.Handler) http.Handler
+
+	// trustedProxies are the peers whose X-Forwarded-For header names the
+	// client of a request.
+	trustedProxies []netip.Prefix
 }
 
 // RemoteWriteAPIOption configures optional behavior on a RemoteWriteAPI.
@@ -107,6 +119,27 @@ func WithErrorRateTracker(t *middleware.ErrorRateTracker) RemoteWriteAPIOption {
 	}
 }
 
+// WithAuthentication requires clients to be authenticated by authn, and when
+// allowed is not empty, to have one of the identities listed. Requests which
+// are not authenticated are not recorded by the ErrorRateTracker, including
+// the 503 answered when the credentials cannot be checked, so they do not
+// count against the health of the collector.
+func WithAuthentication(authn middleware.Authenticator, allowed []string) RemoteWriteAPIOption {
+	return func(a *RemoteWriteAPI) {
+		a.auth = middleware.AuthMiddleware(authn, allowed)
+	}
+}
+
+// WithTrustedProxies honours the X-Forwarded-For header of the requests sent
+// from the proxies, such as the shard router, so samples are attributed to the
+// client the proxy received them from. The header is ignored on requests from
+// any other peer.
+func WithTrustedProxies(proxies []netip.Prefix) RemoteWriteAPIOption {
+	return func(a *RemoteWriteAPI) {
+		a.trustedProxies = proxies
+	}
+}
+
 // NewRemoteWriteAPI creates a new HTTP API server for Prometheus remote_write metric ingestion.
 // This constructor initializes all necessary components for receiving and processing Prometheus
 // metrics through the CloudZero Agent cost allocation pipeline.
@@ -207,6 +240,14 @@ func (a *RemoteWriteAPI) Register(app server.Server) error {
 // while maintaining high throughput and reliability for CloudZero metric processing.
 func (a *RemoteWriteAPI) Routes() *chi.Mux {
 	r := chi.NewRouter()
+	// Continue the trace of the sender, so rejected requests are traced too
+	r.Use(instr.TraceMiddleware)
+	// Authentication is outside of the tracker, so neither rejected clients
+	// nor an unavailable authentication backend count against the health of
+	// the collector: only the requests it handles do.
+	if a.auth != nil {
+		r.Use(a.auth)
+	}
 	if a.errorRateTracker != nil {
 		r.Use(middleware.ErrorRateMiddleware(a.errorRateTracker))
 	}
@@ -288,7 +329,7 @@ func (a *RemoteWriteAPI) PostMetrics(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	stats, err := a.metrics.PutMetrics(r.Context(), contentType, encodingType, data)
+	stats, err := a.metrics.PutMetrics(r.Context(), contentType, encodingType, a.remoteWriteSource(r), data)
 	if err != nil {
 		log.Ctx(ctx).Err(err).Msg("failed to put metrics")
 		// On 5xx, force HTTP/1.x clients to tear down the TCP connection so
@@ -324,3 +365,48 @@ func (a *RemoteWriteAPI) PostMetrics(w http.ResponseWriter, r *http.Request) {
 
 	request.Reply(r, w, nil, http.StatusNoContent)
 }
+
+// remoteWriteSource identifies the client of a remote_write request. Requests
+// forwarded by a trusted proxy, such as the shard router, carry the address of
+// the client in X-Forwarded-For.
+func (a *RemoteWriteAPI) remoteWriteSource(r *http.Request) domain.RemoteWriteSource {
+	return domain.RemoteWriteSource{
+		Address:   clientAddress(r, a.trustedProxies),
+		UserAgent: r.UserAgent(),
+		Version:   r.Header.Get(domain.RemoteWriteVersionHeader),
+	}
+}
+
+// clientAddress returns the host of the client of the request. When the peer
+// is one of the trusted proxies, the last address of X-Forwarded-For, which
+// the proxy set, is preferred.
+func clientAddress(r *http.Request, trustedProxies []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	forwarded := r.Header.Values("X-Forwarded-For")
+	if len(forwarded) == 0 || !isTrusted(host, trustedProxies) {
+		return host
+	}
+	addresses := strings.Split(forwarded[len(forwarded)-1], ",")
+	if last := strings.TrimSpace(addresses[len(addresses)-1]); last != "" {
+		return last
+	}
+	return host
+}
+
+// isTrusted reports whether the host is in one of the trusted prefixes.
+func isTrusted(host string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
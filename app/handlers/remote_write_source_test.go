@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientAddress(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "192.0.2.1:1234", want: "192.0.2.1"},
+		{name: "untrusted peer", remoteAddr: "192.0.2.1:1234", forwarded: []string{"203.0.113.9"}, want: "192.0.2.1"},
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:1234", forwarded: []string{"192.0.2.7"}, want: "192.0.2.7"},
+		{name: "trusted proxy appended", remoteAddr: "10.1.2.3:1234", forwarded: []string{"203.0.113.9, 192.0.2.7"}, want: "192.0.2.7"},
+		{name: "trusted proxy last header", remoteAddr: "10.1.2.3:1234", forwarded: []string{"203.0.113.9", "192.0.2.7"}, want: "192.0.2.7"},
+		{name: "trusted proxy empty header", remoteAddr: "10.1.2.3:1234", forwarded: []string{""}, want: "10.1.2.3"},
+		{name: "mapped trusted proxy", remoteAddr: "[::ffff:10.1.2.3]:1234", forwarded: []string{"192.0.2.7"}, want: "192.0.2.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			assert.Equal(t, tt.want, clientAddress(r, trusted))
+		})
+	}
+}
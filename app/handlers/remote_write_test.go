@@ -183,3 +183,77 @@ func TestRemoteWrite_ErrorRateTrackerWiring(t *testing.T) {
 	assert.False(t, tracker.Healthy(0.20, 3),
 		"after 3 × 500 through the RemoteWriteAPI, the tracker should report unhealthy")
 }
+
+type staticAuthenticator map[string]string
+
+func (a staticAuthenticator) Authenticate(r *http.Request) (string, error) {
+	identity, ok := a[r.Header.Get("Authorization")]
+	switch {
+	case ok:
+		return identity, nil
+	case r.Header.Get("Authorization") == "Bearer unverifiable":
+		return "", errors.New("the TokenReview API is unavailable")
+	}
+	return "", middleware.ErrInvalidCredentials
+}
+
+// TestRemoteWrite_AuthenticationWiring verifies that unauthenticated requests
+// are rejected before their metrics are stored, and that the rejections,
+// including those of an unavailable authentication backend, are not counted as
+// failures by the ErrorRateTracker.
+func TestRemoteWrite_AuthenticationWiring(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClock := mocks.NewMockClock(time.Date(2023, 10, 1, 12, 0, 0, 0, time.UTC))
+	storage := mocks.NewMockStore(ctrl)
+
+	cfg := config.Settings{
+		CloudAccountID: "123456789012",
+		Region:         "us-west-2",
+		ClusterName:    "testcluster",
+		Cloudzero: config.Cloudzero{
+			Host:           "api.cloudzero.com",
+			RotateInterval: 10 * time.Minute,
+		},
+	}
+
+	d, err := domain.NewMetricCollector(&cfg, mockClock, storage, nil)
+	assert.NoError(t, err)
+	defer d.Close()
+
+	tracker := middleware.NewErrorRateTracker(60 * time.Second)
+	authn := staticAuthenticator{"Bearer prometheus": "prometheus", "Bearer intruder": "intruder"}
+	handler := handlers.NewRemoteWriteAPI(MountBase, d,
+		handlers.WithErrorRateTracker(tracker),
+		handlers.WithAuthentication(authn, []string{"prometheus"}),
+	)
+
+	storage.EXPECT().Put(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+	storage.EXPECT().Flush().Return(nil).AnyTimes()
+
+	payload, _, _, err := testdata.BuildWriteRequest(testdata.WriteRequestFixture.Timeseries, nil, nil, nil, nil, "snappy")
+	assert.NoError(t, err)
+
+	for token, code := range map[string]int{
+		"":                    http.StatusUnauthorized,
+		"Bearer forged":       http.StatusUnauthorized,
+		"Bearer intruder":     http.StatusForbidden,
+		"Bearer unverifiable": http.StatusServiceUnavailable,
+		"Bearer prometheus":   http.StatusNoContent,
+	} {
+		for i := 0; i < 3; i++ {
+			req := createRequest("POST", "/", bytes.NewReader(payload))
+			if token != "" {
+				req.Header.Set("Authorization", token)
+			}
+			resp, err := test.InvokeService(handler.Service, "/", *req)
+			assert.NoError(t, err)
+			_ = resp.Body.Close()
+			assert.Equal(t, code, resp.StatusCode, token)
+		}
+	}
+
+	assert.True(t, tracker.Healthy(0, 1),
+		"rejected clients and an unavailable authentication backend should not make the tracker report unhealthy")
+}
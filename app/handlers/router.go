@@ -0,0 +1,129 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-obvious/server"
+	"github.com/go-obvious/server/api"
+	"github.com/go-obvious/server/request"
+	"github.com/rs/zerolog/log"
+
+	"github.com/cloudzero/cloudzero-agent/app/domain/shard"
+	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
+	"github.com/cloudzero/cloudzero-agent/app/logging/instr"
+)
+
+// RouterAPI receives Prometheus remote_write requests in front of sharded
+// collector replicas, and forwards each series to the replica owning it.
+type RouterAPI struct {
+	// api.Service provides the foundational HTTP server infrastructure from go-obvious/server.
+	api.Service
+
+	router *shard.Router
+
+	// auth, if non-nil, authenticates the clients of the router before their
+	// series are forwarded.
+	auth func(http.Handler) http.Handler
+}
+
+// RouterAPIOption configures optional behavior on a RouterAPI.
+type RouterAPIOption func(*RouterAPI)
+
+// WithRouterAuthentication requires clients to be authenticated by authn, and
+// when allowed is not empty, to have one of the identities listed, as the
+// collector replicas do with WithAuthentication. The Authorization header is
+// still forwarded, so the replicas authenticate the client of the router too.
+func WithRouterAuthentication(authn middleware.Authenticator, allowed []string) RouterAPIOption {
+	return func(a *RouterAPI) {
+		a.auth = middleware.AuthMiddleware(authn, allowed)
+	}
+}
+
+// NewRouterAPI creates a RouterAPI forwarding the series with the router.
+func NewRouterAPI(base string, router *shard.Router, opts ...RouterAPIOption) *RouterAPI {
+	a := &RouterAPI{
+		router: router,
+		Service: api.Service{
+			APIName: "router",
+			Mounts:  map[string]*chi.Mux{},
+		},
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	a.Mounts[base] = a.Routes()
+	return a
+}
+
+// Register integrates the RouterAPI with the CloudZero Agent HTTP server.
+func (a *RouterAPI) Register(app server.Server) error {
+	return a.Service.Register(app)
+}
+
+// Routes configures HTTP request routing for the remote_write endpoint.
+func (a *RouterAPI) Routes() *chi.Mux {
+	r := chi.NewRouter()
+	// The forwarded requests continue the trace of the sender
+	r.Use(instr.TraceMiddleware)
+	if a.auth != nil {
+		r.Use(a.auth)
+	}
+	r.Post("/", a.PostMetrics)
+	return r
+}
+
+// PostMetrics forwards the series of a remote_write request to their owners.
+//
+// Requests which cannot be decoded are answered with 400, and replicas
+// all rejecting their series with a 4xx have their status passed on, so the
+// client does not retry them. Any other failure is answered with 503 so the
+// client retries the whole request.
+func (a *RouterAPI) PostMetrics(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	if r.ContentLength > MaxPayloadSize {
+		logErrorReply(r, w, "too big", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, MaxPayloadSize+1))
+	if err != nil {
+		log.Ctx(r.Context()).Err(err).Msg("failed to read request body")
+		request.Reply(r, w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+	if len(data) > MaxPayloadSize {
+		logErrorReply(r, w, "too big", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if len(data) == 0 {
+		request.Reply(r, w, nil, http.StatusNoContent)
+		return
+	}
+
+	// Pass the address of the client on, so the replicas attribute the
+	// samples to it. The router receives the requests of Prometheus directly,
+	// so an X-Forwarded-For header sent by the client is replaced.
+	header := r.Header.Clone()
+	header.Set("X-Forwarded-For", clientAddress(r, nil))
+
+	err = a.router.Route(r.Context(), header, data)
+	if err == nil {
+		request.Reply(r, w, nil, http.StatusNoContent)
+		return
+	}
+
+	code := http.StatusServiceUnavailable
+	if errors.Is(err, shard.ErrInvalidRequest) {
+		code = http.StatusBadRequest
+	} else if status, ok := shard.PermanentStatus(err); ok {
+		code = status
+	}
+	log.Ctx(r.Context()).Err(err).Int("statusCode", code).Msg("failed to route metrics")
+	http.Error(w, err.Error(), code)
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-obvious/server/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/shard"
+	"github.com/cloudzero/cloudzero-agent/app/domain/testdata"
+	"github.com/cloudzero/cloudzero-agent/app/handlers"
+)
+
+func TestRouter_PostMetrics(t *testing.T) {
+	payload, _, _, err := testdata.BuildWriteRequest(testdata.WriteRequestFixture.Timeseries, nil, nil, nil, nil, "snappy")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		members       int
+		replicaStatus int
+		body          []byte
+		want          int
+	}{
+		{name: "forwarded", members: 1, replicaStatus: http.StatusNoContent, body: payload, want: http.StatusNoContent},
+		{name: "empty", members: 1, replicaStatus: http.StatusNoContent, want: http.StatusNoContent},
+		{name: "invalid", members: 1, replicaStatus: http.StatusNoContent, body: []byte("not snappy"), want: http.StatusBadRequest},
+		{name: "rejected", members: 1, replicaStatus: http.StatusUnauthorized, body: payload, want: http.StatusUnauthorized},
+		{name: "replica failed", members: 1, replicaStatus: http.StatusInternalServerError, body: payload, want: http.StatusServiceUnavailable},
+		{name: "no replica", members: 0, body: payload, want: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var forwardedFor string
+			replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				forwardedFor = r.Header.Get("X-Forwarded-For")
+				w.WriteHeader(tt.replicaStatus)
+			}))
+			defer replica.Close()
+			host, port, err := net.SplitHostPort(replica.Listener.Addr().String())
+			require.NoError(t, err)
+			portNum, err := strconv.ParseUint(port, 10, 32)
+			require.NoError(t, err)
+
+			var members shard.StaticDiscovery
+			if tt.members > 0 {
+				members = append(members, shard.Member{Name: "collector-0", Address: host})
+			}
+			router := shard.NewRouter(config.Sharding{
+				Port: uint(portNum), Path: "/collector", Labels: []string{"pod"}, VirtualNodes: 8,
+			}, members)
+			require.NoError(t, router.Refresh(context.Background()))
+
+			handler := handlers.NewRouterAPI(MountBase, router)
+			req := createRequest("POST", "/", bytes.NewReader(tt.body))
+			req.Header.Set("X-Forwarded-For", "203.0.113.9")
+			resp, err := test.InvokeService(handler.Service, "/", *req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.want, resp.StatusCode)
+			if tt.name == "forwarded" {
+				assert.NotEmpty(t, forwardedFor, "the replicas know the client the series came from")
+				assert.NotContains(t, forwardedFor, "203.0.113.9", "the X-Forwarded-For of the client is replaced")
+			}
+		})
+	}
+}
+
+// TestRouter_Authentication verifies that the clients of the router are
+// authenticated before their series are forwarded, and that their credentials
+// are passed on to the replicas.
+func TestRouter_Authentication(t *testing.T) {
+	payload, _, _, err := testdata.BuildWriteRequest(testdata.WriteRequestFixture.Timeseries, nil, nil, nil, nil, "snappy")
+	require.NoError(t, err)
+
+	var forwarded int
+	var authorization string
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded++
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer replica.Close()
+	host, port, err := net.SplitHostPort(replica.Listener.Addr().String())
+	require.NoError(t, err)
+	portNum, err := strconv.ParseUint(port, 10, 32)
+	require.NoError(t, err)
+
+	router := shard.NewRouter(config.Sharding{
+		Port: uint(portNum), Path: "/collector", Labels: []string{"pod"}, VirtualNodes: 8,
+	}, shard.StaticDiscovery{{Name: "collector-0", Address: host}})
+	require.NoError(t, router.Refresh(context.Background()))
+
+	authn := staticAuthenticator{"Bearer prometheus": "prometheus", "Bearer intruder": "intruder"}
+	handler := handlers.NewRouterAPI(MountBase, router, handlers.WithRouterAuthentication(authn, []string{"prometheus"}))
+
+	for _, tt := range []struct {
+		token string
+		want  int
+	}{
+		{token: "", want: http.StatusUnauthorized},
+		{token: "Bearer forged", want: http.StatusUnauthorized},
+		{token: "Bearer intruder", want: http.StatusForbidden},
+		{token: "Bearer prometheus", want: http.StatusNoContent},
+	} {
+		req := createRequest("POST", "/", bytes.NewReader(payload))
+		if tt.token != "" {
+			req.Header.Set("Authorization", tt.token)
+		}
+		resp, err := test.InvokeService(handler.Service, "/", *req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, tt.want, resp.StatusCode, tt.token)
+	}
+
+	assert.Equal(t, 1, forwarded, "only the series of the authenticated client are forwarded")
+	assert.Equal(t, "Bearer prometheus", authorization)
+}
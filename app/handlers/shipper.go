@@ -5,9 +5,14 @@
 package handlers
 
 import (
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/go-obvious/server"
 	"github.com/go-obvious/server/api"
+	"github.com/go-obvious/server/request"
 
 	"github.com/cloudzero/cloudzero-agent/app/domain/shipper"
 )
@@ -126,6 +131,8 @@ func (a *ShipperAPI) Register(app server.Server) error {
 //
 // Route configuration:
 //   - GET /metrics: Prometheus metrics endpoint for operational monitoring and alerting
+//   - GET /canary/{id}?sent=<unix ms>: Progress of a data path diagnostic canary
+//   - GET /identity: Fingerprints seen for the account and cluster name
 //   - Future endpoints: Health checks, debug information, and performance metrics as needed
 //
 // The chi router provides:
@@ -140,5 +147,38 @@ func (a *ShipperAPI) Register(app server.Server) error {
 func (a *ShipperAPI) Routes() *chi.Mux {
 	r := chi.NewRouter()
 	r.Get("/metrics", a.shipper.GetMetricHandler().ServeHTTP)
+	r.Get("/canary/{id}", a.GetCanary)
+	r.Get("/identity", a.GetIdentity)
 	return r
 }
+
+// GetCanary reports how far a canary sample sent to the collector by the data
+// path diagnostic has travelled: whether it was flushed to disk, and whether
+// the file holding it was uploaded. The `sent` query parameter is the
+// timestamp of the canary sample in Unix milliseconds.
+func (a *ShipperAPI) GetCanary(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	sent, err := strconv.ParseInt(request.QS(r, "sent"), 10, 64)
+	if id == "" || err != nil {
+		request.Reply(r, w, "a canary id and sent timestamp are required", http.StatusBadRequest)
+		return
+	}
+
+	trace, err := a.shipper.TraceCanary(r.Context(), id, time.UnixMilli(sent))
+	if err != nil {
+		request.ReplyErr(w, r, err)
+		return
+	}
+	request.Reply(r, w, trace, http.StatusOK)
+}
+
+// GetIdentity reports the fingerprint of the cluster, and every fingerprint
+// seen for its account and cluster name, for the cluster identity diagnostic.
+func (a *ShipperAPI) GetIdentity(w http.ResponseWriter, r *http.Request) {
+	identity, err := a.shipper.ClusterIdentity()
+	if err != nil {
+		request.ReplyErr(w, r, err)
+		return
+	}
+	request.Reply(r, w, identity, http.StatusOK)
+}
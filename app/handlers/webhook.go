@@ -454,12 +454,11 @@ func (a *ValidationWebhookAPI) PostAdmissionRequest(w http.ResponseWriter, r *ht
 		return
 	}
 
-	sendAllowResponse := func(w http.ResponseWriter, r *http.Request) {
-		allowResponse := &types.AdmissionResponse{Allowed: true}
-		resp, err := a.marshallResponseToJSON(ctx, review, allowResponse)
+	sendResponse := func(w http.ResponseWriter, r *http.Request, admission *types.AdmissionResponse) {
+		resp, err := a.marshallResponseToJSON(ctx, review, admission)
 		if err != nil {
 			// Log the error but still allow the request - fail-open behavior
-			log.Ctx(ctx).Err(err).Msg("could not marshal allow response to json, allowing request anyway")
+			log.Ctx(ctx).Err(err).Msg("could not marshal admission response to json, allowing request anyway")
 
 			// Use minimal JSON response to ensure we always allow
 			w.Header().Set("Content-Type", "application/json")
@@ -479,10 +478,12 @@ func (a *ValidationWebhookAPI) PostAdmissionRequest(w http.ResponseWriter, r *ht
 		Str("operation", string(review.Operation)).
 		Msg("processing review request")
 
-	if _, err := a.controller.Review(ctx, review); err != nil {
-		log.Ctx(ctx).Error().Err(err).Send()
-		sendAllowResponse(w, r)
-		return
+	admission, err := a.controller.Review(ctx, review)
+	if err != nil || admission == nil {
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Send()
+		}
+		admission = &types.AdmissionResponse{Allowed: true}
 	}
 
 	// If we're using HTTP/1.x, we want to periodically close the connection to
@@ -497,7 +498,9 @@ func (a *ValidationWebhookAPI) PostAdmissionRequest(w http.ResponseWriter, r *ht
 		}
 	}
 
-	sendAllowResponse(w, r)
+	// The controller only denies or patches requests when a cost allocation
+	// policy is configured; otherwise this is always an allow response.
+	sendResponse(w, r, admission)
 }
 
 // configReader safely reads and validates HTTP request bodies for admission webhook processing.
@@ -591,25 +594,39 @@ func (a *ValidationWebhookAPI) marshallResponseToJSON(ctx context.Context, revie
 			log.Ctx(ctx).Warn().Msg("warnings used in a 'v1beta1' webhook")
 		}
 
+		response := &admissionv1beta1.AdmissionResponse{
+			UID:     k8stypes.UID(review.ID),
+			Allowed: resp.Allowed,
+			Result:  resultStatus,
+		}
+		if len(resp.Patch) > 0 {
+			patchType := admissionv1beta1.PatchTypeJSONPatch
+			response.Patch = resp.Patch
+			response.PatchType = &patchType
+		}
+
 		data, err := json.Marshal(admissionv1beta1.AdmissionReview{
 			TypeMeta: v1beta1AdmissionReviewTypeMeta,
-			Response: &admissionv1beta1.AdmissionResponse{
-				UID:     k8stypes.UID(review.ID),
-				Allowed: resp.Allowed,
-				Result:  resultStatus,
-			},
+			Response: response,
 		})
 		return data, err
 
 	case *admissionv1.AdmissionReview:
+		response := &admissionv1.AdmissionResponse{
+			UID:      k8stypes.UID(review.ID),
+			Warnings: resp.Warnings,
+			Allowed:  resp.Allowed,
+			Result:   resultStatus,
+		}
+		if len(resp.Patch) > 0 {
+			patchType := admissionv1.PatchTypeJSONPatch
+			response.Patch = resp.Patch
+			response.PatchType = &patchType
+		}
+
 		data, err := json.Marshal(admissionv1.AdmissionReview{
 			TypeMeta: v1AdmissionReviewTypeMeta,
-			Response: &admissionv1.AdmissionResponse{
-				UID:      k8stypes.UID(review.ID),
-				Warnings: resp.Warnings,
-				Allowed:  resp.Allowed,
-				Result:   resultStatus,
-			},
+			Response: response,
 		})
 		return data, err
 	}
@@ -37,6 +37,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/sirupsen/logrus"
+
+	"github.com/cloudzero/cloudzero-agent/app/logging/instr"
 )
 
 const (
@@ -124,6 +126,8 @@ func Do(
 	}
 	// Ensure consistent User-Agent identification for CloudZero Agent requests
 	setUserAgent(headers)
+	// Propagate the trace of the caller with the W3C traceparent header
+	instr.InjectTraceContext(ctx, req.Header)
 
 	// Configure URL query parameters with proper encoding
 	// This ensures parameter values are correctly escaped for HTTP transmission
@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrNoCredentials is returned by an Authenticator when the request
+	// carries no credentials.
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrInvalidCredentials is returned by an Authenticator when the
+	// credentials of the request are not valid.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Reasons requests are rejected, reported in the reason label of
+// http_requests_rejected_total.
+const (
+	RejectionMissingCredentials  = "missing_credentials"
+	RejectionInvalidCredentials  = "invalid_credentials"
+	RejectionForbidden           = "forbidden"
+	RejectionAuthenticationError = "authentication_error"
+	RejectionClientCertificate   = "client_certificate"
+)
+
+// RequestsRejected counts the requests rejected by AuthMiddleware and
+// RecordRejection.
+var RequestsRejected = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "http_requests_rejected_total",
+		Help: "Count of requests rejected by authentication or authorization, labeled by reason.",
+	},
+	[]string{"reason"},
+)
+
+// RecordRejection counts a request rejected outside of the HTTP handlers, for
+// example a TLS handshake with an invalid client certificate.
+func RecordRejection(reason string) {
+	RequestsRejected.WithLabelValues(reason).Inc()
+}
+
+// Authenticator identifies the client which sent a request.
+type Authenticator interface {
+	// Authenticate returns the identity of the client. It returns
+	// ErrNoCredentials or ErrInvalidCredentials when the client cannot be
+	// identified, and any other error when the credentials could not be
+	// checked.
+	Authenticate(r *http.Request) (string, error)
+}
+
+// AuthMiddleware returns HTTP middleware which only lets requests from
+// authenticated clients through. When allowed is not empty, the identity of
+// the client must also be listed in it.
+//
+// Rejected requests are answered with 401 or 403. When the credentials cannot
+// be checked, for example because the Kubernetes API is unavailable, the
+// request is answered with 503 so the client retries it. Mount it outside of
+// ErrorRateMiddleware: neither a misconfigured client nor an unavailable
+// authentication backend must make the server look unhealthy.
+func AuthMiddleware(authn Authenticator, allowed []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := authn.Authenticate(r)
+			if err != nil {
+				reason, code := RejectionAuthenticationError, http.StatusServiceUnavailable
+				switch {
+				case errors.Is(err, ErrNoCredentials):
+					reason, code = RejectionMissingCredentials, http.StatusUnauthorized
+				case errors.Is(err, ErrInvalidCredentials):
+					reason, code = RejectionInvalidCredentials, http.StatusUnauthorized
+				}
+				reject(w, r, reason, code, err.Error())
+				return
+			}
+
+			if len(allowed) > 0 && !slices.Contains(allowed, identity) {
+				reject(w, r, RejectionForbidden, http.StatusForbidden,
+					fmt.Sprintf("identity %q is not allowed", identity))
+				return
+			}
+
+			ctx := r.Context()
+			logger := log.Ctx(ctx).With().Str("identity", identity).Logger()
+			next.ServeHTTP(w, r.WithContext(logger.WithContext(ctx)))
+		})
+	}
+}
+
+func reject(w http.ResponseWriter, r *http.Request, reason string, code int, msg string) {
+	RecordRejection(reason)
+	log.Ctx(r.Context()).Warn().
+		Str("reason", reason).
+		Str("client", r.RemoteAddr).
+		Msg("rejected request: " + msg)
+
+	if code == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	http.Error(w, http.StatusText(code), code)
+}
+
+// bearerToken returns the bearer token of the request.
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return "", ErrNoCredentials
+	}
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", fmt.Errorf("%w: expected a bearer token", ErrInvalidCredentials)
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// tokenFileCheckInterval is how often a TokenFileAuthenticator checks whether
+// its file changed.
+const tokenFileCheckInterval = 10 * time.Second
+
+// TokenFileAuthenticator authenticates bearer tokens listed in a file. Each
+// line of the file holds a token and the identity of its client, separated by
+// a comma; empty lines and lines starting with # are ignored. The file is
+// reloaded when it changes, so tokens can be rotated without a restart.
+type TokenFileAuthenticator struct {
+	path string
+	now  func() time.Time
+
+	mu        sync.Mutex
+	modTime   time.Time
+	checkedAt time.Time
+	tokens    map[[sha256.Size]byte]string
+}
+
+// NewTokenFileAuthenticator creates an authenticator of the tokens in the
+// file, which must be valid.
+func NewTokenFileAuthenticator(path string) (*TokenFileAuthenticator, error) {
+	a := &TokenFileAuthenticator{path: path, now: time.Now}
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *TokenFileAuthenticator) Authenticate(r *http.Request) (string, error) {
+	token, err := bearerToken(r)
+	if err != nil {
+		return "", err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if now := a.now(); now.Sub(a.checkedAt) >= tokenFileCheckInterval {
+		a.checkedAt = now
+		if err := a.reloadLocked(); err != nil {
+			// Keep the tokens which were loaded last.
+			log.Ctx(r.Context()).Err(err).Str("file", a.path).Msg("failed to reload the tokens file")
+		}
+	}
+
+	// Tokens are looked up by digest, so the lookup time does not depend on
+	// how much of a token matches.
+	identity, ok := a.tokens[sha256.Sum256([]byte(token))]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown token", ErrInvalidCredentials)
+	}
+	return identity, nil
+}
+
+func (a *TokenFileAuthenticator) load() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checkedAt = a.now()
+	return a.reloadLocked()
+}
+
+// reloadLocked reads the file when it changed since it was last read. Caller
+// must hold a.mu.
+func (a *TokenFileAuthenticator) reloadLocked() error {
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to read the tokens file: %w", err)
+	}
+	if a.tokens != nil && info.ModTime().Equal(a.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to read the tokens file: %w", err)
+	}
+
+	tokens := map[[sha256.Size]byte]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		token, identity, found := strings.Cut(line, ",")
+		token, identity = strings.TrimSpace(token), strings.TrimSpace(identity)
+		if !found || token == "" || identity == "" {
+			return fmt.Errorf("invalid tokens file %s: line %d is not 'token,identity'", a.path, n)
+		}
+		tokens[sha256.Sum256([]byte(token))] = identity
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read the tokens file: %w", err)
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("tokens file %s has no tokens", a.path)
+	}
+
+	a.tokens = tokens
+	a.modTime = info.ModTime()
+	return nil
+}
+
+// CertificateAuthenticator identifies clients by the common name of the
+// certificate they presented. The certificate must have been verified during
+// the TLS handshake, see monitor.WithClientVerification.
+type CertificateAuthenticator struct{}
+
+func (CertificateAuthenticator) Authenticate(r *http.Request) (string, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return "", ErrNoCredentials
+	}
+	cn := r.TLS.PeerCertificates[0].Subject.CommonName
+	if cn == "" {
+		return "", fmt.Errorf("%w: the client certificate has no common name", ErrInvalidCredentials)
+	}
+	return cn, nil
+}
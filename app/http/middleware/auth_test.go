@@ -0,0 +1,237 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
+)
+
+func authRequest(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/collector", nil)
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return req
+}
+
+func writeTokens(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestTokenFileAuthenticator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	writeTokens(t, path, "# remote_write clients\nsecret-1, prometheus\n\nsecret-2,other-agent\n")
+
+	authn, err := middleware.NewTokenFileAuthenticator(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		header   string
+		identity string
+		err      error
+	}{
+		{name: "valid token", header: "Bearer secret-1", identity: "prometheus"},
+		{name: "scheme is case insensitive", header: "bearer secret-2", identity: "other-agent"},
+		{name: "unknown token", header: "Bearer secret-3", err: middleware.ErrInvalidCredentials},
+		{name: "basic auth", header: "Basic c2VjcmV0LTE=", err: middleware.ErrInvalidCredentials},
+		{name: "no credentials", err: middleware.ErrNoCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := authn.Authenticate(authRequest(tt.header))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.identity, identity)
+		})
+	}
+
+	t.Run("invalid files", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := middleware.NewTokenFileAuthenticator(filepath.Join(dir, "missing"))
+		assert.Error(t, err)
+
+		invalid := filepath.Join(dir, "invalid")
+		writeTokens(t, invalid, "secret-without-identity\n")
+		_, err = middleware.NewTokenFileAuthenticator(invalid)
+		assert.ErrorContains(t, err, "line 1")
+
+		empty := filepath.Join(dir, "empty")
+		writeTokens(t, empty, "# nothing\n")
+		_, err = middleware.NewTokenFileAuthenticator(empty)
+		assert.ErrorContains(t, err, "no tokens")
+	})
+}
+
+// newTokenReviewClient returns a clientset whose token reviews authenticate
+// the tokens of the map, and fail when err is set.
+func newTokenReviewClient(users map[string]string, reviews *int, err *error) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		*reviews++
+		if *err != nil {
+			return true, nil, *err
+		}
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if user, ok := users[review.Spec.Token]; ok {
+			review.Status.Authenticated = true
+			review.Status.User.Username = user
+		} else {
+			review.Status.Error = "invalid bearer token"
+		}
+		return true, review, nil
+	})
+	return client
+}
+
+func TestTokenReviewAuthenticator(t *testing.T) {
+	reviews := 0
+	var reviewErr error
+	client := newTokenReviewClient(map[string]string{
+		"sa-token": "system:serviceaccount:monitoring:prometheus",
+	}, &reviews, &reviewErr)
+	authn := middleware.NewTokenReviewAuthenticator(client, []string{"cloudzero-collector"}, time.Minute)
+
+	identity, err := authn.Authenticate(authRequest("Bearer sa-token"))
+	require.NoError(t, err)
+	assert.Equal(t, "system:serviceaccount:monitoring:prometheus", identity)
+
+	_, err = authn.Authenticate(authRequest("Bearer forged"))
+	assert.ErrorIs(t, err, middleware.ErrInvalidCredentials)
+	assert.ErrorContains(t, err, "invalid bearer token")
+	assert.Equal(t, 2, reviews)
+
+	// Both results are cached, so an API outage does not affect them.
+	reviewErr = errors.New("connection refused")
+	identity, err = authn.Authenticate(authRequest("Bearer sa-token"))
+	require.NoError(t, err)
+	assert.Equal(t, "system:serviceaccount:monitoring:prometheus", identity)
+	_, err = authn.Authenticate(authRequest("Bearer forged"))
+	assert.ErrorIs(t, err, middleware.ErrInvalidCredentials)
+	assert.Equal(t, 2, reviews)
+
+	_, err = authn.Authenticate(authRequest("Bearer new-token"))
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, middleware.ErrInvalidCredentials)
+	assert.ErrorContains(t, err, "connection refused")
+
+	_, err = authn.Authenticate(authRequest(""))
+	assert.ErrorIs(t, err, middleware.ErrNoCredentials)
+}
+
+func TestCertificateAuthenticator(t *testing.T) {
+	authn := middleware.CertificateAuthenticator{}
+
+	_, err := authn.Authenticate(authRequest(""))
+	assert.ErrorIs(t, err, middleware.ErrNoCredentials)
+
+	req := authRequest("")
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+		{Subject: pkix.Name{CommonName: "prometheus"}},
+	}}
+	identity, err := authn.Authenticate(req)
+	require.NoError(t, err)
+	assert.Equal(t, "prometheus", identity)
+
+	req.TLS.PeerCertificates[0].Subject.CommonName = ""
+	_, err = authn.Authenticate(req)
+	assert.ErrorIs(t, err, middleware.ErrInvalidCredentials)
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	writeTokens(t, path, "secret-1,prometheus\nsecret-2,intruder\n")
+	tokens, err := middleware.NewTokenFileAuthenticator(path)
+	require.NoError(t, err)
+
+	reviews := 0
+	reviewErr := errors.New("the server is currently unable to handle the request")
+	tokenReview := middleware.NewTokenReviewAuthenticator(newTokenReviewClient(nil, &reviews, &reviewErr), nil, time.Minute)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		authn  middleware.Authenticator
+		header string
+		code   int
+		reason string
+	}{
+		{name: "allowed", authn: tokens, header: "Bearer secret-1", code: http.StatusNoContent},
+		{name: "not allowed", authn: tokens, header: "Bearer secret-2", code: http.StatusForbidden, reason: middleware.RejectionForbidden},
+		{name: "invalid", authn: tokens, header: "Bearer secret-3", code: http.StatusUnauthorized, reason: middleware.RejectionInvalidCredentials},
+		{name: "missing", authn: tokens, code: http.StatusUnauthorized, reason: middleware.RejectionMissingCredentials},
+		{name: "review failure", authn: tokenReview, header: "Bearer sa-token", code: http.StatusServiceUnavailable, reason: middleware.RejectionAuthenticationError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := rejections(t, tt.reason)
+
+			rec := httptest.NewRecorder()
+			middleware.AuthMiddleware(tt.authn, []string{"prometheus"})(ok).ServeHTTP(rec, authRequest(tt.header))
+			assert.Equal(t, tt.code, rec.Code)
+
+			if tt.reason != "" {
+				assert.Equal(t, before+1, rejections(t, tt.reason))
+			}
+			if tt.code == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+// TestAuthMiddleware_ErrorRate verifies that rejected clients do not make the
+// tracker report the endpoint as failing.
+func TestAuthMiddleware_ErrorRate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	writeTokens(t, path, "secret-1,prometheus\n")
+	tokens, err := middleware.NewTokenFileAuthenticator(path)
+	require.NoError(t, err)
+
+	tracker := middleware.NewErrorRateTracker(time.Minute)
+	handler := middleware.ErrorRateMiddleware(tracker)(
+		middleware.AuthMiddleware(tokens, nil)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})),
+	)
+
+	for range 10 {
+		handler.ServeHTTP(httptest.NewRecorder(), authRequest("Bearer forged"))
+	}
+	assert.True(t, tracker.Healthy(0.20, 3))
+}
+
+func rejections(t *testing.T, reason string) float64 {
+	t.Helper()
+	if reason == "" {
+		return 0
+	}
+	return testutil.ToFloat64(middleware.RequestsRejected.WithLabelValues(reason))
+}
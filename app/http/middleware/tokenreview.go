@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package middleware
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// tokenReviewCacheSize bounds the number of cached TokenReview results.
+const tokenReviewCacheSize = 1024
+
+// TokenReviewAuthenticator authenticates Kubernetes ServiceAccount tokens with
+// the TokenReview API. The identity of a client is its username, for example
+// system:serviceaccount:monitoring:prometheus.
+//
+// Results are cached for a TTL, so a Prometheus sending a request every few
+// seconds does not cost a TokenReview each time, and a short Kubernetes API
+// outage does not interrupt clients which were already authenticated.
+type TokenReviewAuthenticator struct {
+	client    kubernetes.Interface
+	audiences []string
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]tokenReviewResult
+}
+
+type tokenReviewResult struct {
+	identity string
+	expires  time.Time
+}
+
+// NewTokenReviewAuthenticator creates an authenticator reviewing tokens with
+// the client. When audiences is not empty, tokens must be issued for one of
+// them.
+func NewTokenReviewAuthenticator(client kubernetes.Interface, audiences []string, ttl time.Duration) *TokenReviewAuthenticator {
+	return &TokenReviewAuthenticator{
+		client:    client,
+		audiences: audiences,
+		ttl:       ttl,
+		now:       time.Now,
+		cache:     map[[sha256.Size]byte]tokenReviewResult{},
+	}
+}
+
+func (a *TokenReviewAuthenticator) Authenticate(r *http.Request) (string, error) {
+	token, err := bearerToken(r)
+	if err != nil {
+		return "", err
+	}
+	key := sha256.Sum256([]byte(token))
+
+	if identity, ok := a.cached(key); ok {
+		if identity == "" {
+			return "", fmt.Errorf("%w: the token was rejected by the token review", ErrInvalidCredentials)
+		}
+		return identity, nil
+	}
+
+	review, err := a.client.AuthenticationV1().TokenReviews().Create(r.Context(), &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{
+			Token:     token,
+			Audiences: a.audiences,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to review the token: %w", err)
+	}
+
+	// Rejected tokens are cached too, so a misconfigured client does not
+	// cause a TokenReview per request.
+	identity := ""
+	if review.Status.Authenticated {
+		identity = review.Status.User.Username
+	}
+	a.store(key, identity)
+
+	if identity == "" {
+		if review.Status.Error != "" {
+			return "", fmt.Errorf("%w: %s", ErrInvalidCredentials, review.Status.Error)
+		}
+		return "", fmt.Errorf("%w: the token was rejected by the token review", ErrInvalidCredentials)
+	}
+	return identity, nil
+}
+
+// cached returns the cached identity of a token, which is empty when the
+// token was rejected.
+func (a *TokenReviewAuthenticator) cached(key [sha256.Size]byte) (string, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result, ok := a.cache[key]
+	if !ok || a.now().After(result.expires) {
+		return "", false
+	}
+	return result.identity, true
+}
+
+func (a *TokenReviewAuthenticator) store(key [sha256.Size]byte, identity string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if len(a.cache) >= tokenReviewCacheSize {
+		for k, result := range a.cache {
+			if now.After(result.expires) {
+				delete(a.cache, k)
+			}
+		}
+	}
+	if len(a.cache) >= tokenReviewCacheSize {
+		// Still full of live results: start over rather than grow.
+		clear(a.cache)
+	}
+	a.cache[key] = tokenReviewResult{identity: identity, expires: now.Add(a.ttl)}
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package serving builds the listeners, TLS configurations and authenticators
+// shared by the servers of the agent receiving remote_write requests: the
+// collector and the shard router.
+package serving
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/go-obvious/server"
+	"github.com/rs/zerolog/log"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/domain/k8s"
+	"github.com/cloudzero/cloudzero-agent/app/domain/monitor"
+	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
+)
+
+// NewListener returns the listener of the server mode. In https mode the
+// certificates are reloaded from disk periodically, so a certificate rotated
+// by cert-manager is served without a restart.
+func NewListener(ctx context.Context, cfg config.Server) server.ListenAndServeFunc {
+	if cfg.Mode != config.ServerModeHTTPS {
+		return server.HTTPListener()
+	}
+	return server.TLSListener(0, 0, 0, func() *tls.Config {
+		return NewTLSConfig(ctx, cfg.TLS)
+	})
+}
+
+// NewTLSConfig returns the TLS configuration of the server. When a client CA
+// is configured, clients must present a certificate signed by it, and
+// handshakes with invalid certificates are counted as rejections.
+func NewTLSConfig(ctx context.Context, cfg config.ServerTLS) *tls.Config {
+	opts := []monitor.Option{
+		monitor.WithCertificatesPaths(cfg.CertFile, cfg.KeyFile, cfg.ClientCAFile),
+		monitor.WithDurationReload(cfg.ReloadInterval),
+		monitor.WithOnReload(func(_ *tls.Config) {
+			log.Ctx(ctx).Debug().Msg("TLS certificates reloaded")
+		}),
+	}
+	if cfg.ClientCAFile != "" {
+		opts = append(opts, monitor.WithClientVerification())
+	}
+
+	tlsConfig := monitor.TLSConfig(opts...)
+	tlsConfig.MinVersion = tls.VersionTLS12
+	if verify := tlsConfig.VerifyConnection; verify != nil {
+		tlsConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+			if err := verify(cs); err != nil {
+				middleware.RecordRejection(middleware.RejectionClientCertificate)
+				log.Ctx(ctx).Warn().Err(err).Msg("rejected client certificate")
+				return err
+			}
+			return nil
+		}
+	}
+	return tlsConfig
+}
+
+// NewClientTLSConfig returns the TLS configuration of the connections of the
+// shard router to the collector replicas. The certificates of the replicas are
+// verified against cfg.ServerName rather than their pod IP. With a client
+// certificate, the certificate and the CA bundle are reloaded from disk
+// periodically; otherwise the CA bundle is read once.
+func NewClientTLSConfig(ctx context.Context, cfg config.ShardingTLS) (*tls.Config, error) {
+	if cfg.CertFile != "" {
+		tlsConfig := monitor.TLSConfig(
+			monitor.WithCertificatesPaths(cfg.CertFile, cfg.KeyFile, cfg.CAFile),
+			monitor.WithVerifyConnection(),
+			monitor.WithDurationReload(cfg.ReloadInterval),
+			monitor.WithOnReload(func(_ *tls.Config) {
+				log.Ctx(ctx).Debug().Msg("TLS client certificates reloaded")
+			}),
+		)
+		tlsConfig.ServerName = cfg.ServerName
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName: cfg.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA bundle: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in the CA bundle %s", cfg.CAFile)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// NewAuthenticator returns the authenticator of the remote_write endpoint, or
+// nil when clients are not authenticated.
+func NewAuthenticator(ctx context.Context, cfg config.ServerAuth) (middleware.Authenticator, error) {
+	switch cfg.Mode {
+	case config.AuthModeToken:
+		return middleware.NewTokenFileAuthenticator(cfg.TokensFile)
+	case config.AuthModeTokenReview:
+		client, err := k8s.GetClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the client for token reviews: %w", err)
+		}
+		return middleware.NewTokenReviewAuthenticator(client, cfg.Audiences, cfg.CacheTTL), nil
+	case config.AuthModeCertificate:
+		return middleware.CertificateAuthenticator{}, nil
+	default:
+		log.Ctx(ctx).Warn().Msg("remote_write requests are not authenticated; any client able to reach the server can write metrics")
+		return nil, nil //nolint:nilnil // no authentication is not an error
+	}
+}
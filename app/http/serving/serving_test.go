@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package serving_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/http/middleware"
+	"github.com/cloudzero/cloudzero-agent/app/http/serving"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue creates a certificate signed by the parent, or self-signed when the
+// parent is nil.
+func issue(t *testing.T, parent *testCert, cn string, isCA bool, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	t.Helper()
+	certFile = filepath.Join(dir, name+".crt")
+	keyFile = filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600))
+	return certFile, keyFile
+}
+
+func (c *testCert) tls() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key, Leaf: c.cert}
+}
+
+func TestNewTLSConfig_ClientVerification(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, nil, "collector-ca", true, 0)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := issue(t, ca, "collector", false, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+
+	tlsConfig := serving.NewTLSConfig(context.Background(), config.ServerTLS{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ClientCAFile:   caFile,
+		ReloadInterval: time.Minute,
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{
+		Handler: middleware.AuthMiddleware(middleware.CertificateAuthenticator{}, []string{"prometheus"})(
+			http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, "ok")
+			}),
+		),
+		ReadHeaderTimeout: time.Second,
+	}
+	go func() { _ = srv.Serve(tls.NewListener(listener, tlsConfig)) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	url := "https://" + listener.Addr().String() + "/collector"
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	get := func(certs ...tls.Certificate) (int, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+			MinVersion:   tls.VersionTLS12,
+		}}}
+		resp, err := client.Get(url)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+	rejected := func() float64 {
+		return testutil.ToFloat64(middleware.RequestsRejected.WithLabelValues(middleware.RejectionClientCertificate))
+	}
+
+	t.Run("client certificate signed by the CA", func(t *testing.T) {
+		code, err := get(issue(t, ca, "prometheus", false, x509.ExtKeyUsageClientAuth).tls())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("identity not allowed", func(t *testing.T) {
+		code, err := get(issue(t, ca, "intruder", false, x509.ExtKeyUsageClientAuth).tls())
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("client certificate signed by another CA", func(t *testing.T) {
+		before := rejected()
+		other := issue(t, nil, "other-ca", true, 0)
+		_, err := get(issue(t, other, "prometheus", false, x509.ExtKeyUsageClientAuth).tls())
+		require.Error(t, err)
+		assert.Equal(t, before+1, rejected())
+	})
+
+	t.Run("server certificate used as a client certificate", func(t *testing.T) {
+		before := rejected()
+		_, err := get(issue(t, ca, "prometheus", false, x509.ExtKeyUsageServerAuth).tls())
+		require.Error(t, err)
+		assert.Equal(t, before+1, rejected())
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		_, err := get()
+		require.Error(t, err)
+	})
+}
+
+func TestNewClientTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, nil, "collector-ca", true, 0)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := issue(t, ca, "collector", false, x509.ExtKeyUsageServerAuth).write(t, dir, "server")
+	routerCert, routerKey := issue(t, ca, "router", false, x509.ExtKeyUsageClientAuth).write(t, dir, "router")
+
+	serve := func(t *testing.T, clientCAFile string) string {
+		t.Helper()
+		tlsConfig := serving.NewTLSConfig(context.Background(), config.ServerTLS{
+			CertFile:       certFile,
+			KeyFile:        keyFile,
+			ClientCAFile:   clientCAFile,
+			ReloadInterval: time.Minute,
+		})
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		srv := &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if clientCAFile != "" {
+					_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+				}
+			}),
+			ReadHeaderTimeout: time.Second,
+		}
+		go func() { _ = srv.Serve(tls.NewListener(listener, tlsConfig)) }()
+		t.Cleanup(func() { _ = srv.Close() })
+		return "https://" + listener.Addr().String() + "/collector"
+	}
+	get := func(cfg config.ShardingTLS, url string) (string, error) {
+		tlsConfig, err := serving.NewClientTLSConfig(context.Background(), cfg)
+		require.NoError(t, err)
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, err := client.Get(url)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+
+	t.Run("client certificate presented to a replica requiring mTLS", func(t *testing.T) {
+		identity, err := get(config.ShardingTLS{
+			CAFile: caFile, CertFile: routerCert, KeyFile: routerKey, ServerName: "127.0.0.1", ReloadInterval: time.Minute,
+		}, serve(t, caFile))
+		require.NoError(t, err)
+		assert.Equal(t, "router", identity)
+	})
+
+	t.Run("replica certificate not issued for the server name", func(t *testing.T) {
+		_, err := get(config.ShardingTLS{
+			CAFile: caFile, CertFile: routerCert, KeyFile: routerKey, ServerName: "collector.cloudzero.svc", ReloadInterval: time.Minute,
+		}, serve(t, caFile))
+		require.Error(t, err)
+	})
+
+	t.Run("CA bundle without a client certificate", func(t *testing.T) {
+		_, err := get(config.ShardingTLS{CAFile: caFile, ServerName: "127.0.0.1"}, serve(t, ""))
+		require.NoError(t, err)
+	})
+
+	t.Run("replica certificate signed by another CA", func(t *testing.T) {
+		otherFile, _ := issue(t, nil, "other-ca", true, 0).write(t, dir, "other-ca")
+		_, err := get(config.ShardingTLS{CAFile: otherFile, ServerName: "127.0.0.1"}, serve(t, ""))
+		require.Error(t, err)
+	})
+
+	t.Run("CA bundle without a certificate", func(t *testing.T) {
+		empty := filepath.Join(dir, "empty.crt")
+		require.NoError(t, os.WriteFile(empty, nil, 0o600))
+		_, err := serving.NewClientTLSConfig(context.Background(), config.ShardingTLS{CAFile: empty})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,100 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+)
+
+// Probe is the connection made by Check.
+type Probe struct {
+	// Proxy is the proxy the request was sent through, without its password,
+	// or nil when it was sent directly.
+	Proxy *url.URL
+	// TLSVersion and Chain are the TLS version negotiated with the server and
+	// the certificate chain it was verified with, leaf first. They are empty
+	// for http URLs.
+	TLSVersion uint16
+	Chain      []*x509.Certificate
+	// StatusCode is the status of the response of the server.
+	StatusCode int
+}
+
+// Check sends a HEAD request to the URL, verifying that the proxy lets it
+// through and that the certificate chain of the server is trusted. Any
+// response of the server is a success, whatever its status: the request is
+// not authenticated.
+func (t *Transport) Check(ctx context.Context, target string) (*Probe, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", target, err)
+	}
+	probe := &Probe{}
+	proxy, err := t.Proxy(u)
+	if err != nil {
+		return probe, fmt.Errorf("failed to select the proxy of %s: %w", u.Host, err)
+	}
+	if proxy != nil {
+		redacted := *proxy
+		if _, ok := redacted.User.Password(); ok {
+			redacted.User = url.UserPassword(redacted.User.Username(), "xxxxx")
+		}
+		probe.Proxy = &redacted
+	}
+
+	var (
+		mu           sync.Mutex
+		handshake    bool
+		handshakeErr error
+	)
+	trace := &httptrace.ClientTrace{
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			defer mu.Unlock()
+			handshake = true
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			handshakeErr = err
+			if err == nil {
+				probe.TLSVersion = state.Version
+				if len(state.VerifiedChains) > 0 {
+					probe.Chain = state.VerifiedChains[0]
+				}
+			}
+		},
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodHead, u.String(), nil)
+	if err != nil {
+		return probe, fmt.Errorf("failed to create the request: %w", err)
+	}
+	// A new connection is needed to observe the handshake.
+	req.Close = true
+
+	resp, err := t.RoundTrip(req)
+	mu.Lock()
+	defer mu.Unlock()
+	switch {
+	case err == nil:
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		probe.StatusCode = resp.StatusCode
+		return probe, nil
+	case handshakeErr != nil:
+		return probe, fmt.Errorf("the TLS handshake with %s failed: %w", u.Host, handshakeErr)
+	case probe.Proxy != nil && u.Scheme == "https" && !handshake:
+		return probe, fmt.Errorf("the proxy %s did not connect to %s: %w", probe.Proxy, u.Host, err)
+	default:
+		return probe, fmt.Errorf("the request to %s failed: %w", u.Host, err)
+	}
+}
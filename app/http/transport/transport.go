@@ -0,0 +1,320 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package transport builds the transport of the outbound HTTP clients of the
+// agent, so every client honours the same egress proxy, CA bundle, client
+// certificate and TLS version.
+package transport
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	DefaultMinTLSVersion  = TLSVersion12
+	DefaultReloadInterval = time.Minute
+
+	// TLS versions accepted by Config.MinTLSVersion
+	TLSVersion12 = "1.2"
+	TLSVersion13 = "1.3"
+)
+
+// Config configures the outbound connections of the agent. It is shared by
+// the configurations of the agent components under the egress key.
+type Config struct {
+	// ProxyURL is the proxy requests are sent through, such as
+	// http://proxy.corp:3128. When empty, the HTTPS_PROXY, HTTP_PROXY and
+	// NO_PROXY environment variables apply.
+	ProxyURL string `yaml:"proxy_url" env:"EGRESS_PROXY_URL" env-description:"URL of the proxy outbound requests are sent through"`
+	// ProxyCredentialsFile holds the user:password the proxy authenticates,
+	// so they need not be part of the proxy URL.
+	ProxyCredentialsFile string `yaml:"proxy_credentials_file" env:"EGRESS_PROXY_CREDENTIALS_FILE" env-description:"file holding the user:password of the proxy"`
+	// NoProxy lists the hosts which are reached directly: host names, domains
+	// such as .example.internal, IP addresses and CIDR ranges. Cluster-local
+	// hosts are always reached directly, see clusterLocalHosts.
+	NoProxy []string `yaml:"no_proxy" env:"EGRESS_NO_PROXY" env-description:"hosts, domains and CIDR ranges reached without the proxy"`
+	// CABundlePath is a PEM bundle of CA certificates trusted in addition to
+	// the system roots, such as the CA of a proxy inspecting TLS traffic.
+	CABundlePath string `yaml:"ca_bundle_path" env:"EGRESS_CA_BUNDLE_PATH" env-description:"PEM bundle of CA certificates trusted in addition to the system roots"`
+	// ClientCertPath and ClientKeyPath are the certificate presented to the
+	// servers which require one (mTLS).
+	ClientCertPath string `yaml:"client_cert_path" env:"EGRESS_CLIENT_CERT_PATH" env-description:"PEM client certificate presented to servers"`
+	ClientKeyPath  string `yaml:"client_key_path" env:"EGRESS_CLIENT_KEY_PATH" env-description:"PEM key of the client certificate"`
+	// MinTLSVersion is the lowest TLS version negotiated, 1.2 or 1.3.
+	MinTLSVersion string `yaml:"min_tls_version" default:"1.2" env:"EGRESS_MIN_TLS_VERSION" env-description:"lowest TLS version negotiated, 1.2 or 1.3"`
+	// ReloadInterval is how often the files are checked for changes, so a
+	// rotated CA bundle, certificate or proxy password is used without a
+	// restart.
+	ReloadInterval time.Duration `yaml:"reload_interval" default:"1m" env:"EGRESS_RELOAD_INTERVAL" env-description:"how often to reload the CA bundle, client certificate and proxy credentials"`
+}
+
+// Validate checks the configuration and sets the defaults. It does not read
+// the files, which New does.
+func (c *Config) Validate() error {
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q, expected a URL such as http://proxy:3128", redact(c.ProxyURL))
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return fmt.Errorf("unsupported proxy scheme %q, expected http, https or socks5", u.Scheme)
+		}
+	}
+	if (c.ClientCertPath == "") != (c.ClientKeyPath == "") {
+		return errors.New("the client certificate and key must be set together")
+	}
+	switch c.MinTLSVersion {
+	case "":
+		c.MinTLSVersion = DefaultMinTLSVersion
+	case TLSVersion12, TLSVersion13:
+	default:
+		return fmt.Errorf("unsupported minimum TLS version %q, expected %s or %s", c.MinTLSVersion, TLSVersion12, TLSVersion13)
+	}
+	if c.ReloadInterval <= 0 {
+		c.ReloadInterval = DefaultReloadInterval
+	}
+	return nil
+}
+
+func (c *Config) files() []string {
+	var files []string
+	for _, f := range []string{c.ProxyCredentialsFile, c.CABundlePath, c.ClientCertPath, c.ClientKeyPath} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// Transport is an http.RoundTripper applying a Config. When the files of the
+// configuration change, the next request rebuilds the underlying transport,
+// and the connections of the previous one are closed once idle.
+type Transport struct {
+	cfg Config
+
+	mu        sync.Mutex
+	current   *http.Transport
+	proxy     func(*url.URL) (*url.URL, error)
+	stamps    []fileStamp
+	nextCheck time.Time
+}
+
+// fileStamp identifies a version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// New returns a transport applying the configuration. It fails when a file of
+// the configuration cannot be loaded.
+func New(cfg Config) (*Transport, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	t := &Transport{cfg: cfg}
+	t.stamps = t.stat()
+	current, proxy, err := t.build()
+	if err != nil {
+		return nil, err
+	}
+	t.current, t.proxy = current, proxy
+	t.nextCheck = time.Now().Add(cfg.ReloadInterval)
+	return t, nil
+}
+
+// NewClient returns an HTTP client using a transport applying the
+// configuration. Requests time out after the timeout unless it is zero.
+func NewClient(cfg Config, timeout time.Duration) (*http.Client, error) {
+	t, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Timeout: timeout, Transport: t}, nil
+}
+
+// RoundTrip sends the request with the current transport.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.reload(req).RoundTrip(req)
+}
+
+// CloseIdleConnections closes the idle connections of the current transport.
+func (t *Transport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current.CloseIdleConnections()
+}
+
+// Proxy returns the proxy requests to the URL are sent through, or nil when
+// they are sent directly.
+func (t *Transport) Proxy(u *url.URL) (*url.URL, error) {
+	t.mu.Lock()
+	proxy := t.proxy
+	t.mu.Unlock()
+	return proxy(u)
+}
+
+// reload returns the current transport, rebuilding it first when the files
+// changed since it was built. When the files cannot be loaded, for example
+// while a ConfigMap is being updated, the previous transport is kept and the
+// reload is tried again after the reload interval.
+func (t *Transport) reload(req *http.Request) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.stamps) == 0 || time.Now().Before(t.nextCheck) {
+		return t.current
+	}
+	t.nextCheck = time.Now().Add(t.cfg.ReloadInterval)
+
+	stamps := t.stat()
+	if slices.Equal(stamps, t.stamps) {
+		return t.current
+	}
+	current, proxy, err := t.build()
+	if err != nil {
+		log.Ctx(req.Context()).Warn().Err(err).Msg("failed to reload the egress configuration, keeping the previous one")
+		return t.current
+	}
+	log.Ctx(req.Context()).Info().Strs("files", t.cfg.files()).Msg("reloaded the egress configuration")
+
+	previous := t.current
+	t.current, t.proxy, t.stamps = current, proxy, stamps
+	previous.CloseIdleConnections()
+	return t.current
+}
+
+// stat returns the versions of the files of the configuration. A missing file
+// has a zero stamp, so it is reloaded once it appears.
+func (t *Transport) stat() []fileStamp {
+	files := t.cfg.files()
+	stamps := make([]fileStamp, len(files))
+	for i, f := range files {
+		if info, err := os.Stat(f); err == nil {
+			stamps[i] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return stamps
+}
+
+func (t *Transport) build() (*http.Transport, func(*url.URL) (*url.URL, error), error) {
+	tlsConfig, err := t.tlsConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	proxy, err := t.proxyFunc()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	base, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil, nil, errors.New("the default transport is not an *http.Transport")
+	}
+	current := base.Clone()
+	current.TLSClientConfig = tlsConfig
+	current.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+	return current, proxy, nil
+}
+
+func (t *Transport) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if t.cfg.MinTLSVersion == TLSVersion13 {
+		tlsConfig.MinVersion = tls.VersionTLS13
+	}
+
+	if t.cfg.CABundlePath != "" {
+		bundle, err := os.ReadFile(t.cfg.CABundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no PEM certificate found in the CA bundle %s", t.cfg.CABundlePath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if t.cfg.ClientCertPath != "" {
+		cert, err := tls.LoadX509KeyPair(t.cfg.ClientCertPath, t.cfg.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// clusterLocalHosts are the hosts which are never sent through the proxy, so
+// the clients of in-cluster services, such as the collector and the
+// Kubernetes API, keep working when an egress proxy is configured.
+func clusterLocalHosts() []string {
+	hosts := []string{".svc", ".cluster.local"}
+	if apiServer := os.Getenv("KUBERNETES_SERVICE_HOST"); apiServer != "" {
+		hosts = append(hosts, apiServer)
+	}
+	return hosts
+}
+
+func (t *Transport) proxyFunc() (func(*url.URL) (*url.URL, error), error) {
+	cfg := httpproxy.FromEnvironment()
+	if t.cfg.ProxyURL != "" {
+		cfg.HTTPProxy, cfg.HTTPSProxy = t.cfg.ProxyURL, t.cfg.ProxyURL
+		cfg.NoProxy = ""
+	}
+	noProxy := append(clusterLocalHosts(), t.cfg.NoProxy...)
+	if cfg.NoProxy != "" {
+		noProxy = append([]string{cfg.NoProxy}, noProxy...)
+	}
+	cfg.NoProxy = strings.Join(noProxy, ",")
+	proxy := cfg.ProxyFunc()
+	if t.cfg.ProxyCredentialsFile == "" {
+		return proxy, nil
+	}
+
+	credentials, err := os.ReadFile(t.cfg.ProxyCredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the proxy credentials: %w", err)
+	}
+	user, password, ok := strings.Cut(string(bytes.TrimSpace(credentials)), ":")
+	if !ok || user == "" {
+		return nil, fmt.Errorf("the proxy credentials file %s does not hold user:password", t.cfg.ProxyCredentialsFile)
+	}
+	return func(u *url.URL) (*url.URL, error) {
+		p, err := proxy(u)
+		if p == nil || err != nil {
+			return p, err
+		}
+		withCredentials := *p
+		withCredentials.User = url.UserPassword(user, password)
+		return &withCredentials, nil
+	}, nil
+}
+
+// redact hides the password of a URL, so it can be logged.
+func redact(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<invalid URL>"
+	}
+	return u.Redacted()
+}
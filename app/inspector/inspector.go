@@ -14,12 +14,14 @@ import (
 
 // Inspector inspects HTTP responses.
 type Inspector struct {
+	catalog    *Catalog
 	inspectors map[int]ResponseInspectorFunc
 }
 
-// New returns a new Inspector.
+// New returns a new Inspector, explaining responses with the remediation
+// catalog embedded in the binary.
 func New() *Inspector {
-	i := &Inspector{}
+	i := &Inspector{catalog: DefaultCatalog()}
 
 	i.inspectors = map[int]ResponseInspectorFunc{
 		http.StatusForbidden: i.inspect403,
@@ -41,6 +43,13 @@ func (i *Inspector) Inspect(ctx context.Context, resp *http.Response, logger zer
 	logger = logger.With().Int("status", resp.StatusCode).Logger()
 	logger = addCommonHeaders(logger, resp.Header)
 
+	if remediation, err := i.catalog.matchResponse(responseData); err != nil {
+		return err
+	} else if remediation != nil {
+		remediation.log(logger)
+		return nil
+	}
+
 	if inspector, ok := i.inspectors[resp.StatusCode]; ok {
 		if handled, err := inspector(ctx, responseData, logger); err != nil {
 			return err
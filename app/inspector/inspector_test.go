@@ -82,10 +82,24 @@ func TestInspector_Inspect(t *testing.T) {
 				Body: io.NopCloser(bytes.NewBufferString(`{"message": "User is not authorized to access this resource"}`)),
 			},
 			want: map[string]any{
-				"status":       float64(http.StatusForbidden),
-				"Content-Type": "application/json",
-				"level":        "error",
-				"message":      "Invalid CloudZero API key",
+				"status":         float64(http.StatusForbidden),
+				"Content-Type":   "application/json",
+				"level":          "error",
+				"message":        "Invalid CloudZero API key",
+				"remediation_id": "api-key-invalid",
+				"remediation":    "The CloudZero API did not accept the API key. Create a new API key and update the API key secret of the agent.",
+				"doc_url":        "https://docs.cloudzero.com/reference/authorization#creating-a-new-api-key",
+			},
+		},
+		{
+			name: "503 Service Unavailable",
+			resp: &http.Response{StatusCode: http.StatusServiceUnavailable},
+			want: map[string]any{
+				"status":         float64(http.StatusServiceUnavailable),
+				"level":          "warn",
+				"message":        "CloudZero API temporarily unavailable",
+				"remediation_id": "api-unavailable",
+				"remediation":    "The CloudZero API, or a proxy in front of it, is temporarily unavailable. Requests are retried; if this persists, check the egress proxy of the cluster.",
 			},
 		},
 	}
@@ -0,0 +1,236 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package recording records the requests made to the CloudZero API and their
+// responses to a HAR file, and replays them in place of the API. It lets the
+// shipper's allocation, abandon and upload flows be debugged against the exact
+// historical behaviour of the API, in tests and on laptops.
+//
+// The files follow the HAR 1.2 format, so they can be opened by browsers and
+// HAR viewers, but only the fields the replayer needs are filled in.
+package recording
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// harVersion is the version of the HAR format written.
+const harVersion = "1.2"
+
+// Redacted replaces the value of the headers listed in RedactedHeaders.
+const Redacted = "REDACTED"
+
+// RedactedHeaders are the headers whose values are never recorded.
+var RedactedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// RedactedQueryParameters are the query parameters whose values are never
+// recorded, in request URLs or in bodies. They carry the credentials of the
+// presigned upload URLs returned by the CloudZero API.
+var RedactedQueryParameters = []string{
+	"X-Amz-Credential",
+	"X-Amz-Security-Token",
+	"X-Amz-Signature",
+}
+
+// redactedQueryPattern matches the values of RedactedQueryParameters in URLs
+// embedded in text, such as the JSON bodies listing presigned URLs.
+var redactedQueryPattern = regexp.MustCompile(`(?i)((?:` + strings.Join(RedactedQueryParameters, "|") + `)=)[^&"'\\\s]+`)
+
+// Archive is the root of a HAR file.
+type Archive struct {
+	Log Log `json:"log"`
+}
+
+// Log holds the recorded entries.
+type Log struct {
+	Version string   `json:"version"`
+	Creator Creator  `json:"creator"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Creator is the application which recorded the archive.
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Entry is a request and its response.
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is the duration of the request in milliseconds.
+	Time     float64  `json:"time"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method      string    `json:"method"`
+	URL         string    `json:"url"`
+	HTTPVersion string    `json:"httpVersion"`
+	Headers     []Header  `json:"headers"`
+	QueryString []Header  `json:"queryString"`
+	PostData    *PostData `json:"postData,omitempty"`
+	HeadersSize int       `json:"headersSize"`
+	BodySize    int       `json:"bodySize"`
+}
+
+// PostData is the body of a recorded request.
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status      int      `json:"status"`
+	StatusText  string   `json:"statusText"`
+	HTTPVersion string   `json:"httpVersion"`
+	Headers     []Header `json:"headers"`
+	Content     Content  `json:"content"`
+	RedirectURL string   `json:"redirectURL"`
+	HeadersSize int      `json:"headersSize"`
+	BodySize    int      `json:"bodySize"`
+}
+
+// Content is the body of a recorded response.
+type Content struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Header is a header or query parameter.
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// LoadArchive reads a HAR file.
+func LoadArchive(path string) (*Archive, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the recording: %w", err)
+	}
+	var archive Archive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, fmt.Errorf("failed to decode the recording %s: %w", path, err)
+	}
+	return &archive, nil
+}
+
+// Save atomically writes the archive to a HAR file.
+func (a *Archive) Save(path string) error {
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the recording: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write the recording: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write the recording: %w", err)
+	}
+	return nil
+}
+
+// headers converts HTTP headers, sorted by name, redacting the values of
+// RedactedHeaders.
+func headers(h http.Header) []Header {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := []Header{}
+	for _, name := range names {
+		redact := isRedacted(name)
+		for _, v := range h[name] {
+			if redact {
+				v = Redacted
+			}
+			out = append(out, Header{Name: name, Value: v})
+		}
+	}
+	return out
+}
+
+func isRedacted(name string) bool {
+	for _, r := range RedactedHeaders {
+		if strings.EqualFold(r, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactQuery returns the URL with the values of RedactedQueryParameters
+// redacted.
+func redactQuery(u *url.URL) *url.URL {
+	query := u.Query()
+	redacted := false
+	for name, values := range query {
+		if !isRedactedQueryParameter(name) {
+			continue
+		}
+		for i := range values {
+			values[i] = Redacted
+		}
+		redacted = true
+	}
+	if !redacted {
+		return u
+	}
+	out := *u
+	out.RawQuery = query.Encode()
+	return &out
+}
+
+func isRedactedQueryParameter(name string) bool {
+	for _, r := range RedactedQueryParameters {
+		if strings.EqualFold(r, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeBody returns a body as text, base64 encoded when it is not UTF-8. The
+// values of RedactedQueryParameters in text bodies are redacted.
+func encodeBody(body []byte) (text, encoding string) {
+	if utf8.Valid(body) {
+		return redactedQueryPattern.ReplaceAllString(string(body), "${1}"+Redacted), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decodeBody reverses encodeBody.
+func decodeBody(text, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(text)
+	}
+	return []byte(text), nil
+}
@@ -0,0 +1,283 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudzero/cloudzero-agent/app/build"
+)
+
+// DefaultMaxBodySize is the size above which request and response bodies are
+// not recorded.
+const DefaultMaxBodySize = 1 << 20
+
+// Recorder is an http.RoundTripper which records every request and its
+// response to a HAR file. Each entry is appended to the file as it is
+// recorded, so a recording survives the process being stopped and the entries
+// are not kept in memory. Bodies larger than the maximum body size are
+// omitted.
+type Recorder struct {
+	base        http.RoundTripper
+	path        string
+	maxBodySize int
+
+	mu      sync.Mutex
+	file    *os.File
+	trailer []byte
+	entries int
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+
+// RecorderOption configures a Recorder.
+type RecorderOption func(*Recorder)
+
+// WithMaxBodySize sets the size above which bodies are omitted from the
+// recording, DefaultMaxBodySize by default. A negative size records every
+// body.
+func WithMaxBodySize(size int) RecorderOption {
+	return func(r *Recorder) {
+		r.maxBodySize = size
+	}
+}
+
+// NewRecorder creates a recorder sending requests with base, or
+// http.DefaultTransport when it is nil. Entries are appended to the file
+// when it already holds a recording. Close releases the file.
+func NewRecorder(base http.RoundTripper, path string, opts ...RecorderOption) (*Recorder, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	archive, err := LoadArchive(path)
+	if errors.Is(err, os.ErrNotExist) {
+		archive, err = &Archive{Log: Log{
+			Version: harVersion,
+			Creator: Creator{Name: "cloudzero-agent-inspector", Version: build.GetVersion()},
+		}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recorder{base: base, path: path, maxBodySize: DefaultMaxBodySize}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if err := r.open(archive); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open rewrites the archive in the layout entries are appended to, and opens
+// it. The entries of the archive are written, so a recording is resumed.
+func (r *Recorder) open(archive *Archive) error {
+	entries := archive.Log.Entries
+	archive.Log.Entries = []*Entry{}
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the recording: %w", err)
+	}
+	head, tail, ok := bytes.Cut(data, []byte(`"entries": []`))
+	if !ok {
+		return errors.New("failed to encode the recording: no entries")
+	}
+	r.trailer = append([]byte("\n    ]"), tail...)
+
+	tmp := r.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to write the recording: %w", err)
+	}
+	buf := &bytes.Buffer{}
+	buf.Write(head)
+	buf.WriteString(`"entries": [`)
+	for i, e := range entries {
+		if err := writeEntry(buf, e, i == 0); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	buf.Write(r.trailer)
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write the recording: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write the recording: %w", err)
+	}
+
+	r.file = f
+	r.entries = len(entries)
+	return nil
+}
+
+// Close closes the recording. Requests sent afterwards fail.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+// RoundTrip sends the request, and records it with its response. Requests
+// which fail without a response are not recorded.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the request body: %w", err)
+		}
+		reqBody = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	started := time.Now()
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := r.record(newEntry(req, reqBody, resp, respBody, started, r.maxBodySize)); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Entries returns the number of entries recorded.
+func (r *Recorder) Entries() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.entries
+}
+
+// record appends the entry to the file, in place of the trailer closing the
+// entries array, which is written again after it.
+func (r *Recorder) record(e *Entry) error {
+	buf := &bytes.Buffer{}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return errors.New("the recording is closed")
+	}
+	if err := writeEntry(buf, e, r.entries == 0); err != nil {
+		return err
+	}
+	buf.Write(r.trailer)
+	if _, err := r.file.Seek(-int64(len(r.trailer)), io.SeekEnd); err != nil {
+		return fmt.Errorf("failed to write the recording: %w", err)
+	}
+	if _, err := r.file.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write the recording: %w", err)
+	}
+	r.entries++
+	return nil
+}
+
+// writeEntry writes an element of the entries array.
+func writeEntry(buf *bytes.Buffer, e *Entry, first bool) error {
+	data, err := json.MarshalIndent(e, "      ", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the recording: %w", err)
+	}
+	if !first {
+		buf.WriteByte(',')
+	}
+	buf.WriteString("\n      ")
+	buf.Write(data)
+	return nil
+}
+
+func newEntry(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, started time.Time, maxBodySize int) *Entry {
+	reqURL := redactQuery(req.URL)
+	e := &Entry{
+		StartedDateTime: started.UTC(),
+		Time:            float64(time.Since(started).Microseconds()) / 1000,
+		Request: Request{
+			Method:      req.Method,
+			URL:         reqURL.String(),
+			HTTPVersion: req.Proto,
+			Headers:     headers(req.Header),
+			QueryString: []Header{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: Response{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Headers:     headers(resp.Header),
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(respBody),
+		},
+	}
+
+	query := reqURL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range query[name] {
+			e.Request.QueryString = append(e.Request.QueryString, Header{Name: name, Value: v})
+		}
+	}
+	if len(reqBody) > 0 {
+		e.Request.PostData = &PostData{MimeType: req.Header.Get("Content-Type")}
+		if omitted(reqBody, maxBodySize) {
+			e.Request.PostData.Comment = omittedComment(reqBody)
+		} else {
+			e.Request.PostData.Text, e.Request.PostData.Encoding = encodeBody(reqBody)
+		}
+	}
+
+	e.Response.Content = Content{
+		Size:     len(respBody),
+		MimeType: resp.Header.Get("Content-Type"),
+	}
+	if omitted(respBody, maxBodySize) {
+		e.Response.Content.Comment = omittedComment(respBody)
+	} else {
+		e.Response.Content.Text, e.Response.Content.Encoding = encodeBody(respBody)
+	}
+	return e
+}
+
+// omitted reports whether a body is too large to be recorded.
+func omitted(body []byte, maxBodySize int) bool {
+	return maxBodySize >= 0 && len(body) > maxBodySize
+}
+
+func omittedComment(body []byte) string {
+	return fmt.Sprintf("body of %d bytes omitted", len(body))
+}
@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package recording_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudzero/cloudzero-agent/app/inspector/recording"
+)
+
+// api answers allocation requests with the count they asked for, and abandon
+// requests with a 403 the first time.
+func api(t *testing.T) *httptest.Server {
+	t.Helper()
+	abandoned := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret-key", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/v1/container-metrics/upload":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"count":"`+r.URL.Query().Get("count")+`"}`)
+		case "/v1/container-metrics/abandon":
+			body, _ := io.ReadAll(r.Body)
+			assert.Equal(t, `["file-1"]`, string(body))
+			abandoned++
+			if abandoned == 1 {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/binary":
+			_, _ = w.Write([]byte{0xff, 0xfe, 0x00})
+		case "/large":
+			_, _ = w.Write([]byte(strings.Repeat("x", 64)))
+		case "/presigned":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"url":"https://bucket.s3.amazonaws.com/file?X-Amz-Credential=AKIAEXAMPLE%2F20260101&X-Amz-Signature=0123abcd"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func do(t *testing.T, client *http.Client, method, url, body string) (int, string) {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer secret-key")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	upstream := api(t)
+	path := filepath.Join(t.TempDir(), "api.har")
+
+	recorder, err := recording.NewRecorder(nil, path)
+	require.NoError(t, err)
+	defer recorder.Close()
+	client := &http.Client{Transport: recorder}
+
+	code, body := do(t, client, http.MethodPost, upstream.URL+"/v1/container-metrics/upload?count=1", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.JSONEq(t, `{"count":"1"}`, body)
+	do(t, client, http.MethodPost, upstream.URL+"/v1/container-metrics/upload?count=2", "")
+	code, _ = do(t, client, http.MethodPost, upstream.URL+"/v1/container-metrics/abandon", `["file-1"]`)
+	require.Equal(t, http.StatusForbidden, code)
+	code, _ = do(t, client, http.MethodPost, upstream.URL+"/v1/container-metrics/abandon", `["file-1"]`)
+	require.Equal(t, http.StatusOK, code)
+	_, body = do(t, client, http.MethodGet, upstream.URL+"/binary", "")
+	assert.Equal(t, string([]byte{0xff, 0xfe, 0x00}), body)
+	assert.Equal(t, 5, recorder.Entries())
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "secret-key", "the API key is redacted")
+	assert.Contains(t, string(raw), recording.Redacted)
+
+	archive, err := recording.LoadArchive(path)
+	require.NoError(t, err)
+	require.Len(t, archive.Log.Entries, 5)
+	abandon := archive.Log.Entries[2]
+	require.NotNil(t, abandon.Request.PostData)
+	assert.Equal(t, `["file-1"]`, abandon.Request.PostData.Text)
+	assert.Equal(t, "base64", archive.Log.Entries[4].Response.Content.Encoding)
+
+	// a new recorder appends to the recording
+	require.NoError(t, recorder.Close())
+	recorder, err = recording.NewRecorder(nil, path)
+	require.NoError(t, err)
+	assert.Equal(t, 5, recorder.Entries())
+	require.NoError(t, recorder.Close())
+
+	replay := httptest.NewServer(recording.NewReplayer(archive))
+	defer replay.Close()
+	client = replay.Client()
+
+	t.Run("matches query parameters", func(t *testing.T) {
+		_, body := do(t, client, http.MethodPost, replay.URL+"/v1/container-metrics/upload?count=2", "")
+		assert.JSONEq(t, `{"count":"2"}`, body)
+		_, body = do(t, client, http.MethodPost, replay.URL+"/v1/container-metrics/upload?count=1", "")
+		assert.JSONEq(t, `{"count":"1"}`, body)
+	})
+
+	t.Run("replays in order and repeats the last", func(t *testing.T) {
+		code, _ := do(t, client, http.MethodPost, replay.URL+"/v1/container-metrics/abandon", `["file-1"]`)
+		assert.Equal(t, http.StatusForbidden, code)
+		code, _ = do(t, client, http.MethodPost, replay.URL+"/v1/container-metrics/abandon", `["file-1"]`)
+		assert.Equal(t, http.StatusOK, code)
+		code, _ = do(t, client, http.MethodPost, replay.URL+"/v1/container-metrics/abandon", `["file-1"]`)
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("binary bodies", func(t *testing.T) {
+		_, body := do(t, client, http.MethodGet, replay.URL+"/binary", "")
+		assert.Equal(t, string([]byte{0xff, 0xfe, 0x00}), body)
+	})
+
+	t.Run("not recorded", func(t *testing.T) {
+		code, body := do(t, client, http.MethodGet, replay.URL+"/v1/other", "")
+		assert.Equal(t, http.StatusNotFound, code)
+		assert.Contains(t, body, "no recorded response for GET /v1/other")
+	})
+}
+
+func TestRecorder_Append(t *testing.T) {
+	upstream := api(t)
+	path := filepath.Join(t.TempDir(), "api.har")
+
+	recorder, err := recording.NewRecorder(nil, path)
+	require.NoError(t, err)
+	client := &http.Client{Transport: recorder}
+
+	// the file is a valid recording after every request
+	for i := 1; i <= 3; i++ {
+		do(t, client, http.MethodPost, upstream.URL+"/v1/container-metrics/upload?count=1", "")
+		archive, err := recording.LoadArchive(path)
+		require.NoError(t, err)
+		assert.Len(t, archive.Log.Entries, i)
+	}
+	require.NoError(t, recorder.Close())
+
+	recorder, err = recording.NewRecorder(nil, path)
+	require.NoError(t, err)
+	do(t, &http.Client{Transport: recorder}, http.MethodPost, upstream.URL+"/v1/container-metrics/upload?count=2", "")
+	require.NoError(t, recorder.Close())
+
+	archive, err := recording.LoadArchive(path)
+	require.NoError(t, err)
+	require.Len(t, archive.Log.Entries, 4)
+	assert.JSONEq(t, `{"count":"2"}`, archive.Log.Entries[3].Response.Content.Text)
+}
+
+func TestRecorder_MaxBodySize(t *testing.T) {
+	upstream := api(t)
+	path := filepath.Join(t.TempDir(), "api.har")
+
+	recorder, err := recording.NewRecorder(nil, path, recording.WithMaxBodySize(16))
+	require.NoError(t, err)
+	defer recorder.Close()
+
+	// the body is still forwarded, only the recording omits it
+	_, body := do(t, &http.Client{Transport: recorder}, http.MethodGet, upstream.URL+"/large", "")
+	assert.Len(t, body, 64)
+
+	archive, err := recording.LoadArchive(path)
+	require.NoError(t, err)
+	content := archive.Log.Entries[0].Response.Content
+	assert.Empty(t, content.Text)
+	assert.Equal(t, 64, content.Size)
+	assert.Equal(t, "body of 64 bytes omitted", content.Comment)
+}
+
+func TestRecorder_RedactsPresignedCredentials(t *testing.T) {
+	upstream := api(t)
+	path := filepath.Join(t.TempDir(), "api.har")
+
+	recorder, err := recording.NewRecorder(nil, path)
+	require.NoError(t, err)
+	defer recorder.Close()
+	client := &http.Client{Transport: recorder}
+
+	_, body := do(t, client, http.MethodGet, upstream.URL+"/presigned", "")
+	assert.Contains(t, body, "0123abcd", "the response is forwarded unchanged")
+	do(t, client, http.MethodPost, upstream.URL+"/v1/container-metrics/upload?count=1&X-Amz-Signature=0123abcd", "")
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "0123abcd")
+	assert.NotContains(t, string(raw), "AKIAEXAMPLE")
+
+	archive, err := recording.LoadArchive(path)
+	require.NoError(t, err)
+	assert.Contains(t, archive.Log.Entries[0].Response.Content.Text, "X-Amz-Signature="+recording.Redacted)
+
+	// a redacted query parameter matches any value on replay
+	replay := httptest.NewServer(recording.NewReplayer(archive))
+	defer replay.Close()
+	_, body = do(t, replay.Client(), http.MethodPost, replay.URL+"/v1/container-metrics/upload?count=1&X-Amz-Signature=other", "")
+	assert.JSONEq(t, `{"count":"1"}`, body)
+}
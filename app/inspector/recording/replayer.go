@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package recording
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+// hopHeaders are response headers which describe the recorded connection
+// rather than the response, and are not replayed.
+var hopHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Keep-Alive":        true,
+	"Transfer-Encoding": true,
+}
+
+// Replayer is an http.Handler serving recorded responses in place of the
+// CloudZero API.
+//
+// Requests are matched to entries by method and path. Among the entries of a
+// method and path, those whose query parameters are all present in the
+// request with the same values are preferred, as allocation requests differ
+// by their parameters. Matching entries are served in the order they were
+// recorded, and the last one is repeated once all were served, so a flow
+// replays as it happened. Requests no entry matches get a 404 response.
+type Replayer struct {
+	entries []*Entry
+
+	mu     sync.Mutex
+	served map[*Entry]bool
+}
+
+var _ http.Handler = (*Replayer)(nil)
+
+// NewReplayer creates a replayer serving the entries of the archive.
+func NewReplayer(archive *Archive) *Replayer {
+	return &Replayer{
+		entries: archive.Log.Entries,
+		served:  make(map[*Entry]bool),
+	}
+}
+
+func (r *Replayer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	e := r.match(req)
+	if e == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"message": "no recorded response for " + req.Method + " " + req.URL.Path,
+		})
+		return
+	}
+
+	body, err := decodeBody(e.Response.Content.Text, e.Response.Content.Encoding)
+	if err != nil {
+		http.Error(w, "invalid recorded response body: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, h := range e.Response.Headers {
+		if hopHeaders[http.CanonicalHeaderKey(h.Name)] {
+			continue
+		}
+		w.Header().Add(h.Name, h.Value)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(e.Response.Status)
+	_, _ = w.Write(body)
+}
+
+// match returns the entry to serve for the request, or nil.
+func (r *Replayer) match(req *http.Request) *Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var candidates, queryMatches []*Entry
+	for _, e := range r.entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || e.Request.Method != req.Method || u.Path != req.URL.Path {
+			continue
+		}
+		candidates = append(candidates, e)
+		if queryMatch(e.Request.QueryString, req.URL.Query()) {
+			queryMatches = append(queryMatches, e)
+		}
+	}
+	if len(queryMatches) > 0 {
+		candidates = queryMatches
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	for _, e := range candidates {
+		if !r.served[e] {
+			r.served[e] = true
+			return e
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+// queryMatch returns true if every recorded query parameter is in the query
+// with the same value. A redacted parameter matches any value.
+func queryMatch(recorded []Header, query url.Values) bool {
+	for _, p := range recorded {
+		if p.Value == Redacted && query.Has(p.Name) {
+			continue
+		}
+		found := false
+		for _, v := range query[p.Name] {
+			if v == p.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,194 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package inspector
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity is how serious the failure a remediation applies to is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Remediation explains a failure and what to do about it.
+type Remediation struct {
+	// ID identifies the failure. It is stable across releases.
+	ID          string   `yaml:"id"`
+	Severity    Severity `yaml:"severity"`
+	Summary     string   `yaml:"summary"`
+	Explanation string   `yaml:"explanation"`
+	DocURL      string   `yaml:"doc_url"`
+}
+
+// Level returns the log level of the severity.
+func (s Severity) Level() zerolog.Level {
+	switch s {
+	case SeverityError:
+		return zerolog.ErrorLevel
+	case SeverityWarning:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.InfoLevel
+	}
+}
+
+// rule maps the failures it matches to a remediation.
+type rule struct {
+	Remediation `yaml:",inline"`
+	Match       struct {
+		Status []int  `yaml:"status"`
+		JQ     string `yaml:"jq"`
+		Check  string `yaml:"check"`
+		Error  string `yaml:"error"`
+	} `yaml:"match"`
+
+	errorPattern *regexp.Regexp
+}
+
+// forResponses returns true if the rule matches HTTP responses rather than
+// diagnostic checks.
+func (r *rule) forResponses() bool {
+	return len(r.Match.Status) > 0 || r.Match.JQ != ""
+}
+
+// Catalog maps failures to remediations. The rules are data, so new ones ship
+// without code changes; see remediations.yaml.
+type Catalog struct {
+	rules []*rule
+}
+
+//go:embed remediations.yaml
+var defaultCatalogData []byte
+
+var defaultCatalog = sync.OnceValue(func() *Catalog {
+	c, err := LoadCatalog(defaultCatalogData)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded remediation catalog: %v", err))
+	}
+	return c
+})
+
+// DefaultCatalog returns the catalog embedded in the binary.
+func DefaultCatalog() *Catalog {
+	return defaultCatalog()
+}
+
+// LoadCatalog parses a catalog from YAML, and checks that its rules are valid.
+func LoadCatalog(data []byte) (*Catalog, error) {
+	var doc struct {
+		Rules []*rule `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the remediation catalog: %w", err)
+	}
+
+	ids := map[string]bool{}
+	for i, r := range doc.Rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("remediation rule %d has no id", i)
+		}
+		if ids[r.ID] {
+			return nil, fmt.Errorf("remediation rule %s is defined twice", r.ID)
+		}
+		ids[r.ID] = true
+
+		if !slices.Contains([]Severity{SeverityError, SeverityWarning, SeverityInfo}, r.Severity) {
+			return nil, fmt.Errorf("remediation rule %s has invalid severity %q", r.ID, r.Severity)
+		}
+		if r.Summary == "" || r.Explanation == "" {
+			return nil, fmt.Errorf("remediation rule %s needs a summary and an explanation", r.ID)
+		}
+		if !r.forResponses() && r.Match.Check == "" && r.Match.Error == "" {
+			return nil, fmt.Errorf("remediation rule %s matches nothing", r.ID)
+		}
+		if r.forResponses() && r.Match.Check != "" {
+			return nil, fmt.Errorf("remediation rule %s matches both responses and checks", r.ID)
+		}
+		if r.Match.JQ != "" {
+			if _, err := gojqCache.Get(r.Match.JQ); err != nil {
+				return nil, fmt.Errorf("remediation rule %s has an invalid jq predicate: %w", r.ID, err)
+			}
+		}
+		if r.Match.Error != "" {
+			pattern, err := regexp.Compile(r.Match.Error)
+			if err != nil {
+				return nil, fmt.Errorf("remediation rule %s has an invalid error pattern: %w", r.ID, err)
+			}
+			r.errorPattern = pattern
+		}
+	}
+	return &Catalog{rules: doc.Rules}, nil
+}
+
+// MatchCheck returns the remediation of a diagnostic check which failed with
+// the error, or nil when no rule matches. An empty error matches the rules of
+// the check which do not depend on the error.
+func (c *Catalog) MatchCheck(name, errText string) *Remediation {
+	for _, r := range c.rules {
+		if r.forResponses() {
+			continue
+		}
+		if r.Match.Check != "" && r.Match.Check != name {
+			continue
+		}
+		if r.errorPattern != nil && !r.errorPattern.MatchString(errText) {
+			continue
+		}
+		remediation := r.Remediation
+		return &remediation
+	}
+	return nil
+}
+
+// matchResponse returns the remediation of an HTTP response, or nil when no
+// rule matches. It fails when a rule needs the JSON body, and the body of a
+// JSON response cannot be decoded.
+func (c *Catalog) matchResponse(resp *responseData) (*Remediation, error) {
+	for _, r := range c.rules {
+		if !r.forResponses() {
+			continue
+		}
+		if len(r.Match.Status) > 0 && !slices.Contains(r.Match.Status, resp.resp.StatusCode) {
+			continue
+		}
+		if r.Match.JQ != "" {
+			match, err := resp.JSONMatch(r.Match.JQ)
+			if err != nil {
+				return nil, err
+			}
+			if !match {
+				continue
+			}
+		}
+		if r.errorPattern != nil && !r.errorPattern.Match(resp.body()) {
+			continue
+		}
+		remediation := r.Remediation
+		return &remediation, nil
+	}
+	return nil, nil
+}
+
+// log logs the remediation at the level of its severity.
+func (r *Remediation) log(logger zerolog.Logger) {
+	event := logger.WithLevel(r.Severity.Level()).
+		Str("remediation_id", r.ID).
+		Str("remediation", r.Explanation)
+	if r.DocURL != "" {
+		event = event.Str("doc_url", r.DocURL)
+	}
+	event.Msg(r.Summary)
+}
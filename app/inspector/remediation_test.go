@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package inspector_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudzero/cloudzero-agent/app/inspector"
+)
+
+func TestCatalog_MatchCheck(t *testing.T) {
+	catalog := inspector.DefaultCatalog()
+
+	tests := []struct {
+		name   string
+		check  string
+		err    string
+		wantID string
+	}{
+		{name: "check specific error", check: "kube_state_metrics_reachable", err: "dial tcp 10.0.0.1:8080: connect: connection refused", wantID: "kms-connection-refused"},
+		{name: "check without specific error", check: "kube_state_metrics_reachable", err: "unreachable", wantID: "kms-check-failed"},
+		{name: "error of any check", check: "api_key_valid", err: `Post "https://api.cloudzero.com": tls: failed to verify certificate: x509: certificate signed by unknown authority`, wantID: "tls-unknown-authority"},
+		{name: "rbac", check: "k8s_namespace", err: `namespaces "cza" is forbidden: User "system:serviceaccount:cza:validator" cannot get resource`, wantID: "k8s-rbac-forbidden"},
+		{name: "check by name", check: "cluster_identity", wantID: "cluster-name-duplicate"},
+		{name: "proxy credentials", check: "egress", err: "the proxy http://proxy:3128 did not connect to api.cloudzero.com: Proxy Authentication Required", wantID: "proxy-auth-required"},
+		{name: "egress", check: "egress", err: "failed to load the client certificate: open /etc/egress/tls.crt: no such file or directory", wantID: "egress-check-failed"},
+		{name: "unknown", check: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := catalog.MatchCheck(tt.check, tt.err)
+			if tt.wantID == "" {
+				assert.Nil(t, r)
+				return
+			}
+			require.NotNil(t, r)
+			assert.Equal(t, tt.wantID, r.ID)
+			assert.NotEmpty(t, r.Explanation)
+		})
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			data: `
+rules:
+  - id: a
+    severity: info
+    summary: A
+    explanation: Do A.
+    match:
+      status: [500]
+      jq: .code == "A"
+`,
+		},
+		{name: "no id", data: "rules: [{severity: info, summary: A, explanation: B, match: {check: a}}]", wantErr: "has no id"},
+		{name: "duplicate", data: "rules: [{id: a, severity: info, summary: A, explanation: B, match: {check: a}}, {id: a, severity: info, summary: A, explanation: B, match: {check: b}}]", wantErr: "defined twice"},
+		{name: "severity", data: "rules: [{id: a, severity: fatal, summary: A, explanation: B, match: {check: a}}]", wantErr: "invalid severity"},
+		{name: "matches nothing", data: "rules: [{id: a, severity: info, summary: A, explanation: B}]", wantErr: "matches nothing"},
+		{name: "mixed", data: "rules: [{id: a, severity: info, summary: A, explanation: B, match: {check: a, status: [500]}}]", wantErr: "both responses and checks"},
+		{name: "jq", data: "rules: [{id: a, severity: info, summary: A, explanation: B, match: {jq: '.a =='}}]", wantErr: "invalid jq predicate"},
+		{name: "error pattern", data: "rules: [{id: a, severity: info, summary: A, explanation: B, match: {error: '('}}]", wantErr: "invalid error pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := inspector.LoadCatalog([]byte(tt.data))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -11,14 +11,9 @@ import (
 
 type ResponseInspectorFunc func(ctx context.Context, resp *responseData, logger zerolog.Logger) (bool, error)
 
+// inspect403 logs a 403 Forbidden response which no rule of the remediation
+// catalog matched.
 func (i *Inspector) inspect403(_ context.Context, resp *responseData, logger zerolog.Logger) (bool, error) {
-	if match, err := resp.JSONMatch(".message == \"User is not authorized to access this resource\""); err != nil {
-		return false, err
-	} else if match {
-		logger.Error().Msg("Invalid CloudZero API key")
-		return true, nil
-	}
-
 	// Couldn't find a match, dump it all.
 	logger.Warn().
 		Interface("headers", resp.resp.Header).
@@ -13,6 +13,8 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
@@ -36,6 +38,10 @@ type Span struct {
 	start    time.Time
 	err      error
 	ended    bool
+
+	// span is the OpenTelemetry span, exported when tracing is initialized.
+	// ctx carries it, so spans started from ctx are its children.
+	span trace.Span
 }
 
 // StartSpan starts a span using the default prometheus registry
@@ -44,12 +50,15 @@ func StartSpan(ctx context.Context, name string) *Span {
 		prometheus.MustRegister(functionDuration)
 	})
 
+	parentID := getParentID(ctx) // search context for a parent span
+	ctx, span := tracer().Start(ctx, name)
 	return &Span{
 		ctx:      ctx,
 		id:       uuid.NewString(),
-		parentID: getParentID(ctx), // search context for a parent span
+		parentID: parentID,
 		name:     name,
 		start:    time.Now(),
+		span:     span,
 	}
 }
 
@@ -65,7 +74,7 @@ func RunSpan(ctx context.Context, name string, fn func(ctx context.Context, span
 	defer span.End()
 
 	// create a new context with the span id as the context key
-	ctxWithSpan := context.WithValue(ctx, spanIDKey, span.id)
+	ctxWithSpan := context.WithValue(span.ctx, spanIDKey, span.id)
 
 	// call the function wrapped with span error handler
 	return span.Error(fn(ctxWithSpan, span))
@@ -108,16 +117,24 @@ func (s *Span) End() {
 
 		// debug print the span status
 		s.TraceLog()
+
+		if s.err != nil {
+			s.span.RecordError(s.err)
+			s.span.SetStatus(codes.Error, s.err.Error())
+		}
+		s.span.End()
 	}
 }
 
 func (s *Span) StartChildSpan(name string) *Span {
+	ctx, span := tracer().Start(s.ctx, name)
 	return &Span{
-		ctx:      s.ctx,
+		ctx:      ctx,
 		id:       uuid.NewString(),
 		parentID: s.id,
 		name:     name,
 		start:    time.Now(),
+		span:     span,
 	}
 }
 
@@ -130,6 +147,11 @@ func SpanLogger(ctx context.Context, id string, attrs ...logging.Attr) zerolog.L
 	// build the logger
 	builder := log.Ctx(ctx).With().Str("spanId", id)
 
+	// correlate the logs with the exported trace
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		builder = builder.Str("traceId", sc.TraceID().String())
+	}
+
 	// search the current context for a span
 	parentID := getParentID(ctx)
 	if parentID != "" {
@@ -185,7 +207,7 @@ func (p *PrometheusMetrics) SpanCtx(ctx context.Context, name string, fn func(ct
 	defer span.End()
 
 	// create a new context with the span id as the context key
-	ctxWithSpan := context.WithValue(ctx, spanIDKey, span.id)
+	ctxWithSpan := context.WithValue(span.ctx, spanIDKey, span.id)
 
 	// call with the embeded context
 	err := span.Error(fn(ctxWithSpan, span.id))
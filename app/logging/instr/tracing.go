@@ -0,0 +1,193 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package instr
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName is the instrumentation scope of the spans of the agent.
+	tracerName = "github.com/cloudzero/cloudzero-agent"
+
+	// tracingFlushTimeout bounds the export of the pending spans on exit.
+	tracingFlushTimeout = 5 * time.Second
+)
+
+const (
+	// TracingProtocolHTTP exports spans with OTLP over HTTP.
+	TracingProtocolHTTP = "http/protobuf"
+	// TracingProtocolGRPC exports spans with OTLP over gRPC.
+	TracingProtocolGRPC = "grpc"
+)
+
+// TracingConfig configures the export of spans to an OpenTelemetry collector.
+type TracingConfig struct {
+	// ServiceName and ServiceVersion identify the exporting service.
+	ServiceName    string
+	ServiceVersion string
+	// Protocol is TracingProtocolHTTP or TracingProtocolGRPC.
+	Protocol string
+	// Endpoint is the URL of the collector, such as http://localhost:4318.
+	// The scheme selects whether TLS is used. When empty, the standard
+	// OTEL_EXPORTER_OTLP_* environment variables apply.
+	Endpoint string
+	// SampleRatio is the ratio of traces started by the service which are
+	// sampled. Traces started by a caller follow the caller's decision.
+	SampleRatio float64
+}
+
+// InitTracing exports the spans of the service to an OpenTelemetry collector,
+// and propagates the trace context of requests with the W3C traceparent
+// header. Without it, spans are only logged and measured.
+//
+// The returned function flushes the pending spans and stops the export. It
+// waits at most tracingFlushTimeout, so it can be called on exit.
+func InitTracing(ctx context.Context, cfg TracingConfig) (func(), error) {
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch cfg.Protocol {
+	case TracingProtocolHTTP, "":
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case TracingProtocolGRPC:
+		var opts []otlptracegrpc.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported tracing protocol %q", cfg.Protocol)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+		attribute.String("service.version", cfg.ServiceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	log.Ctx(ctx).Info().
+		Str("protocol", cfg.Protocol).
+		Str("endpoint", cfg.Endpoint).
+		Float64("sampleRatio", cfg.SampleRatio).
+		Msg("exporting traces")
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			log.Ctx(ctx).Err(err).Msg("failed to flush the pending spans")
+		}
+	}, nil
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// InjectTraceContext sets the traceparent header of an outgoing request to
+// the span of the context.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// transport traces outgoing requests.
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport returns a transport starting a client span for each request,
+// and propagating it to the server with the traceparent header. Each attempt
+// of a retried request is its own span.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracer().Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	defer span.End()
+
+	// A round tripper must not modify the request of its caller.
+	req = req.Clone(ctx)
+	InjectTraceContext(ctx, req.Header)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, strconv.Itoa(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// TraceMiddleware starts a server span for each request, continuing the trace
+// of the caller when the request has a traceparent header.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer().Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+				attribute.String("client.address", r.RemoteAddr),
+			),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package instr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans records the spans ended during the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func spansByName(recorder *tracetest.SpanRecorder) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	return spans
+}
+
+func TestUnit_Instr_Tracing_Spans(t *testing.T) {
+	recorder := recordSpans(t)
+
+	err := RunSpan(t.Context(), "parent", func(ctx context.Context, span *Span) error {
+		child := span.StartChildSpan("child")
+		child.End()
+
+		return RunSpan(ctx, "nested", func(context.Context, *Span) error {
+			return errors.New("failed")
+		})
+	})
+	require.Error(t, err)
+
+	spans := spansByName(recorder)
+	require.Len(t, spans, 3)
+	parent := spans["parent"].SpanContext()
+	for _, name := range []string{"child", "nested"} {
+		assert.Equal(t, parent.TraceID(), spans[name].SpanContext().TraceID(), name)
+		assert.Equal(t, parent.SpanID(), spans[name].Parent().SpanID(), name)
+	}
+	assert.Equal(t, codes.Error, spans["nested"].Status().Code)
+	assert.Equal(t, codes.Error, spans["parent"].Status().Code, "the error is passed to the parent")
+	assert.Equal(t, codes.Unset, spans["child"].Status().Code)
+}
+
+func TestUnit_Instr_Tracing_Propagation(t *testing.T) {
+	recorder := recordSpans(t)
+
+	var traceparent atomic.Value
+	srv := httptest.NewServer(TraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent.Store(r.Header.Get("traceparent"))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil)}
+	err := RunSpan(t.Context(), "upload", func(ctx context.Context, _ *Span) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPut, srv.URL+"/file", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Empty(t, req.Header.Get("traceparent"), "the request of the caller is not modified")
+		return nil
+	})
+	require.NoError(t, err)
+
+	spans := spansByName(recorder)
+	require.Len(t, spans, 3)
+	upload, client_, server := spans["upload"], spans["HTTP PUT"], spans["PUT /file"]
+	require.NotNil(t, client_)
+	require.NotNil(t, server)
+
+	// The trace continues from the span of the upload through the request.
+	assert.Equal(t, upload.SpanContext().SpanID(), client_.Parent().SpanID())
+	assert.Equal(t, client_.SpanContext().SpanID(), server.Parent().SpanID())
+	assert.Equal(t, upload.SpanContext().TraceID(), server.SpanContext().TraceID())
+	assert.True(t, server.Parent().IsRemote())
+	assert.Contains(t, traceparent.Load(), client_.SpanContext().SpanID().String())
+
+	assert.Equal(t, codes.Error, client_.Status().Code)
+	assert.Equal(t, codes.Error, server.Status().Code)
+}
+
+func TestUnit_Instr_Tracing_Export(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	var exported atomic.Int32
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" {
+			exported.Add(1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	stop, err := InitTracing(t.Context(), TracingConfig{
+		ServiceName: "test",
+		Protocol:    TracingProtocolHTTP,
+		Endpoint:    collector.URL,
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	StartSpan(t.Context(), "exported").End()
+	stop()
+	assert.Equal(t, int32(1), exported.Load(), "the pending spans are flushed on stop")
+
+	_, err = InitTracing(t.Context(), TracingConfig{Protocol: "thrift"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
+)
+
+// Prometheus metrics comparing the codecs the metric files are compressed
+// with.
+var (
+	// compressionRatio tracks how many times smaller than the JSON each file
+	// is once compressed.
+	compressionRatio = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "storage_compression_ratio",
+			Help:    "Ratio of the uncompressed to the compressed size of the flushed metric files",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 8),
+		},
+		[]string{"codec"},
+	)
+
+	// compressionDuration tracks the time spent compressing each file.
+	compressionDuration = promauto.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "storage_compression_seconds",
+			Help:    "Time spent compressing each flushed metric file",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+		[]string{"codec"},
+	)
+
+	// compressionInputBytes and compressionOutputBytes track the bytes before
+	// and after compression, so the ratio can be computed over any window.
+	compressionInputBytes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "storage_compression_input_bytes_total",
+			Help: "Total number of uncompressed bytes written to metric files",
+		},
+		[]string{"codec"},
+	)
+	compressionOutputBytes = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "storage_compression_output_bytes_total",
+			Help: "Total number of compressed bytes of the flushed metric files",
+		},
+		[]string{"codec"},
+	)
+)
+
+// compressionSignals are the conditions a codec is picked from.
+type compressionSignals struct {
+	// cpuHeadroom is the share of the CPU available to the process which was
+	// left idle since the previous file was started, from 0 to 1.
+	cpuHeadroom float64
+	// diskPercentUsed is the usage of the storage volume, from 0 to 100.
+	diskPercentUsed float64
+	// pendingBytes is the size of the flushed files not yet uploaded.
+	pendingBytes int64
+}
+
+// compressionStrategy picks the codec and level of each file. The codec only
+// applies to the local files: MetricFile transcodes every file to Parquet when
+// it is uploaded.
+type compressionStrategy struct {
+	cfg         config.Compression
+	brotliLevel int
+	cpu         cpuSampler
+}
+
+// adaptive reports whether the codec depends on the signals, which are
+// otherwise not needed.
+func (s *compressionStrategy) adaptive() bool {
+	return s.cfg.Mode == config.CompressionModeAdaptive
+}
+
+// choose returns the codec and level of the next file. In adaptive mode, a
+// busy CPU favours the cheapest codec and a filling disk the densest one; when
+// both hold, zstd is a compromise between the two, and when neither does, it
+// saves the CPU brotli would spend on space which is not needed.
+func (s *compressionStrategy) choose(signals compressionSignals) (compress.Codec, int) {
+	if !s.adaptive() {
+		codec := compress.Codec(s.cfg.Codec)
+		if codec == compress.Brotli {
+			return codec, s.brotliLevel
+		}
+		return codec, -1
+	}
+
+	busy := signals.cpuHeadroom < s.cfg.MinCPUHeadroom
+	pressure := signals.diskPercentUsed >= s.cfg.HighDiskPercent ||
+		signals.pendingBytes >= s.cfg.HighPendingBytes
+	switch {
+	case busy && !pressure:
+		return compress.Snappy, -1
+	case pressure && !busy:
+		return compress.Brotli, s.brotliLevel
+	default:
+		return compress.Zstd, compress.DefaultZstdLevel
+	}
+}
+
+// cpuSampler measures the CPU time of the process between two samples.
+type cpuSampler struct {
+	lastWall time.Time
+	lastCPU  time.Duration
+}
+
+// headroom returns the share of the CPU available to the process, as limited
+// by GOMAXPROCS, which was left idle since the previous sample. The first
+// sample reports the CPU as idle.
+func (s *cpuSampler) headroom() float64 {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 1
+	}
+	now := time.Now()
+	used := time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+	lastWall, lastCPU := s.lastWall, s.lastCPU
+	s.lastWall, s.lastCPU = now, used
+	if lastWall.IsZero() {
+		return 1
+	}
+
+	available := now.Sub(lastWall) * time.Duration(runtime.GOMAXPROCS(0))
+	if available <= 0 {
+		return 1
+	}
+	return min(max(1-float64(used-lastCPU)/float64(available), 0), 1)
+}
+
+// meteredWriter measures the bytes written to a compressor and the time spent
+// compressing them.
+type meteredWriter struct {
+	w       io.Writer
+	bytes   int64
+	elapsed time.Duration
+}
+
+func (m *meteredWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := m.w.Write(p)
+	m.elapsed += time.Since(start)
+	m.bytes += int64(n)
+	return n, err
+}
+
+// observeCompression records the compression of a flushed file.
+func observeCompression(codec compress.Codec, uncompressed int64, elapsed time.Duration, path string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	label := string(codec)
+	compressionDuration.WithLabelValues(label).Observe(elapsed.Seconds())
+	compressionInputBytes.WithLabelValues(label).Add(float64(uncompressed))
+	compressionOutputBytes.WithLabelValues(label).Add(float64(info.Size()))
+	if info.Size() > 0 {
+		compressionRatio.WithLabelValues(label).Observe(float64(uncompressed) / float64(info.Size()))
+	}
+}
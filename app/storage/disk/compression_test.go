@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
+)
+
+func TestCompressionStrategy_Choose(t *testing.T) {
+	adaptive := config.Compression{Mode: config.CompressionModeAdaptive}
+	require.NoError(t, adaptive.Validate())
+
+	tests := []struct {
+		name    string
+		cfg     config.Compression
+		signals compressionSignals
+		codec   compress.Codec
+		level   int
+	}{
+		{
+			name:  "fixed brotli",
+			cfg:   config.Compression{Mode: config.CompressionModeFixed, Codec: "brotli"},
+			codec: compress.Brotli,
+			level: 6,
+		},
+		{
+			name:    "fixed zstd ignores the signals",
+			cfg:     config.Compression{Mode: config.CompressionModeFixed, Codec: "zstd"},
+			signals: compressionSignals{cpuHeadroom: 0, diskPercentUsed: 99},
+			codec:   compress.Zstd,
+			level:   -1,
+		},
+		{
+			name:    "idle with an empty disk",
+			cfg:     adaptive,
+			signals: compressionSignals{cpuHeadroom: 0.9, diskPercentUsed: 10},
+			codec:   compress.Zstd,
+			level:   compress.DefaultZstdLevel,
+		},
+		{
+			name:    "busy",
+			cfg:     adaptive,
+			signals: compressionSignals{cpuHeadroom: 0.1, diskPercentUsed: 10},
+			codec:   compress.Snappy,
+			level:   -1,
+		},
+		{
+			name:    "disk filling up",
+			cfg:     adaptive,
+			signals: compressionSignals{cpuHeadroom: 0.9, diskPercentUsed: 85},
+			codec:   compress.Brotli,
+			level:   6,
+		},
+		{
+			name:    "uploads falling behind",
+			cfg:     adaptive,
+			signals: compressionSignals{cpuHeadroom: 0.9, pendingBytes: 1 << 30},
+			codec:   compress.Brotli,
+			level:   6,
+		},
+		{
+			name:    "busy with the disk filling up",
+			cfg:     adaptive,
+			signals: compressionSignals{cpuHeadroom: 0.1, diskPercentUsed: 85},
+			codec:   compress.Zstd,
+			level:   compress.DefaultZstdLevel,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &compressionStrategy{cfg: tt.cfg, brotliLevel: 6}
+			codec, level := s.choose(tt.signals)
+			assert.Equal(t, tt.codec, codec)
+			assert.Equal(t, tt.level, level)
+		})
+	}
+}
+
+func TestCPUSampler_Headroom(t *testing.T) {
+	var s cpuSampler
+	assert.Equal(t, 1.0, s.headroom(), "the first sample reports the CPU as idle")
+
+	// Burn some CPU so the second sample measures it.
+	x := 0
+	for i := range 10_000_000 {
+		x += i % 7
+	}
+	_ = x
+	headroom := s.headroom()
+	assert.GreaterOrEqual(t, headroom, 0.0)
+	assert.LessOrEqual(t, headroom, 1.0)
+}
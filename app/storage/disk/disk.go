@@ -2,7 +2,7 @@
 // SPDX-License-Identifier: Apache-2.0
 
 // Package disk implements the secondary adapter for persistent storage in hexagonal architecture.
-// This package provides high-performance disk-based storage with Brotli compression and JSON streaming
+// This package provides high-performance disk-based storage with compressed JSON streaming
 // for the CloudZero Agent's metric collection and processing pipeline.
 package disk
 
@@ -10,8 +10,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -26,6 +29,7 @@ import (
 
 	config "github.com/cloudzero/cloudzero-agent/app/config/gator"
 	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
 	"github.com/rs/zerolog/log"
 )
 
@@ -36,6 +40,10 @@ const (
 
 	// jsonBufferSize sets the initial buffer size for JSON streaming operations.
 	jsonBufferSize = 1024
+
+	// jsonExtension precedes the extension of the codec in the name of the
+	// flushed files, as in metrics_<start>_<stop>.json.br.
+	jsonExtension = ".json"
 )
 
 // Content type identifiers for metric classification and storage routing.
@@ -68,7 +76,8 @@ func WithMaxInterval(interval time.Duration) DiskStoreOpt {
 	}
 }
 
-// DiskStore is a data store intended to be backed by a disk. Currently, data is stored in Brotli-compressed JSON, but transcoded to Snappy-compressed Parquet
+// DiskStore is a data store intended to be backed by a disk. Data is stored in compressed JSON, with the codec
+// recorded in the file extension, but transcoded to Snappy-compressed Parquet
 type DiskStore struct {
 	dirPath           string
 	id                string
@@ -77,8 +86,11 @@ type DiskStore struct {
 	rowLimit          int
 	rowCount          int
 	file              *os.File
-	compressionLevel  int
-	compressor        *brotli.Writer
+	strategy          *compressionStrategy
+	availableBytes    uint64
+	codec             compress.Codec
+	compressor        io.WriteCloser
+	metered           *meteredWriter
 	writer            *jwriter.Writer
 	arrayState        *jwriter.ArrayState
 	startTime         int64
@@ -107,6 +119,9 @@ func NewDiskStore(settings config.Database, opts ...DiskStoreOpt) (*DiskStore, e
 	if settings.CompressionLevel <= 0 || settings.CompressionLevel > brotli.BestCompression {
 		settings.CompressionLevel = config.DefaultDatabaseCompressionLevel
 	}
+	if err := settings.Compression.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid compression settings: %w", err)
+	}
 	if _, err := os.Stat(settings.StoragePath); os.IsNotExist(err) {
 		if err := os.MkdirAll(settings.StoragePath, directoryMode); err != nil {
 			return nil, fmt.Errorf("failed to create directory: %w", err)
@@ -114,12 +129,13 @@ func NewDiskStore(settings config.Database, opts ...DiskStoreOpt) (*DiskStore, e
 	}
 
 	store := &DiskStore{
-		dirPath:          settings.StoragePath,
-		rowLimit:         settings.MaxRecords,
-		id:               uuid.New().String()[:8],
-		compressionLevel: settings.CompressionLevel,
-		maxInterval:      settings.CostMaxInterval,
-		ticker:           time.NewTicker(settings.CostMaxInterval),
+		dirPath:        settings.StoragePath,
+		rowLimit:       settings.MaxRecords,
+		id:             uuid.New().String()[:8],
+		strategy:       &compressionStrategy{cfg: settings.Compression, brotliLevel: settings.CompressionLevel},
+		availableBytes: settings.AvailableSizeBytes(),
+		maxInterval:    settings.CostMaxInterval,
+		ticker:         time.NewTicker(settings.CostMaxInterval),
 	}
 
 	// apply the opts
@@ -157,20 +173,61 @@ func (d *DiskStore) newFileWriter() error {
 		return fmt.Errorf("failed to create active file: %w", err)
 	}
 
-	compressor := brotli.NewWriterLevel(file, d.compressionLevel)
+	codec, level := d.chooseCodec()
+	compressor, err := compress.NewWriter(file, codec, level)
+	if err != nil {
+		file.Close()
+		os.Remove(d.activeFilePath)
+		return fmt.Errorf("failed to create compressor: %w", err)
+	}
+	metered := &meteredWriter{w: compressor}
 
-	writer := jwriter.NewStreamingWriter(compressor, jsonBufferSize)
+	writer := jwriter.NewStreamingWriter(metered, jsonBufferSize)
 	arrayState := writer.Array()
 
 	d.rowCount = 0
 	d.startTime = timestamp.Milli() // Capture the start time
 	d.file = file
+	d.codec = codec
 	d.compressor = compressor
+	d.metered = metered
 	d.writer = &writer
 	d.arrayState = &arrayState
 	return nil
 }
 
+// chooseCodec picks the codec of the next file. The signals of the adaptive
+// mode are only gathered when it is enabled; a signal which cannot be
+// gathered does not favour any codec.
+func (d *DiskStore) chooseCodec() (compress.Codec, int) {
+	var signals compressionSignals
+	if d.strategy.adaptive() {
+		signals.cpuHeadroom = d.strategy.cpu.headroom()
+		if usage, err := Usage(d.dirPath, d.availableBytes); err == nil {
+			signals.diskPercentUsed = usage.PercentUsed
+		}
+		if files, err := d.GetFiles(); err == nil {
+			for _, f := range files {
+				if info, err := os.Stat(f); err == nil {
+					signals.pendingBytes += info.Size()
+				}
+			}
+		}
+	}
+
+	codec, level := d.strategy.choose(signals)
+	if d.strategy.adaptive() {
+		log.Debug().
+			Str("codec", string(codec)).
+			Int("level", level).
+			Float64("cpuHeadroom", signals.cpuHeadroom).
+			Float64("diskPercentUsed", signals.diskPercentUsed).
+			Int64("pendingBytes", signals.pendingBytes).
+			Msg("picked the codec of the next file")
+	}
+	return codec, level
+}
+
 // Put appends metrics to the JSON file, creating a new file if the row limit is reached
 func (d *DiskStore) Put(ctx context.Context, metrics ...types.Metric) error {
 	d.mu.Lock()
@@ -246,6 +303,7 @@ func (d *DiskStore) flushUnlocked() (retErr error) {
 			d.arrayState = nil
 			d.file = nil
 			d.compressor = nil
+			d.metered = nil
 			d.rowCount = 0
 
 			log.Warn().Err(retErr).Msg("flush failed, abandoned file to allow recovery")
@@ -261,9 +319,11 @@ func (d *DiskStore) flushUnlocked() (retErr error) {
 	}
 
 	// Close the compressor to flush data
+	closeStart := time.Now()
 	if err := d.compressor.Close(); err != nil {
 		return fmt.Errorf("failed to close compressor: %w", err)
 	}
+	elapsed := d.metered.elapsed + time.Since(closeStart)
 
 	// Close the file
 	if err := d.file.Close(); err != nil {
@@ -278,7 +338,7 @@ func (d *DiskStore) flushUnlocked() (retErr error) {
 	if filename == "" {
 		filename = "file"
 	}
-	filename += fmt.Sprintf("_%d_%d.json.br", d.startTime, stopTime)
+	filename += fmt.Sprintf("_%d_%d", d.startTime, stopTime) + jsonExtension + d.codec.Extension()
 
 	// Reset the ticker to the max interval
 	d.ticker.Reset(d.maxInterval)
@@ -292,11 +352,13 @@ func (d *DiskStore) flushUnlocked() (retErr error) {
 	if err != nil {
 		return fmt.Errorf("failed to rename active parquet file: %w", err)
 	}
+	observeCompression(d.codec, d.metered.bytes, elapsed, timestampedFilePath)
 
 	log.Ctx(context.Background()).Info().
 		Time("startTime", time.UnixMilli(d.startTime)).
 		Time("stopTime", time.UnixMilli(stopTime)).
 		Str("filePath", timestampedFilePath).
+		Str("codec", string(d.codec)).
 		Int("rowCount", d.rowCount).
 		Msg("flushed disk store")
 
@@ -305,6 +367,7 @@ func (d *DiskStore) flushUnlocked() (retErr error) {
 	d.arrayState = nil
 	d.file = nil
 	d.compressor = nil
+	d.metered = nil
 	d.rowCount = 0
 	return nil
 }
@@ -319,23 +382,48 @@ func (d *DiskStore) Pending() int {
 
 func (d *DiskStore) GetFiles(paths ...string) ([]string, error) {
 	// set to root path
-	allPaths := make([]string, 0, 1+len(paths)+1)
+	allPaths := make([]string, 0, 1+len(paths))
 	allPaths = append(allPaths, d.dirPath)
 
 	// add specified location
 	allPaths = append(allPaths, paths...)
 
-	base := d.contentIdentifier
-	if base == "" {
-		base = "*"
+	return FlushedFiles(filepath.Join(allPaths...), d.contentIdentifier)
+}
+
+// FlushedFiles lists the files of the content flushed to the directory by a
+// store, whatever their codec, sorted by name. Files of any content are
+// listed when it is empty.
+func FlushedFiles(dir, content string) ([]string, error) {
+	if content == "" {
+		content = "*"
 	}
 
-	// add file filter
-	allPaths = append(allPaths, base+"_*_*.json.br")
+	var files []string
+	for _, codec := range compress.Codecs {
+		matches, err := filepath.Glob(filepath.Join(dir, content+"_*_*"+jsonExtension+codec.Extension()))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
 
-	// list with glob find
-	pattern := filepath.Join(allPaths...)
-	return filepath.Glob(pattern)
+// IsMetricsFile reports whether the path is a file flushed by a store.
+func IsMetricsFile(path string) bool {
+	_, ok := metricsFileCodec(path)
+	return ok
+}
+
+// metricsFileCodec returns the codec recorded in the name of a flushed file.
+func metricsFileCodec(path string) (compress.Codec, bool) {
+	codec, ok := compress.FromPath(path)
+	if !ok || !strings.HasSuffix(strings.TrimSuffix(path, codec.Extension()), jsonExtension) {
+		return "", false
+	}
+	return codec, true
 }
 
 func (d *DiskStore) ListFiles(paths ...string) ([]os.DirEntry, error) {
@@ -355,7 +443,7 @@ func (d *DiskStore) Walk(loc string, process filepath.WalkFunc) error {
 	return nil
 }
 
-// All retrieves all metrics from uncompacted .json.br files, excluding the active and compressed files.
+// All retrieves all metrics from uncompacted flushed files, excluding the active and compressed files.
 // It reads the data into memory and returns a MetricRange.
 func (d *DiskStore) All(ctx context.Context, file string) (types.MetricRange, error) {
 	metrics, err := d.readCompressedJSONFile(file)
@@ -369,8 +457,14 @@ func (d *DiskStore) All(ctx context.Context, file string) (types.MetricRange, er
 	}, nil
 }
 
-// readCompressedJSONFile reads all metrics from a single .json.br file and returns them as a slice.
+// readCompressedJSONFile reads all metrics from a single flushed file and returns them as a slice.
 func (d *DiskStore) readCompressedJSONFile(filePath string) ([]types.Metric, error) {
+	return ReadMetricsFile(filePath)
+}
+
+// ReadMetricsFile reads all metrics from a single flushed file, decompressed
+// with the codec recorded in its name. A missing file holds no metrics.
+func ReadMetricsFile(filePath string) ([]types.Metric, error) {
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return []types.Metric{}, nil // No file to read
 	}
@@ -382,9 +476,15 @@ func (d *DiskStore) readCompressedJSONFile(filePath string) ([]types.Metric, err
 	}
 	defer file.Close()
 
-	// Create Brotli decompressor
-	decompressor := brotli.NewReader(file)
-	// defer decompressor.Close() // Necessary for cbrotli, but not the Go-native version
+	codec, ok := metricsFileCodec(filePath)
+	if !ok {
+		return nil, fmt.Errorf("unknown codec of %s", filePath)
+	}
+	decompressor, err := compress.NewReader(file, codec)
+	if err != nil {
+		return nil, err
+	}
+	defer decompressor.Close()
 
 	// Create a JSON decoder
 	decoder := json.NewDecoder(decompressor)
@@ -399,12 +499,39 @@ func (d *DiskStore) readCompressedJSONFile(filePath string) ([]types.Metric, err
 	return metrics, nil
 }
 
+// FileTimeRange returns the times of the first and last write of a flushed
+// file, which are encoded in its name as <content>_<start>_<stop>.json.<codec>.
+func FileTimeRange(filePath string) (start, stop time.Time, err error) {
+	name := filepath.Base(filePath)
+	if codec, ok := metricsFileCodec(name); ok {
+		name = strings.TrimSuffix(name, jsonExtension+codec.Extension())
+	}
+	parts := strings.Split(name, "_")
+	if len(parts) < 3 {
+		return time.Time{}, time.Time{}, fmt.Errorf("not a flushed metrics file: %s", filePath)
+	}
+	startMs, err := strconv.ParseInt(parts[len(parts)-2], 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start time in %s: %w", filePath, err)
+	}
+	stopMs, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid stop time in %s: %w", filePath, err)
+	}
+	return time.UnixMilli(startMs), time.UnixMilli(stopMs), nil
+}
+
 // GetUsage gathers disk usage stats using syscall.Statfs.
 // paths will be used as `filepath.Join(paths...)`
 func (d *DiskStore) GetUsage(limit uint64, paths ...string) (*types.StoreUsage, error) {
 	fullpath := filepath.Join(paths...)
 	fullpath = filepath.Join(d.dirPath, fullpath)
+	return Usage(fullpath, limit)
+}
 
+// Usage gathers the disk usage stats of the filesystem holding fullpath, like
+// GetUsage, without opening a store.
+func Usage(fullpath string, limit uint64) (*types.StoreUsage, error) {
 	// run the syscall ourselves
 	var stat syscall.Statfs_t
 	if err := syscall.Statfs(fullpath, &stat); err != nil {
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
 	"github.com/cloudzero/cloudzero-agent/app/types"
 	"github.com/cloudzero/cloudzero-agent/app/types/mocks"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -245,6 +247,52 @@ func TestDiskStore_GetFiles(t *testing.T) {
 	})
 }
 
+func TestDiskStore_Codecs(t *testing.T) {
+	for _, codec := range compress.Codecs {
+		t.Run(string(codec), func(t *testing.T) {
+			dirPath := t.TempDir()
+			ps, err := disk.NewDiskStore(config.Database{
+				StoragePath: dirPath,
+				MaxRecords:  100,
+				Compression: config.Compression{Codec: string(codec)},
+			}, disk.WithContentIdentifier(disk.CostContentIdentifier))
+			require.NoError(t, err)
+
+			metric := types.Metric{
+				ID:             uuid.New(),
+				ClusterName:    "cluster",
+				CloudAccountID: "cloudaccount",
+				MetricName:     "test_metric",
+				NodeName:       "node1",
+				CreatedAt:      time.UnixMilli(1700000000000).UTC(),
+				TimeStamp:      time.UnixMilli(1700000000000).UTC(),
+				Labels:         map[string]string{"label": "test"},
+				Value:          "123.45",
+			}
+			require.NoError(t, ps.Put(context.Background(), metric, metric))
+			require.NoError(t, ps.Flush())
+
+			files, err := ps.GetFiles()
+			require.NoError(t, err)
+			require.Len(t, files, 1)
+			assert.True(t, strings.HasSuffix(files[0], ".json"+codec.Extension()), files[0])
+			assert.True(t, disk.IsMetricsFile(files[0]))
+
+			_, _, err = disk.FileTimeRange(files[0])
+			require.NoError(t, err)
+
+			metrics, err := ps.All(context.Background(), files[0])
+			require.NoError(t, err)
+			assert.Equal(t, []types.Metric{metric, metric}, metrics.Metrics)
+		})
+	}
+
+	t.Run("invalid codec", func(t *testing.T) {
+		_, err := disk.NewDiskStore(config.Database{StoragePath: t.TempDir(), Compression: config.Compression{Codec: "gzip"}})
+		assert.ErrorContains(t, err, "invalid compression settings")
+	})
+}
+
 func TestDiskStore_RecoveryAfterFlushFailure(t *testing.T) {
 	dirPath := t.TempDir()
 	rowLimit := 5
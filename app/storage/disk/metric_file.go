@@ -79,6 +79,9 @@ func (f *MetricFile) Size() (int64, error) {
 	return s.Size(), nil
 }
 
+// Read returns the metrics of the file as Snappy-compressed Parquet, whatever
+// codec the file is stored with, so the codec picked by the store only affects
+// local storage and never the files the shipper uploads.
 func (f *MetricFile) Read(p []byte) (int, error) {
 	if f.reader == nil {
 		_, err := f.Seek(0, io.SeekStart)
@@ -9,8 +9,8 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/andybalholm/brotli"
 	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
 	"github.com/parquet-go/parquet-go"
 )
 
@@ -18,15 +18,14 @@ const (
 	parquetBufferSize = 16384
 )
 
-// NewParquetStreamer reads a Brotli-compressed JSON file containing an array of
+// NewParquetStreamer reads a compressed JSON file containing an array of
 // Metrics, and returns a reader with the data transcoded to Snappy-compressed
-// Parquet.
+// Parquet. The codec of the file is detected from its header.
+//
+// Canary samples of the data path diagnostic are left out: they are kept in
+// the local file, which is where the diagnostic looks for them, but are not
+// cost data and are never uploaded.
 func NewParquetStreamer(input io.Reader) io.ReadCloser {
-	decompressor := brotli.NewReader(input)
-
-	decoder := json.NewDecoder(decompressor)
-	decoder.DisallowUnknownFields()
-
 	pipeReader, pipeWriter := io.Pipe()
 
 	parquetWriter := parquet.NewGenericWriter[types.ParquetMetric](pipeWriter, parquet.Compression(&parquet.Snappy))
@@ -35,9 +34,18 @@ func NewParquetStreamer(input io.Reader) io.ReadCloser {
 		defer func() {
 			parquetWriter.Close()
 			pipeWriter.Close()
-			// decompressor.Close() // Necessary for cbrotli, but not the Go-native version
 		}()
 
+		decompressor, _, err := compress.Detect(input)
+		if err != nil {
+			pipeWriter.CloseWithError(fmt.Errorf("failed to decompress the file: %w", err))
+			return
+		}
+		defer decompressor.Close()
+
+		decoder := json.NewDecoder(decompressor)
+		decoder.DisallowUnknownFields()
+
 		if firstToken, err := decoder.Token(); err != nil {
 			pipeWriter.CloseWithError(fmt.Errorf("failed to read first token from JSON: %w", err))
 			return
@@ -55,6 +63,9 @@ func NewParquetStreamer(input io.Reader) io.ReadCloser {
 					pipeWriter.CloseWithError(fmt.Errorf("failed to decode JSON: %w", err))
 					return
 				}
+				if metric.MetricName == types.CanaryMetricName {
+					continue
+				}
 				metrics = append(metrics, metric.Parquet())
 			}
 
@@ -14,9 +14,11 @@ import (
 	"github.com/andybalholm/brotli"
 	"github.com/cloudzero/cloudzero-agent/app/storage/disk"
 	"github.com/cloudzero/cloudzero-agent/app/types"
+	"github.com/cloudzero/cloudzero-agent/app/utils/compress"
 	"github.com/google/go-cmp/cmp"
 	"github.com/parquet-go/parquet-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var testMetrics = []types.Metric{
@@ -100,6 +102,56 @@ func TestNewParquetStreamer_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestNewParquetStreamer_SkipsCanary(t *testing.T) {
+	canary := testMetrics[0]
+	canary.MetricName = types.CanaryMetricName
+	canary.Labels = map[string]string{types.CanaryIDLabel: "c0ffee"}
+
+	var compressed bytes.Buffer
+	compressor := brotli.NewWriterLevel(&compressed, 1)
+	require.NoError(t, json.NewEncoder(compressor).Encode(append([]types.Metric{canary}, testMetrics...)))
+	require.NoError(t, compressor.Close())
+
+	parquetStreamer := disk.NewParquetStreamer(&compressed)
+	defer parquetStreamer.Close()
+	parquetData, err := io.ReadAll(parquetStreamer)
+	require.NoError(t, err)
+
+	parquetReader := parquet.NewGenericReader[types.ParquetMetric](bytes.NewReader(parquetData))
+	defer parquetReader.Close()
+	require.Equal(t, len(testMetrics), int(parquetReader.NumRows()))
+
+	decoded := make([]types.ParquetMetric, len(testMetrics))
+	_, err = parquetReader.Read(decoded)
+	if err != nil {
+		assert.ErrorIs(t, err, io.EOF)
+	}
+	for _, metric := range decoded {
+		assert.NotEqual(t, types.CanaryMetricName, metric.MetricName)
+	}
+}
+
+func TestNewParquetStreamer_Codecs(t *testing.T) {
+	for _, codec := range compress.Codecs {
+		t.Run(string(codec), func(t *testing.T) {
+			var compressed bytes.Buffer
+			compressor, err := compress.NewWriter(&compressed, codec, -1)
+			require.NoError(t, err)
+			require.NoError(t, json.NewEncoder(compressor).Encode(testMetrics))
+			require.NoError(t, compressor.Close())
+
+			parquetStreamer := disk.NewParquetStreamer(&compressed)
+			defer parquetStreamer.Close()
+			parquetData, err := io.ReadAll(parquetStreamer)
+			require.NoError(t, err)
+
+			parquetReader := parquet.NewGenericReader[types.ParquetMetric](bytes.NewReader(parquetData))
+			defer parquetReader.Close()
+			assert.Equal(t, len(testMetrics), int(parquetReader.NumRows()))
+		})
+	}
+}
+
 func TestNewParquetStreamer_WrongCompression(t *testing.T) {
 	pr, pw := io.Pipe()
 
@@ -8,7 +8,9 @@ package streaming
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"sync"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 
 	config "github.com/cloudzero/cloudzero-agent/app/config/webhook"
 	"github.com/cloudzero/cloudzero-agent/app/domain/pusher"
+	"github.com/cloudzero/cloudzero-agent/app/http/transport"
 	"github.com/cloudzero/cloudzero-agent/app/types"
 )
 
@@ -26,26 +29,36 @@ const defaultBatchRecords = 500
 
 type Store struct {
 	settings      *config.Settings
+	client        *http.Client
 	clock         types.TimeProvider
 	mu            sync.Mutex
 	batch         []*types.ResourceTags
 	maxBatchCount int
 	maxRetries    int
 	sendTimeout   time.Duration
+
+	// dropped is the error of the batches dropped since the last Flush, so
+	// Flush reports every record which was not sent.
+	dropped error
 }
 
 // New creates a streaming store that sends records directly to the
 // collector endpoint configured in settings.RemoteWrite. The clock stamps
 // record timestamps.
-func New(settings *config.Settings, clock types.TimeProvider) *Store {
+func New(settings *config.Settings, clock types.TimeProvider) (*Store, error) {
+	client, err := transport.NewClient(settings.Egress, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure the egress transport: %w", err)
+	}
 	return &Store{
 		settings:      settings,
+		client:        client,
 		clock:         clock,
 		batch:         make([]*types.ResourceTags, 0, defaultBatchRecords),
 		maxBatchCount: defaultBatchRecords,
 		maxRetries:    settings.RemoteWrite.MaxRetries,
 		sendTimeout:   settings.RemoteWrite.SendTimeout,
-	}
+	}, nil
 }
 
 func (s *Store) Create(_ context.Context, record *types.ResourceTags) error {
@@ -59,7 +72,10 @@ func (s *Store) Create(_ context.Context, record *types.ResourceTags) error {
 	s.batch = append(s.batch, record)
 
 	if len(s.batch) >= s.maxBatchCount {
-		return s.flushLocked()
+		if err := s.flushLocked(); err != nil {
+			s.dropped = errors.Join(s.dropped, err)
+			return err
+		}
 	}
 	return nil
 }
@@ -68,11 +84,15 @@ func (s *Store) Update(ctx context.Context, record *types.ResourceTags) error {
 	return s.Create(ctx, record)
 }
 
-// Flush sends any buffered records to the collector.
+// Flush sends any buffered records to the collector. It fails if the records
+// could not be sent, or if a batch sent when the threshold was reached since
+// the previous Flush failed.
 func (s *Store) Flush() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.flushLocked()
+	err := errors.Join(s.dropped, s.flushLocked())
+	s.dropped = nil
+	return err
 }
 
 func (s *Store) flushLocked() error {
@@ -88,6 +108,7 @@ func (s *Store) flushLocked() error {
 
 	err := pusher.PushMetrics(
 		context.Background(),
+		s.client,
 		s.settings.RemoteWrite.Host,
 		ts,
 		s.maxRetries,
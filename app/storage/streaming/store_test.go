@@ -47,7 +47,8 @@ func TestStreamingStoreEndToEnd(t *testing.T) {
 	clockTime := time.Date(2026, 6, 22, 12, 0, 0, 0, time.UTC)
 	clock := mocks.NewMockClock(clockTime)
 
-	store := streaming.New(settings, clock)
+	store, err := streaming.New(settings, clock)
+	require.NoError(t, err)
 	wc, err := webhook.NewWebhookFactory(store, settings, clock)
 	require.NoError(t, err)
 
@@ -171,7 +172,8 @@ func TestStoreCreateStampsTimestamps(t *testing.T) {
 	clock := mocks.NewMockClock(clockTime)
 
 	settings := makeSettings(t, "http://example.invalid")
-	store := streaming.New(settings, clock)
+	store, err := streaming.New(settings, clock)
+	require.NoError(t, err)
 
 	record := &types.ResourceTags{Type: config.Pod, Name: "web-1"}
 	require.True(t, record.RecordCreated.IsZero(), "precondition: RecordCreated must be zero")
@@ -311,3 +313,35 @@ func compilePatterns(patterns []string) []regexp.Regexp {
 // Ensure we don't accidentally reference the unused runtime import from
 // the fake clientset's k8sruntime alias.
 var _ k8sruntime.Object = (*corev1.Pod)(nil)
+
+// TestStoreFlushReportsDroppedBatches verifies Flush fails when a batch sent
+// at the threshold since the previous Flush could not be delivered, so callers
+// do not treat the dropped records as sent.
+func TestStoreFlushReportsDroppedBatches(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer failing.Close()
+
+	settings := makeSettings(t, failing.URL)
+	settings.RemoteWrite.MaxRetries = 0
+	store, err := streaming.New(settings, mocks.NewMockClock(time.Now()))
+	require.NoError(t, err)
+
+	var createErr error
+	for i := 0; i < 500; i++ {
+		record := &types.ResourceTags{
+			Type:         config.Pod,
+			Name:         fmt.Sprintf("web-%d", i),
+			Labels:       &config.MetricLabelTags{},
+			MetricLabels: &config.MetricLabels{},
+		}
+		if err := store.Create(context.Background(), record); err != nil {
+			createErr = err
+		}
+	}
+	require.Error(t, createErr, "the batch sent at the threshold fails")
+
+	assert.Error(t, store.Flush(), "the dropped batch is reported")
+	assert.NoError(t, store.Flush(), "a dropped batch is reported once")
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import "time"
+
+// A canary is a single sample sent to the collector by the data path
+// diagnostic and followed through the aggregator until it is uploaded.
+//
+// The canary is a cost metric: its name matches the default "cloudzero_" cost
+// name prefix, and its ID label the default "_" cost label prefix. When the
+// metric filters are changed so that either no longer matches, the canary is
+// dropped just like real data would be, which is what the diagnostic is meant
+// to detect. The canary is kept in the local files but left out of uploads.
+const (
+	// CanaryMetricName is the name of the canary metric.
+	CanaryMetricName = "cloudzero_agent_canary"
+
+	// CanaryIDLabel is the label holding the unique ID of a canary.
+	CanaryIDLabel = "_cloudzero_canary_id"
+)
+
+// CanaryTrace is how far a canary has travelled through the aggregator.
+type CanaryTrace struct {
+	// ID is the ID of the canary.
+	ID string `json:"id"`
+
+	// File is the flushed file holding the canary, empty until the canary
+	// has been flushed.
+	File string `json:"file,omitempty"`
+
+	// ReceivedAt is when the collector received the canary.
+	ReceivedAt time.Time `json:"receivedAt,omitzero"`
+
+	// FlushedAt is when the file holding the canary was flushed.
+	FlushedAt time.Time `json:"flushedAt,omitzero"`
+
+	// Uploaded is set once the shipper has uploaded the file.
+	Uploaded bool `json:"uploaded"`
+
+	// LabelDropped is set when a canary was found by its name and timestamp,
+	// but without its ID label.
+	LabelDropped bool `json:"labelDropped,omitempty"`
+}
+
+// Flushed reports whether the canary has been written to a flushed file.
+func (t *CanaryTrace) Flushed() bool {
+	return t.File != ""
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import "time"
+
+// SeriesCoverage records that the collector received a metric for a node from
+// a scrape job.
+type SeriesCoverage struct {
+	// Node is the value of the node label, empty for cluster-level metrics.
+	Node string `json:"node,omitempty"`
+
+	// Job is the value of the job label, i.e. the scrape job the metric
+	// came from.
+	Job string `json:"job,omitempty"`
+
+	// Metric is the name of the metric.
+	Metric string `json:"metric"`
+
+	// Cost is set when the metric matches the cost metric filters.
+	Cost bool `json:"cost"`
+
+	// LastSeen is when the collector last received the metric.
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// CoverageReport lists the series coverage observed by the collector since a
+// point in time.
+type CoverageReport struct {
+	// Since is the start of the window the report covers.
+	Since time.Time `json:"since"`
+
+	// Series holds one entry per node, job and metric seen in the window.
+	Series []SeriesCoverage `json:"series"`
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"slices"
+	"time"
+)
+
+// ClusterFingerprintHeader carries the fingerprint of the cluster on requests
+// to the CloudZero API. On responses to upload allocations, it carries the
+// fingerprint the CloudZero API has on record for the account and cluster
+// name, when there is one.
+const ClusterFingerprintHeader = "X-CloudZero-Cluster-Fingerprint"
+
+// Sources of the fingerprints of a ClusterIdentity.
+const (
+	// FingerprintSourceLocal is a fingerprint computed by the agent itself.
+	FingerprintSourceLocal = "local"
+
+	// FingerprintSourceAPI is a fingerprint the CloudZero API has on record
+	// for the account and cluster name.
+	FingerprintSourceAPI = "api"
+)
+
+// FingerprintConflictWindow is how recently a fingerprint other than the
+// current one must have been seen to be a conflict. Older fingerprints are
+// those of a cluster which was replaced, and reused its name.
+const FingerprintConflictWindow = 24 * time.Hour
+
+// ClusterIdentity records the fingerprints seen for the account and cluster
+// name of the agent.
+type ClusterIdentity struct {
+	// Fingerprint is the fingerprint of the cluster the agent runs in, empty
+	// when it could not be computed.
+	Fingerprint string `json:"fingerprint"`
+
+	// Seen lists every fingerprint seen, the current one included.
+	Seen []FingerprintRecord `json:"seen"`
+}
+
+// FingerprintRecord records when a fingerprint was seen.
+type FingerprintRecord struct {
+	Fingerprint string    `json:"fingerprint"`
+	Source      string    `json:"source"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// Observe records that a fingerprint was seen from a source at a time.
+//
+// The agent computes a single fingerprint, so a new local fingerprint means
+// the cluster itself changed, for example when it was rebuilt in place. The
+// local records of the previous fingerprints are expired then, rather than
+// reported as another cluster.
+func (c *ClusterIdentity) Observe(fingerprint, source string, at time.Time) {
+	if source == FingerprintSourceLocal {
+		c.Seen = slices.DeleteFunc(c.Seen, func(r FingerprintRecord) bool {
+			return r.Source == FingerprintSourceLocal && r.Fingerprint != fingerprint
+		})
+	}
+	for i := range c.Seen {
+		if c.Seen[i].Fingerprint == fingerprint && c.Seen[i].Source == source {
+			c.Seen[i].LastSeen = at
+			return
+		}
+	}
+	c.Seen = append(c.Seen, FingerprintRecord{Fingerprint: fingerprint, Source: source, FirstSeen: at, LastSeen: at})
+}
+
+// Conflicts returns the fingerprints other than the current one seen since
+// the conflict window before now, i.e. those of other clusters which use the
+// same account and cluster name.
+func (c *ClusterIdentity) Conflicts(now time.Time) []FingerprintRecord {
+	var conflicts []FingerprintRecord
+	for _, r := range c.Seen {
+		if r.Fingerprint != c.Fingerprint && !r.LastSeen.Before(now.Add(-FingerprintConflictWindow)) {
+			conflicts = append(conflicts, r)
+		}
+	}
+	return conflicts
+}
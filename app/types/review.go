@@ -403,4 +403,10 @@ type AdmissionResponse struct {
 	//   - "Namespace 'default' has no cost center assignment"
 	//   - "Resource lacks owner information for cost allocation"
 	Warnings []string
+
+	// Patch holds an RFC 6902 JSON patch to apply to the admitted object, such
+	// as defaulted cost allocation labels. It is only honoured when the webhook
+	// is registered through a MutatingWebhookConfiguration; the API server
+	// ignores patches returned by validating webhooks.
+	Patch []byte
 }
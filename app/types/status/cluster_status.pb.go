@@ -80,10 +80,17 @@ func (StatusType) EnumDescriptor() ([]byte, []int) {
 }
 
 type StatusCheck struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Passing       bool                   `protobuf:"varint,2,opt,name=passing,proto3" json:"passing,omitempty"`
-	Error         string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Name    string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Passing bool                   `protobuf:"varint,2,opt,name=passing,proto3" json:"passing,omitempty"`
+	Error   string                 `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	// 10/18/26 updates
+	Type          string `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	DurationMs    int64  `protobuf:"varint,5,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Remediation   string `protobuf:"bytes,6,opt,name=remediation,proto3" json:"remediation,omitempty"`
+	RemediationId string `protobuf:"bytes,7,opt,name=remediation_id,json=remediationId,proto3" json:"remediation_id,omitempty"`
+	Severity      string `protobuf:"bytes,8,opt,name=severity,proto3" json:"severity,omitempty"`
+	DocUrl        string `protobuf:"bytes,9,opt,name=doc_url,json=docUrl,proto3" json:"doc_url,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -139,6 +146,48 @@ func (x *StatusCheck) GetError() string {
 	return ""
 }
 
+func (x *StatusCheck) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *StatusCheck) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *StatusCheck) GetRemediation() string {
+	if x != nil {
+		return x.Remediation
+	}
+	return ""
+}
+
+func (x *StatusCheck) GetRemediationId() string {
+	if x != nil {
+		return x.RemediationId
+	}
+	return ""
+}
+
+func (x *StatusCheck) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *StatusCheck) GetDocUrl() string {
+	if x != nil {
+		return x.DocUrl
+	}
+	return ""
+}
+
 type ClusterStatus struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	Account          string                 `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
@@ -152,11 +201,13 @@ type ClusterStatus struct {
 	K8SVersion       string                 `protobuf:"bytes,9,opt,name=k8s_version,json=k8sVersion,proto3" json:"k8s_version,omitempty"`
 	Checks           []*StatusCheck         `protobuf:"bytes,10,rep,name=checks,proto3" json:"checks,omitempty"`
 	// 05/15/25 updates
-	ProviderId    string `protobuf:"bytes,11,opt,name=provider_id,json=providerId,proto3" json:"provider_id,omitempty"`
-	ReleaseName   string `protobuf:"bytes,12,opt,name=release_name,json=releaseName,proto3" json:"release_name,omitempty"`
-	Namespace     string `protobuf:"bytes,13,opt,name=namespace,proto3" json:"namespace,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	ProviderId  string `protobuf:"bytes,11,opt,name=provider_id,json=providerId,proto3" json:"provider_id,omitempty"`
+	ReleaseName string `protobuf:"bytes,12,opt,name=release_name,json=releaseName,proto3" json:"release_name,omitempty"`
+	Namespace   string `protobuf:"bytes,13,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// 10/18/26 updates
+	ClusterFingerprint string `protobuf:"bytes,14,opt,name=cluster_fingerprint,json=clusterFingerprint,proto3" json:"cluster_fingerprint,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
 func (x *ClusterStatus) Reset() {
@@ -280,15 +331,29 @@ func (x *ClusterStatus) GetNamespace() string {
 	return ""
 }
 
+func (x *ClusterStatus) GetClusterFingerprint() string {
+	if x != nil {
+		return x.ClusterFingerprint
+	}
+	return ""
+}
+
 var File_cluster_status_proto protoreflect.FileDescriptor
 
 const file_cluster_status_proto_rawDesc = "" +
 	"\n" +
-	"\x14cluster_status.proto\x12\x06status\"Q\n" +
+	"\x14cluster_status.proto\x12\x06status\"\x84\x02\n" +
 	"\vStatusCheck\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
 	"\apassing\x18\x02 \x01(\bR\apassing\x12\x14\n" +
-	"\x05error\x18\x03 \x01(\tR\x05error\"\xcb\x03\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x1f\n" +
+	"\vduration_ms\x18\x05 \x01(\x03R\n" +
+	"durationMs\x12 \n" +
+	"\vremediation\x18\x06 \x01(\tR\vremediation\x12%\n" +
+	"\x0eremediation_id\x18\a \x01(\tR\rremediationId\x12\x1a\n" +
+	"\bseverity\x18\b \x01(\tR\bseverity\x12\x17\n" +
+	"\adoc_url\x18\t \x01(\tR\x06docUrl\"\xfc\x03\n" +
 	"\rClusterStatus\x12\x18\n" +
 	"\aaccount\x18\x01 \x01(\tR\aaccount\x12\x16\n" +
 	"\x06region\x18\x02 \x01(\tR\x06region\x12\x12\n" +
@@ -305,7 +370,8 @@ const file_cluster_status_proto_rawDesc = "" +
 	"\vprovider_id\x18\v \x01(\tR\n" +
 	"providerId\x12!\n" +
 	"\frelease_name\x18\f \x01(\tR\vreleaseName\x12\x1c\n" +
-	"\tnamespace\x18\r \x01(\tR\tnamespace*\xb8\x01\n" +
+	"\tnamespace\x18\r \x01(\tR\tnamespace\x12/\n" +
+	"\x13cluster_fingerprint\x18\x0e \x01(\tR\x12clusterFingerprint*\xb8\x01\n" +
 	"\n" +
 	"StatusType\x12\x1b\n" +
 	"\x17STATUS_TYPE_UNSPECIFIED\x10\x00\x12\x1c\n" +
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package compress provides the codecs metric files are compressed with. The
+// codec of a file is recorded in its extension, and can be detected from the
+// header of the stream when only the content is available.
+package compress
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec is a compression format.
+type Codec string
+
+const (
+	Brotli Codec = "brotli"
+	Zstd   Codec = "zstd"
+	Snappy Codec = "snappy"
+)
+
+// Codecs lists the supported codecs.
+var Codecs = []Codec{Brotli, Zstd, Snappy}
+
+const (
+	// DefaultBrotliLevel and DefaultZstdLevel are used when the level is out
+	// of the range of the codec. Snappy has no levels.
+	DefaultBrotliLevel = 8
+	DefaultZstdLevel   = 3
+
+	maxZstdLevel = 22
+)
+
+var (
+	// zstdMagic and snappyMagic start every zstd frame and framed snappy
+	// stream. Brotli streams have no magic number.
+	zstdMagic   = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	snappyMagic = []byte("\xff\x06\x00\x00sNaPpY")
+)
+
+// ParseCodec returns the codec with the name.
+func ParseCodec(name string) (Codec, error) {
+	c := Codec(strings.ToLower(strings.TrimSpace(name)))
+	switch c {
+	case Brotli, Zstd, Snappy:
+		return c, nil
+	}
+	return "", fmt.Errorf("unsupported codec %q, expected brotli, zstd or snappy", name)
+}
+
+// Extension returns the file extension of the codec, such as ".br".
+func (c Codec) Extension() string {
+	switch c {
+	case Zstd:
+		return ".zst"
+	case Snappy:
+		return ".sz"
+	default:
+		return ".br"
+	}
+}
+
+// FromPath returns the codec recorded in the extension of the path.
+func FromPath(path string) (Codec, bool) {
+	for _, c := range Codecs {
+		if strings.HasSuffix(strings.ToLower(path), c.Extension()) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// NewWriter returns a writer compressing to w with the codec at the level.
+// Closing it flushes the compressed data, but does not close w.
+func NewWriter(w io.Writer, c Codec, level int) (io.WriteCloser, error) {
+	switch c {
+	case Brotli:
+		if level < brotli.BestSpeed || level > brotli.BestCompression {
+			level = DefaultBrotliLevel
+		}
+		return brotli.NewWriterLevel(w, level), nil
+	case Zstd:
+		if level < 1 || level > maxZstdLevel {
+			level = DefaultZstdLevel
+		}
+		// A single goroutine, so the codec stays cheap on CPU-constrained
+		// pods.
+		return zstd.NewWriter(w,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+			zstd.WithEncoderConcurrency(1),
+		)
+	case Snappy:
+		return snappy.NewBufferedWriter(w), nil
+	}
+	return nil, fmt.Errorf("unsupported codec %q", c)
+}
+
+// NewReader returns a reader decompressing r with the codec. Closing it does
+// not close r.
+func NewReader(r io.Reader, c Codec) (io.ReadCloser, error) {
+	switch c {
+	case Brotli:
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case Zstd:
+		decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the zstd reader: %w", err)
+		}
+		return decoder.IOReadCloser(), nil
+	case Snappy:
+		return io.NopCloser(snappy.NewReader(r)), nil
+	}
+	return nil, fmt.Errorf("unsupported codec %q", c)
+}
+
+// Detect returns a reader decompressing r with the codec found in its header.
+// Streams without a known header are Brotli, the codec of files written
+// before the codec was recorded.
+func Detect(r io.Reader) (io.ReadCloser, Codec, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(len(snappyMagic))
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", fmt.Errorf("failed to read the header: %w", err)
+	}
+
+	c := Brotli
+	switch {
+	case bytes.HasPrefix(header, zstdMagic):
+		c = Zstd
+	case bytes.HasPrefix(header, snappyMagic):
+		c = Snappy
+	}
+	reader, err := NewReader(buffered, c)
+	return reader, c, err
+}
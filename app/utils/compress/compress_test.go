@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package compress
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	content := []byte(strings.Repeat(`{"metric_name":"container_cpu_usage_seconds_total","value":"1"},`, 1000))
+
+	for _, c := range Codecs {
+		t.Run(string(c), func(t *testing.T) {
+			var compressed bytes.Buffer
+			w, err := NewWriter(&compressed, c, -1)
+			require.NoError(t, err)
+			_, err = w.Write(content)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			assert.Less(t, compressed.Len(), len(content))
+
+			r, err := NewReader(bytes.NewReader(compressed.Bytes()), c)
+			require.NoError(t, err)
+			decompressed, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			assert.Equal(t, content, decompressed)
+
+			r, detected, err := Detect(bytes.NewReader(compressed.Bytes()))
+			require.NoError(t, err)
+			assert.Equal(t, c, detected)
+			decompressed, err = io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, content, decompressed)
+		})
+	}
+}
+
+func TestFromPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		codec Codec
+		ok    bool
+	}{
+		{path: "metrics_1_2.json.br", codec: Brotli, ok: true},
+		{path: "/data/metrics_1_2.json.zst", codec: Zstd, ok: true},
+		{path: "metrics_1_2.JSON.SZ", codec: Snappy, ok: true},
+		{path: "metrics_1_2.json"},
+		{path: "a1b2c3d4.1700000000000"},
+	}
+	for _, tt := range tests {
+		codec, ok := FromPath(tt.path)
+		assert.Equal(t, tt.ok, ok, tt.path)
+		assert.Equal(t, tt.codec, codec, tt.path)
+	}
+}
+
+func TestParseCodec(t *testing.T) {
+	c, err := ParseCodec(" ZSTD ")
+	require.NoError(t, err)
+	assert.Equal(t, Zstd, c)
+
+	_, err = ParseCodec("gzip")
+	assert.ErrorContains(t, err, `unsupported codec "gzip"`)
+}
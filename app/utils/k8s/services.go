@@ -14,6 +14,7 @@ import (
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -51,17 +52,24 @@ func UpdateConfigMap(ctx context.Context, clientset kubernetes.Interface, namesp
 	return errors.Wrap(err, "updating configmap")
 }
 
+// ClientOption customizes the rest.Config of a client created by NewClient.
+type ClientOption func(*rest.Config)
+
 // NewClient creates a new Kubernetes client using the provided kubeconfig file path.
 // It returns a kubernetes.Interface which can be used to interact with the Kubernetes API.
 // The function sets the QPS (Queries Per Second) and Burst rate for the client to ensure efficient communication with the cluster.
+// The options are applied afterwards, so they can override these settings.
 // If there is an error building the kubeconfig or creating the clientset, it returns an error.
-func NewClient(kubeconfigPath string) (kubernetes.Interface, error) {
+func NewClient(kubeconfigPath string, opts ...ClientOption) (kubernetes.Interface, error) {
 	config, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
 	if err != nil {
 		return nil, errors.Wrap(err, "building kubeconfig")
 	}
 	config.QPS = queriesPerSecond
 	config.Burst = maxBurst
+	for _, opt := range opts {
+		opt(config)
+	}
 
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
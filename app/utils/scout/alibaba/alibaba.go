@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package alibaba provides Alibaba Cloud environment detection and metadata
+// retrieval using the ECS instance metadata service, in hardened (token) mode
+// with fallback to normal mode.
+package alibaba
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
+)
+
+const (
+	// ECS metadata service endpoints
+	// https://www.alibabacloud.com/help/en/ecs/user-guide/view-instance-metadata
+	metadataBaseURL        = "http://100.100.100.200/latest/meta-data"
+	tokenURL               = "http://100.100.100.200/latest/api/token" // #nosec G101 - This is a metadata service URL, not a credential
+	instanceIDEndpoint     = metadataBaseURL + "/instance-id"
+	regionEndpoint         = metadataBaseURL + "/region-id"
+	ownerAccountIDEndpoint = metadataBaseURL + "/owner-account-id"
+
+	// HTTP headers
+	tokenTTLHeader = "X-aliyun-ecs-metadata-token-ttl-seconds" // #nosec G101 - This is a header name, not a credential
+	tokenHeader    = "X-aliyun-ecs-metadata-token"             // #nosec G101 - This is a header name, not a credential
+
+	requestTimeout = 5 * time.Second
+	tokenTTL       = "21600" // 6 hours
+)
+
+// Scout detects and describes an Alibaba Cloud environment.
+type Scout struct {
+	client *http.Client
+}
+
+// NewScout creates a new Alibaba Cloud metadata scout.
+func NewScout() *Scout {
+	return &Scout{
+		client: &http.Client{
+			Timeout: requestTimeout,
+		},
+	}
+}
+
+// Detect determines whether the current environment is running on Alibaba
+// Cloud by reading the instance ID from the metadata service. Network
+// failures and non-200 responses are treated as "not Alibaba Cloud" rather
+// than errors.
+func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
+	instanceID, err := s.getMetadata(ctx, instanceIDEndpoint, s.getToken(ctx))
+	if err != nil {
+		return types.CloudProviderUnknown, nil //nolint:nilerr // inability to reach the metadata service means "not Alibaba Cloud", not a hard error
+	}
+
+	// ECS instance IDs start with "i-"
+	if strings.HasPrefix(instanceID, "i-") {
+		return types.CloudProviderAlibaba, nil
+	}
+
+	return types.CloudProviderUnknown, nil
+}
+
+// EnvironmentInfo retrieves Alibaba Cloud environment information from the
+// ECS metadata service. The ID of the account owning the instance is used as
+// the account ID.
+func (s *Scout) EnvironmentInfo(ctx context.Context) (*types.EnvironmentInfo, error) {
+	token := s.getToken(ctx)
+
+	region, err := s.getMetadata(ctx, regionEndpoint, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get region: %w", err)
+	}
+	if region == "" {
+		return nil, errors.New("region-id not found in Alibaba Cloud metadata")
+	}
+
+	accountID, err := s.getMetadata(ctx, ownerAccountIDEndpoint, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account ID: %w", err)
+	}
+
+	return &types.EnvironmentInfo{
+		CloudProvider: types.CloudProviderAlibaba,
+		Region:        region,
+		AccountID:     accountID,
+	}, nil
+}
+
+// getToken retrieves a token for the hardened mode of the metadata service.
+// An empty token is returned when none could be retrieved, in which case the
+// metadata is read in normal mode.
+func (s *Scout) getToken(ctx context.Context) string {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, tokenURL, nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set(tokenTTLHeader, tokenTTL)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// getMetadata retrieves metadata from the specified endpoint, using the token
+// when one is given.
+func (s *Scout) getMetadata(ctx context.Context, endpoint, token string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set(tokenHeader, token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get metadata, status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
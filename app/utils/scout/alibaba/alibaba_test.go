@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package alibaba
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
+)
+
+const testToken = "test-token" // #nosec G101 - test value
+
+// metadataServer fakes the ECS metadata service. When hardened is set,
+// metadata requests without a token are rejected.
+func metadataServer(t *testing.T, hardened bool, metadata map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/api/token" {
+			assert.Equal(t, http.MethodPut, r.Method)
+			assert.Equal(t, tokenTTL, r.Header.Get(tokenTTLHeader))
+			if !hardened {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(testToken))
+			return
+		}
+
+		if hardened && r.Header.Get(tokenHeader) != testToken {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		value, ok := metadata[strings.TrimPrefix(r.URL.Path, "/latest/meta-data/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(value))
+	}))
+}
+
+var validMetadata = map[string]string{
+	"instance-id":      "i-bp67acfmxazb4ph2hxyz",
+	"region-id":        "cn-hangzhou",
+	"owner-account-id": "1234567890123456",
+}
+
+func TestScout_Detect(t *testing.T) {
+	tests := []struct {
+		name     string
+		hardened bool
+		metadata map[string]string
+		expected types.CloudProvider
+	}{
+		{name: "normal mode", metadata: validMetadata, expected: types.CloudProviderAlibaba},
+		{name: "hardened mode", hardened: true, metadata: validMetadata, expected: types.CloudProviderAlibaba},
+		{name: "no instance ID", metadata: map[string]string{}, expected: types.CloudProviderUnknown},
+		{name: "not an ECS instance ID", metadata: map[string]string{"instance-id": "<html>"}, expected: types.CloudProviderUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := metadataServer(t, tt.hardened, tt.metadata)
+			defer server.Close()
+
+			result, err := createScoutWithCustomURL(server.URL).Detect(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestScout_Detect_NetworkError(t *testing.T) {
+	result, err := createScoutWithCustomURL("http://192.0.2.1").Detect(context.Background()) // RFC 5737 TEST-NET-1
+	assert.NoError(t, err)
+	assert.Equal(t, types.CloudProviderUnknown, result)
+}
+
+func TestScout_EnvironmentInfo(t *testing.T) {
+	for _, hardened := range []bool{false, true} {
+		server := metadataServer(t, hardened, validMetadata)
+
+		result, err := createScoutWithCustomURL(server.URL).EnvironmentInfo(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, &types.EnvironmentInfo{
+			CloudProvider: types.CloudProviderAlibaba,
+			Region:        "cn-hangzhou",
+			AccountID:     "1234567890123456",
+		}, result)
+
+		server.Close()
+	}
+}
+
+func TestScout_EnvironmentInfo_Errors(t *testing.T) {
+	server := metadataServer(t, false, map[string]string{"region-id": "cn-hangzhou"})
+	defer server.Close()
+
+	result, err := createScoutWithCustomURL(server.URL).EnvironmentInfo(context.Background())
+	assert.ErrorContains(t, err, "failed to get account ID")
+	assert.Nil(t, result)
+
+	result, err = createScoutWithCustomURL(server.URL + "/missing").EnvironmentInfo(context.Background())
+	assert.ErrorContains(t, err, "failed to get region")
+	assert.Nil(t, result)
+}
+
+func createScoutWithCustomURL(baseURL string) *Scout {
+	return &Scout{
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &customTransport{baseURL: baseURL},
+		},
+	}
+}
+
+// customTransport redirects metadata requests to a test server
+type customTransport struct {
+	baseURL string
+}
+
+func (t *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	newReq := req.Clone(req.Context())
+	var err error
+	newReq.URL, err = newReq.URL.Parse(strings.Replace(req.URL.String(), "http://100.100.100.200", t.baseURL, 1))
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultTransport.RoundTrip(newReq)
+}
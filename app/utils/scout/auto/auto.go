@@ -27,7 +27,8 @@ type Scout struct {
 	detectOnce           sync.Once
 	environmentInfoOnce  sync.Once
 
-	scouts []types.Scout
+	scouts     []types.Scout
+	sequential bool
 }
 
 // NewScout creates a new auto-detection Scout that tries the provided scouts.
@@ -43,15 +44,37 @@ func NewScout(scouts ...types.Scout) *Scout {
 	}
 }
 
-// Detect iterates through all provided scouts concurrently and returns the
-// first cloud provider detected. Returns CloudProviderUnknown if no cloud
-// provider is detected by any scout.
+// NewSequentialScout creates a new auto-detection Scout that tries the
+// provided scouts one after the other, in order.
+//
+// It is used to give precedence to some scouts over others, such as metadata
+// services over the Kubernetes API, which are only tried when no earlier scout
+// detected a cloud provider.
+func NewSequentialScout(scouts ...types.Scout) *Scout {
+	s := NewScout(scouts...)
+	s.sequential = true
+	return s
+}
+
+// Detect iterates through all provided scouts concurrently (or in order, for
+// a sequential Scout) and returns the first cloud provider detected. Returns
+// CloudProviderUnknown if no cloud provider is detected by any scout.
 //
 // Network errors during detection are treated as "not detected" and do not
 // prevent other scouts from running.
 func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
+	if s.sequential {
+		s.detectOnce.Do(func() {
+			s.detectSequentially(ctx)
+		})
+		return s.environmentInfo.CloudProvider, s.environmentInfoError
+	}
+
 	s.detectOnce.Do(func() {
-		var wg sync.WaitGroup
+		var (
+			wg sync.WaitGroup
+			mu sync.Mutex
+		)
 
 		cancellableCtx, cancel := context.WithCancel(ctx)
 		defer cancel()
@@ -75,9 +98,13 @@ func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
 				// We have a match, cancel the context to stop the other scouts
 				cancel()
 
-				// Technically there could be a race condition here, but only if
-				// two Scouts return a non-unknown cloud provider at the same
-				// time, which shouldn't happen.
+				// Two scouts may still report a match at the same time, in
+				// which case the first one to get here wins.
+				mu.Lock()
+				defer mu.Unlock()
+				if s.scout != nil {
+					return
+				}
 				s.environmentInfo.CloudProvider = detected
 				s.scout = currentScout
 			}(scout)
@@ -91,6 +118,27 @@ func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
 	return s.environmentInfo.CloudProvider, s.environmentInfoError
 }
 
+// detectSequentially tries the scouts in order, stopping at the first which
+// detects a cloud provider.
+func (s *Scout) detectSequentially(ctx context.Context) {
+	for _, scout := range s.scouts {
+		if ctx.Err() != nil {
+			break
+		}
+
+		detected, err := scout.Detect(ctx)
+		if err != nil || detected == types.CloudProviderUnknown {
+			continue
+		}
+
+		s.environmentInfo.CloudProvider = detected
+		s.scout = scout
+		break
+	}
+
+	s.scouts = nil
+}
+
 // EnvironmentInfo attempts to retrieve environment information.
 func (s *Scout) EnvironmentInfo(ctx context.Context) (*types.EnvironmentInfo, error) {
 	s.environmentInfoOnce.Do(func() {
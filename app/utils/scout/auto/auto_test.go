@@ -112,3 +112,76 @@ func TestScoutWithMultipleProviders(t *testing.T) {
 		t.Errorf("Expected AWS, got: %s", info.CloudProvider)
 	}
 }
+
+func TestScoutWithSimultaneousDetections(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Both scouts report a match; exactly one of them must be kept
+	awsScout := mocks.NewMockScout(ctrl)
+	awsScout.EXPECT().
+		Detect(gomock.Any()).
+		Return(types.CloudProviderAWS, nil)
+	openstackScout := mocks.NewMockScout(ctrl)
+	openstackScout.EXPECT().
+		Detect(gomock.Any()).
+		Return(types.CloudProviderOpenStack, nil)
+
+	autoScout := NewScout(awsScout, openstackScout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	detected, err := autoScout.Detect(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	switch {
+	case detected == types.CloudProviderAWS && autoScout.scout == awsScout:
+	case detected == types.CloudProviderOpenStack && autoScout.scout == openstackScout:
+	default:
+		t.Errorf("Detected %s does not match the kept scout", detected)
+	}
+}
+
+func TestSequentialScout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockInfo := &types.EnvironmentInfo{
+		CloudProvider: types.CloudProviderHetzner,
+		Region:        "fsn1",
+	}
+
+	// First scout doesn't detect
+	scout1 := mocks.NewMockScout(ctrl)
+	scout1.EXPECT().
+		Detect(gomock.Any()).
+		Return(types.CloudProviderUnknown, nil)
+
+	// Second scout detects, so the third is never tried
+	scout2 := mocks.NewMockScout(ctrl)
+	scout2.EXPECT().
+		Detect(gomock.Any()).
+		Return(types.CloudProviderHetzner, nil)
+	scout2.EXPECT().
+		EnvironmentInfo(gomock.Any()).
+		Return(mockInfo, nil)
+
+	scout3 := mocks.NewMockScout(ctrl)
+
+	autoScout := NewSequentialScout(scout1, scout2, scout3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	info, err := autoScout.EnvironmentInfo(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if *info != *mockInfo {
+		t.Errorf("Expected %v, got: %v", mockInfo, info)
+	}
+}
@@ -26,6 +26,10 @@ const (
 	instanceIDEndpoint  = metadataBaseURL + "/instance-id"
 	identityDocEndpoint = "http://169.254.169.254/latest/dynamic/instance-identity/document"
 
+	// Endpoints of clouds which also serve an EC2-compatible metadata service
+	openstackEndpoint = "http://169.254.169.254/openstack"
+	hetznerEndpoint   = "http://169.254.169.254/hetzner/v1/metadata"
+
 	// HTTP headers
 	tokenTTLHeader = "X-aws-ec2-metadata-token-ttl-seconds" // #nosec G101 - This is a header name, not a credential
 	tokenHeader    = "X-aws-ec2-metadata-token"             // #nosec G101 - This is a header name, not a credential
@@ -34,6 +38,11 @@ const (
 	tokenTTL       = "21600" // 6 hours
 )
 
+// ec2CompatibleEndpoints are probed before an IMDSv1 response is attributed to
+// AWS. OpenStack and Hetzner both answer /latest/meta-data/instance-id, so an
+// instance ID alone does not prove we are on AWS.
+var ec2CompatibleEndpoints = []string{openstackEndpoint, hetznerEndpoint}
+
 // ErrIMDSv2Unavailable is returned when IMDSv2 token endpoint is not available
 var ErrIMDSv2Unavailable = errors.New("IMDSv2 token endpoint unavailable, falling back to IMDSv1")
 
@@ -99,9 +108,10 @@ func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
 				return types.CloudProviderUnknown, nil
 			}
 
-			// If we can retrieve an instance ID from the metadata service, we're on AWS
-			// Instance IDs can have various formats, so we just check for non-empty response
-			if strings.TrimSpace(string(body)) != "" {
+			// If we can retrieve an instance ID from the metadata service, and it
+			// is not one of the EC2-compatible clouds, we're on AWS. Instance IDs
+			// can have various formats, so we just check for non-empty response
+			if strings.TrimSpace(string(body)) != "" && !s.isEC2Compatible(ctx) {
 				return types.CloudProviderAWS, nil
 			}
 		}
@@ -110,6 +120,29 @@ func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
 	return types.CloudProviderUnknown, nil
 }
 
+// isEC2Compatible reports whether the metadata service belongs to another cloud
+// that mimics the EC2 metadata API.
+func (s *Scout) isEC2Compatible(ctx context.Context) bool {
+	for _, endpoint := range ec2CompatibleEndpoints {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			continue
+		}
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK {
+			return true
+		}
+	}
+
+	return false
+}
+
 // EnvironmentInfo retrieves AWS environment information from EC2 metadata service
 // with IMDSv2 support and fallback to IMDSv1 for compatibility.
 func (s *Scout) EnvironmentInfo(ctx context.Context) (*types.EnvironmentInfo, error) {
@@ -990,3 +990,47 @@ func TestGetAccountIDIMDSv1_MissingAccountID(t *testing.T) {
 		t.Errorf("Expected error to contain %q, got %q", expectedError, err.Error())
 	}
 }
+
+func TestDetect_IMDSv1(t *testing.T) {
+	testCases := []struct {
+		name           string
+		otherPath      string
+		expectedResult types.CloudProvider
+	}{
+		{"AWS", "", types.CloudProviderAWS},
+		{"OpenStack", "/openstack", types.CloudProviderUnknown},
+		{"Hetzner", "/hetzner/v1/metadata", types.CloudProviderUnknown},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Every case serves the EC2-compatible IMDSv1 API; only the
+			// provider-specific endpoint differs
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/latest/meta-data/":
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte("instance-id\n"))
+				case "/latest/meta-data/instance-id":
+					w.WriteHeader(http.StatusOK)
+					w.Write([]byte("i-1234567890abcdef"))
+				case tc.otherPath:
+					w.WriteHeader(http.StatusOK)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			scout := createScoutWithCustomURLs(server.URL)
+
+			result, err := scout.Detect(context.Background())
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result != tc.expectedResult {
+				t.Errorf("Expected %s, got %s", tc.expectedResult, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package digitalocean provides DigitalOcean environment detection and metadata
+// retrieval using the Droplet Metadata Service.
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
+)
+
+const (
+	// metadataURL is the JSON document of the Droplet Metadata Service.
+	// https://docs.digitalocean.com/reference/api/metadata/
+	metadataURL = "http://169.254.169.254/metadata/v1.json"
+
+	requestTimeout = 5 * time.Second
+)
+
+// Scout detects and describes a DigitalOcean environment.
+type Scout struct {
+	client *http.Client
+}
+
+// dropletMetadata is the subset of the Droplet metadata we consume.
+type dropletMetadata struct {
+	// DropletID is the ID of the Droplet, which is only served by
+	// DigitalOcean.
+	DropletID int64 `json:"droplet_id"`
+	// Region is the region slug (e.g. "nyc3").
+	Region string `json:"region"`
+}
+
+// NewScout creates a new DigitalOcean metadata scout.
+func NewScout() *Scout {
+	return &Scout{
+		client: &http.Client{
+			Timeout: requestTimeout,
+		},
+	}
+}
+
+// fetchMetadata retrieves and parses the Droplet metadata document.
+func (s *Scout) fetchMetadata(ctx context.Context) (*dropletMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DigitalOcean metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get DigitalOcean metadata, status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DigitalOcean metadata response: %w", err)
+	}
+
+	var md dropletMetadata
+	if err := json.Unmarshal(body, &md); err != nil {
+		return nil, fmt.Errorf("failed to parse DigitalOcean metadata JSON: %w", err)
+	}
+
+	return &md, nil
+}
+
+// Detect determines whether the current environment is running on
+// DigitalOcean by querying the Droplet Metadata Service. Network failures,
+// non-200 responses, and unparseable bodies are treated as "not DigitalOcean"
+// rather than errors.
+func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
+	md, err := s.fetchMetadata(ctx)
+	if err != nil {
+		return types.CloudProviderUnknown, nil //nolint:nilerr // inability to reach/parse the metadata means "not DigitalOcean", not a hard error
+	}
+
+	if md.DropletID != 0 {
+		return types.CloudProviderDigitalOcean, nil
+	}
+
+	return types.CloudProviderUnknown, nil
+}
+
+// EnvironmentInfo retrieves DigitalOcean environment information from the
+// Droplet Metadata Service.
+func (s *Scout) EnvironmentInfo(ctx context.Context) (*types.EnvironmentInfo, error) {
+	md, err := s.fetchMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	region := strings.TrimSpace(md.Region)
+	if region == "" {
+		return nil, errors.New("region not found in DigitalOcean metadata")
+	}
+
+	return &types.EnvironmentInfo{
+		CloudProvider: types.CloudProviderDigitalOcean,
+		Region:        region,
+		// AccountID and ClusterName are not exposed by the metadata service,
+		// and must be configured manually (cloudAccountId and clusterName in
+		// the Helm chart).
+	}, nil
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package hetzner provides Hetzner Cloud environment detection and metadata
+// retrieval using the Hetzner Cloud Metadata Service.
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
+)
+
+const (
+	// metadataURL is the YAML document of the Hetzner Cloud Metadata Service.
+	// https://docs.hetzner.cloud/#server-metadata
+	metadataURL = "http://169.254.169.254/hetzner/v1/metadata"
+
+	requestTimeout = 5 * time.Second
+)
+
+// Scout detects and describes a Hetzner Cloud environment.
+type Scout struct {
+	client *http.Client
+}
+
+// serverMetadata is the subset of the server metadata we consume.
+type serverMetadata struct {
+	// InstanceID is the ID of the server.
+	InstanceID int64 `yaml:"instance-id"`
+	// AvailabilityZone is the datacenter of the server (e.g. "fsn1-dc14").
+	AvailabilityZone string `yaml:"availability-zone"`
+	// Region is the network zone of the server (e.g. "eu-central"). It spans
+	// several locations, so it is only a detection signal.
+	Region string `yaml:"region"`
+}
+
+// NewScout creates a new Hetzner Cloud metadata scout.
+func NewScout() *Scout {
+	return &Scout{
+		client: &http.Client{
+			Timeout: requestTimeout,
+		},
+	}
+}
+
+// fetchMetadata retrieves and parses the server metadata document.
+func (s *Scout) fetchMetadata(ctx context.Context) (*serverMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Hetzner metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get Hetzner metadata, status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Hetzner metadata response: %w", err)
+	}
+
+	var md serverMetadata
+	if err := yaml.Unmarshal(body, &md); err != nil {
+		return nil, fmt.Errorf("failed to parse Hetzner metadata YAML: %w", err)
+	}
+
+	return &md, nil
+}
+
+// Detect determines whether the current environment is running on Hetzner
+// Cloud by querying the Metadata Service. Network failures, non-200
+// responses, and unparseable bodies are treated as "not Hetzner" rather than
+// errors.
+func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
+	md, err := s.fetchMetadata(ctx)
+	if err != nil {
+		return types.CloudProviderUnknown, nil //nolint:nilerr // inability to reach/parse the metadata means "not Hetzner", not a hard error
+	}
+
+	if md.InstanceID != 0 && md.AvailabilityZone != "" {
+		return types.CloudProviderHetzner, nil
+	}
+
+	return types.CloudProviderUnknown, nil
+}
+
+// EnvironmentInfo retrieves Hetzner Cloud environment information from the
+// Metadata Service.
+//
+// The region is the location of the server (e.g. "fsn1"), taken from its
+// datacenter (e.g. "fsn1-dc14"). This is what the Hetzner Cloud Controller
+// Manager sets as the topology.kubernetes.io/region label of nodes.
+func (s *Scout) EnvironmentInfo(ctx context.Context) (*types.EnvironmentInfo, error) {
+	md, err := s.fetchMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	location, _, _ := strings.Cut(strings.TrimSpace(md.AvailabilityZone), "-")
+	if location == "" {
+		return nil, errors.New("availability-zone not found in Hetzner metadata")
+	}
+
+	return &types.EnvironmentInfo{
+		CloudProvider: types.CloudProviderHetzner,
+		Region:        location,
+		// AccountID and ClusterName are not exposed by the metadata service,
+		// and must be configured manually (cloudAccountId and clusterName in
+		// the Helm chart).
+	}, nil
+}
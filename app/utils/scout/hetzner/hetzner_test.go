@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package hetzner
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
+)
+
+// validMetadataResponse is the document served by the Hetzner Cloud Metadata
+// Service.
+const validMetadataResponse = `availability-zone: fsn1-dc14
+hostname: worker-1
+instance-id: 42
+local-ipv4: ''
+public-ipv4: 203.0.113.10
+region: eu-central
+`
+
+func TestScout_Detect(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		expected   types.CloudProvider
+	}{
+		{name: "server", statusCode: http.StatusOK, body: validMetadataResponse, expected: types.CloudProviderHetzner},
+		{name: "not found", statusCode: http.StatusNotFound, expected: types.CloudProviderUnknown},
+		{name: "ok but not Hetzner metadata", statusCode: http.StatusOK, body: "<html></html>", expected: types.CloudProviderUnknown},
+		{name: "ok but invalid yaml", statusCode: http.StatusOK, body: "a: [", expected: types.CloudProviderUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/hetzner/v1/metadata", r.URL.Path)
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			result, err := createScoutWithCustomURL(server.URL).Detect(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestScout_Detect_NetworkError(t *testing.T) {
+	result, err := createScoutWithCustomURL("http://192.0.2.1").Detect(context.Background()) // RFC 5737 TEST-NET-1
+	assert.NoError(t, err)
+	assert.Equal(t, types.CloudProviderUnknown, result)
+}
+
+func TestScout_EnvironmentInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(validMetadataResponse))
+	}))
+	defer server.Close()
+
+	result, err := createScoutWithCustomURL(server.URL).EnvironmentInfo(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &types.EnvironmentInfo{
+		CloudProvider: types.CloudProviderHetzner,
+		Region:        "fsn1",
+	}, result)
+}
+
+func TestScout_EnvironmentInfo_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          string
+		errorContains string
+	}{
+		{name: "non-200 status", statusCode: http.StatusInternalServerError, errorContains: "status: 500"},
+		{name: "invalid YAML", statusCode: http.StatusOK, body: "a: [", errorContains: "failed to parse Hetzner metadata YAML"},
+		{name: "missing availability zone", statusCode: http.StatusOK, body: "instance-id: 42\n", errorContains: "availability-zone not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			result, err := createScoutWithCustomURL(server.URL).EnvironmentInfo(context.Background())
+			assert.ErrorContains(t, err, tt.errorContains)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func createScoutWithCustomURL(baseURL string) *Scout {
+	return &Scout{
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &customTransport{baseURL: baseURL},
+		},
+	}
+}
+
+// customTransport redirects metadata requests to a test server
+type customTransport struct {
+	baseURL string
+}
+
+func (t *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	newReq := req.Clone(req.Context())
+	var err error
+	newReq.URL, err = newReq.URL.Parse(strings.Replace(req.URL.String(), "http://169.254.169.254", t.baseURL, 1))
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultTransport.RoundTrip(newReq)
+}
@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package kubernetes provides environment detection from the Kubernetes API,
+// for clusters whose nodes have no reachable metadata service, such as
+// on-premises and bare-metal clusters.
+//
+// The cloud provider is derived from the providerID of the nodes, and the
+// region from their topology.kubernetes.io/region label. Any of these, as well
+// as the account ID and cluster name, can be set manually in an identity
+// ConfigMap, whose values take precedence over those derived from the nodes.
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
+)
+
+const (
+	// IdentityConfigMapEnv is the environment variable naming the identity
+	// ConfigMap, either as "namespace/name", or as "name" in the namespace
+	// given by K8S_NAMESPACE.
+	IdentityConfigMapEnv = "CLOUDZERO_IDENTITY_CONFIGMAP"
+
+	namespaceEnv = "K8S_NAMESPACE"
+
+	// Keys of the identity ConfigMap, named after the fields of
+	// types.EnvironmentInfo.
+	KeyCloudProvider = "cloudProvider"
+	KeyRegion        = "region"
+	KeyAccountID     = "accountId"
+	KeyClusterName   = "clusterName"
+
+	requestTimeout = 5 * time.Second
+
+	// nodeListLimit bounds the nodes read to derive the environment, which
+	// are expected to agree with each other.
+	nodeListLimit = 100
+)
+
+// providerIDSchemes maps the scheme of node providerIDs, as set by the cloud
+// controller managers, to cloud providers.
+var providerIDSchemes = map[string]types.CloudProvider{
+	"aws":          types.CloudProviderAWS,
+	"gce":          types.CloudProviderGoogle,
+	"azure":        types.CloudProviderAzure,
+	"oci":          types.CloudProviderOCI,
+	"digitalocean": types.CloudProviderDigitalOcean,
+	"hcloud":       types.CloudProviderHetzner,
+	"openstack":    types.CloudProviderOpenStack,
+	"alicloud":     types.CloudProviderAlibaba,
+	"vsphere":      types.CloudProviderVSphere,
+	"equinixmetal": types.CloudProviderEquinix,
+	"packet":       types.CloudProviderEquinix,
+}
+
+// Scout derives the environment from the Kubernetes API.
+type Scout struct {
+	clientset    kubernetes.Interface
+	newClientset func() (kubernetes.Interface, error)
+
+	configMapNamespace string
+	configMapName      string
+}
+
+// Option configures a Scout.
+type Option func(*Scout)
+
+// WithClientset sets the clientset used to query the Kubernetes API, instead
+// of an in-cluster one.
+func WithClientset(clientset kubernetes.Interface) Option {
+	return func(s *Scout) {
+		s.clientset = clientset
+	}
+}
+
+// WithConfigMap sets the identity ConfigMap, instead of the one named by
+// IdentityConfigMapEnv. An empty name disables it.
+func WithConfigMap(namespace, name string) Option {
+	return func(s *Scout) {
+		s.configMapNamespace = namespace
+		s.configMapName = name
+	}
+}
+
+// NewScout creates a new Kubernetes scout. Unless configured otherwise, it
+// uses an in-cluster client, so it detects nothing outside of a pod.
+func NewScout(opts ...Option) *Scout {
+	s := &Scout{newClientset: inClusterClientset}
+	s.configMapNamespace, s.configMapName = configMapFromEnv()
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Detect determines the cloud provider from the identity ConfigMap or the
+// providerID of the nodes. Failures to reach the Kubernetes API are treated as
+// "not detected" rather than errors.
+func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
+	ei, err := s.identify(ctx)
+	if err != nil {
+		return types.CloudProviderUnknown, nil //nolint:nilerr // inability to reach the Kubernetes API means "not detected", not a hard error
+	}
+	return ei.CloudProvider, nil
+}
+
+// EnvironmentInfo retrieves the environment information from the identity
+// ConfigMap and the nodes. Values which cannot be derived are left empty.
+func (s *Scout) EnvironmentInfo(ctx context.Context) (*types.EnvironmentInfo, error) {
+	ei, err := s.identify(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ei.CloudProvider == types.CloudProviderUnknown {
+		return nil, fmt.Errorf("cloud provider could not be derived from the providerID of the nodes, set %q in the identity ConfigMap", KeyCloudProvider)
+	}
+	return ei, nil
+}
+
+// identify derives the environment from the nodes, and overrides it with the
+// identity ConfigMap. An error is only returned when neither could be read.
+func (s *Scout) identify(ctx context.Context) (*types.EnvironmentInfo, error) {
+	clientset, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	ei := &types.EnvironmentInfo{CloudProvider: types.CloudProviderUnknown}
+	nodesErr := s.fromNodes(ctx, clientset, ei)
+	configMapErr := s.fromConfigMap(ctx, clientset, ei)
+	if nodesErr != nil && configMapErr != nil {
+		return nil, errors.Join(nodesErr, configMapErr)
+	}
+	return ei, nil
+}
+
+// fromNodes sets the cloud provider, region and account ID derivable from the
+// nodes of the cluster.
+func (s *Scout) fromNodes(ctx context.Context, clientset kubernetes.Interface, ei *types.EnvironmentInfo) error {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: nodeListLimit})
+	if err != nil {
+		return fmt.Errorf("failed to list the nodes: %w", err)
+	}
+
+	for _, node := range nodes.Items {
+		if ei.CloudProvider == types.CloudProviderUnknown && node.Spec.ProviderID != "" {
+			ei.CloudProvider, ei.AccountID = ParseProviderID(node.Spec.ProviderID)
+		}
+		if ei.Region == "" {
+			ei.Region = nodeRegion(&node)
+		}
+	}
+	return nil
+}
+
+// fromConfigMap overrides the environment with the non-empty values of the
+// identity ConfigMap. A ConfigMap which is not configured, or does not exist,
+// is not an error.
+func (s *Scout) fromConfigMap(ctx context.Context, clientset kubernetes.Interface, ei *types.EnvironmentInfo) error {
+	if s.configMapName == "" {
+		return errors.New("no identity ConfigMap configured")
+	}
+
+	cm, err := clientset.CoreV1().ConfigMaps(s.configMapNamespace).Get(ctx, s.configMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get the identity ConfigMap %s/%s: %w", s.configMapNamespace, s.configMapName, err)
+	}
+
+	if v := strings.TrimSpace(cm.Data[KeyCloudProvider]); v != "" {
+		ei.CloudProvider = types.CloudProvider(strings.ToLower(v))
+	}
+	for key, field := range map[string]*string{
+		KeyRegion:      &ei.Region,
+		KeyAccountID:   &ei.AccountID,
+		KeyClusterName: &ei.ClusterName,
+	} {
+		if v := strings.TrimSpace(cm.Data[key]); v != "" {
+			*field = v
+		}
+	}
+	return nil
+}
+
+func (s *Scout) client() (kubernetes.Interface, error) {
+	if s.clientset == nil {
+		clientset, err := s.newClientset()
+		if err != nil {
+			return nil, err
+		}
+		s.clientset = clientset
+	}
+	return s.clientset, nil
+}
+
+// ParseProviderID returns the cloud provider of a node providerID, and the
+// account ID when the providerID holds one. Only Azure providerIDs, which
+// hold the subscription ID, do.
+func ParseProviderID(providerID string) (types.CloudProvider, string) {
+	scheme, rest, ok := strings.Cut(providerID, "://")
+	if !ok {
+		return types.CloudProviderUnknown, ""
+	}
+	provider, ok := providerIDSchemes[strings.ToLower(scheme)]
+	if !ok {
+		return types.CloudProviderUnknown, ""
+	}
+
+	// azure:///subscriptions/<subscription>/resourceGroups/<group>/...
+	if provider == types.CloudProviderAzure {
+		parts := strings.Split(strings.Trim(rest, "/"), "/")
+		if len(parts) >= 2 && strings.EqualFold(parts[0], "subscriptions") {
+			return provider, parts[1]
+		}
+	}
+	return provider, ""
+}
+
+// nodeRegion returns the region label of a node, preferring the stable label
+// over the deprecated one.
+func nodeRegion(node *corev1.Node) string {
+	if region := node.Labels[corev1.LabelTopologyRegion]; region != "" {
+		return region
+	}
+	return node.Labels[corev1.LabelFailureDomainBetaRegion]
+}
+
+// configMapFromEnv returns the identity ConfigMap named by
+// IdentityConfigMapEnv.
+func configMapFromEnv() (string, string) {
+	value := strings.TrimSpace(os.Getenv(IdentityConfigMapEnv))
+	if namespace, name, ok := strings.Cut(value, "/"); ok {
+		return namespace, name
+	}
+	return os.Getenv(namespaceEnv), value
+}
+
+func inClusterClientset() (kubernetes.Interface, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("not running in a cluster: %w", err)
+	}
+	cfg.Timeout = requestTimeout
+	return kubernetes.NewForConfig(cfg)
+}
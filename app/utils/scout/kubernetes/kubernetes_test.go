@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
+)
+
+func node(name, providerID string, labels map[string]string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       corev1.NodeSpec{ProviderID: providerID},
+	}
+}
+
+func identityConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cza", Name: "identity"},
+		Data:       data,
+	}
+}
+
+func TestParseProviderID(t *testing.T) {
+	tests := []struct {
+		providerID string
+		provider   types.CloudProvider
+		accountID  string
+	}{
+		{"aws:///us-east-1a/i-0123456789abcdef0", types.CloudProviderAWS, ""},
+		{"gce://my-project/us-central1-a/gke-node", types.CloudProviderGoogle, ""},
+		{"azure:///subscriptions/0000-1111/resourceGroups/mc_rg/providers/Microsoft.Compute/virtualMachineScaleSets/aks/virtualMachines/0", types.CloudProviderAzure, "0000-1111"},
+		{"hcloud://42", types.CloudProviderHetzner, ""},
+		{"digitalocean://2756294", types.CloudProviderDigitalOcean, ""},
+		{"openstack:///d8e02d56-2648-49a3-bf97-6be8f1204f38", types.CloudProviderOpenStack, ""},
+		{"vsphere://4201f6e6-2bd4-4b4d-9bd3-0c1f6e2c0a4b", types.CloudProviderVSphere, ""},
+		{"equinixmetal://a1b2c3", types.CloudProviderEquinix, ""},
+		{"kind://docker/kind/kind-control-plane", types.CloudProviderUnknown, ""},
+		{"cn-hangzhou.i-bp67acfmxazb4ph2hxyz", types.CloudProviderUnknown, ""},
+		{"", types.CloudProviderUnknown, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.providerID, func(t *testing.T) {
+			provider, accountID := ParseProviderID(tt.providerID)
+			assert.Equal(t, tt.provider, provider)
+			assert.Equal(t, tt.accountID, accountID)
+		})
+	}
+}
+
+func TestScout_EnvironmentInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		objects  []runtime.Object
+		expected *types.EnvironmentInfo
+	}{
+		{
+			name: "derived from the nodes",
+			objects: []runtime.Object{
+				node("control-plane", "", nil),
+				node("worker", "hcloud://42", map[string]string{corev1.LabelTopologyRegion: "fsn1"}),
+			},
+			expected: &types.EnvironmentInfo{CloudProvider: types.CloudProviderHetzner, Region: "fsn1"},
+		},
+		{
+			name: "deprecated region label",
+			objects: []runtime.Object{
+				node("worker", "vsphere://4201f6e6", map[string]string{corev1.LabelFailureDomainBetaRegion: "dc-east"}),
+			},
+			expected: &types.EnvironmentInfo{CloudProvider: types.CloudProviderVSphere, Region: "dc-east"},
+		},
+		{
+			name: "completed by the identity ConfigMap",
+			objects: []runtime.Object{
+				node("worker", "vsphere://4201f6e6", map[string]string{corev1.LabelTopologyRegion: "dc-east"}),
+				identityConfigMap(map[string]string{KeyAccountID: "on-prem-1", KeyClusterName: "prod"}),
+			},
+			expected: &types.EnvironmentInfo{CloudProvider: types.CloudProviderVSphere, Region: "dc-east", AccountID: "on-prem-1", ClusterName: "prod"},
+		},
+		{
+			name: "bare metal identity ConfigMap",
+			objects: []runtime.Object{
+				node("worker", "", nil),
+				identityConfigMap(map[string]string{KeyCloudProvider: "OnPrem", KeyRegion: "dc-west", KeyAccountID: "on-prem-1", KeyClusterName: "prod"}),
+			},
+			expected: &types.EnvironmentInfo{CloudProvider: "onprem", Region: "dc-west", AccountID: "on-prem-1", ClusterName: "prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scout := NewScout(WithClientset(fake.NewSimpleClientset(tt.objects...)), WithConfigMap("cza", "identity"))
+
+			detected, err := scout.Detect(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected.CloudProvider, detected)
+
+			ei, err := scout.EnvironmentInfo(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ei)
+		})
+	}
+}
+
+func TestScout_NotDetected(t *testing.T) {
+	t.Run("unknown providerID", func(t *testing.T) {
+		scout := NewScout(WithClientset(fake.NewSimpleClientset(node("kind", "kind://docker/kind/kind", nil))), WithConfigMap("", ""))
+
+		detected, err := scout.Detect(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, types.CloudProviderUnknown, detected)
+
+		_, err = scout.EnvironmentInfo(context.Background())
+		assert.ErrorContains(t, err, KeyCloudProvider)
+	})
+
+	t.Run("not in a cluster", func(t *testing.T) {
+		scout := NewScout()
+		scout.newClientset = func() (kubernetes.Interface, error) { return nil, errors.New("not running in a cluster") }
+
+		detected, err := scout.Detect(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, types.CloudProviderUnknown, detected)
+	})
+
+	t.Run("nodes forbidden", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(identityConfigMap(map[string]string{KeyRegion: "dc-west"}))
+		clientset.PrependReactor("list", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("forbidden")
+		})
+		scout := NewScout(WithClientset(clientset), WithConfigMap("cza", "identity"))
+
+		_, err := scout.EnvironmentInfo(context.Background())
+		assert.ErrorContains(t, err, KeyCloudProvider)
+	})
+}
+
+func TestConfigMapFromEnv(t *testing.T) {
+	t.Setenv(namespaceEnv, "cza")
+
+	t.Setenv(IdentityConfigMapEnv, "other/identity")
+	namespace, name := configMapFromEnv()
+	assert.Equal(t, "other", namespace)
+	assert.Equal(t, "identity", name)
+
+	t.Setenv(IdentityConfigMapEnv, "identity")
+	namespace, name = configMapFromEnv()
+	assert.Equal(t, "cza", namespace)
+	assert.Equal(t, "identity", name)
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package openstack provides OpenStack environment detection and metadata
+// retrieval using the Nova metadata service.
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
+)
+
+const (
+	// metadataURL is the OpenStack flavoured instance metadata document.
+	// https://docs.openstack.org/nova/latest/user/metadata.html
+	metadataURL = "http://169.254.169.254/openstack/latest/meta_data.json"
+
+	requestTimeout = 5 * time.Second
+)
+
+// Scout detects and describes an OpenStack environment.
+type Scout struct {
+	client *http.Client
+}
+
+// instanceMetadata is the subset of the OpenStack metadata we consume.
+type instanceMetadata struct {
+	// UUID is the ID of the instance.
+	UUID string `json:"uuid"`
+	// ProjectID is the ID of the project owning the instance, served since
+	// the Liberty release.
+	ProjectID string `json:"project_id"`
+	// Meta holds the user-defined properties of the instance.
+	Meta map[string]string `json:"meta"`
+}
+
+// regionMetaKey is the instance property read as the region, since OpenStack
+// does not expose the region of an instance in its metadata.
+const regionMetaKey = "region"
+
+// NewScout creates a new OpenStack metadata scout.
+func NewScout() *Scout {
+	return &Scout{
+		client: &http.Client{
+			Timeout: requestTimeout,
+		},
+	}
+}
+
+// fetchMetadata retrieves and parses the OpenStack metadata document.
+func (s *Scout) fetchMetadata(ctx context.Context) (*instanceMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OpenStack metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get OpenStack metadata, status: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenStack metadata response: %w", err)
+	}
+
+	var md instanceMetadata
+	if err := json.Unmarshal(body, &md); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenStack metadata JSON: %w", err)
+	}
+
+	return &md, nil
+}
+
+// Detect determines whether the current environment is running on OpenStack
+// by querying the metadata service. Network failures, non-200 responses, and
+// unparseable bodies are treated as "not OpenStack" rather than errors.
+func (s *Scout) Detect(ctx context.Context) (types.CloudProvider, error) {
+	md, err := s.fetchMetadata(ctx)
+	if err != nil {
+		return types.CloudProviderUnknown, nil //nolint:nilerr // inability to reach/parse the metadata means "not OpenStack", not a hard error
+	}
+
+	if md.UUID != "" {
+		return types.CloudProviderOpenStack, nil
+	}
+
+	return types.CloudProviderUnknown, nil
+}
+
+// EnvironmentInfo retrieves OpenStack environment information from the
+// metadata service. The project ID is used as the account ID.
+//
+// OpenStack does not expose the region of an instance, so it is only set when
+// the instance has a "region" property; otherwise it is left empty and must be
+// configured manually (region in the Helm chart).
+func (s *Scout) EnvironmentInfo(ctx context.Context) (*types.EnvironmentInfo, error) {
+	md, err := s.fetchMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.EnvironmentInfo{
+		CloudProvider: types.CloudProviderOpenStack,
+		Region:        strings.TrimSpace(md.Meta[regionMetaKey]),
+		AccountID:     strings.TrimSpace(md.ProjectID),
+	}, nil
+}
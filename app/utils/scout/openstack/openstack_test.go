@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package openstack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
+)
+
+// validMetadataResponse is a representative subset of the OpenStack metadata
+// document.
+const validMetadataResponse = `{
+	"uuid": "d8e02d56-2648-49a3-bf97-6be8f1204f38",
+	"name": "worker-1",
+	"availability_zone": "nova",
+	"project_id": "f7ac731cc11f40efbc03a9f9e1d1d21f",
+	"launch_index": 0,
+	"meta": {"region": "RegionOne"}
+}`
+
+func TestScout_Detect(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		expected   types.CloudProvider
+	}{
+		{name: "instance", statusCode: http.StatusOK, body: validMetadataResponse, expected: types.CloudProviderOpenStack},
+		{name: "not found", statusCode: http.StatusNotFound, expected: types.CloudProviderUnknown},
+		{name: "ok but not OpenStack metadata", statusCode: http.StatusOK, body: `{"foo": "bar"}`, expected: types.CloudProviderUnknown},
+		{name: "ok but invalid json", statusCode: http.StatusOK, body: `{not json`, expected: types.CloudProviderUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/openstack/latest/meta_data.json", r.URL.Path)
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			result, err := createScoutWithCustomURL(server.URL).Detect(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestScout_Detect_NetworkError(t *testing.T) {
+	result, err := createScoutWithCustomURL("http://192.0.2.1").Detect(context.Background()) // RFC 5737 TEST-NET-1
+	assert.NoError(t, err)
+	assert.Equal(t, types.CloudProviderUnknown, result)
+}
+
+func TestScout_EnvironmentInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected *types.EnvironmentInfo
+	}{
+		{
+			name: "with region property",
+			body: validMetadataResponse,
+			expected: &types.EnvironmentInfo{
+				CloudProvider: types.CloudProviderOpenStack,
+				Region:        "RegionOne",
+				AccountID:     "f7ac731cc11f40efbc03a9f9e1d1d21f",
+			},
+		},
+		{
+			// the region must then be configured manually
+			name: "without region property",
+			body: `{"uuid": "d8e02d56", "project_id": "f7ac731c"}`,
+			expected: &types.EnvironmentInfo{
+				CloudProvider: types.CloudProviderOpenStack,
+				AccountID:     "f7ac731c",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			result, err := createScoutWithCustomURL(server.URL).EnvironmentInfo(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestScout_EnvironmentInfo_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          string
+		errorContains string
+	}{
+		{name: "non-200 status", statusCode: http.StatusInternalServerError, errorContains: "status: 500"},
+		{name: "invalid JSON", statusCode: http.StatusOK, body: `{invalid`, errorContains: "failed to parse OpenStack metadata JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			result, err := createScoutWithCustomURL(server.URL).EnvironmentInfo(context.Background())
+			assert.ErrorContains(t, err, tt.errorContains)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func createScoutWithCustomURL(baseURL string) *Scout {
+	return &Scout{
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: &customTransport{baseURL: baseURL},
+		},
+	}
+}
+
+// customTransport redirects metadata requests to a test server
+type customTransport struct {
+	baseURL string
+}
+
+func (t *customTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	newReq := req.Clone(req.Context())
+	var err error
+	newReq.URL, err = newReq.URL.Parse(strings.Replace(req.URL.String(), "http://169.254.169.254", t.baseURL, 1))
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultTransport.RoundTrip(newReq)
+}
@@ -6,20 +6,36 @@
 package scout
 
 import (
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/alibaba"
 	"github.com/cloudzero/cloudzero-agent/app/utils/scout/auto"
 	"github.com/cloudzero/cloudzero-agent/app/utils/scout/aws"
 	"github.com/cloudzero/cloudzero-agent/app/utils/scout/azure"
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/digitalocean"
 	"github.com/cloudzero/cloudzero-agent/app/utils/scout/google"
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/hetzner"
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/kubernetes"
+	"github.com/cloudzero/cloudzero-agent/app/utils/scout/openstack"
 	"github.com/cloudzero/cloudzero-agent/app/utils/scout/oracle"
 	"github.com/cloudzero/cloudzero-agent/app/utils/scout/types"
 )
 
 // NewScout creates a new Scout implementation with auto-detection capabilities.
+//
+// The metadata services of the cloud providers are queried concurrently. The
+// Kubernetes API is only used when none of them answered, since it cannot tell
+// the account ID of most providers.
 func NewScout() types.Scout {
-	return auto.NewScout(
-		aws.NewScout(),
-		azure.NewScout(),
-		google.NewScout(),
-		oracle.NewScout(),
+	return auto.NewSequentialScout(
+		auto.NewScout(
+			aws.NewScout(),
+			azure.NewScout(),
+			google.NewScout(),
+			oracle.NewScout(),
+			digitalocean.NewScout(),
+			hetzner.NewScout(),
+			openstack.NewScout(),
+			alibaba.NewScout(),
+		),
+		kubernetes.NewScout(),
 	)
 }
@@ -17,6 +17,18 @@ const (
 	CloudProviderAzure CloudProvider = "azure"
 	// CloudProviderOCI represents Oracle Cloud Infrastructure (OCI/OKE)
 	CloudProviderOCI CloudProvider = "oracle"
+	// CloudProviderDigitalOcean represents DigitalOcean (DOKS)
+	CloudProviderDigitalOcean CloudProvider = "digitalocean"
+	// CloudProviderHetzner represents Hetzner Cloud
+	CloudProviderHetzner CloudProvider = "hetzner"
+	// CloudProviderOpenStack represents an OpenStack cloud
+	CloudProviderOpenStack CloudProvider = "openstack"
+	// CloudProviderAlibaba represents Alibaba Cloud (ACK)
+	CloudProviderAlibaba CloudProvider = "alibaba"
+	// CloudProviderVSphere represents VMware vSphere
+	CloudProviderVSphere CloudProvider = "vsphere"
+	// CloudProviderEquinix represents Equinix Metal
+	CloudProviderEquinix CloudProvider = "equinix"
 	// CloudProviderUnknown represents an undetected or unsupported cloud provider
 	CloudProviderUnknown CloudProvider = "unknown"
 	// CloudProviderMock represents a mock provider for testing
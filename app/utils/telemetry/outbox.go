@@ -0,0 +1,522 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	net "net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	http "github.com/cloudzero/cloudzero-agent/app/http/client"
+	logging "github.com/cloudzero/cloudzero-agent/app/logging/validator"
+	"github.com/cloudzero/cloudzero-agent/app/types"
+	pb "github.com/cloudzero/cloudzero-agent/app/types/status"
+	"github.com/cloudzero/cloudzero-agent/app/utils"
+)
+
+// Reasons a status report is dropped from the outbox.
+const (
+	DropReasonCapacity = "capacity" // the outbox is full; the oldest report is dropped
+	DropReasonExpired  = "expired"  // the report was not posted within outbox.max_age
+	DropReasonRejected = "rejected" // the CloudZero API refused the report
+	DropReasonSpool    = "spool"    // the report could not be written to the outbox
+	DropReasonCorrupt  = "corrupt"  // the report in the outbox could not be read
+)
+
+// entryKeyChecks is the outbox key of reports without a lifecycle stage, such
+// as the check changes posted by the daemon.
+const entryKeyChecks = "checks"
+
+var (
+	outboxPending = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: types.ObservabilityMetric("validator_telemetry_outbox_pending"),
+			Help: "Number of status reports waiting in the outbox to be posted",
+		},
+	)
+	outboxDropped = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: types.ObservabilityMetric("validator_telemetry_outbox_dropped_total"),
+			Help: "Number of status reports dropped from the outbox without being posted",
+		},
+		[]string{"reason"},
+	)
+
+	registerOutboxMetricsOnce sync.Once
+)
+
+// PostFunc posts a status report to CloudZero.
+type PostFunc func(ctx context.Context, client *net.Client, cfg *config.Settings, accessor pb.Accessor) error
+
+// entry is a status report kept in the outbox, one file per entry.
+type entry struct {
+	Key         string    `json:"key"`
+	Report      []byte    `json:"report"`
+	Attempts    int       `json:"attempts"`
+	Created     time.Time `json:"created"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	LastError   string    `json:"lastError,omitempty"`
+
+	size int64
+}
+
+// Outbox posts status reports to CloudZero, and keeps those which could not be
+// posted for a reason which may be temporary, such as the API being
+// unreachable while a proxy is still starting. Kept reports are retried with
+// exponential backoff, both by the next Post and in the background while the
+// outbox runs.
+//
+// Reports are kept in the directory of outbox.directory, so a report kept by
+// one run of the validator is retried by the next. There is at most one
+// report per lifecycle stage: a newer report of a stage replaces the older
+// one, keeping the checks which only the older one has.
+//
+// Only a running outbox retries reports between runs, and only the diagnostics
+// daemon runs one. The lifecycle hooks of the agent post once and exit, so
+// without a daemon sharing the directory, a kept report waits for the next
+// hook of the same pod, and the report of the last hook is never retried.
+type Outbox struct {
+	cfg    *config.Settings
+	client *net.Client
+	post   PostFunc
+	clock  types.TimeProvider
+	logger *logrus.Entry
+
+	// spoolMu serializes access to the outbox directory.
+	spoolMu sync.Mutex
+
+	mu          sync.Mutex
+	running     bool
+	originalCtx context.Context
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+var _ types.Runnable = (*Outbox)(nil)
+
+// OutboxOption configures an Outbox.
+type OutboxOption func(*Outbox)
+
+// WithPoster sets the function posting reports, telemetry.Post by default.
+func WithPoster(post PostFunc) OutboxOption {
+	return func(o *Outbox) { o.post = post }
+}
+
+// WithClock sets the clock used to schedule retries.
+func WithClock(clock types.TimeProvider) OutboxOption {
+	return func(o *Outbox) { o.clock = clock }
+}
+
+// NewOutbox creates an outbox posting reports with the client. Reports are
+// posted once, without retries, when the outbox is disabled or has no
+// directory.
+func NewOutbox(ctx context.Context, cfg *config.Settings, client *net.Client, opts ...OutboxOption) *Outbox {
+	registerOutboxMetricsOnce.Do(func() {
+		prometheus.MustRegister(outboxPending, outboxDropped)
+	})
+
+	if client == nil {
+		client = net.DefaultClient
+	}
+	o := &Outbox{
+		cfg:         cfg,
+		client:      client,
+		post:        Post,
+		clock:       &utils.Clock{},
+		originalCtx: ctx,
+		logger: logging.NewLogger().
+			WithContext(ctx).WithField(logging.OpField, "outbox"),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func (o *Outbox) enabled() bool {
+	return !o.cfg.Outbox.Disabled && o.cfg.Outbox.Directory != ""
+}
+
+// Post has the signature of telemetry.Post, so it can be used in its place. It
+// first retries the reports due in the outbox, then posts the report. When
+// the report cannot be posted for a reason which may be temporary, it is kept
+// in the outbox and nil is returned.
+//
+// Kept reports are retried with the client and settings of the outbox.
+func (o *Outbox) Post(ctx context.Context, client *net.Client, cfg *config.Settings, accessor pb.Accessor) error {
+	if !o.enabled() {
+		return o.post(ctx, client, cfg, accessor)
+	}
+
+	if err := o.Flush(ctx); err != nil {
+		o.logger.WithError(err).Debug("outbox is not yet flushed")
+	}
+
+	err := o.post(ctx, client, cfg, accessor)
+	if err == nil || !retryable(err) {
+		return err
+	}
+
+	if spoolErr := o.enqueue(accessor, err); spoolErr != nil {
+		outboxDropped.WithLabelValues(DropReasonSpool).Inc()
+		return fmt.Errorf("%w (failed to keep the report for retry: %v)", err, spoolErr)
+	}
+	o.logger.WithError(err).Warn("failed to post status, kept in the outbox for retry")
+	return nil
+}
+
+// Flush posts the reports of the outbox which are due, in the order they were
+// kept. It stops at the first report which cannot be posted for a reason which
+// may be temporary, and returns the error.
+func (o *Outbox) Flush(ctx context.Context) error {
+	if !o.enabled() {
+		return nil
+	}
+
+	o.spoolMu.Lock()
+	defer o.spoolMu.Unlock()
+
+	entries, err := o.list()
+	if err != nil {
+		return err
+	}
+	defer func() { o.updatePending() }()
+
+	now := o.clock.GetCurrentTime()
+	for _, e := range entries {
+		if now.Sub(e.Created) > o.cfg.Outbox.MaxAge {
+			o.drop(e, DropReasonExpired)
+			continue
+		}
+		if e.NextAttempt.After(now) {
+			continue
+		}
+
+		report := &pb.ClusterStatus{}
+		if err := proto.Unmarshal(e.Report, report); err != nil {
+			o.drop(e, DropReasonCorrupt)
+			continue
+		}
+
+		err := o.post(ctx, o.client, o.cfg, pb.NewAccessor(report))
+		switch {
+		case err == nil:
+			o.remove(e)
+			o.logger.WithFields(logrus.Fields{"key": e.Key, "attempts": e.Attempts + 1}).
+				Info("posted status from the outbox")
+		case !retryable(err):
+			o.logger.WithError(err).WithField("key", e.Key).Warn("status in the outbox was rejected")
+			o.drop(e, DropReasonRejected)
+		default:
+			e.Attempts++
+			e.NextAttempt = now.Add(o.backoff(e.Attempts))
+			e.LastError = err.Error()
+			if writeErr := o.write(e); writeErr != nil {
+				o.logger.WithError(writeErr).Error("failed to update the outbox")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of reports in the outbox.
+func (o *Outbox) Pending() (int, error) {
+	if !o.enabled() {
+		return 0, nil
+	}
+
+	o.spoolMu.Lock()
+	defer o.spoolMu.Unlock()
+
+	entries, err := o.list()
+	return len(entries), err
+}
+
+// Run retries the reports of the outbox in the background until Shutdown.
+func (o *Outbox) Run() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.running || !o.enabled() {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(o.originalCtx)
+	o.cancel = cancel
+	o.done = make(chan struct{})
+	o.running = true
+
+	go func(done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(o.cfg.Outbox.InitialBackoff)
+		defer ticker.Stop()
+		for {
+			if err := o.Flush(ctx); err != nil && ctx.Err() == nil {
+				o.logger.WithError(err).Debug("outbox is not yet flushed")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}(o.done)
+	return nil
+}
+
+func (o *Outbox) IsRunning() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.running
+}
+
+// Shutdown stops retrying in the background. Reports still in the outbox are
+// kept for the next run.
+func (o *Outbox) Shutdown() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.running {
+		return nil
+	}
+	o.cancel()
+	<-o.done
+	o.running = false
+	return nil
+}
+
+// enqueue keeps a report which could not be posted, replacing the report of
+// the same stage, and then drops the oldest reports beyond the capacity of
+// the outbox.
+func (o *Outbox) enqueue(accessor pb.Accessor, cause error) error {
+	var report *pb.ClusterStatus
+	accessor.ReadFromReport(func(cs *pb.ClusterStatus) {
+		report = proto.Clone(cs).(*pb.ClusterStatus)
+	})
+
+	o.spoolMu.Lock()
+	defer o.spoolMu.Unlock()
+	defer o.updatePending()
+
+	if err := os.MkdirAll(o.cfg.Outbox.Directory, 0o700); err != nil {
+		return fmt.Errorf("failed to create the outbox directory: %w", err)
+	}
+
+	key := entryKey(report)
+	entries, err := o.list()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Key != key {
+			continue
+		}
+		previous := &pb.ClusterStatus{}
+		if err := proto.Unmarshal(e.Report, previous); err == nil {
+			report = merge(previous, report)
+		}
+	}
+
+	data, err := proto.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the report: %w", err)
+	}
+	now := o.clock.GetCurrentTime()
+	if err := o.write(&entry{
+		Key:         key,
+		Report:      data,
+		Attempts:    1,
+		Created:     now,
+		NextAttempt: now.Add(o.backoff(1)),
+		LastError:   cause.Error(),
+	}); err != nil {
+		return err
+	}
+
+	return o.trim()
+}
+
+// trim drops the oldest reports until the outbox is within its capacity.
+func (o *Outbox) trim() error {
+	entries, err := o.list()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	for len(entries) > 0 && (len(entries) > o.cfg.Outbox.MaxEntries || total > o.cfg.Outbox.MaxBytes) {
+		o.logger.WithField("key", entries[0].Key).Warn("outbox is full, dropping the oldest status")
+		o.drop(entries[0], DropReasonCapacity)
+		total -= entries[0].size
+		entries = entries[1:]
+	}
+	return nil
+}
+
+// list reads the reports of the outbox, oldest first. Files which cannot be
+// read are dropped.
+func (o *Outbox) list() ([]*entry, error) {
+	files, err := os.ReadDir(o.cfg.Outbox.Directory)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the outbox: %w", err)
+	}
+
+	var entries []*entry
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(o.cfg.Outbox.Directory, f.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the outbox: %w", err)
+		}
+		e := &entry{}
+		if err := json.Unmarshal(data, e); err != nil || e.Key+".json" != f.Name() {
+			_ = os.Remove(path)
+			outboxDropped.WithLabelValues(DropReasonCorrupt).Inc()
+			continue
+		}
+		e.size = int64(len(data))
+		entries = append(entries, e)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Created.Before(entries[j].Created) })
+	return entries, nil
+}
+
+// write atomically writes an entry to the outbox.
+func (o *Outbox) write(e *entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode the outbox entry: %w", err)
+	}
+
+	path := o.path(e.Key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write the outbox entry: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write the outbox entry: %w", err)
+	}
+	e.size = int64(len(data))
+	return nil
+}
+
+func (o *Outbox) remove(e *entry) {
+	if err := os.Remove(o.path(e.Key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		o.logger.WithError(err).WithField("key", e.Key).Error("failed to remove the outbox entry")
+	}
+}
+
+func (o *Outbox) drop(e *entry, reason string) {
+	o.remove(e)
+	outboxDropped.WithLabelValues(reason).Inc()
+}
+
+func (o *Outbox) updatePending() {
+	entries, err := o.list()
+	if err == nil {
+		outboxPending.Set(float64(len(entries)))
+	}
+}
+
+func (o *Outbox) path(key string) string {
+	return filepath.Join(o.cfg.Outbox.Directory, key+".json")
+}
+
+// backoff returns the delay before the given attempt, doubling from the
+// initial backoff up to the maximum.
+func (o *Outbox) backoff(attempts int) time.Duration {
+	delay := o.cfg.Outbox.InitialBackoff
+	for i := 1; i < attempts && delay < o.cfg.Outbox.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, o.cfg.Outbox.MaxBackoff)
+}
+
+// entryKey returns the key reports are deduplicated by: their lifecycle
+// stage, if any.
+func entryKey(report *pb.ClusterStatus) string {
+	if report.State == pb.StatusType_STATUS_TYPE_UNSPECIFIED {
+		return entryKeyChecks
+	}
+	return strings.ToLower(strings.TrimPrefix(report.State.String(), "STATUS_TYPE_"))
+}
+
+// merge returns the newer report, with the checks which only the older one
+// has.
+func merge(older, newer *pb.ClusterStatus) *pb.ClusterStatus {
+	seen := make(map[string]bool, len(newer.Checks))
+	for _, c := range newer.Checks {
+		seen[c.Name] = true
+	}
+	var checks []*pb.StatusCheck
+	for _, c := range older.Checks {
+		if !seen[c.Name] {
+			checks = append(checks, c)
+		}
+	}
+	newer.Checks = append(checks, newer.Checks...)
+	return newer
+}
+
+// permanentErrors are the responses of the CloudZero API for which posting the
+// same report again cannot succeed.
+var permanentErrors = []error{
+	http.ErrStatusBadRequest,
+	http.ErrStatusUnauthorized,
+	http.ErrStatusPaymentRequired,
+	http.ErrStatusForbidden,
+	http.ErrStatusNotFound,
+	http.ErrStatusMethodNotAllowed,
+	http.ErrStatusNotAcceptable,
+	http.ErrStatusGone,
+	http.ErrStatusRequestEntityTooLarge,
+	http.ErrStatusUnsupportedMediaType,
+	http.ErrStatusUnprocessableEntity,
+}
+
+// retryable returns true if posting a report failed for a reason which may be
+// temporary: the request did not reach the CloudZero API, or it responded
+// with an error which is not about the report itself.
+func retryable(err error) bool {
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	for code := net.StatusInternalServerError; code <= net.StatusNetworkAuthenticationRequired; code++ {
+		if target := http.ToError(code); target != nil && errors.Is(err, target) {
+			return true
+		}
+	}
+	return errors.Is(err, http.ErrStatusRequestTimeout) || errors.Is(err, http.ErrStatusTooManyRequests)
+}
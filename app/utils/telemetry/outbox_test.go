@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package telemetry_test
+
+import (
+	"context"
+	"io"
+	net "net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
+	pb "github.com/cloudzero/cloudzero-agent/app/types/status"
+	"github.com/cloudzero/cloudzero-agent/app/utils/telemetry"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) GetCurrentTime() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// statusAPI is a CloudZero API answering status posts with a code, recording
+// the reports it accepted.
+type statusAPI struct {
+	mu       sync.Mutex
+	code     int
+	requests int
+	accepted []*pb.ClusterStatus
+}
+
+func (a *statusAPI) setCode(code int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.code = code
+}
+
+func (a *statusAPI) ServeHTTP(w net.ResponseWriter, r *net.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.requests++
+	if a.code != net.StatusOK {
+		w.WriteHeader(a.code)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	report := &pb.ClusterStatus{}
+	if err := proto.Unmarshal(body, report); err != nil {
+		w.WriteHeader(net.StatusBadRequest)
+		return
+	}
+	a.accepted = append(a.accepted, report)
+	w.WriteHeader(net.StatusOK)
+}
+
+func newOutbox(t *testing.T, api *statusAPI, maxEntries int) (*telemetry.Outbox, *config.Settings, *fakeClock) {
+	t.Helper()
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	cfg := &config.Settings{
+		Deployment: config.Deployment{AccountID: Account, Region: Region, ClusterName: Name},
+		Cloudzero:  config.Cloudzero{Host: server.URL, Credential: "key"},
+		Outbox:     config.Outbox{Directory: t.TempDir(), MaxEntries: maxEntries},
+	}
+	require.NoError(t, cfg.Outbox.Validate())
+
+	clock := &fakeClock{now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}
+	return telemetry.NewOutbox(context.Background(), cfg, server.Client(), telemetry.WithClock(clock)), cfg, clock
+}
+
+func report(state pb.StatusType, checks ...*pb.StatusCheck) pb.Accessor {
+	return pb.NewAccessor(&pb.ClusterStatus{Account: Account, Region: Region, Name: Name, State: state, Checks: checks})
+}
+
+func pending(t *testing.T, o *telemetry.Outbox) int {
+	t.Helper()
+	n, err := o.Pending()
+	require.NoError(t, err)
+	return n
+}
+
+func TestOutbox_RetriesWithBackoff(t *testing.T) {
+	api := &statusAPI{code: net.StatusServiceUnavailable}
+	o, cfg, clock := newOutbox(t, api, 0)
+	ctx := context.Background()
+
+	require.NoError(t, o.Post(ctx, nil, cfg, report(pb.StatusType_STATUS_TYPE_INIT_STARTED)))
+	assert.Equal(t, 1, pending(t, o))
+	assert.Equal(t, 1, api.requests)
+
+	// not due before the initial backoff
+	require.NoError(t, o.Flush(ctx))
+	assert.Equal(t, 1, api.requests)
+
+	// the second attempt fails, and doubles the backoff
+	clock.Advance(cfg.Outbox.InitialBackoff)
+	assert.Error(t, o.Flush(ctx))
+	assert.Equal(t, 2, api.requests)
+
+	api.setCode(net.StatusOK)
+	clock.Advance(cfg.Outbox.InitialBackoff)
+	require.NoError(t, o.Flush(ctx))
+	assert.Equal(t, 2, api.requests)
+
+	clock.Advance(cfg.Outbox.InitialBackoff)
+	require.NoError(t, o.Flush(ctx))
+	assert.Equal(t, 3, api.requests)
+	assert.Equal(t, 0, pending(t, o))
+	require.Len(t, api.accepted, 1)
+	assert.Equal(t, pb.StatusType_STATUS_TYPE_INIT_STARTED, api.accepted[0].State)
+}
+
+func TestOutbox_FlushedByNextPost(t *testing.T) {
+	api := &statusAPI{code: net.StatusBadGateway}
+	o, cfg, clock := newOutbox(t, api, 0)
+	ctx := context.Background()
+
+	require.NoError(t, o.Post(ctx, nil, cfg, report(pb.StatusType_STATUS_TYPE_INIT_STARTED)))
+	api.setCode(net.StatusOK)
+	clock.Advance(cfg.Outbox.InitialBackoff)
+
+	// e.g. the post-start hook of the next container
+	require.NoError(t, o.Post(ctx, nil, cfg, report(pb.StatusType_STATUS_TYPE_POD_STARTED)))
+	assert.Equal(t, 0, pending(t, o))
+	require.Len(t, api.accepted, 2)
+	assert.Equal(t, pb.StatusType_STATUS_TYPE_INIT_STARTED, api.accepted[0].State)
+	assert.Equal(t, pb.StatusType_STATUS_TYPE_POD_STARTED, api.accepted[1].State)
+}
+
+func TestOutbox_DeduplicatesByStage(t *testing.T) {
+	api := &statusAPI{code: net.StatusServiceUnavailable}
+	o, cfg, clock := newOutbox(t, api, 0)
+	ctx := context.Background()
+
+	require.NoError(t, o.Post(ctx, nil, cfg, report(pb.StatusType_STATUS_TYPE_INIT_STARTED, Check1, &pb.StatusCheck{Name: "check2", Passing: true})))
+	clock.Advance(time.Millisecond)
+	require.NoError(t, o.Post(ctx, nil, cfg, report(pb.StatusType_STATUS_TYPE_INIT_STARTED, Check2)))
+	clock.Advance(time.Millisecond)
+	require.NoError(t, o.Post(ctx, nil, cfg, report(pb.StatusType_STATUS_TYPE_UNSPECIFIED, Check1)))
+	assert.Equal(t, 2, pending(t, o))
+
+	api.setCode(net.StatusOK)
+	clock.Advance(cfg.Outbox.InitialBackoff)
+	require.NoError(t, o.Flush(ctx))
+	require.Len(t, api.accepted, 2)
+
+	checks := map[string]bool{}
+	for _, c := range api.accepted[0].Checks {
+		checks[c.Name] = c.Passing
+	}
+	assert.Equal(t, map[string]bool{"check1": true, "check2": false}, checks, "the newer report of a stage wins")
+}
+
+func TestOutbox_DropsOldestBeyondCapacity(t *testing.T) {
+	api := &statusAPI{code: net.StatusServiceUnavailable}
+	o, cfg, clock := newOutbox(t, api, 2)
+	ctx := context.Background()
+
+	for _, state := range []pb.StatusType{
+		pb.StatusType_STATUS_TYPE_INIT_STARTED,
+		pb.StatusType_STATUS_TYPE_INIT_OK,
+		pb.StatusType_STATUS_TYPE_POD_STARTED,
+	} {
+		require.NoError(t, o.Post(ctx, nil, cfg, report(state)))
+		clock.Advance(time.Millisecond)
+	}
+	assert.Equal(t, 2, pending(t, o))
+
+	api.setCode(net.StatusOK)
+	clock.Advance(cfg.Outbox.InitialBackoff)
+	require.NoError(t, o.Flush(ctx))
+	require.Len(t, api.accepted, 2)
+	assert.Equal(t, pb.StatusType_STATUS_TYPE_INIT_OK, api.accepted[0].State)
+	assert.Equal(t, pb.StatusType_STATUS_TYPE_POD_STARTED, api.accepted[1].State)
+}
+
+func TestOutbox_DropsExpiredAndRejected(t *testing.T) {
+	api := &statusAPI{code: net.StatusServiceUnavailable}
+	o, cfg, clock := newOutbox(t, api, 0)
+	ctx := context.Background()
+
+	require.NoError(t, o.Post(ctx, nil, cfg, report(pb.StatusType_STATUS_TYPE_INIT_STARTED)))
+	clock.Advance(cfg.Outbox.MaxAge + time.Second)
+	require.NoError(t, o.Flush(ctx))
+	assert.Equal(t, 0, pending(t, o), "an expired report is dropped")
+	assert.Equal(t, 1, api.requests)
+
+	require.NoError(t, o.Post(ctx, nil, cfg, report(pb.StatusType_STATUS_TYPE_INIT_STARTED)))
+	api.setCode(net.StatusUnauthorized)
+	clock.Advance(cfg.Outbox.InitialBackoff)
+	require.NoError(t, o.Flush(ctx))
+	assert.Equal(t, 0, pending(t, o), "a rejected report is dropped")
+
+	// a report the API refuses is not kept
+	assert.Error(t, o.Post(ctx, nil, cfg, report(pb.StatusType_STATUS_TYPE_INIT_OK)))
+	assert.Equal(t, 0, pending(t, o))
+}
+
+func TestOutbox_Disabled(t *testing.T) {
+	api := &statusAPI{code: net.StatusServiceUnavailable}
+	o, cfg, _ := newOutbox(t, api, 0)
+	cfg.Outbox.Disabled = true
+
+	assert.Error(t, o.Post(context.Background(), nil, cfg, report(pb.StatusType_STATUS_TYPE_INIT_STARTED)))
+	assert.Equal(t, 0, pending(t, o))
+
+	require.NoError(t, o.Run())
+	assert.False(t, o.IsRunning())
+}
+
+func TestOutbox_Run(t *testing.T) {
+	api := &statusAPI{code: net.StatusOK}
+	o, _, _ := newOutbox(t, api, 0)
+
+	require.NoError(t, o.Run())
+	assert.True(t, o.IsRunning())
+	require.NoError(t, o.Shutdown())
+	assert.False(t, o.IsRunning())
+}
@@ -16,6 +16,7 @@ import (
 
 	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
 	http "github.com/cloudzero/cloudzero-agent/app/http/client"
+	"github.com/cloudzero/cloudzero-agent/app/types"
 	pb "github.com/cloudzero/cloudzero-agent/app/types/status"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -61,10 +62,12 @@ func Post(ctx context.Context, client *net.Client, cfg *config.Settings, accesso
 	}
 
 	var (
-		err  error
-		data []byte
+		err         error
+		data        []byte
+		fingerprint string
 	)
 	accessor.ReadFromReport(func(cs *pb.ClusterStatus) {
+		fingerprint = cs.ClusterFingerprint
 		data, err = proto.Marshal(cs)
 		logrus.Info("marshalled cluster status: " + strconv.Itoa(len(data)) + " bytes")
 	})
@@ -81,13 +84,18 @@ func Post(ctx context.Context, client *net.Client, cfg *config.Settings, accesso
 
 	logrus.Infof("compressed size is: %d bytes", buf.Len())
 
+	headers := map[string]string{
+		http.HeaderAuthorization: "Bearer " + cfg.Cloudzero.Credential,
+		http.HeaderContentType:   http.ContentTypeProtobuf,
+	}
+	if fingerprint != "" {
+		headers[types.ClusterFingerprintHeader] = fingerprint
+	}
+
 	endpoint := fmt.Sprintf("%s%s", cfg.Cloudzero.Host, URLPath)
 	_, err = http.Do(
 		ctx, client, net.MethodPost,
-		map[string]string{
-			http.HeaderAuthorization: "Bearer " + cfg.Cloudzero.Credential,
-			http.HeaderContentType:   http.ContentTypeProtobuf,
-		},
+		headers,
 		map[string]string{
 			http.QueryParamAccountID:   cfg.Deployment.AccountID,
 			http.QueryParamRegion:      cfg.Deployment.Region,
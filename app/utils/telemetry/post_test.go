@@ -15,6 +15,7 @@ import (
 
 	config "github.com/cloudzero/cloudzero-agent/app/config/validator"
 	http "github.com/cloudzero/cloudzero-agent/app/http/client"
+	"github.com/cloudzero/cloudzero-agent/app/types"
 	pb "github.com/cloudzero/cloudzero-agent/app/types/status"
 	"github.com/cloudzero/cloudzero-agent/app/utils/telemetry"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +34,7 @@ const (
 	AgentVersion     = "0.0.2"
 	ValidatorVersion = "0.0.3"
 	K8SVersion       = "0.0.4"
+	Fingerprint      = "0123456789abcdef0123456789abcdef"
 )
 
 var (
@@ -50,7 +52,10 @@ var (
 )
 
 // global for easier testing
-var serverDecodedStatus pb.ClusterStatus
+var (
+	serverDecodedStatus pb.ClusterStatus
+	serverFingerprint   string
+)
 
 // TestPostStatus tests the PostStatus function.
 func TestPostStatus(t *testing.T) {
@@ -129,6 +134,8 @@ func TestPostStatus(t *testing.T) {
 			assert.Equal(t, ValidatorVersion, serverDecodedStatus.ValidatorVersion)
 			assert.Equal(t, K8SVersion, serverDecodedStatus.K8SVersion)
 			assert.Len(t, serverDecodedStatus.Checks, 2)
+			assert.Equal(t, Fingerprint, serverDecodedStatus.ClusterFingerprint)
+			assert.Equal(t, Fingerprint, serverFingerprint)
 		})
 	}
 }
@@ -165,6 +172,8 @@ func (ts *testHandler) Handler(w net.ResponseWriter, r *net.Request) {
 	}
 	defer r.Body.Close()
 
+	serverFingerprint = r.Header.Get(types.ClusterFingerprintHeader)
+
 	// Unmarshal the Protobuf message
 	err = proto.Unmarshal(data, &serverDecodedStatus)
 	if err != nil {
@@ -180,15 +189,16 @@ func (ts *testHandler) Handler(w net.ResponseWriter, r *net.Request) {
 func createTestClusterStatus(t *testing.T) *pb.ClusterStatus {
 	t.Helper()
 	return &pb.ClusterStatus{
-		Account:          Account,
-		Region:           Region,
-		Name:             Name,
-		State:            State,
-		ChartVersion:     ChartVersion,
-		AgentVersion:     AgentVersion,
-		ScrapeConfig:     ScrapeConfig,
-		ValidatorVersion: ValidatorVersion,
-		K8SVersion:       K8SVersion,
-		Checks:           []*pb.StatusCheck{Check1, Check2},
+		Account:            Account,
+		Region:             Region,
+		Name:               Name,
+		State:              State,
+		ChartVersion:       ChartVersion,
+		AgentVersion:       AgentVersion,
+		ScrapeConfig:       ScrapeConfig,
+		ValidatorVersion:   ValidatorVersion,
+		K8SVersion:         K8SVersion,
+		Checks:             []*pb.StatusCheck{Check1, Check2},
+		ClusterFingerprint: Fingerprint,
 	}
 }
@@ -1059,7 +1059,7 @@ Usage: {{ include "cloudzero-agent.alloy.remoteWrite" . }}
 
 prometheus.remote_write "cloudzero" {
   endpoint {
-    url = "http://{{ include "cloudzero-agent.aggregator.name" . }}.{{ .Release.Namespace }}.svc.cluster.local/collector"
+    url = "http://{{ include "cloudzero-agent.aggregator.collectorHost" . }}/collector"
 
     // Disable metadata - not needed for CloudZero cost metrics
     send_exemplars         = false
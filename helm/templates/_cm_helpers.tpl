@@ -115,6 +115,7 @@ Configuration includes:
 - TLS certificate paths for webhook HTTPS
 - Server settings (port, timeouts, reconnection)
 - Label/annotation filters for resource tracking
+- Cost allocation label policy, when enabled
 
 Usage: {{ include "cloudzero-agent.insightsController.configuration" . }}
 */}}
@@ -155,6 +156,24 @@ filters:
   {{- .Values.insightsController.labels | toYaml | nindent 4 }}
   annotations:
   {{- .Values.insightsController.annotations | toYaml | nindent 4 }}
+{{- if .Values.insightsController.watch.enabled }}
+watch:
+  enabled: true
+  resync_period: {{ .Values.insightsController.watch.resyncPeriod }}
+{{- end }}
+{{- with .Values.insightsController.policy }}
+{{- if ne .mode "disabled" }}
+policy:
+  mode: {{ .mode }}
+  dry_run: {{ .dryRun }}
+  required_labels: {{ .requiredLabels | toYaml | nindent 4 }}
+  namespaces: {{ .namespaces | toYaml | nindent 4 }}
+  defaults: {{ .defaults | toYaml | nindent 4 }}
+  exemptions:
+    namespaces: {{ .exemptions.namespaces | toYaml | nindent 6 }}
+    service_accounts: {{ .exemptions.serviceAccounts | toYaml | nindent 6 }}
+{{- end }}
+{{- end }}
 {{- end}}
 
 
@@ -205,9 +224,19 @@ database:
     metrics_older_than: {{ .Values.aggregator.database.purgeRules.metricsOlderThan }}
     lazy: {{ .Values.aggregator.database.purgeRules.lazy }}
     percent: {{ .Values.aggregator.database.purgeRules.percent }}
-  {{- if .Values.aggregator.database.emptyDir.enabled }}
+  {{- if .Values.aggregator.sharding.enabled }}
+  available_storage: {{ .Values.aggregator.sharding.storage.size }}
+  {{- else if .Values.aggregator.database.emptyDir.enabled }}
   available_storage: {{ .Values.aggregator.database.emptyDir.sizeLimit }}
   {{- end}}
+{{- if .Values.aggregator.sharding.enabled }}
+sharding:
+  service: {{ include "cloudzero-agent.aggregator.replicasName" . }}
+  scheme: http
+  port: {{ .Values.aggregator.collector.port }}
+  labels:
+    {{- toYaml .Values.aggregator.sharding.labels | nindent 4 }}
+{{- end }}
 cloudzero:
   api_key_path: {{ include "cloudzero-agent.secretFileFullPath" . }}
   send_interval: {{ .Values.aggregator.cloudzero.sendInterval }}
@@ -148,6 +148,49 @@ Returns: string (e.g., "cloudzero-webhook.default.svc")
       fieldPath: metadata.labels['topology.istio.io/cluster']
 {{- end}}
 
+{{/*
+cloudzero-agent.leaderElectionEnv - Environment of a component elected with a Lease
+
+Takes whether leader election is enabled. The Lease is created in the namespace
+of the pod, and the pod name identifies the replica holding it.
+*/}}
+{{ define "cloudzero-agent.leaderElectionEnv" -}}
+{{- if . }}
+- name: LEADER_ELECTION_ENABLED
+  value: "true"
+- name: POD_NAMESPACE
+  valueFrom:
+    fieldRef:
+      fieldPath: metadata.namespace
+- name: POD_NAME
+  valueFrom:
+    fieldRef:
+      fieldPath: metadata.name
+{{- end }}
+{{- end}}
+
+{{/*
+cloudzero-agent.shardingEnv - Environment of a sharded aggregator component
+
+Takes whether sharding is enabled. The pod name identifies the collector
+replica, naming its storage directory, and the headless Service is looked up in
+the namespace of the pod.
+*/}}
+{{ define "cloudzero-agent.shardingEnv" -}}
+{{- if . }}
+- name: SHARDING_ENABLED
+  value: "true"
+- name: POD_NAMESPACE
+  valueFrom:
+    fieldRef:
+      fieldPath: metadata.namespace
+- name: POD_NAME
+  valueFrom:
+    fieldRef:
+      fieldPath: metadata.name
+{{- end }}
+{{- end}}
+
 {{/*
   This helper function trims whitespace and newlines from a given string.
   Returns empty string if input is nil.
@@ -643,6 +686,11 @@ app.kubernetes.io/name: aggregator
 {{ include "cloudzero-agent.common.matchLabels" . }}
 {{- end -}}
 
+{{- define "cloudzero-agent.aggregator.routerMatchLabels" -}}
+app.kubernetes.io/name: aggregator-router
+{{ include "cloudzero-agent.common.matchLabels" . }}
+{{- end -}}
+
 {{/*
 imagePullSecrets for the insights controller webhook server
 */}}
@@ -761,6 +809,21 @@ Name for the validating webhook configuration resource
 {{ include "cloudzero-agent.internal.resourceName" (dict "context" . "component" "aggregator" "override" .Values.aggregator.name) }}
 {{- end}}
 
+{{/*
+Name of the shard router of the aggregator, and of its Service
+*/}}
+{{ define "cloudzero-agent.aggregator.routerName" -}}
+{{ include "cloudzero-agent.aggregator.name" . | trunc 56 | trimSuffix "-" }}-router
+{{- end}}
+
+{{/*
+Name of the headless Service listing the collector replicas of a sharded
+aggregator
+*/}}
+{{ define "cloudzero-agent.aggregator.replicasName" -}}
+{{ include "cloudzero-agent.aggregator.name" . | trunc 54 | trimSuffix "-" }}-replicas
+{{- end}}
+
 {{/*
 Mount path for the insights server configuration file
 */}}
@@ -845,6 +908,13 @@ Name for the certificate init job resource. Should be a new name each installati
 {{- include "cloudzero-agent.jobName" (dict "Release" .Release.Name "Name" "init-cert" "Version" .Chart.Version "Values" .Values) -}}
 {{- end }}
 
+{{/*
+Name of the CronJob rotating the webhook certificate
+*/}}
+{{- define "cloudzero-agent.certRotationCronJobName" -}}
+{{- printf "%s-cert-rotation" .Release.Name -}}
+{{- end }}
+
 {{/*
 Name for the helmless job resource. Should be a new name each installation/upgrade.
 */}}
@@ -925,7 +995,20 @@ Otherwise, it will be the CloudZero API endpoint.
 
 */}}
 {{- define "cloudzero-agent.metricsDestination" -}}
-'http://{{ include "cloudzero-agent.aggregator.name" . }}.{{ .Release.Namespace }}.svc.cluster.local/collector'
+'http://{{ include "cloudzero-agent.aggregator.collectorHost" . }}/collector'
+{{- end -}}
+
+{{/*
+Return the host of the Service receiving the remote_write requests: the shard
+router when the aggregator is sharded, since it forwards each series to the
+replica owning it, and the aggregator otherwise.
+*/}}
+{{- define "cloudzero-agent.aggregator.collectorHost" -}}
+{{- if .Values.aggregator.sharding.enabled -}}
+{{ include "cloudzero-agent.aggregator.routerName" . }}.{{ .Release.Namespace }}.svc.cluster.local
+{{- else -}}
+{{ include "cloudzero-agent.aggregator.name" . }}.{{ .Release.Namespace }}.svc.cluster.local
+{{- end -}}
 {{- end -}}
 
 {{/*
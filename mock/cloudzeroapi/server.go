@@ -0,0 +1,450 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cloudzeroapi provides a fake of the CloudZero upload API the shipper
+// talks to, for tests which must not reach the network.
+//
+// The fake allocates presigned URLs served by an embedded object store, and
+// records every request and uploaded object for assertions. Replay requests
+// are scripted with QueueReplay, and errors, latency and partial failures are
+// injected with Inject.
+package cloudzeroapi
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// BasePath is the path of the API below the host the shipper is
+	// configured with.
+	BasePath = "/v1/container-metrics"
+
+	// ObjectsPath is the path the presigned URLs are served below.
+	ObjectsPath = "/objects/"
+
+	// ReplayHeader carries the replay requests of an upload response.
+	ReplayHeader = "X-CloudZero-Replay"
+
+	// DefaultURLExpiry is how long presigned URLs are valid by default.
+	DefaultURLExpiry = 10 * time.Minute
+
+	// expiredBody is the body S3 answers expired presigned URLs with.
+	expiredBody = `<?xml version="1.0" encoding="UTF-8"?><Error><Code>AccessDenied</Code><Message>Request has expired</Message></Error>`
+)
+
+// Endpoint identifies the endpoints faults are injected into.
+type Endpoint string
+
+const (
+	EndpointUpload  Endpoint = "upload"
+	EndpointAbandon Endpoint = "abandon"
+	EndpointObject  Endpoint = "object"
+)
+
+// Options configures the fake.
+type Options struct {
+	// APIKey is the key the Authorization header must carry. Any key is
+	// accepted when empty.
+	APIKey string
+	// URLExpiry is how long presigned URLs are valid, DefaultURLExpiry when
+	// zero.
+	URLExpiry time.Duration
+	// Now returns the current time, time.Now when nil.
+	Now func() time.Time
+	// DiscardData drops the content of the uploaded objects, and of the
+	// requests uploading them, once OnUpload has seen it, for long runs.
+	DiscardData bool
+	// OnUpload is called with every uploaded object.
+	OnUpload func(Object)
+}
+
+// Fault alters the responses of an endpoint.
+type Fault struct {
+	Endpoint Endpoint
+	// Status is returned instead of handling the request, when set.
+	Status int
+	// Latency delays the response.
+	Latency time.Duration
+	// Times is the number of requests the fault applies to, or every request
+	// until ClearFaults when zero.
+	Times int
+	// ReferenceIDs limits the fault to the objects, or the allocation of the
+	// files, with these reference IDs. On the upload endpoint, such a fault
+	// without a status leaves the files out of the allocation, as the API
+	// does for files it does not accept.
+	ReferenceIDs []string
+}
+
+// Object is a file uploaded to a presigned URL.
+type Object struct {
+	ReferenceID string
+	Data        []byte
+	Size        int
+	UploadedAt  time.Time
+}
+
+// Abandoned is a file the shipper gave up on.
+type Abandoned struct {
+	ReferenceID string `json:"reference_id"` //nolint:tagliatelle // matches the CloudZero API
+	Reason      string `json:"reason"`
+}
+
+// Request is a request the fake received.
+type Request struct {
+	Endpoint Endpoint
+	Method   string
+	URL      string
+	Header   http.Header
+	Body     []byte
+	// Status is the status the fake answered with.
+	Status int
+}
+
+// presignedURL is an allocated presigned URL.
+type presignedURL struct {
+	referenceID string
+	expires     time.Time
+}
+
+// Server is the fake CloudZero upload API. It is an http.Handler, usually
+// served with httptest.NewServer, and is safe for concurrent use.
+type Server struct {
+	opts Options
+
+	mu       sync.Mutex
+	urls     map[string]presignedURL
+	objects  []Object
+	latest   map[string]int
+	abandons []Abandoned
+	requests []Request
+	replays  [][]string
+	faults   []*Fault
+}
+
+// New creates the fake.
+func New(opts Options) *Server {
+	if opts.URLExpiry <= 0 {
+		opts.URLExpiry = DefaultURLExpiry
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Server{
+		opts:   opts,
+		urls:   map[string]presignedURL{},
+		latest: map[string]int{},
+	}
+}
+
+// QueueReplay makes the next upload response ask for the files with the
+// reference IDs to be uploaded again. Each call scripts one response.
+func (s *Server) QueueReplay(referenceIDs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replays = append(s.replays, referenceIDs)
+}
+
+// Inject adds a fault. The faults apply in the order they were added, the
+// first matching one answering the request.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes the faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// Objects returns the uploaded objects, in upload order. Objects uploaded
+// again, such as replays, appear once per upload.
+func (s *Server) Objects() []Object {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.objects)
+}
+
+// Object returns the latest upload of the object with the reference ID.
+func (s *Server) Object(referenceID string) (Object, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, ok := s.latest[referenceID]
+	if !ok {
+		return Object{}, false
+	}
+	return s.objects[i], true
+}
+
+// Abandoned returns the files the shipper abandoned.
+func (s *Server) Abandoned() []Abandoned {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.abandons)
+}
+
+// Requests returns the requests received, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var endpoint Endpoint
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == BasePath+"/upload":
+		endpoint = EndpointUpload
+	case r.Method == http.MethodPost && r.URL.Path == BasePath+"/abandon":
+		endpoint = EndpointAbandon
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, ObjectsPath):
+		endpoint = EndpointObject
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read the body", http.StatusBadRequest)
+		return
+	}
+	// Record the request as the response starts, so that it is recorded
+	// by the time the client sees the response.
+	rec := &recorder{ResponseWriter: w, record: func(status int) {
+		body := body
+		if endpoint == EndpointObject && s.opts.DiscardData {
+			body = nil
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests = append(s.requests, Request{
+			Endpoint: endpoint,
+			Method:   r.Method,
+			URL:      r.URL.String(),
+			Header:   r.Header.Clone(),
+			Body:     body,
+			Status:   status,
+		})
+	}}
+
+	switch endpoint {
+	case EndpointUpload:
+		s.upload(rec, r, body)
+	case EndpointAbandon:
+		s.abandon(rec, r, body)
+	case EndpointObject:
+		s.put(rec, r, body)
+	}
+}
+
+// upload allocates a presigned URL per file of the request, along with the
+// scripted replays.
+func (s *Server) upload(w http.ResponseWriter, r *http.Request, body []byte) {
+	var payload struct {
+		Files []struct {
+			ReferenceID string `json:"reference_id"` //nolint:tagliatelle // matches the CloudZero API
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	refIDs := make([]string, 0, len(payload.Files))
+	for _, file := range payload.Files {
+		refIDs = append(refIDs, file.ReferenceID)
+	}
+
+	if s.fail(w, EndpointUpload, refIDs) || !s.authorize(w, r) {
+		return
+	}
+	query := r.URL.Query()
+	for _, param := range []string{"cluster_name", "cloud_account_id", "region"} {
+		if query.Get(param) == "" {
+			http.Error(w, "missing the "+param+" query parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	allocation := map[string]string{}
+	for _, refID := range refIDs {
+		if !s.omitted(refID) {
+			allocation[refID] = s.allocate(r, refID)
+		}
+	}
+	var replay []map[string]string
+	if len(s.replays) > 0 {
+		for _, refID := range s.replays[0] {
+			replay = append(replay, map[string]string{"ref_id": refID, "url": s.allocate(r, refID)})
+		}
+		s.replays = s.replays[1:]
+	}
+	s.mu.Unlock()
+
+	if len(replay) > 0 {
+		header, err := json.Marshal(replay)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(ReplayHeader, string(header))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(allocation)
+}
+
+// abandon records the files the shipper gave up on.
+func (s *Server) abandon(w http.ResponseWriter, r *http.Request, body []byte) {
+	var files []Abandoned
+	if err := json.Unmarshal(body, &files); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	refIDs := make([]string, 0, len(files))
+	for _, file := range files {
+		refIDs = append(refIDs, file.ReferenceID)
+	}
+	if s.fail(w, EndpointAbandon, refIDs) || !s.authorize(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	s.abandons = append(s.abandons, files...)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// put stores an object uploaded to a presigned URL.
+func (s *Server) put(w http.ResponseWriter, r *http.Request, body []byte) {
+	s.mu.Lock()
+	url, ok := s.urls[strings.TrimPrefix(r.URL.Path, ObjectsPath)]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown presigned URL", http.StatusForbidden)
+		return
+	}
+	if s.fail(w, EndpointObject, []string{url.referenceID}) {
+		return
+	}
+	now := s.opts.Now()
+	if now.After(url.expires) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, expiredBody)
+		return
+	}
+
+	object := Object{ReferenceID: url.referenceID, Data: body, Size: len(body), UploadedAt: now}
+	if s.opts.OnUpload != nil {
+		s.opts.OnUpload(object)
+	}
+	if s.opts.DiscardData {
+		object.Data = nil
+	}
+
+	s.mu.Lock()
+	s.objects = append(s.objects, object)
+	s.latest[object.ReferenceID] = len(s.objects) - 1
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusOK)
+}
+
+// allocate returns a new presigned URL for the reference ID. The lock must be
+// held.
+func (s *Server) allocate(r *http.Request, refID string) string {
+	token := make([]byte, 16)
+	_, _ = rand.Read(token)
+	id := hex.EncodeToString(token)
+	s.urls[id] = presignedURL{referenceID: refID, expires: s.opts.Now().Add(s.opts.URLExpiry)}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + ObjectsPath + id
+}
+
+// omitted reports whether a partial failure leaves the file out of the
+// allocation. The lock must be held.
+func (s *Server) omitted(refID string) bool {
+	for _, f := range s.faults {
+		if f.Endpoint == EndpointUpload && f.Status == 0 && slices.Contains(f.ReferenceIDs, refID) {
+			return true
+		}
+	}
+	return false
+}
+
+// fail applies the first fault matching the request, and reports whether it
+// answered it.
+func (s *Server) fail(w http.ResponseWriter, endpoint Endpoint, refIDs []string) bool {
+	s.mu.Lock()
+	var fault *Fault
+	for i, f := range s.faults {
+		if f.Endpoint != endpoint || (f.Status == 0 && f.Latency == 0) {
+			continue
+		}
+		if len(f.ReferenceIDs) > 0 && !slices.ContainsFunc(refIDs, func(id string) bool { return slices.Contains(f.ReferenceIDs, id) }) {
+			continue
+		}
+		fault = f
+		if f.Times > 0 {
+			if f.Times--; f.Times == 0 {
+				s.faults = slices.Delete(s.faults, i, i+1)
+			}
+		}
+		break
+	}
+	s.mu.Unlock()
+
+	if fault == nil {
+		return false
+	}
+	time.Sleep(fault.Latency)
+	if fault.Status == 0 {
+		return false
+	}
+	http.Error(w, http.StatusText(fault.Status), fault.Status)
+	return true
+}
+
+// authorize checks the API key, and answers the request when it is wrong.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if s.opts.APIKey == "" || r.Header.Get("Authorization") == s.opts.APIKey {
+		return true
+	}
+	http.Error(w, "invalid API key", http.StatusUnauthorized)
+	return false
+}
+
+// recorder records the request with the status of its response.
+type recorder struct {
+	http.ResponseWriter
+	record   func(status int)
+	recorded bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.recorded {
+		r.recorded = true
+		r.record(status)
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if !r.recorded {
+		r.WriteHeader(http.StatusOK)
+	}
+	return r.ResponseWriter.Write(b)
+}
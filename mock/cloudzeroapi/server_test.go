@@ -0,0 +1,197 @@
+// SPDX-FileCopyrightText: Copyright (c) 2016-2026, CloudZero, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package cloudzeroapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudzero/cloudzero-agent/mock/cloudzeroapi"
+)
+
+const uploadQuery = "?cluster_name=c&cloud_account_id=a&region=r&count=2"
+
+func allocate(t *testing.T, url, key string, refIDs ...string) (*http.Response, map[string]string) {
+	t.Helper()
+	var payload struct {
+		Files []map[string]string `json:"files"`
+	}
+	for _, refID := range refIDs {
+		payload.Files = append(payload.Files, map[string]string{"reference_id": refID})
+	}
+	body, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, url+cloudzeroapi.BasePath+"/upload"+uploadQuery, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", key)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	urls := map[string]string{}
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&urls))
+	}
+	return resp, urls
+}
+
+func put(t *testing.T, url string, data []byte) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServer_UploadAndAbandon(t *testing.T) {
+	var seen []string
+	api := cloudzeroapi.New(cloudzeroapi.Options{
+		APIKey:   "key",
+		OnUpload: func(o cloudzeroapi.Object) { seen = append(seen, o.ReferenceID) },
+	})
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	resp, _ := allocate(t, server.URL, "wrong", "a.parquet")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, urls := allocate(t, server.URL, "key", "a.parquet", "b.parquet")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, urls, 2)
+
+	assert.Equal(t, http.StatusOK, put(t, urls["a.parquet"], []byte("first")))
+	assert.Equal(t, http.StatusOK, put(t, urls["a.parquet"], []byte("second")), "URLs are reusable until they expire")
+	assert.Equal(t, http.StatusForbidden, put(t, server.URL+cloudzeroapi.ObjectsPath+"unknown", nil))
+
+	object, ok := api.Object("a.parquet")
+	require.True(t, ok)
+	assert.Equal(t, []byte("second"), object.Data)
+	assert.Len(t, api.Objects(), 2)
+	assert.Equal(t, []string{"a.parquet", "a.parquet"}, seen)
+	_, ok = api.Object("b.parquet")
+	assert.False(t, ok)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+cloudzeroapi.BasePath+"/abandon",
+		bytes.NewBufferString(`[{"reference_id":"b.parquet","reason":"gone"}]`))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "key")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []cloudzeroapi.Abandoned{{ReferenceID: "b.parquet", Reason: "gone"}}, api.Abandoned())
+
+	requests := api.Requests()
+	require.Len(t, requests, 6)
+	assert.Equal(t, cloudzeroapi.EndpointUpload, requests[0].Endpoint)
+	assert.Equal(t, http.StatusUnauthorized, requests[0].Status)
+	assert.Equal(t, cloudzeroapi.EndpointAbandon, requests[5].Endpoint)
+}
+
+func TestServer_MissingQuery(t *testing.T) {
+	server := httptest.NewServer(cloudzeroapi.New(cloudzeroapi.Options{}))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+cloudzeroapi.BasePath+"/upload", "application/json",
+		bytes.NewBufferString(`{"files":[{"reference_id":"a.parquet"}]}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestServer_Expiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	api := cloudzeroapi.New(cloudzeroapi.Options{
+		URLExpiry: time.Minute,
+		Now:       func() time.Time { return now },
+	})
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	_, urls := allocate(t, server.URL, "", "a.parquet")
+	now = now.Add(2 * time.Minute)
+
+	req, err := http.NewRequest(http.MethodPut, urls["a.parquet"], bytes.NewBufferString("data"))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Contains(t, string(body), "Request has expired")
+	assert.Empty(t, api.Objects())
+}
+
+func TestServer_Replay(t *testing.T) {
+	api := cloudzeroapi.New(cloudzeroapi.Options{})
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	api.QueueReplay("old.parquet")
+	resp, urls := allocate(t, server.URL, "", "new.parquet")
+	assert.Len(t, urls, 1)
+
+	var replay []map[string]string
+	require.NoError(t, json.Unmarshal([]byte(resp.Header.Get(cloudzeroapi.ReplayHeader)), &replay))
+	require.Len(t, replay, 1)
+	assert.Equal(t, "old.parquet", replay[0]["ref_id"])
+	assert.Equal(t, http.StatusOK, put(t, replay[0]["url"], []byte("data")))
+	_, ok := api.Object("old.parquet")
+	assert.True(t, ok)
+
+	resp, _ = allocate(t, server.URL, "", "new.parquet")
+	assert.Empty(t, resp.Header.Get(cloudzeroapi.ReplayHeader), "each replay is sent once")
+}
+
+func TestServer_Faults(t *testing.T) {
+	api := cloudzeroapi.New(cloudzeroapi.Options{})
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	t.Run("status", func(t *testing.T) {
+		api.Inject(cloudzeroapi.Fault{Endpoint: cloudzeroapi.EndpointUpload, Status: http.StatusServiceUnavailable, Times: 1})
+		resp, _ := allocate(t, server.URL, "", "a.parquet")
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		resp, _ = allocate(t, server.URL, "", "a.parquet")
+		assert.Equal(t, http.StatusOK, resp.StatusCode, "the fault applied once")
+	})
+
+	t.Run("partial allocation", func(t *testing.T) {
+		api.Inject(cloudzeroapi.Fault{Endpoint: cloudzeroapi.EndpointUpload, ReferenceIDs: []string{"b.parquet"}})
+		defer api.ClearFaults()
+		_, urls := allocate(t, server.URL, "", "a.parquet", "b.parquet")
+		assert.Contains(t, urls, "a.parquet")
+		assert.NotContains(t, urls, "b.parquet")
+	})
+
+	t.Run("object", func(t *testing.T) {
+		api.Inject(cloudzeroapi.Fault{Endpoint: cloudzeroapi.EndpointObject, Status: http.StatusInternalServerError, ReferenceIDs: []string{"b.parquet"}})
+		defer api.ClearFaults()
+		_, urls := allocate(t, server.URL, "", "a.parquet", "b.parquet")
+		assert.Equal(t, http.StatusOK, put(t, urls["a.parquet"], nil))
+		assert.Equal(t, http.StatusInternalServerError, put(t, urls["b.parquet"], nil))
+		assert.Equal(t, http.StatusInternalServerError, put(t, urls["b.parquet"], nil), "the fault applies until cleared")
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		api.Inject(cloudzeroapi.Fault{Endpoint: cloudzeroapi.EndpointUpload, Latency: 50 * time.Millisecond, Times: 1})
+		start := time.Now()
+		resp, _ := allocate(t, server.URL, "", "a.parquet")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+}
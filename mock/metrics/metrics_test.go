@@ -89,7 +89,7 @@ func TestUnit_Metrics_ToPromb(t *testing.T) {
 	require.NoError(t, err, "failed to create the metric collector")
 
 	// decode the metrics
-	decodedMetrics, err := collector.DecodeV1(data)
+	decodedMetrics, err := collector.DecodeV1(t.Context(), domain.RemoteWriteSource{}, data)
 	require.NoError(t, err, "failed to decode the metrics")
 
 	fmt.Println("--- Generation Stats:")